@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/api"
+	"github.com/hubblenetwork/hubcli/internal/auth"
+	"github.com/hubblenetwork/hubcli/internal/config"
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+// runExportDevices implements `hubcli export-devices [file]`. It writes the
+// device list as CSV to file, or to stdout when no file is given.
+func runExportDevices(args []string) (err error) {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: hubcli export-devices [file]")
+	}
+
+	creds, err := auth.GetCredentials()
+	if err != nil {
+		return err
+	}
+	// The config is optional; its defaults are used if it can't be read
+	cfg, _ := config.Load()
+	timeout := api.DefaultTimeout
+	if d, ok := cfg.RequestTimeout(); ok {
+		timeout = d
+	}
+	client := api.NewClientFromCredentials(*creds,
+		api.WithRetry(api.DefaultRetryAttempts, api.DefaultRetryBaseDelay),
+		api.WithTimeout(timeout),
+	)
+
+	devices, err := listAllDevices(client, timeout)
+	if err != nil {
+		return fmt.Errorf("listing devices: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if len(args) == 1 {
+		f, err := os.Create(args[0])
+		if err != nil {
+			return err
+		}
+		// A failed close can lose buffered writes, so it fails the export
+		defer func() {
+			if cerr := f.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+
+	return models.WriteDevicesCSV(w, devices)
+}
+
+// listAllDevices lists every device a page at a time, giving each page its
+// own timeout so a large org isn't cut off partway through
+func listAllDevices(client *api.Client, timeout time.Duration) ([]models.Device, error) {
+	var devices []models.Device
+	token := ""
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		page, next, err := client.ListDevicesPage(ctx, token)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, page...)
+		if next == "" {
+			return devices, nil
+		}
+		token = next
+	}
+}
@@ -1,15 +1,61 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"log/slog"
 	"os"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hubblenetwork/hubcli/internal/api"
+	"github.com/hubblenetwork/hubcli/internal/ble"
 	"github.com/hubblenetwork/hubcli/internal/tui"
 )
 
 func main() {
-	p := tea.NewProgram(tui.NewApp(), tea.WithAltScreen())
+	if len(os.Args) > 1 && os.Args[1] == "export-devices" {
+		if err := runExportDevices(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	offline := flag.Bool("offline", false, "use sample devices, packets and BLE advertisements instead of the Hubble API and Bluetooth, without logging in")
+	replay := flag.String("replay", "", "replay a saved BLE capture `file` on the BLE scan screen instead of scanning")
+	replayFast := flag.Bool("replay-fast", false, "replay the capture immediately instead of at its recorded pace")
+	verbose := flag.Bool("verbose", false, "log every API request, without the API token, to the -log-file")
+	logFile := flag.String("log-file", "hubcli.log", "append verbose request logs to `file`")
+	flag.Parse()
+
+	app := tui.NewApp()
+	if *verbose {
+		// Logs go to a file because the TUI owns the terminal
+		f, err := os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		app.SetRequestLogger(api.SlogLogger(slog.New(slog.NewTextHandler(f, nil))))
+	}
+	if *offline {
+		client := api.NewFakeClient(time.Now())
+		scanner := ble.NewMockScanner()
+		scanner.SetPackets(client.Advertisements())
+		app.SetOffline(client, scanner)
+	}
+	if *replay != "" {
+		scanner, err := ble.NewFileScanner(*replay, !*replayFast)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		app.SetReplayScanner(scanner)
+	}
+
+	p := tea.NewProgram(app, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,147 @@
+// Package analysis derives statistics from a device's packet history, such
+// as transmissions that never reached the backend.
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+// SequenceRange is a run of missing sequence numbers from Start to End
+// inclusive. End is less than Start when the run wraps past 1023 to 0.
+type SequenceRange struct {
+	Start int
+	End   int
+}
+
+// Len returns the number of sequence numbers in the range.
+func (r SequenceRange) Len() int {
+	return mod(r.End-r.Start) + 1
+}
+
+// String formats the range as "120–122", or "120" for a single number.
+func (r SequenceRange) String() string {
+	if r.Start == r.End {
+		return fmt.Sprintf("%d", r.Start)
+	}
+	return fmt.Sprintf("%d–%d", r.Start, r.End)
+}
+
+// SequenceGap is a run of missing sequence numbers along with the packets
+// on either side of it in sequence order.
+type SequenceGap struct {
+	Missing SequenceRange
+	Before  models.RetrievedPacket // Packet with the sequence number before the run
+	After   models.RetrievedPacket // Packet with the sequence number after the run
+}
+
+// SequenceGaps returns the runs of sequence numbers absent from a device's
+// packets, which should be in time order. Each packet is placed at the step
+// from the previous packet's sequence number that is shortest forwards or
+// backwards, so a packet that arrives late fills the gap it left instead of
+// counting as a wraparound. As a result, runs of half of
+// models.SequenceModulus or more dropped packets are not detected.
+func SequenceGaps(packets []models.RetrievedPacket) []SequenceGap {
+	if len(packets) < 2 {
+		return nil
+	}
+
+	// Unwrap the 10-bit counter into a continuous one, keeping the first
+	// packet seen with each value
+	seen := make(map[int]models.RetrievedPacket, len(packets))
+	prev := packets[0].Device.SequenceNumber
+	unwrapped := prev
+	seen[unwrapped] = packets[0]
+	for _, p := range packets[1:] {
+		seq := p.Device.SequenceNumber
+		step := mod(seq - prev)
+		if step >= models.SequenceModulus/2 {
+			step -= models.SequenceModulus
+		}
+		unwrapped += step
+		prev = seq
+		if _, ok := seen[unwrapped]; !ok {
+			seen[unwrapped] = p
+		}
+	}
+
+	values := make([]int, 0, len(seen))
+	for v := range seen {
+		values = append(values, v)
+	}
+	sort.Ints(values)
+
+	var gaps []SequenceGap
+	for i := 1; i < len(values); i++ {
+		if values[i]-values[i-1] > 1 {
+			gaps = append(gaps, SequenceGap{
+				Missing: SequenceRange{
+					Start: mod(values[i-1] + 1),
+					End:   mod(values[i] - 1),
+				},
+				Before: seen[values[i-1]],
+				After:  seen[values[i]],
+			})
+		}
+	}
+	return gaps
+}
+
+// MissingSequences returns the runs of sequence numbers absent from a
+// device's packets, found like SequenceGaps.
+func MissingSequences(packets []models.RetrievedPacket) []SequenceRange {
+	gaps := SequenceGaps(packets)
+	if gaps == nil {
+		return nil
+	}
+	ranges := make([]SequenceRange, len(gaps))
+	for i, g := range gaps {
+		ranges[i] = g.Missing
+	}
+	return ranges
+}
+
+// MissingCount returns the total number of sequence numbers in ranges.
+func MissingCount(ranges []SequenceRange) int {
+	total := 0
+	for _, r := range ranges {
+		total += r.Len()
+	}
+	return total
+}
+
+// FormatMissing summarizes ranges as "3 packets missing: seq 120–122",
+// listing at most maxRanges ranges. It returns "" when nothing is missing.
+func FormatMissing(ranges []SequenceRange, maxRanges int) string {
+	if len(ranges) == 0 {
+		return ""
+	}
+
+	shown := ranges
+	if len(shown) > maxRanges {
+		shown = shown[:maxRanges]
+	}
+	parts := make([]string, len(shown))
+	for i, r := range shown {
+		parts[i] = r.String()
+	}
+	list := strings.Join(parts, ", ")
+	if extra := len(ranges) - len(shown); extra > 0 {
+		list += fmt.Sprintf(" and %d more", extra)
+	}
+
+	count := MissingCount(ranges)
+	noun := "packets"
+	if count == 1 {
+		noun = "packet"
+	}
+	return fmt.Sprintf("%d %s missing: seq %s", count, noun, list)
+}
+
+// mod reduces n to a sequence number in [0, models.SequenceModulus).
+func mod(n int) int {
+	return (n%models.SequenceModulus + models.SequenceModulus) % models.SequenceModulus
+}
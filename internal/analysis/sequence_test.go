@@ -0,0 +1,79 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func packetsWithSeqs(seqs ...int) []models.RetrievedPacket {
+	packets := make([]models.RetrievedPacket, len(seqs))
+	for i, s := range seqs {
+		packets[i] = models.RetrievedPacket{Device: models.RetrievedDevice{
+			ID:             "device-1",
+			Timestamp:      float64(1700000000 + i),
+			SequenceNumber: s,
+		}}
+	}
+	return packets
+}
+
+func TestMissingSequences(t *testing.T) {
+	tests := []struct {
+		name     string
+		seqs     []int
+		expected []SequenceRange
+	}{
+		{"empty", nil, nil},
+		{"single", []int{42}, nil},
+		{"consecutive", []int{1, 2, 3, 4}, nil},
+		{"duplicate is not a gap", []int{7, 7, 8}, nil},
+		{"single missing", []int{1, 2, 4}, []SequenceRange{{3, 3}}},
+		{"normal gap", []int{118, 119, 123}, []SequenceRange{{120, 122}}},
+		{"two gaps", []int{1, 3, 4, 8}, []SequenceRange{{2, 2}, {5, 7}}},
+		{"wraparound without gap", []int{1022, 1023, 0, 1}, nil},
+		{"gap before wraparound", []int{1020, 0}, []SequenceRange{{1021, 1023}}},
+		{"gap across wraparound", []int{1021, 2}, []SequenceRange{{1022, 1}}},
+		{"out of order fills gap", []int{5, 7, 6, 8}, nil},
+		{"out of order leaves gap", []int{5, 8, 6, 9}, []SequenceRange{{7, 7}}},
+		{"out of order across wraparound", []int{1022, 0, 1023, 1}, nil},
+		{"late arrival after wraparound", []int{1022, 1, 1023, 2}, []SequenceRange{{0, 0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MissingSequences(packetsWithSeqs(tt.seqs...)))
+		})
+	}
+}
+
+func TestSequenceGaps(t *testing.T) {
+	// A late packet fills its gap; the rest are listed with their neighbours
+	// in sequence order
+	packets := packetsWithSeqs(1021, 2, 1022, 5)
+	gaps := SequenceGaps(packets)
+
+	assert.Equal(t, []SequenceGap{
+		{Missing: SequenceRange{1023, 1}, Before: packets[2], After: packets[1]},
+		{Missing: SequenceRange{3, 4}, Before: packets[1], After: packets[3]},
+	}, gaps)
+	assert.Nil(t, SequenceGaps(packetsWithSeqs(7)))
+}
+
+func TestSequenceRange(t *testing.T) {
+	assert.Equal(t, 3, SequenceRange{120, 122}.Len())
+	assert.Equal(t, "120–122", SequenceRange{120, 122}.String())
+	assert.Equal(t, 1, SequenceRange{5, 5}.Len())
+	assert.Equal(t, "5", SequenceRange{5, 5}.String())
+	assert.Equal(t, 4, SequenceRange{1022, 1}.Len())
+}
+
+func TestFormatMissing(t *testing.T) {
+	assert.Equal(t, "", FormatMissing(nil, 3))
+	assert.Equal(t, "3 packets missing: seq 120–122", FormatMissing([]SequenceRange{{120, 122}}, 3))
+	assert.Equal(t, "1 packet missing: seq 7", FormatMissing([]SequenceRange{{7, 7}}, 3))
+	assert.Equal(t,
+		"6 packets missing: seq 1, 3–4 and 2 more",
+		FormatMissing([]SequenceRange{{1, 1}, {3, 4}, {6, 7}, {9, 9}}, 2))
+}
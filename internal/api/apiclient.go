@@ -0,0 +1,32 @@
+package api
+
+import (
+	"context"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+// APIClient is the part of the Hubble API used by the TUI, so screens can be
+// given a stub in tests. Client implements it against the real API and
+// FakeClient with seeded sample data for offline use.
+type APIClient interface {
+	OrgID() string
+	CheckCredentials(ctx context.Context) error
+	GetOrganization(ctx context.Context) (*models.Organization, error)
+
+	ListDevices(ctx context.Context) ([]models.Device, error)
+	ListDevicesPage(ctx context.Context, token string) ([]models.Device, string, error)
+	GetDevice(ctx context.Context, deviceID string) (*models.Device, error)
+	RegisterDevice(ctx context.Context, req models.RegisterDeviceRequest) (*models.Device, error)
+	RegisterDevices(ctx context.Context, req models.RegisterDeviceRequest) ([]models.Device, error)
+	SetDeviceName(ctx context.Context, deviceID, name string) (*models.Device, error)
+	SetDeviceTags(ctx context.Context, deviceID string, tags map[string]string) (*models.Device, error)
+	DeleteDevice(ctx context.Context, deviceID string) error
+	DeleteDevices(ctx context.Context, deviceIDs []string) []error
+
+	PacketsURL(opts RetrievePacketsOptions) string
+	RetrievePacketsWithPagination(ctx context.Context, opts RetrievePacketsOptions) (*RetrievePacketsResult, error)
+	RetrievePacketsPages(ctx context.Context, opts RetrievePacketsOptions) (<-chan PacketsPage, <-chan error)
+	IngestEncryptedPackets(ctx context.Context, packets []models.EncryptedPacket) error
+	IngestEncryptedPacketsWithReport(ctx context.Context, packets []models.EncryptedPacket) (IngestReport, error)
+}
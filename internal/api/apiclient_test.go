@@ -0,0 +1,12 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientImplementsAPIClient(t *testing.T) {
+	assert.Implements(t, (*APIClient)(nil), NewClient("test-org", "test-token"))
+	assert.Implements(t, (*APIClient)(nil), NewFakeClient(fakeNow))
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// circuitBreaker stops sending requests after repeated failures so a down
+// API isn't hammered by refreshes. After threshold consecutive failures the
+// circuit opens and requests fail fast with ErrCircuitOpen. Once the cooldown
+// has passed a single probe request is let through (half-open); its outcome
+// closes or re-opens the circuit.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	failures int
+	openedAt time.Time
+	open     bool
+	probing  bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow returns an error if the request should be short-circuited.
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return nil
+	}
+
+	remaining := b.cooldown - b.now().Sub(b.openedAt)
+	if remaining > 0 || b.probing {
+		if remaining < 0 {
+			remaining = 0
+		}
+		return fmt.Errorf("%w (retrying in %s)", ErrCircuitOpen, remaining.Round(time.Second))
+	}
+
+	// Half-open: let one probe through
+	b.probing = true
+	return nil
+}
+
+// record updates the breaker with the outcome of a request.
+func (b *circuitBreaker) record(err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasProbe := b.probing
+	b.probing = false
+
+	// A cancelled request says nothing about the backend, which it may
+	// never have reached: free the probe slot and leave the state as is
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	if !isBreakerFailure(err) {
+		b.failures = 0
+		b.open = false
+		return
+	}
+
+	b.failures++
+	if wasProbe || b.failures >= b.threshold {
+		b.open = true
+		b.openedAt = b.now()
+	}
+}
+
+// isBreakerFailure reports whether err indicates the service is unhealthy.
+// Client errors (4xx other than 429) and cancellations are not failures.
+func isBreakerFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return errors.Is(apiErr, ErrServerError) || errors.Is(apiErr, ErrRateLimited)
+	}
+
+	// Transport errors (connection refused, timeouts, ...)
+	return true
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	serverErr := NewAPIError(http.StatusBadGateway, "")
+
+	require.NoError(t, b.allow())
+	b.record(serverErr)
+	require.NoError(t, b.allow())
+	b.record(serverErr)
+
+	assert.ErrorIs(t, b.allow(), ErrCircuitOpen)
+
+	// Half-open after cooldown: one probe is allowed, others are not
+	now = now.Add(time.Minute)
+	require.NoError(t, b.allow())
+	assert.ErrorIs(t, b.allow(), ErrCircuitOpen)
+
+	// Failed probe re-opens the circuit
+	b.record(serverErr)
+	assert.ErrorIs(t, b.allow(), ErrCircuitOpen)
+
+	// Successful probe closes it
+	now = now.Add(time.Minute)
+	require.NoError(t, b.allow())
+	b.record(nil)
+	assert.NoError(t, b.allow())
+	assert.NoError(t, b.allow())
+}
+
+func TestCircuitBreaker_CancelledProbeIsNeutral(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	serverErr := NewAPIError(http.StatusBadGateway, "")
+	b.record(serverErr)
+	now = now.Add(time.Minute)
+
+	// A cancelled probe leaves the circuit open but frees the probe slot
+	require.NoError(t, b.allow())
+	b.record(context.Canceled)
+	require.NoError(t, b.allow())
+	assert.ErrorIs(t, b.allow(), ErrCircuitOpen)
+
+	// The next probe decides
+	b.record(serverErr)
+	assert.ErrorIs(t, b.allow(), ErrCircuitOpen)
+}
+
+func TestCircuitBreaker_CancelledRequestKeepsFailures(t *testing.T) {
+	b := newCircuitBreaker(2, time.Minute)
+	serverErr := NewAPIError(http.StatusBadGateway, "")
+
+	b.record(serverErr)
+	b.record(fmt.Errorf("request: %w", context.Canceled))
+	b.record(serverErr)
+	assert.ErrorIs(t, b.allow(), ErrCircuitOpen)
+}
+
+func TestCircuitBreaker_IgnoresClientErrors(t *testing.T) {
+	b := newCircuitBreaker(1, time.Minute)
+
+	b.record(NewAPIError(http.StatusNotFound, ""))
+	b.record(NewAPIError(http.StatusUnauthorized, ""))
+	b.record(context.Canceled)
+
+	assert.NoError(t, b.allow())
+}
+
+func TestIsBreakerFailure(t *testing.T) {
+	assert.False(t, isBreakerFailure(nil))
+	assert.False(t, isBreakerFailure(NewAPIError(http.StatusBadRequest, "")))
+	assert.True(t, isBreakerFailure(NewAPIError(http.StatusTooManyRequests, "")))
+	assert.True(t, isBreakerFailure(NewAPIError(http.StatusServiceUnavailable, "")))
+	assert.True(t, isBreakerFailure(errors.New("connection refused")))
+}
+
+func TestClient_CircuitBreakerShortCircuits(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-org", "test-token",
+		WithBaseURL(server.URL),
+		WithCircuitBreaker(3, time.Hour),
+	)
+
+	for i := 0; i < 3; i++ {
+		_, err := client.get(context.Background(), "/test")
+		assert.ErrorIs(t, err, ErrServerError)
+	}
+
+	_, err := client.get(context.Background(), "/test")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestClient_CircuitBreakerDisabled(t *testing.T) {
+	client := NewClient("test-org", "test-token", WithCircuitBreaker(0, time.Minute))
+
+	assert.Nil(t, client.breaker)
+}
@@ -7,14 +7,27 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/hubblenetwork/hubcli/internal/models"
 )
 
 const (
-	defaultTimeout = 30 * time.Second
+	// DefaultTimeout is the timeout for every request except ingests,
+	// unless WithTimeout sets another.
+	DefaultTimeout = 30 * time.Second
 	userAgent      = "hubcli/1.0"
+
+	// Ingest requests carry whole scan batches, so they get a longer
+	// timeout. Retries are few because the API does not deduplicate uploads.
+	defaultIngestTimeout    = 60 * time.Second
+	defaultIngestRetries    = 2
+	defaultIngestRetryDelay = 2 * time.Second
+
+	// DefaultContinuationHeader is the header carrying the pagination token
+	// in both directions.
+	DefaultContinuationHeader = "Continuation-Token"
 )
 
 // Client is an HTTP client for the Hubble API.
@@ -23,6 +36,22 @@ type Client struct {
 	orgID      string
 	token      string
 	httpClient *http.Client
+	breaker    *circuitBreaker
+	err        error // Construction error returned by every request
+
+	continuationHeader string
+
+	ingestTimeout    time.Duration
+	ingestRetries    int
+	ingestRetryDelay time.Duration
+	ingested         *ingestLedger
+
+	maxConcurrency int
+
+	logger func(RequestLog)
+
+	retryAttempts  int
+	retryBaseDelay time.Duration
 }
 
 // ClientOption configures the Client.
@@ -42,15 +71,120 @@ func WithBaseURL(url string) ClientOption {
 	}
 }
 
-// NewClient creates a new Hubble API client.
+// WithEnvironment points the client at the base URL of env. WithBaseURL
+// overrides it if both are given, whichever comes last.
+func WithEnvironment(env models.Environment) ClientOption {
+	return func(client *Client) {
+		client.baseURL = env.BaseURL()
+	}
+}
+
+// WithContinuationHeader sets the name of the pagination token header.
+// Header names are matched case-insensitively.
+func WithContinuationHeader(name string) ClientOption {
+	return func(client *Client) {
+		client.continuationHeader = name
+	}
+}
+
+// WithCircuitBreaker configures the circuit breaker. After threshold
+// consecutive failures (network errors, 429 or 5xx responses) requests fail
+// with ErrCircuitOpen until cooldown has passed. A threshold of 0 or less
+// disables the breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ClientOption {
+	return func(client *Client) {
+		if threshold <= 0 {
+			client.breaker = nil
+			return
+		}
+		client.breaker = newCircuitBreaker(threshold, cooldown)
+	}
+}
+
+// WithTimeout sets the timeout for every request except ingests, which use
+// WithIngestTimeout. It applies to the HTTP client set by WithHTTPClient only
+// if it comes after it, and copies that client rather than changing it. A
+// value of 0 or less keeps the default, DefaultTimeout.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(client *Client) {
+		if d <= 0 {
+			return
+		}
+		httpClient := *client.httpClient
+		httpClient.Timeout = d
+		client.httpClient = &httpClient
+	}
+}
+
+// WithIngestTimeout sets the timeout for packet ingest requests, separately
+// from the timeout used by every other request. A value of 0 or less keeps
+// the default.
+func WithIngestTimeout(d time.Duration) ClientOption {
+	return func(client *Client) {
+		if d > 0 {
+			client.ingestTimeout = d
+		}
+	}
+}
+
+// WithIngestRetries sets how many times a failed ingest request is retried.
+// Only failures where the server cannot have accepted the packets are
+// retried; see IngestPacket. A value of 0 or less disables retries.
+func WithIngestRetries(n int) ClientOption {
+	return func(client *Client) {
+		if n < 0 {
+			n = 0
+		}
+		client.ingestRetries = n
+	}
+}
+
+// WithIngestHistory makes the client share prev's record of acknowledged
+// ingests, so a client rebuilt with new settings still skips the packets
+// prev uploaded. prev must use the same organization.
+func WithIngestHistory(prev *Client) ClientOption {
+	return func(client *Client) {
+		if prev != nil {
+			client.ingested = prev.ingested
+		}
+	}
+}
+
+// WithMaxConcurrency limits how many requests a fan-out operation, such as
+// DeleteDevices, sends at once. A value of 0 or less keeps the default,
+// DefaultMaxConcurrency.
+func WithMaxConcurrency(n int) ClientOption {
+	return func(client *Client) {
+		if n > 0 {
+			client.maxConcurrency = n
+		}
+	}
+}
+
+// NewClient creates a new Hubble API client. If orgID or token is empty the
+// client is still returned, but Err reports the problem and every request
+// fails with it instead of reaching the API.
 func NewClient(orgID, token string, opts ...ClientOption) *Client {
 	c := &Client{
 		baseURL: models.EnvProduction.BaseURL(),
 		orgID:   orgID,
 		token:   token,
 		httpClient: &http.Client{
-			Timeout: defaultTimeout,
+			Timeout: DefaultTimeout,
 		},
+		breaker: newCircuitBreaker(defaultBreakerThreshold, defaultBreakerCooldown),
+		err:     ValidateCredentials(orgID, token),
+
+		continuationHeader: DefaultContinuationHeader,
+
+		ingestTimeout:    defaultIngestTimeout,
+		ingestRetries:    defaultIngestRetries,
+		ingestRetryDelay: defaultIngestRetryDelay,
+		ingested:         newIngestLedger(),
+
+		maxConcurrency: DefaultMaxConcurrency,
+
+		retryAttempts: 1,
 	}
 
 	for _, opt := range opts {
@@ -60,18 +194,74 @@ func NewClient(orgID, token string, opts ...ClientOption) *Client {
 	return c
 }
 
-// NewClientFromCredentials creates a client from a Credentials struct.
+// ValidateCredentials returns an error wrapping ErrMissingCredentials if
+// orgID or token is empty.
+func ValidateCredentials(orgID, token string) error {
+	switch {
+	case strings.TrimSpace(orgID) == "":
+		return fmt.Errorf("%w: organization ID is empty", ErrMissingCredentials)
+	case strings.TrimSpace(token) == "":
+		return fmt.Errorf("%w: API token is empty", ErrMissingCredentials)
+	}
+	return nil
+}
+
+// continuationToken returns the pagination token from a response, or "" if
+// there are no more pages. http.Header.Get canonicalizes the name, so servers
+// sending it in any case are handled.
+func (c *Client) continuationToken(resp *response) string {
+	return resp.Header.Get(c.continuationHeader)
+}
+
+// Err returns the error recorded when the client was constructed, if any.
+func (c *Client) Err() error {
+	return c.err
+}
+
+// NewClientFromCredentials creates a client from a Credentials struct,
+// targeting the credentials' environment unless opts say otherwise.
 func NewClientFromCredentials(creds models.Credentials, opts ...ClientOption) *Client {
+	if creds.Environment != "" {
+		opts = append([]ClientOption{WithEnvironment(creds.Environment)}, opts...)
+	}
 	return NewClient(creds.OrgID, creds.Token, opts...)
 }
 
-// request performs an HTTP request and returns the response body.
-func (c *Client) request(ctx context.Context, method, path string, body interface{}) ([]byte, http.Header, error) {
+// response is a successful HTTP response along with the request it answered,
+// so decode failures can report where the unexpected body came from.
+type response struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// request performs an HTTP request and returns the response.
+func (c *Client) request(ctx context.Context, method, path string, body interface{}) (*response, error) {
+	return c.do(ctx, method, path, body, "")
+}
+
+// do performs an HTTP request with an optional continuation token header.
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, contToken string) (*response, error) {
+	return c.doWith(ctx, c.httpClient, method, path, body, contToken)
+}
+
+// doWith performs an HTTP request using the given HTTP client.
+func (c *Client) doWith(ctx context.Context, httpClient *http.Client, method, path string, body interface{}, contToken string) (_ *response, err error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	defer func() { c.breaker.record(err) }()
+
 	var bodyReader io.Reader
 	if body != nil {
 		data, err := json.Marshal(body)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to marshal request body: %w", err)
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
 		bodyReader = bytes.NewReader(data)
 	}
@@ -79,22 +269,47 @@ func (c *Client) request(ctx context.Context, method, path string, body interfac
 	url := c.baseURL + path
 	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.token)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", userAgent)
+	if contToken != "" {
+		req.Header.Set(c.continuationHeader, contToken)
+	}
+
+	var statusCode int
+	var nextToken string
+	if c.logger != nil {
+		start := time.Now()
+		defer func() {
+			c.logger(RequestLog{
+				Method:                method,
+				Path:                  path,
+				Header:                redactHeader(req.Header),
+				StatusCode:            statusCode,
+				Duration:              time.Since(start),
+				ContinuationToken:     contToken,
+				NextContinuationToken: nextToken,
+				Err:                   err,
+			})
+		}()
+	}
+	// Runs before the logger, so neither the log nor the caller sees the token
+	defer func() { err = redactError(err, c.token) }()
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	statusCode = resp.StatusCode
+	nextToken = resp.Header.Get(c.continuationHeader)
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	if resp.StatusCode >= 400 {
@@ -112,82 +327,60 @@ func (c *Client) request(ctx context.Context, method, path string, body interfac
 
 		apiErr := &APIError{
 			StatusCode: resp.StatusCode,
-			Message:    msg,
+			Message:    Redact(msg, c.token),
 			Details:    errResp.Details,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
 		}
-		return nil, resp.Header, apiErr
+		return nil, apiErr
 	}
 
-	return respBody, resp.Header, nil
+	return &response{
+		Method:     method,
+		Path:       path,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       respBody,
+	}, nil
+}
+
+// decode unmarshals the response body into v. On failure it returns a
+// DecodeError describing the endpoint, status and a redacted body snippet.
+func (r *response) decode(v interface{}, what string) error {
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		return &DecodeError{
+			What:       what,
+			Endpoint:   r.Method + " " + r.Path,
+			StatusCode: r.StatusCode,
+			Snippet:    bodySnippet(r.Body),
+			Err:        err,
+		}
+	}
+	return nil
 }
 
 // get performs a GET request.
-func (c *Client) get(ctx context.Context, path string) ([]byte, http.Header, error) {
+func (c *Client) get(ctx context.Context, path string) (*response, error) {
 	return c.getWithContToken(ctx, path, "")
 }
 
-// getWithContToken performs a GET request with an optional continuation token header.
-func (c *Client) getWithContToken(ctx context.Context, path string, contToken string) ([]byte, http.Header, error) {
-	url := c.baseURL + path
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.token)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", userAgent)
-	if contToken != "" {
-		req.Header.Set("Continuation-Token", contToken)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if resp.StatusCode >= 400 {
-		var errResp struct {
-			Message string                 `json:"message"`
-			Error   string                 `json:"error"`
-			Details map[string]interface{} `json:"details"`
-		}
-		_ = json.Unmarshal(respBody, &errResp)
-
-		msg := errResp.Message
-		if msg == "" {
-			msg = errResp.Error
-		}
-
-		apiErr := &APIError{
-			StatusCode: resp.StatusCode,
-			Message:    msg,
-			Details:    errResp.Details,
-		}
-		return nil, resp.Header, apiErr
-	}
-
-	return respBody, resp.Header, nil
+// getWithContToken performs a GET request with an optional continuation token
+// header. Transient failures are retried; see WithRetry.
+func (c *Client) getWithContToken(ctx context.Context, path string, contToken string) (*response, error) {
+	return c.doRetry(ctx, http.MethodGet, path, nil, contToken)
 }
 
 // post performs a POST request.
-func (c *Client) post(ctx context.Context, path string, body interface{}) ([]byte, http.Header, error) {
+func (c *Client) post(ctx context.Context, path string, body interface{}) (*response, error) {
 	return c.request(ctx, http.MethodPost, path, body)
 }
 
 // patch performs a PATCH request.
-func (c *Client) patch(ctx context.Context, path string, body interface{}) ([]byte, http.Header, error) {
+func (c *Client) patch(ctx context.Context, path string, body interface{}) (*response, error) {
 	return c.request(ctx, http.MethodPatch, path, body)
 }
 
 // delete performs a DELETE request.
-func (c *Client) delete(ctx context.Context, path string) ([]byte, http.Header, error) {
+func (c *Client) delete(ctx context.Context, path string) (*response, error) {
 	return c.request(ctx, http.MethodDelete, path, nil)
 }
 
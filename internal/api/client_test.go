@@ -3,10 +3,13 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/hubblenetwork/hubcli/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -18,6 +21,46 @@ func TestNewClient(t *testing.T) {
 	assert.NotNil(t, client.httpClient)
 }
 
+func TestWithEnvironment(t *testing.T) {
+	assert.Equal(t, models.EnvProduction.BaseURL(), NewClient("test-org", "test-token").baseURL)
+
+	client := NewClient("test-org", "test-token", WithEnvironment(models.EnvStaging))
+	assert.Equal(t, models.EnvStaging.BaseURL(), client.baseURL)
+
+	// The credentials' environment is used unless an option overrides it
+	creds := models.Credentials{OrgID: "test-org", Token: "test-token", Environment: models.EnvDevelopment}
+	assert.Equal(t, models.EnvDevelopment.BaseURL(), NewClientFromCredentials(creds).baseURL)
+	assert.Equal(t, "http://example.test", NewClientFromCredentials(creds, WithBaseURL("http://example.test")).baseURL)
+}
+
+func TestNewClient_EmptyOrgID(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewClient("", "test-token", WithBaseURL(server.URL))
+	require.Error(t, client.Err())
+	assert.ErrorIs(t, client.Err(), ErrMissingCredentials)
+	assert.Contains(t, client.Err().Error(), "organization ID is empty")
+
+	// Requests fail with the descriptive error instead of hitting /org//devices
+	_, err := client.ListDevices(context.Background())
+	assert.ErrorIs(t, err, ErrMissingCredentials)
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 0, calls)
+}
+
+func TestValidateCredentials(t *testing.T) {
+	assert.NoError(t, ValidateCredentials("test-org", "test-token"))
+
+	err := ValidateCredentials("test-org", "  ")
+	assert.ErrorIs(t, err, ErrMissingCredentials)
+	assert.Contains(t, err.Error(), "API token is empty")
+}
+
 func TestClient_RequestSetsHeaders(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
@@ -29,7 +72,7 @@ func TestClient_RequestSetsHeaders(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
-	_, _, err := client.get(context.Background(), "/test")
+	_, err := client.get(context.Background(), "/test")
 
 	require.NoError(t, err)
 }
@@ -47,6 +90,12 @@ func TestClient_HandlesErrorResponses(t *testing.T) {
 			response:   `{"message": "invalid token"}`,
 			wantErr:    ErrInvalidCredentials,
 		},
+		{
+			name:       "403 forbidden",
+			statusCode: http.StatusForbidden,
+			response:   `{"message": "access denied"}`,
+			wantErr:    ErrForbidden,
+		},
 		{
 			name:       "404 not found",
 			statusCode: http.StatusNotFound,
@@ -82,7 +131,7 @@ func TestClient_HandlesErrorResponses(t *testing.T) {
 			defer server.Close()
 
 			client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
-			_, _, err := client.get(context.Background(), "/test")
+			_, err := client.get(context.Background(), "/test")
 
 			require.Error(t, err)
 			assert.ErrorIs(t, err, tt.wantErr)
@@ -94,6 +143,82 @@ func TestClient_HandlesErrorResponses(t *testing.T) {
 	}
 }
 
+func TestClient_ErrorsNeverContainToken(t *testing.T) {
+	const token = "s3cr3t-t0ken"
+
+	// A server echoing the request back in its error message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "rejected " + r.Header.Get("Authorization") + " (token " + token + ")",
+		})
+	}))
+	defer server.Close()
+
+	var logged []RequestLog
+	client := NewClient("test-org", token, WithBaseURL(server.URL), WithLogger(func(l RequestLog) {
+		logged = append(logged, l)
+	}))
+	_, err := client.get(context.Background(), "/test")
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.NotContains(t, err.Error(), token)
+	assert.NotContains(t, fmt.Sprintf("%v %+v %#v", err, err, err), token)
+	assert.Contains(t, err.Error(), "Bearer [redacted]")
+
+	require.Len(t, logged, 1)
+	assert.NotContains(t, logged[0].Err.Error(), token)
+	assert.NotContains(t, logged[0].LogValue().String(), token)
+}
+
+func TestClient_RetryAfterOnAPIError(t *testing.T) {
+	tests := []struct {
+		name   string
+		header func() string
+		min    time.Duration
+		max    time.Duration
+	}{
+		{
+			name:   "seconds",
+			header: func() string { return "120" },
+			min:    120 * time.Second,
+			max:    120 * time.Second,
+		},
+		{
+			// HTTP dates have one-second resolution
+			name:   "HTTP date",
+			header: func() string { return time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat) },
+			min:    88 * time.Second,
+			max:    90 * time.Second,
+		},
+		{
+			name:   "absent",
+			header: func() string { return "" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if v := tt.header(); v != "" {
+					w.Header().Set("Retry-After", v)
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			defer server.Close()
+
+			client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+			_, err := client.get(context.Background(), "/test")
+
+			var apiErr *APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.GreaterOrEqual(t, apiErr.RetryAfter, tt.min)
+			assert.LessOrEqual(t, apiErr.RetryAfter, tt.max)
+		})
+	}
+}
+
 func TestClient_PostSendsBody(t *testing.T) {
 	type testBody struct {
 		Name  string `json:"name"`
@@ -116,7 +241,7 @@ func TestClient_PostSendsBody(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
-	_, _, err := client.post(context.Background(), "/test", testBody{Name: "test", Value: 42})
+	_, err := client.post(context.Background(), "/test", testBody{Name: "test", Value: 42})
 
 	require.NoError(t, err)
 }
@@ -130,7 +255,7 @@ func TestClient_PatchSendsBody(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
-	_, _, err := client.patch(context.Background(), "/test", map[string]string{"key": "value"})
+	_, err := client.patch(context.Background(), "/test", map[string]string{"key": "value"})
 
 	require.NoError(t, err)
 }
@@ -141,3 +266,20 @@ func TestWithHTTPClient(t *testing.T) {
 
 	assert.Same(t, customClient, client.httpClient)
 }
+
+func TestWithTimeout(t *testing.T) {
+	assert.Equal(t, DefaultTimeout, NewClient("org", "token").httpClient.Timeout)
+
+	client := NewClient("org", "token", WithTimeout(90*time.Second))
+	assert.Equal(t, 90*time.Second, client.httpClient.Timeout)
+
+	// Non-positive values keep the default
+	client = NewClient("org", "token", WithTimeout(0))
+	assert.Equal(t, DefaultTimeout, client.httpClient.Timeout)
+
+	// A custom HTTP client is copied, not changed
+	customClient := &http.Client{Timeout: time.Second}
+	client = NewClient("org", "token", WithHTTPClient(customClient), WithTimeout(time.Minute))
+	assert.Equal(t, time.Minute, client.httpClient.Timeout)
+	assert.Equal(t, time.Second, customClient.Timeout)
+}
@@ -2,8 +2,9 @@ package api
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/hubblenetwork/hubcli/internal/models"
 )
@@ -11,30 +12,19 @@ import (
 // ListDevices returns all devices registered to the organization.
 // Handles pagination automatically to retrieve all devices.
 func (c *Client) ListDevices(ctx context.Context) ([]models.Device, error) {
-	path := fmt.Sprintf("/org/%s/devices", c.orgID)
-
 	var allDevices []models.Device
 	var contToken string
 
 	// Handle pagination
 	for {
-		body, headers, err := c.getWithContToken(ctx, path, contToken)
+		page, nextToken, err := c.ListDevicesPage(ctx, contToken)
 		if err != nil {
 			return nil, err
 		}
 
-		// API returns {"devices": [...]}
-		var response struct {
-			Devices []models.Device `json:"devices"`
-		}
-		if err := json.Unmarshal(body, &response); err != nil {
-			return nil, fmt.Errorf("failed to parse devices response: %w", err)
-		}
-
-		allDevices = append(allDevices, response.Devices...)
+		allDevices = append(allDevices, page...)
 
-		// Check for continuation token in response header
-		contToken = headers.Get("Continuation-Token")
+		contToken = nextToken
 		if contToken == "" {
 			break
 		}
@@ -43,49 +33,116 @@ func (c *Client) ListDevices(ctx context.Context) ([]models.Device, error) {
 	return allDevices, nil
 }
 
+// ListDevicesPage fetches a single page of devices starting at the given
+// continuation token (empty for the first page). It returns the devices and
+// the token for the next page, which is empty when there are no more.
+func (c *Client) ListDevicesPage(ctx context.Context, token string) ([]models.Device, string, error) {
+	path := fmt.Sprintf("/org/%s/devices", c.orgID)
+
+	resp, err := c.getWithContToken(ctx, path, token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// API returns {"devices": [...]}
+	var page struct {
+		Devices []models.Device `json:"devices"`
+	}
+	if err := resp.decode(&page, "devices"); err != nil {
+		return nil, "", err
+	}
+
+	// Check for continuation token in response header
+	return page.Devices, c.continuationToken(resp), nil
+}
+
+// MaxRegisterDevices is the most devices a single registration request may
+// create.
+const MaxRegisterDevices = 100
+
+// GetDevice returns a single device by its ID. The error wraps ErrNotFound
+// if the organization has no such device.
+func (c *Client) GetDevice(ctx context.Context, deviceID string) (*models.Device, error) {
+	path := fmt.Sprintf("/org/%s/devices/%s", c.orgID, deviceID)
+
+	resp, err := c.get(ctx, path)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("device %s not found: %w", deviceID, err)
+		}
+		return nil, err
+	}
+
+	var device models.Device
+	if err := resp.decode(&device, "device"); err != nil {
+		return nil, err
+	}
+	if device.ID == "" {
+		return nil, fmt.Errorf("no device returned for %s", deviceID)
+	}
+
+	return &device, nil
+}
+
 // RegisterDevice creates a new device with the specified encryption type.
 // If encryption is empty, defaults to AES-256-CTR.
 func (c *Client) RegisterDevice(ctx context.Context, req models.RegisterDeviceRequest) (*models.Device, error) {
+	devices, err := c.RegisterDevices(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &devices[0], nil
+}
+
+// RegisterDevices creates req.NDevices devices (1 if unset, at most
+// MaxRegisterDevices) with the specified encryption type and returns all of
+// them. Device keys are only returned here, so callers should show or store
+// them. If encryption is empty, defaults to AES-256-CTR.
+func (c *Client) RegisterDevices(ctx context.Context, req models.RegisterDeviceRequest) ([]models.Device, error) {
 	path := fmt.Sprintf("/v2/org/%s/devices", c.orgID)
 
 	// Set defaults
 	if req.NDevices == 0 {
 		req.NDevices = 1
 	}
+	if req.NDevices < 0 || req.NDevices > MaxRegisterDevices {
+		return nil, fmt.Errorf("cannot register %d devices at once; the limit is %d", req.NDevices, MaxRegisterDevices)
+	}
 	if req.Encryption == "" {
 		req.Encryption = models.EncryptionAES256CTR
 	}
 
-	body, _, err := c.post(ctx, path, req)
+	// Unlike other writes, registration is retried on transient failures
+	resp, err := c.doRetry(ctx, http.MethodPost, path, req, "")
 	if err != nil {
 		return nil, err
 	}
 
 	// The API returns a list of devices even when registering one
 	var devices []models.Device
-	if err := json.Unmarshal(body, &devices); err != nil {
-		return nil, fmt.Errorf("failed to parse register device response: %w", err)
+	if err := resp.decode(&devices, "register device"); err != nil {
+		return nil, err
 	}
 
 	if len(devices) == 0 {
 		return nil, fmt.Errorf("no device returned from registration")
 	}
 
-	return &devices[0], nil
+	return devices, nil
 }
 
 // UpdateDevice updates device metadata (name and/or tags).
 func (c *Client) UpdateDevice(ctx context.Context, deviceID string, req models.UpdateDeviceRequest) (*models.Device, error) {
 	path := fmt.Sprintf("/org/%s/devices/%s", c.orgID, deviceID)
 
-	body, _, err := c.patch(ctx, path, req)
+	resp, err := c.patch(ctx, path, req)
 	if err != nil {
 		return nil, err
 	}
 
 	var device models.Device
-	if err := json.Unmarshal(body, &device); err != nil {
-		return nil, fmt.Errorf("failed to parse update device response: %w", err)
+	if err := resp.decode(&device, "update device"); err != nil {
+		return nil, err
 	}
 
 	return &device, nil
@@ -109,10 +166,20 @@ func (c *Client) SetDeviceTags(ctx context.Context, deviceID string, tags map[st
 func (c *Client) DeleteDevice(ctx context.Context, deviceID string) error {
 	path := fmt.Sprintf("/org/%s/devices/%s", c.orgID, deviceID)
 
-	_, _, err := c.delete(ctx, path)
+	_, err := c.delete(ctx, path)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// DeleteDevices deletes several devices concurrently, with at most the
+// client's concurrency limit (see WithMaxConcurrency) in flight at once. Every
+// device is attempted; the result holds the error deleting each device, nil
+// if it was deleted, indexed like deviceIDs.
+func (c *Client) DeleteDevices(ctx context.Context, deviceIDs []string) []error {
+	return c.forEach(ctx, len(deviceIDs), func(ctx context.Context, i int) error {
+		return c.DeleteDevice(ctx, deviceIDs[i])
+	})
+}
@@ -3,8 +3,10 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
@@ -127,6 +129,96 @@ func TestClient_ListDevices(t *testing.T) {
 	})
 }
 
+func TestClient_ListDevicesPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/org/test-org/devices", r.URL.Path)
+
+		if r.Header.Get("Continuation-Token") == "" {
+			w.Header().Set("Continuation-Token", "token123")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"devices":[{"id":"dev-001"},{"id":"dev-002"}]}`))
+			return
+		}
+		assert.Equal(t, "token123", r.Header.Get("Continuation-Token"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"devices":[{"id":"dev-003"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+
+	devices, token, err := client.ListDevicesPage(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+	assert.Equal(t, "dev-001", devices[0].ID)
+	assert.Equal(t, "token123", token)
+
+	devices, token, err = client.ListDevicesPage(context.Background(), token)
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, "dev-003", devices[0].ID)
+	assert.Empty(t, token)
+}
+
+func TestClient_GetDevice(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodGet, r.Method)
+			assert.Equal(t, "/org/test-org/devices/dev-001", r.URL.Path)
+			json.NewEncoder(w).Encode(models.Device{ID: "dev-001", Name: "Sensor", Encryption: models.EncryptionAES128CTR})
+		}))
+		defer server.Close()
+
+		client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+		device, err := client.GetDevice(context.Background(), "dev-001")
+
+		require.NoError(t, err)
+		assert.Equal(t, "dev-001", device.ID)
+		assert.Equal(t, "Sensor", device.Name)
+		assert.Equal(t, models.EncryptionAES128CTR, device.Encryption)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+		device, err := client.GetDevice(context.Background(), "dev-missing")
+
+		assert.Nil(t, device)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Contains(t, err.Error(), "device dev-missing not found")
+	})
+
+	t.Run("malformed response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"id": 42`))
+		}))
+		defer server.Close()
+
+		client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+		_, err := client.GetDevice(context.Background(), "dev-001")
+
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, "device", decodeErr.What)
+	})
+
+	t.Run("empty response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{}`))
+		}))
+		defer server.Close()
+
+		client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+		_, err := client.GetDevice(context.Background(), "dev-001")
+
+		assert.EqualError(t, err, "no device returned for dev-001")
+	})
+}
+
 func TestClient_RegisterDevice(t *testing.T) {
 	t.Run("success with defaults", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -193,6 +285,58 @@ func TestClient_RegisterDevice(t *testing.T) {
 	})
 }
 
+func TestClient_RegisterDevices(t *testing.T) {
+	t.Run("several devices", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v2/org/test-org/devices", r.URL.Path)
+
+			var req models.RegisterDeviceRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, 3, req.NDevices)
+			assert.Equal(t, models.EncryptionAES128CTR, req.Encryption)
+
+			devices := make([]models.Device, req.NDevices)
+			for i := range devices {
+				devices[i] = models.Device{
+					ID:         fmt.Sprintf("new-dev-%03d", i+1),
+					Key:        fmt.Sprintf("key%d==", i+1),
+					Encryption: req.Encryption,
+				}
+			}
+			json.NewEncoder(w).Encode(devices)
+		}))
+		defer server.Close()
+
+		client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+		devices, err := client.RegisterDevices(context.Background(), models.RegisterDeviceRequest{
+			NDevices:   3,
+			Encryption: models.EncryptionAES128CTR,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, devices, 3)
+		assert.Equal(t, "new-dev-003", devices[2].ID)
+		assert.Equal(t, "key3==", devices[2].Key)
+	})
+
+	t.Run("too many devices", func(t *testing.T) {
+		client := NewClient("test-org", "test-token", WithBaseURL("http://127.0.0.1:0"))
+		_, err := client.RegisterDevices(context.Background(), models.RegisterDeviceRequest{NDevices: MaxRegisterDevices + 1})
+		assert.Error(t, err)
+	})
+
+	t.Run("empty response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("[]"))
+		}))
+		defer server.Close()
+
+		client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+		_, err := client.RegisterDevices(context.Background(), models.RegisterDeviceRequest{NDevices: 2})
+		assert.Error(t, err)
+	})
+}
+
 func TestClient_UpdateDevice(t *testing.T) {
 	t.Run("update name", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -298,3 +442,29 @@ func TestClient_SetDeviceTags(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "test", device.Tags["env"])
 }
+
+func TestClient_DeleteDevices(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		if r.URL.Path == "/org/test-org/devices/dev-bad" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		deleted = append(deleted, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL), WithMaxConcurrency(2))
+	errs := client.DeleteDevices(context.Background(), []string{"dev-001", "dev-bad", "dev-002"})
+
+	require.Len(t, errs, 3)
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], ErrNotFound)
+	assert.NoError(t, errs[2])
+	assert.ElementsMatch(t, []string{"/org/test-org/devices/dev-001", "/org/test-org/devices/dev-002"}, deleted)
+}
@@ -3,15 +3,22 @@ package api
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 )
 
 // Common API errors.
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrForbidden          = errors.New("access denied")
 	ErrNotFound           = errors.New("resource not found")
 	ErrRateLimited        = errors.New("rate limited")
 	ErrServerError        = errors.New("server error")
 	ErrBadRequest         = errors.New("bad request")
+	ErrCircuitOpen        = errors.New("service unavailable, backing off")
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrTimestampRange     = errors.New("timestamp out of range")
 )
 
 // APIError represents an error response from the Hubble API.
@@ -19,31 +26,47 @@ type APIError struct {
 	StatusCode int
 	Message    string
 	Details    map[string]interface{}
+	RetryAfter time.Duration // From the Retry-After header, 0 if absent
 }
 
 func (e *APIError) Error() string {
 	if e.Message != "" {
-		return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Message)
+		return fmt.Sprintf("API error %d: %s", e.StatusCode, Redact(e.Message, ""))
 	}
 	return fmt.Sprintf("API error %d", e.StatusCode)
 }
 
-// Is implements error matching for APIError.
+// Is implements error matching for APIError, so errors.Is(err, ErrNotFound)
+// reports whether a request failed with a 404.
 func (e *APIError) Is(target error) bool {
-	switch e.StatusCode {
+	sentinel := statusError(e.StatusCode)
+	return sentinel != nil && target == sentinel
+}
+
+// statusError returns the common error for an HTTP status code, or nil if
+// the status has none.
+func statusError(statusCode int) error {
+	switch statusCode {
+	case 400:
+		return ErrBadRequest
 	case 401:
-		return errors.Is(target, ErrInvalidCredentials)
+		return ErrInvalidCredentials
+	case 403:
+		return ErrForbidden
 	case 404:
-		return errors.Is(target, ErrNotFound)
+		return ErrNotFound
 	case 429:
-		return errors.Is(target, ErrRateLimited)
-	case 400:
-		return errors.Is(target, ErrBadRequest)
+		return ErrRateLimited
 	}
-	if e.StatusCode >= 500 {
-		return errors.Is(target, ErrServerError)
+	if statusCode >= 500 {
+		return ErrServerError
 	}
-	return false
+	return nil
+}
+
+// IsNotFound reports whether err is an API error for a missing resource.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
 }
 
 // NewAPIError creates an APIError from an HTTP status code.
@@ -53,3 +76,77 @@ func NewAPIError(statusCode int, message string) *APIError {
 		Message:    message,
 	}
 }
+
+// DecodeError is returned when a successful response body can't be parsed.
+type DecodeError struct {
+	What       string // What was being parsed, e.g. "packets"
+	Endpoint   string // Method and path, e.g. "GET /org/123/packets"
+	StatusCode int
+	Snippet    string // Truncated body with secrets redacted
+	Err        error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to parse %s response from %s (status %d): %v; body: %q",
+		e.What, e.Endpoint, e.StatusCode, e.Err, e.Snippet)
+}
+
+// Unwrap returns the underlying JSON error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+const maxSnippetLength = 200
+
+// secretFieldPattern matches JSON string fields that may hold secrets,
+// such as device encryption keys.
+var secretFieldPattern = regexp.MustCompile(`(?i)"(key|token|secret|password)"\s*:\s*"[^"]*"`)
+
+// bodySnippet returns a redacted, truncated copy of a response body for
+// inclusion in error messages.
+func bodySnippet(body []byte) string {
+	s := secretFieldPattern.ReplaceAllString(Redact(string(body), ""), `"$1":"[redacted]"`)
+	if len(s) > maxSnippetLength {
+		s = s[:maxSnippetLength] + "..."
+	}
+	return s
+}
+
+// bearerPattern matches the credentials of a bearer Authorization header.
+var bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[^\s"',;]+`)
+
+// Redact returns s with token, and the credentials of anything that looks
+// like a bearer Authorization header, replaced with [redacted]. An empty
+// token only redacts bearer credentials. Use it on any text that may end up
+// in an error message or log.
+func Redact(s, token string) string {
+	if token != "" {
+		s = strings.ReplaceAll(s, token, "[redacted]")
+	}
+	return bearerPattern.ReplaceAllString(s, "${1}[redacted]")
+}
+
+// redactedError hides the API token in the message of an error that
+// contains it, while still matching the error it wraps.
+type redactedError struct {
+	err   error
+	token string
+}
+
+func (e *redactedError) Error() string {
+	return Redact(e.err.Error(), e.token)
+}
+
+// Unwrap returns the original error.
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError returns err, wrapped so that its message doesn't contain
+// token or bearer credentials if it would otherwise.
+func redactError(err error, token string) error {
+	if err == nil || Redact(err.Error(), token) == err.Error() {
+		return err
+	}
+	return &redactedError{err: err, token: token}
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,6 +25,11 @@ func TestAPIError_Error(t *testing.T) {
 			err:      &APIError{StatusCode: 500},
 			expected: "API error 500",
 		},
+		{
+			name:     "echoed authorization header",
+			err:      &APIError{StatusCode: 401, Message: "bad header Authorization: Bearer s3cr3t-t0ken"},
+			expected: "API error 401: bad header Authorization: Bearer [redacted]",
+		},
 	}
 
 	for _, tt := range tests {
@@ -40,6 +47,7 @@ func TestAPIError_Is(t *testing.T) {
 		expected   bool
 	}{
 		{"401 matches ErrInvalidCredentials", 401, ErrInvalidCredentials, true},
+		{"403 matches ErrForbidden", 403, ErrForbidden, true},
 		{"404 matches ErrNotFound", 404, ErrNotFound, true},
 		{"429 matches ErrRateLimited", 429, ErrRateLimited, true},
 		{"400 matches ErrBadRequest", 400, ErrBadRequest, true},
@@ -47,6 +55,10 @@ func TestAPIError_Is(t *testing.T) {
 		{"502 matches ErrServerError", 502, ErrServerError, true},
 		{"503 matches ErrServerError", 503, ErrServerError, true},
 		{"401 does not match ErrNotFound", 401, ErrNotFound, false},
+		{"401 does not match ErrForbidden", 401, ErrForbidden, false},
+		{"403 does not match ErrInvalidCredentials", 403, ErrInvalidCredentials, false},
+		{"404 does not match ErrServerError", 404, ErrServerError, false},
+		{"500 does not match ErrNotFound", 500, ErrNotFound, false},
 		{"200 does not match any", 200, ErrServerError, false},
 	}
 
@@ -58,9 +70,82 @@ func TestAPIError_Is(t *testing.T) {
 	}
 }
 
+func TestIsNotFound(t *testing.T) {
+	assert.True(t, IsNotFound(&APIError{StatusCode: 404}))
+	assert.True(t, IsNotFound(fmt.Errorf("device abc not found: %w", &APIError{StatusCode: 404})))
+	assert.False(t, IsNotFound(&APIError{StatusCode: 403}))
+	assert.False(t, IsNotFound(errors.New("boom")))
+	assert.False(t, IsNotFound(nil))
+}
+
 func TestNewAPIError(t *testing.T) {
 	err := NewAPIError(404, "not found")
 
 	assert.Equal(t, 404, err.StatusCode)
 	assert.Equal(t, "not found", err.Message)
 }
+
+func TestBodySnippet(t *testing.T) {
+	t.Run("redacts secrets", func(t *testing.T) {
+		body := []byte(`{"id": "abc", "key": "c2VjcmV0LWtleQ==", "Token":"t0k3n"}`)
+
+		snippet := bodySnippet(body)
+
+		assert.NotContains(t, snippet, "c2VjcmV0LWtleQ==")
+		assert.NotContains(t, snippet, "t0k3n")
+		assert.Contains(t, snippet, `"key":"[redacted]"`)
+		assert.Contains(t, snippet, `"id": "abc"`)
+	})
+
+	t.Run("redacts bearer credentials", func(t *testing.T) {
+		snippet := bodySnippet([]byte(`{"error": "rejected Bearer s3cr3t-t0ken"}`))
+
+		assert.NotContains(t, snippet, "s3cr3t-t0ken")
+		assert.Contains(t, snippet, "Bearer [redacted]")
+	})
+
+	t.Run("truncates long bodies", func(t *testing.T) {
+		body := []byte(strings.Repeat("x", maxSnippetLength+50))
+
+		snippet := bodySnippet(body)
+
+		assert.Len(t, snippet, maxSnippetLength+3)
+		assert.True(t, strings.HasSuffix(snippet, "..."))
+	})
+}
+
+func TestDecodeError(t *testing.T) {
+	inner := errors.New("unexpected end of JSON input")
+	err := &DecodeError{
+		What:       "packets",
+		Endpoint:   "GET /org/123/packets",
+		StatusCode: 200,
+		Snippet:    `{"packets": [`,
+		Err:        inner,
+	}
+
+	assert.Contains(t, err.Error(), "failed to parse packets response")
+	assert.Contains(t, err.Error(), "GET /org/123/packets")
+	assert.Contains(t, err.Error(), "status 200")
+	assert.Contains(t, err.Error(), `{\"packets\": [`)
+	assert.ErrorIs(t, err, inner)
+}
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, "token [redacted] rejected", Redact("token s3cr3t-t0ken rejected", "s3cr3t-t0ken"))
+	assert.Equal(t, "Authorization: Bearer [redacted]", Redact("Authorization: Bearer abc.def", ""))
+	assert.Equal(t, `"bearer [redacted]"`, Redact(`"bearer abc"`, ""))
+	assert.Equal(t, "nothing to hide", Redact("nothing to hide", "s3cr3t-t0ken"))
+}
+
+func TestRedactError(t *testing.T) {
+	assert.NoError(t, redactError(nil, "s3cr3t-t0ken"))
+
+	// Errors without the token are returned as they are
+	plain := errors.New("connection refused")
+	assert.Same(t, plain, redactError(plain, "s3cr3t-t0ken"))
+
+	err := redactError(fmt.Errorf("request failed: %w", &APIError{StatusCode: 401, Message: "s3cr3t-t0ken"}), "s3cr3t-t0ken")
+	assert.NotContains(t, err.Error(), "s3cr3t-t0ken")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
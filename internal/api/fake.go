@@ -0,0 +1,512 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+// FakeOrgID is the organization ID reported by a FakeClient.
+const FakeOrgID = "demo-org"
+
+const (
+	// Sample packets are reported every fakePacketInterval for the last
+	// fakePacketHistory
+	fakePacketInterval = 30 * time.Minute
+	fakePacketHistory  = 3 * 24 * time.Hour
+
+	fakeDevicesPageSize = 50
+	fakePacketsPageSize = 100
+	fakeDeviceQuota     = 50
+	fakeRetentionDays   = 30
+)
+
+// fakeDevices are the devices a FakeClient starts with. Silent devices have
+// never reported a packet.
+var fakeDevices = []struct {
+	id         string
+	name       string
+	encryption models.EncryptionType
+	tags       map[string]string
+	lat, lon   float64
+	silent     bool
+}{
+	{"5d0c8f7e-3a41-4b6e-9f27-0c1e8a6b2d01", "Warehouse door", models.EncryptionAES256CTR, map[string]string{"site": "sf"}, 37.7749, -122.4194, false},
+	{"a81f2c44-7b9d-4e03-8c5a-61d2f0e7b902", "Pallet tracker 01", models.EncryptionAES256CTR, map[string]string{"site": "oakland", "fleet": "pallets"}, 37.8044, -122.2712, false},
+	{"c3e97a10-58f2-4d6b-a1c4-9e0b7d3f6a03", "Pallet tracker 02", models.EncryptionAES256CTR, map[string]string{"fleet": "pallets"}, 37.3382, -121.8863, false},
+	{"1f4b6d82-c9e0-4a37-b5d8-2e7a0c9f1b04", "Cold room sensor", models.EncryptionAES128CTR, map[string]string{"site": "seattle"}, 47.6062, -122.3321, false},
+	{"e6a2d9c5-0f18-4b7e-8d63-4c1b9a2e5f05", "", models.EncryptionAES256CTR, nil, 0, 0, true},
+}
+
+// fakeDroppedPackets are the packets, by device index and packet number,
+// left out of the sample data so the packets screen has sequence gaps to
+// show.
+var fakeDroppedPackets = map[[2]int]bool{
+	{1, 20}: true,
+	{1, 21}: true,
+	{1, 50}: true,
+}
+
+// FakeClient is an in-memory APIClient seeded with sample devices and
+// packets, for demos and use without API access. The sample data depends
+// only on the time the client is created with, so fake clients created with
+// the same time return the same data. Registrations, renames, deletions and
+// ingested packets change the in-memory data only.
+type FakeClient struct {
+	mu       sync.Mutex
+	now      time.Time
+	org      models.Organization
+	devices  []models.Device
+	packets  []models.RetrievedPacket // Newest first
+	nextID   int
+	ingested *ingestLedger
+}
+
+// NewFakeClient creates a FakeClient whose sample packets run up to now.
+func NewFakeClient(now time.Time) *FakeClient {
+	retention := fakeRetentionDays
+	quota := fakeDeviceQuota
+	f := &FakeClient{
+		now: now,
+		org: models.Organization{
+			ID:                     FakeOrgID,
+			Name:                   "Demo Organization",
+			RetentionDays:          &retention,
+			AllowedEncryptionTypes: []models.EncryptionType{models.EncryptionAES256CTR, models.EncryptionAES128CTR},
+			DeviceQuota:            &quota,
+		},
+		nextID:   1,
+		ingested: newIngestLedger(),
+	}
+
+	for i, d := range fakeDevices {
+		device := models.Device{
+			ID:         d.id,
+			Name:       d.name,
+			Key:        fakeDeviceKey(d.id, d.encryption),
+			Encryption: d.encryption,
+			Tags:       d.tags,
+			Active:     true,
+			CreatedTS:  now.AddDate(0, 0, -60+10*i).Unix(),
+		}
+		if !d.silent {
+			packets := fakeDevicePackets(i, now)
+			newest := packets[0].Device.Timestamp
+			device.MostRecentPacket = &models.MostRecentPacketInfo{
+				Terrestrial: &models.PacketTimestamp{Timestamp: newest},
+			}
+			f.packets = append(f.packets, packets...)
+		}
+		f.devices = append(f.devices, device)
+	}
+	sortFakePackets(f.packets)
+
+	return f
+}
+
+// fakeDeviceKey derives a key of the right length for the encryption type
+// from the device ID.
+func fakeDeviceKey(id string, encryption models.EncryptionType) string {
+	key := sha256.Sum256([]byte(id))
+	if encryption == models.EncryptionAES128CTR {
+		return base64.StdEncoding.EncodeToString(key[:16])
+	}
+	return base64.StdEncoding.EncodeToString(key[:])
+}
+
+// fakeDevicePackets returns the sample packets of the device at index i,
+// newest first.
+func fakeDevicePackets(i int, now time.Time) []models.RetrievedPacket {
+	d := fakeDevices[i]
+	n := int(fakePacketHistory / fakePacketInterval)
+	newest := now.Truncate(fakePacketInterval).Add(-time.Duration(i) * time.Minute)
+
+	var packets []models.RetrievedPacket
+	for k := n - 1; k >= 0; k-- {
+		if fakeDroppedPackets[[2]int{i, k}] {
+			continue
+		}
+
+		ts := newest.Add(-time.Duration(n-1-k) * fakePacketInterval)
+		temp := 1800 + (k*37+i*11)%600 // Hundredths of a degree
+		battery := 100 - k/4
+		payload := []byte{byte(temp >> 8), byte(temp), byte(battery), byte(k), byte(i), 0}
+
+		packets = append(packets, models.RetrievedPacket{
+			Location: models.RetrievedLocation{
+				Timestamp:          float64(ts.Unix()),
+				Latitude:           d.lat + float64((k*7+i)%11-5)*0.0002,
+				Longitude:          d.lon + float64((k*5+i)%11-5)*0.0002,
+				HorizontalAccuracy: float64(10 + (k%5)*5),
+			},
+			Device: models.RetrievedDevice{
+				ID:             d.id,
+				Name:           d.name,
+				Tags:           d.tags,
+				Payload:        base64.StdEncoding.EncodeToString(payload),
+				Timestamp:      float64(ts.Unix()),
+				RSSI:           -55 - (k*13+i*7)%35,
+				SequenceNumber: (i*100 + k) % 1024,
+				Counter:        int(ts.Unix() / 86400),
+			},
+			NetworkType: "terrestrial",
+		})
+	}
+	return packets
+}
+
+// sortFakePackets sorts packets newest first, by device ID between packets
+// with the same timestamp.
+func sortFakePackets(packets []models.RetrievedPacket) {
+	sort.Slice(packets, func(i, j int) bool {
+		a, b := packets[i], packets[j]
+		if a.Device.Timestamp != b.Device.Timestamp {
+			return a.Device.Timestamp > b.Device.Timestamp
+		}
+		return a.Device.ID < b.Device.ID
+	})
+}
+
+// fakePage returns the bounds of the page of size items starting at token
+// in a list of n, and the token of the next page.
+func fakePage(token string, size, n int) (start, end int, next string, err error) {
+	if token != "" {
+		start, err = strconv.Atoi(token)
+		if err != nil || start < 0 || start > n {
+			return 0, 0, "", fmt.Errorf("%w: invalid continuation token %q", ErrBadRequest, token)
+		}
+	}
+	end = min(start+size, n)
+	if end < n {
+		next = strconv.Itoa(end)
+	}
+	return start, end, next, nil
+}
+
+// OrgID returns the fake organization ID, FakeOrgID.
+func (f *FakeClient) OrgID() string {
+	return f.org.ID
+}
+
+// CheckCredentials always succeeds.
+func (f *FakeClient) CheckCredentials(ctx context.Context) error {
+	return nil
+}
+
+// GetOrganization returns the fake organization.
+func (f *FakeClient) GetOrganization(ctx context.Context) (*models.Organization, error) {
+	org := f.org
+	return &org, nil
+}
+
+// ListDevices returns all devices.
+func (f *FakeClient) ListDevices(ctx context.Context) ([]models.Device, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]models.Device(nil), f.devices...), nil
+}
+
+// ListDevicesPage returns a page of devices like Client.ListDevicesPage.
+func (f *FakeClient) ListDevicesPage(ctx context.Context, token string) ([]models.Device, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	start, end, next, err := fakePage(token, fakeDevicesPageSize, len(f.devices))
+	if err != nil {
+		return nil, "", err
+	}
+	return append([]models.Device(nil), f.devices[start:end]...), next, nil
+}
+
+// GetDevice returns a single device by its ID. The error wraps ErrNotFound
+// if there is no such device.
+func (f *FakeClient) GetDevice(ctx context.Context, deviceID string) (*models.Device, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := f.deviceIndex(deviceID)
+	if i < 0 {
+		return nil, fmt.Errorf("device %s not found: %w", deviceID, ErrNotFound)
+	}
+	device := f.devices[i]
+	return &device, nil
+}
+
+// RegisterDevice creates a single device like RegisterDevices.
+func (f *FakeClient) RegisterDevice(ctx context.Context, req models.RegisterDeviceRequest) (*models.Device, error) {
+	devices, err := f.RegisterDevices(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &devices[0], nil
+}
+
+// RegisterDevices creates req.NDevices devices with the same defaults and
+// limits as Client.RegisterDevices.
+func (f *FakeClient) RegisterDevices(ctx context.Context, req models.RegisterDeviceRequest) ([]models.Device, error) {
+	if req.NDevices == 0 {
+		req.NDevices = 1
+	}
+	if req.NDevices < 0 || req.NDevices > MaxRegisterDevices {
+		return nil, fmt.Errorf("cannot register %d devices at once; the limit is %d", req.NDevices, MaxRegisterDevices)
+	}
+	if req.Encryption == "" {
+		req.Encryption = models.EncryptionAES256CTR
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.devices)+req.NDevices > fakeDeviceQuota {
+		return nil, fmt.Errorf("%w: registering %d device(s) would exceed the quota of %d", ErrBadRequest, req.NDevices, fakeDeviceQuota)
+	}
+
+	devices := make([]models.Device, req.NDevices)
+	for i := range devices {
+		id := fmt.Sprintf("00000000-0000-4000-8000-%012d", f.nextID)
+		f.nextID++
+		devices[i] = models.Device{
+			ID:         id,
+			Key:        fakeDeviceKey(id, req.Encryption),
+			Encryption: req.Encryption,
+			Active:     true,
+			CreatedTS:  f.now.Unix(),
+		}
+	}
+	f.devices = append(f.devices, devices...)
+
+	return devices, nil
+}
+
+// SetDeviceName renames a device. The error wraps ErrNotFound if there is no
+// such device.
+func (f *FakeClient) SetDeviceName(ctx context.Context, deviceID, name string) (*models.Device, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := f.deviceIndex(deviceID)
+	if i < 0 {
+		return nil, fmt.Errorf("device %s not found: %w", deviceID, ErrNotFound)
+	}
+	f.devices[i].Name = name
+	device := f.devices[i]
+	return &device, nil
+}
+
+// SetDeviceTags replaces a device's tags. The error wraps ErrNotFound if
+// there is no such device.
+func (f *FakeClient) SetDeviceTags(ctx context.Context, deviceID string, tags map[string]string) (*models.Device, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := f.deviceIndex(deviceID)
+	if i < 0 {
+		return nil, fmt.Errorf("device %s not found: %w", deviceID, ErrNotFound)
+	}
+	f.devices[i].Tags = tags
+	device := f.devices[i]
+	return &device, nil
+}
+
+// DeleteDevice deletes a device. The error wraps ErrNotFound if there is no
+// such device.
+func (f *FakeClient) DeleteDevice(ctx context.Context, deviceID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := f.deviceIndex(deviceID)
+	if i < 0 {
+		return fmt.Errorf("device %s not found: %w", deviceID, ErrNotFound)
+	}
+	f.devices = append(f.devices[:i], f.devices[i+1:]...)
+	return nil
+}
+
+// DeleteDevices deletes each device like DeleteDevice, returning the errors
+// like Client.DeleteDevices.
+func (f *FakeClient) DeleteDevices(ctx context.Context, deviceIDs []string) []error {
+	errs := make([]error, len(deviceIDs))
+	for i, id := range deviceIDs {
+		errs[i] = f.DeleteDevice(ctx, id)
+	}
+	return errs
+}
+
+// deviceIndex returns the index of the device with the given ID, or -1.
+// f.mu must be held.
+func (f *FakeClient) deviceIndex(deviceID string) int {
+	for i, d := range f.devices {
+		if d.ID == deviceID {
+			return i
+		}
+	}
+	return -1
+}
+
+// PacketsURL returns the URL the real API would be queried with for opts.
+func (f *FakeClient) PacketsURL(opts RetrievePacketsOptions) string {
+	return models.EnvProduction.BaseURL() + packetsPath(f.org.ID, opts)
+}
+
+// RetrievePacketsWithPagination returns the sample packets matching opts
+// like RetrievePacketsPages, all at once.
+func (f *FakeClient) RetrievePacketsWithPagination(ctx context.Context, opts RetrievePacketsOptions) (*RetrievePacketsResult, error) {
+	pages, errs := f.RetrievePacketsPages(ctx, opts)
+
+	result := &RetrievePacketsResult{}
+	for page := range pages {
+		result.Packets = append(result.Packets, page.Packets...)
+		result.ContinuationToken = page.ContinuationToken
+	}
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RetrievePacketsPages sends the sample packets matching opts, newest first,
+// in pages like Client.RetrievePacketsPages. Days is counted back from the
+// time the client was created with.
+func (f *FakeClient) RetrievePacketsPages(ctx context.Context, opts RetrievePacketsOptions) (<-chan PacketsPage, <-chan error) {
+	packets := f.matchingPackets(opts)
+	pages := make(chan PacketsPage)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(pages)
+
+		token := opts.ContinuationToken
+		sent := 0
+		for n := 1; ; n++ {
+			// Pages end at the limit, like the real API's
+			size := fakePacketsPageSize
+			if opts.Limit > 0 {
+				size = min(size, opts.Limit-sent)
+			}
+			start, end, next, err := fakePage(token, size, len(packets))
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			page := packets[start:end]
+			sent += len(page)
+			token = next
+			opts.progress(n, sent)
+
+			select {
+			case pages <- PacketsPage{Packets: page, ContinuationToken: token}:
+			case <-ctx.Done():
+				return
+			}
+
+			if token == "" || (opts.Limit > 0 && sent >= opts.Limit) {
+				return
+			}
+		}
+	}()
+
+	return pages, errs
+}
+
+// matchingPackets returns the packets of opts.DeviceID, or of every device,
+// received since the start of opts and before its end, if set.
+func (f *FakeClient) matchingPackets(opts RetrievePacketsOptions) []models.RetrievedPacket {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	start := float64(opts.start(f.now).Unix())
+	var packets []models.RetrievedPacket
+	for _, p := range f.packets {
+		if p.Device.Timestamp < start {
+			break // Newest first, so the rest are older still
+		}
+		if opts.End != nil && !p.Timestamp().Before(*opts.End) {
+			continue
+		}
+		if opts.DeviceID == nil || p.DeviceID() == *opts.DeviceID {
+			packets = append(packets, p)
+		}
+	}
+	return packets
+}
+
+// IngestEncryptedPackets checks packets like IngestEncryptedPacketsWithReport
+// without reporting what was sent.
+func (f *FakeClient) IngestEncryptedPackets(ctx context.Context, packets []models.EncryptedPacket) error {
+	_, err := f.IngestEncryptedPacketsWithReport(ctx, packets)
+	return err
+}
+
+// IngestEncryptedPacketsWithReport checks packets like
+// Client.IngestEncryptedPacketsWithReport and reports them as sent without
+// adding them to the sample data.
+func (f *FakeClient) IngestEncryptedPacketsWithReport(ctx context.Context, packets []models.EncryptedPacket) (IngestReport, error) {
+	var report IngestReport
+	now := time.Now()
+
+	var keys []string
+	for _, p := range packets {
+		key := p.Key()
+		if f.ingested.has(key) {
+			report.AlreadyIngested++
+			continue
+		}
+		if _, ok := checkIngestTimestamp(p.Timestamp, now); !ok {
+			report.Rejected++
+			continue
+		}
+		if p.Timestamp.IsZero() {
+			report.TimestampsReplaced++
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 && report.Rejected > 0 {
+		return report, fmt.Errorf("%w: all %d packet(s) rejected", ErrTimestampRange, report.Rejected)
+	}
+
+	report.Sent = len(keys)
+	f.ingested.add(keys)
+	return report, nil
+}
+
+// Advertisements returns sample BLE advertisements from the devices that
+// report packets, timestamped with the time the client was created with.
+// They have the Hubble payload layout but random-looking contents, so they
+// do not decrypt. Use them to seed a ble.MockScanner alongside the client.
+func (f *FakeClient) Advertisements() []models.EncryptedPacket {
+	var packets []models.EncryptedPacket
+	for i, d := range fakeDevices {
+		if d.silent {
+			continue
+		}
+		for k := 0; k < 3; k++ {
+			seq := (i*100 + k) % 1024
+			body := sha256.Sum256([]byte(fmt.Sprintf("%s/%d", d.id, k)))
+
+			payload := make([]byte, models.HubbleDataOffset+6)
+			payload[0] = byte(seq >> 8)
+			payload[1] = byte(seq)
+			copy(payload[models.HubbleDeviceIDOffset:], body[:])
+
+			packets = append(packets, models.EncryptedPacket{
+				Payload:   payload,
+				RSSI:      -50 - (i*9+k*4)%40,
+				Timestamp: f.now,
+			})
+		}
+	}
+	return packets
+}
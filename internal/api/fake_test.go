@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fakeNow = time.Date(2025, 10, 16, 12, 0, 0, 0, time.UTC)
+
+// fakePages reads every page RetrievePacketsPages sends for opts.
+func fakePages(t *testing.T, f *FakeClient, opts RetrievePacketsOptions) []PacketsPage {
+	t.Helper()
+	pages, errs := f.RetrievePacketsPages(context.Background(), opts)
+	var got []PacketsPage
+	for page := range pages {
+		got = append(got, page)
+	}
+	require.NoError(t, <-errs)
+	return got
+}
+
+func allPackets(pages []PacketsPage) []models.RetrievedPacket {
+	var packets []models.RetrievedPacket
+	for _, page := range pages {
+		packets = append(packets, page.Packets...)
+	}
+	return packets
+}
+
+func TestFakeClient_Deterministic(t *testing.T) {
+	ctx := context.Background()
+	a, b := NewFakeClient(fakeNow), NewFakeClient(fakeNow)
+
+	devicesA, err := a.ListDevices(ctx)
+	require.NoError(t, err)
+	devicesB, err := b.ListDevices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, devicesA, devicesB)
+	assert.Len(t, devicesA, len(fakeDevices))
+
+	packetsA := allPackets(fakePages(t, a, RetrievePacketsOptions{}))
+	packetsB := allPackets(fakePages(t, b, RetrievePacketsOptions{}))
+	assert.Equal(t, packetsA, packetsB)
+	assert.NotEmpty(t, packetsA)
+
+	assert.Equal(t, a.Advertisements(), b.Advertisements())
+}
+
+func TestFakeClient_Devices(t *testing.T) {
+	ctx := context.Background()
+	f := NewFakeClient(fakeNow)
+
+	devices, err := f.ListDevices(ctx)
+	require.NoError(t, err)
+	for _, d := range devices {
+		key, err := base64.StdEncoding.DecodeString(d.Key)
+		require.NoError(t, err, d.ID)
+		if d.Encryption == models.EncryptionAES128CTR {
+			assert.Len(t, key, 16)
+		} else {
+			assert.Len(t, key, 32)
+		}
+	}
+
+	// The unnamed device has never been seen; the rest were seen at most
+	// an interval ago
+	silent := devices[len(devices)-1]
+	assert.Empty(t, silent.Name)
+	_, seen := silent.LastSeen()
+	assert.False(t, seen)
+	for _, d := range devices[:len(devices)-1] {
+		lastSeen, ok := d.LastSeen()
+		require.True(t, ok, d.ID)
+		assert.WithinDuration(t, fakeNow, lastSeen, fakePacketInterval+time.Hour, d.ID)
+	}
+
+	page, next, err := f.ListDevicesPage(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, devices, page)
+	assert.Empty(t, next)
+
+	_, _, err = f.ListDevicesPage(ctx, "bogus")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	org, err := f.GetOrganization(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, FakeOrgID, org.ID)
+	assert.Equal(t, FakeOrgID, f.OrgID())
+	assert.NoError(t, f.CheckCredentials(ctx))
+}
+
+func TestFakeClient_RetrievePacketsPages(t *testing.T) {
+	f := NewFakeClient(fakeNow)
+
+	pages := fakePages(t, f, RetrievePacketsOptions{})
+	require.Greater(t, len(pages), 1)
+	for _, page := range pages[:len(pages)-1] {
+		assert.Len(t, page.Packets, fakePacketsPageSize)
+		assert.NotEmpty(t, page.ContinuationToken)
+	}
+	assert.Empty(t, pages[len(pages)-1].ContinuationToken)
+
+	packets := allPackets(pages)
+	for i := 1; i < len(packets); i++ {
+		assert.GreaterOrEqual(t, packets[i-1].Device.Timestamp, packets[i].Device.Timestamp, "newest first")
+	}
+
+	// Filtered by device and time
+	id := fakeDevices[0].id
+	day := allPackets(fakePages(t, f, RetrievePacketsOptions{DeviceID: &id, Days: 1}))
+	assert.Len(t, day, int(24*time.Hour/fakePacketInterval)+1)
+	start := float64(fakeNow.AddDate(0, 0, -1).Unix())
+	for _, p := range day {
+		assert.Equal(t, id, p.DeviceID())
+		assert.GreaterOrEqual(t, p.Device.Timestamp, start)
+	}
+
+	// Limited
+	limited := fakePages(t, f, RetrievePacketsOptions{Limit: 150})
+	assert.Len(t, allPackets(limited), 150)
+	assert.NotEmpty(t, limited[len(limited)-1].ContinuationToken)
+
+	// Resuming after the limit continues where it stopped
+	rest := allPackets(fakePages(t, f, RetrievePacketsOptions{ContinuationToken: limited[len(limited)-1].ContinuationToken}))
+	assert.Equal(t, packets, append(allPackets(limited), rest...))
+
+	// Resumed from a continuation token
+	resumed := allPackets(fakePages(t, f, RetrievePacketsOptions{ContinuationToken: pages[0].ContinuationToken}))
+	assert.Equal(t, packets[fakePacketsPageSize:], resumed)
+}
+
+func TestFakeClient_SequenceGaps(t *testing.T) {
+	f := NewFakeClient(fakeNow)
+	id := fakeDevices[1].id
+	packets := allPackets(fakePages(t, f, RetrievePacketsOptions{DeviceID: &id}))
+
+	assert.Len(t, packets, int(fakePacketHistory/fakePacketInterval)-len(fakeDroppedPackets))
+}
+
+func TestFakeClient_DeviceChanges(t *testing.T) {
+	ctx := context.Background()
+	f := NewFakeClient(fakeNow)
+	id := fakeDevices[0].id
+
+	renamed, err := f.SetDeviceName(ctx, id, "Front door")
+	require.NoError(t, err)
+	assert.Equal(t, "Front door", renamed.Name)
+	got, err := f.GetDevice(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Front door", got.Name)
+
+	registered, err := f.RegisterDevices(ctx, models.RegisterDeviceRequest{NDevices: 2})
+	require.NoError(t, err)
+	require.Len(t, registered, 2)
+	assert.NotEqual(t, registered[0].ID, registered[1].ID)
+	assert.Equal(t, models.EncryptionAES256CTR, registered[0].Encryption)
+	assert.NotEmpty(t, registered[0].Key)
+
+	require.NoError(t, f.DeleteDevice(ctx, id))
+	_, err = f.GetDevice(ctx, id)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, f.DeleteDevice(ctx, id), ErrNotFound)
+	_, err = f.SetDeviceName(ctx, id, "x")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	devices, err := f.ListDevices(ctx)
+	require.NoError(t, err)
+	assert.Len(t, devices, len(fakeDevices)+1)
+
+	// Changes are not shared with other fake clients
+	other, err := NewFakeClient(fakeNow).GetDevice(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, fakeDevices[0].name, other.Name)
+}
+
+func TestFakeClient_IngestEncryptedPacketsWithReport(t *testing.T) {
+	ctx := context.Background()
+	f := NewFakeClient(fakeNow)
+	packets := []models.EncryptedPacket{
+		{Payload: []byte{1}, Timestamp: time.Now()},
+		{Payload: []byte{2}},
+		{Payload: []byte{3}, Timestamp: time.Now().AddDate(-2, 0, 0)},
+	}
+
+	report, err := f.IngestEncryptedPacketsWithReport(ctx, packets)
+	require.NoError(t, err)
+	assert.Equal(t, IngestReport{Sent: 2, TimestampsReplaced: 1, Rejected: 1}, report)
+
+	report, err = f.IngestEncryptedPacketsWithReport(ctx, packets[:1])
+	require.NoError(t, err)
+	assert.Equal(t, IngestReport{AlreadyIngested: 1}, report)
+
+	_, err = f.IngestEncryptedPacketsWithReport(ctx, packets[2:])
+	assert.ErrorIs(t, err, ErrTimestampRange)
+}
+
+func TestFakeClient_Advertisements(t *testing.T) {
+	ads := NewFakeClient(fakeNow).Advertisements()
+	require.NotEmpty(t, ads)
+	for _, ad := range ads {
+		payload, err := models.ParseHubblePayload(ad.Payload)
+		require.NoError(t, err)
+		assert.True(t, payload.KnownVersion())
+		assert.NotEmpty(t, payload.Encrypted)
+		assert.Equal(t, fakeNow, ad.Timestamp)
+	}
+}
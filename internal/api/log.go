@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// RequestLog describes one API request, for debugging failed calls. It
+// never holds the API token: the Authorization header is redacted.
+type RequestLog struct {
+	Method     string
+	Path       string
+	Header     http.Header // Request headers, with Authorization redacted
+	StatusCode int         // 0 if no response was received
+	Duration   time.Duration
+
+	ContinuationToken     string // Token sent with the request, if any
+	NextContinuationToken string // Token returned for the next page, if any
+
+	Err error
+}
+
+// LogValue implements slog.LogValuer, so a RequestLog can be logged as a
+// single attribute.
+func (l RequestLog) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("method", l.Method),
+		slog.String("path", l.Path),
+		slog.Int("status", l.StatusCode),
+		slog.Duration("duration", l.Duration),
+	}
+	if auth := l.Header.Get("Authorization"); auth != "" {
+		attrs = append(attrs, slog.String("authorization", auth))
+	}
+	if l.ContinuationToken != "" {
+		attrs = append(attrs, slog.String("continuation_token", l.ContinuationToken))
+	}
+	if l.NextContinuationToken != "" {
+		attrs = append(attrs, slog.String("next_continuation_token", l.NextContinuationToken))
+	}
+	if l.Err != nil {
+		attrs = append(attrs, slog.String("error", Redact(l.Err.Error(), "")))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// WithLogger calls fn after every request, including each retry, with a
+// description of it. fn is called from the goroutine making the request,
+// so it must be safe for concurrent use.
+func WithLogger(fn func(RequestLog)) ClientOption {
+	return func(client *Client) {
+		client.logger = fn
+	}
+}
+
+// SlogLogger returns a WithLogger hook writing each request to logger, at
+// error level if it failed and info level otherwise.
+func SlogLogger(logger *slog.Logger) func(RequestLog) {
+	return func(l RequestLog) {
+		level := slog.LevelInfo
+		if l.Err != nil {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(context.Background(), level, "api request", slog.Any("request", l))
+	}
+}
+
+// redactHeader returns a copy of h that is safe to log.
+func redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	if auth := h.Get("Authorization"); auth != "" {
+		h.Set("Authorization", Redact(auth, ""))
+	}
+	return h
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(DefaultContinuationHeader, "next-page")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var logs []RequestLog
+	client := NewClient("test-org", "secret-token", WithBaseURL(server.URL), WithLogger(func(l RequestLog) {
+		logs = append(logs, l)
+	}))
+
+	_, err := client.do(context.Background(), http.MethodGet, "/ok", nil, "this-page")
+	require.NoError(t, err)
+	_, err = client.get(context.Background(), "/fail")
+	require.Error(t, err)
+
+	require.Len(t, logs, 2)
+	assert.Equal(t, http.MethodGet, logs[0].Method)
+	assert.Equal(t, "/ok", logs[0].Path)
+	assert.Equal(t, http.StatusOK, logs[0].StatusCode)
+	assert.Equal(t, "this-page", logs[0].ContinuationToken)
+	assert.Equal(t, "next-page", logs[0].NextContinuationToken)
+	assert.Equal(t, "Bearer [redacted]", logs[0].Header.Get("Authorization"))
+	assert.NoError(t, logs[0].Err)
+
+	assert.Equal(t, http.StatusInternalServerError, logs[1].StatusCode)
+	assert.Error(t, logs[1].Err)
+
+	// The token is in neither the logs nor their slog output
+	var out bytes.Buffer
+	logger := SlogLogger(slog.New(slog.NewTextHandler(&out, nil)))
+	for _, l := range logs {
+		assert.NotContains(t, fmt.Sprintf("%+v", l), "secret-token")
+		logger(l)
+	}
+	assert.Contains(t, out.String(), "level=INFO")
+	assert.Contains(t, out.String(), "request.path=/ok")
+	assert.Contains(t, out.String(), "request.next_continuation_token=next-page")
+	assert.Contains(t, out.String(), "level=ERROR")
+	assert.Contains(t, out.String(), "request.status=500")
+	assert.NotContains(t, out.String(), "secret-token")
+}
+
+func TestRedactHeader(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer secret-token")
+	h.Set("User-Agent", userAgent)
+
+	redacted := redactHeader(h)
+	assert.Equal(t, "Bearer [redacted]", redacted.Get("Authorization"))
+	assert.Equal(t, userAgent, redacted.Get("User-Agent"))
+
+	// The original header is unchanged
+	assert.Equal(t, "Bearer secret-token", h.Get("Authorization"))
+}
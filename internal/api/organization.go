@@ -2,7 +2,6 @@ package api
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
 	"github.com/hubblenetwork/hubcli/internal/models"
@@ -19,14 +18,14 @@ func (c *Client) CheckCredentials(ctx context.Context) error {
 // GetOrganization retrieves organization metadata.
 func (c *Client) GetOrganization(ctx context.Context) (*models.Organization, error) {
 	path := fmt.Sprintf("/org/%s", c.orgID)
-	body, _, err := c.get(ctx, path)
+	resp, err := c.get(ctx, path)
 	if err != nil {
 		return nil, err
 	}
 
 	var org models.Organization
-	if err := json.Unmarshal(body, &org); err != nil {
-		return nil, fmt.Errorf("failed to parse organization response: %w", err)
+	if err := resp.decode(&org, "organization"); err != nil {
+		return nil, err
 	}
 
 	return &org, nil
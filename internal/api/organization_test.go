@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/hubblenetwork/hubcli/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -58,6 +59,27 @@ func TestClient_GetOrganization(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, "test-org", org.ID)
 		assert.Equal(t, "Test Organization", org.Name)
+		assert.Nil(t, org.RetentionDays)
+		assert.Nil(t, org.DeviceQuota)
+		assert.Empty(t, org.AllowedEncryptionTypes)
+	})
+
+	t.Run("with settings", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"org_id": "test-org", "name": "Test", "retention_days": 30, "device_quota": 500, "allowed_encryption_types": ["AES-256-CTR"]}`))
+		}))
+		defer server.Close()
+
+		client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+		org, err := client.GetOrganization(context.Background())
+
+		require.NoError(t, err)
+		require.NotNil(t, org.RetentionDays)
+		assert.Equal(t, 30, *org.RetentionDays)
+		require.NotNil(t, org.DeviceQuota)
+		assert.Equal(t, 500, *org.DeviceQuota)
+		assert.Equal(t, []models.EncryptionType{models.EncryptionAES256CTR}, org.AllowedEncryptionTypes)
 	})
 
 	t.Run("not found", func(t *testing.T) {
@@ -3,10 +3,13 @@ package api
 import (
 	"context"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/hubblenetwork/hubcli/internal/models"
@@ -16,9 +19,19 @@ import (
 type RetrievePacketsOptions struct {
 	DeviceID          *string
 	Start             *time.Time
-	Days              int    // If Start is nil, query from (now - Days) to now
-	Limit             int    // Maximum number of packets to retrieve (0 = no limit)
-	ContinuationToken string // Token to continue from a previous request
+	Days              int        // If Start is nil, query from (now - Days) to now
+	End               *time.Time // Exclusive end of the range; nil for up to now
+	Limit             int        // Packets to retrieve before stopping (0 = no limit); see RetrievePacketsPages
+	ContinuationToken string     // Token to continue from a previous request
+
+	// AllowPartial makes RetrievePacketsWithPagination return a *PartialResult
+	// error instead of discarding the pages already fetched when a later
+	// page fails.
+	AllowPartial bool
+
+	// OnPage, if set, is called after each page is fetched with the page
+	// number, counting from 1, and the number of packets fetched so far.
+	OnPage func(page, fetched int)
 }
 
 // RetrievePacketsResult contains packets and pagination info.
@@ -27,6 +40,24 @@ type RetrievePacketsResult struct {
 	ContinuationToken string // Non-empty if more packets are available
 }
 
+// PartialResult is the error returned by RetrievePacketsWithPagination when
+// AllowPartial is set and a page fails after earlier pages succeeded. It
+// holds the packets fetched so far and the continuation token of the page
+// that failed, so retrieval can be resumed from there.
+type PartialResult struct {
+	Packets           []models.RetrievedPacket
+	ContinuationToken string
+	Err               error
+}
+
+func (e *PartialResult) Error() string {
+	return fmt.Sprintf("retrieved %d packet(s) before failing: %v", len(e.Packets), e.Err)
+}
+
+func (e *PartialResult) Unwrap() error {
+	return e.Err
+}
+
 // RetrievePackets fetches decrypted packets from the cloud.
 // By default, retrieves packets from the last 7 days.
 func (c *Client) RetrievePackets(ctx context.Context, opts RetrievePacketsOptions) ([]models.RetrievedPacket, error) {
@@ -37,10 +68,19 @@ func (c *Client) RetrievePackets(ctx context.Context, opts RetrievePacketsOption
 	return result.Packets, nil
 }
 
-// RetrievePacketsWithPagination fetches packets with pagination support.
-// Returns packets and a continuation token if more are available.
-func (c *Client) RetrievePacketsWithPagination(ctx context.Context, opts RetrievePacketsOptions) (*RetrievePacketsResult, error) {
-	path := fmt.Sprintf("/org/%s/packets", c.orgID)
+// PacketsURL returns the full packets URL that RetrievePacketsWithPagination
+// requests for opts. The API token is sent in a header and is not part of the
+// URL, so the result is safe to share. Limit and ContinuationToken are not
+// part of the URL.
+func (c *Client) PacketsURL(opts RetrievePacketsOptions) string {
+	opts.Limit = 0
+	return c.baseURL + packetsPath(c.orgID, opts)
+}
+
+// packetsPath builds the packets path and query string for opts in orgID.
+// Limit, if set, is sent as the page size.
+func packetsPath(orgID string, opts RetrievePacketsOptions) string {
+	path := fmt.Sprintf("/org/%s/packets", orgID)
 
 	// Build query parameters
 	params := url.Values{}
@@ -49,57 +89,101 @@ func (c *Client) RetrievePacketsWithPagination(ctx context.Context, opts Retriev
 		params.Set("device_id", *opts.DeviceID)
 	}
 
-	// Calculate start time
-	var start time.Time
-	if opts.Start != nil {
-		start = *opts.Start
-	} else {
-		days := opts.Days
-		if days == 0 {
-			days = 7 // Default to 7 days
-		}
-		start = time.Now().UTC().AddDate(0, 0, -days)
+	params.Set("start", strconv.FormatInt(opts.start(time.Now()).Unix(), 10))
+	if opts.End != nil {
+		params.Set("end", strconv.FormatInt(opts.End.Unix(), 10))
+	}
+	if opts.Limit > 0 {
+		params.Set("limit", strconv.Itoa(opts.Limit))
 	}
-	params.Set("start", strconv.FormatInt(start.Unix(), 10))
 
 	if len(params) > 0 {
 		path += "?" + params.Encode()
 	}
 
+	return path
+}
+
+// start returns the time packets are retrieved from: Start if set, otherwise
+// Days (7 if unset) before now.
+func (opts RetrievePacketsOptions) start(now time.Time) time.Time {
+	if opts.Start != nil {
+		return *opts.Start
+	}
+	days := opts.Days
+	if days == 0 {
+		days = 7 // Default to 7 days
+	}
+	return now.UTC().AddDate(0, 0, -days)
+}
+
+// before returns the packets of page sent before End, or all of them if End
+// is nil, in case the server returns packets past it.
+func (opts RetrievePacketsOptions) before(page []models.RetrievedPacket) []models.RetrievedPacket {
+	if opts.End == nil {
+		return page
+	}
+	var kept []models.RetrievedPacket
+	for _, p := range page {
+		if p.Timestamp().Before(*opts.End) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// withStart returns opts with Start set, so every page of a retrieval
+// queries the same time range.
+func (opts RetrievePacketsOptions) withStart(now time.Time) RetrievePacketsOptions {
+	start := opts.start(now)
+	opts.Start = &start
+	return opts
+}
+
+// pagePath returns the path of the next page of a retrieval once fetched
+// packets have been received, asking for no more than Limit in total.
+func (c *Client) pagePath(opts RetrievePacketsOptions, fetched int) string {
+	if opts.Limit > 0 {
+		opts.Limit -= fetched
+	}
+	return packetsPath(c.orgID, opts)
+}
+
+// RetrievePacketsWithPagination fetches packets with pagination support.
+// Returns packets and a continuation token if more are available. Limit
+// works as for RetrievePacketsPages.
+func (c *Client) RetrievePacketsWithPagination(ctx context.Context, opts RetrievePacketsOptions) (*RetrievePacketsResult, error) {
+	opts = opts.withStart(time.Now())
+
 	var allPackets []models.RetrievedPacket
 	contToken := opts.ContinuationToken
+	pages := 0
 
 	// Handle pagination
 	for {
-		body, headers, err := c.getWithContToken(ctx, path, contToken)
+		page, nextToken, err := c.retrievePacketsPage(ctx, c.pagePath(opts, len(allPackets)), contToken)
 		if err != nil {
+			if opts.AllowPartial && len(allPackets) > 0 {
+				return nil, &PartialResult{
+					Packets:           allPackets,
+					ContinuationToken: contToken,
+					Err:               err,
+				}
+			}
 			return nil, err
 		}
 
-		// API returns {"packets": [...]}
-		var response struct {
-			Packets []models.RetrievedPacket `json:"packets"`
-		}
-		if err := json.Unmarshal(body, &response); err != nil {
-			return nil, fmt.Errorf("failed to parse packets response: %w", err)
-		}
+		allPackets = append(allPackets, opts.before(page)...)
+		pages++
 
-		allPackets = append(allPackets, response.Packets...)
+		contToken = nextToken
 
-		// Check for continuation token in response header
-		contToken = headers.Get("Continuation-Token")
-
-		// Stop if we've reached the limit
-		if opts.Limit > 0 && len(allPackets) >= opts.Limit {
-			// Trim to exact limit
-			if len(allPackets) > opts.Limit {
-				allPackets = allPackets[:opts.Limit]
-			}
-			// Keep the continuation token to indicate more are available
-			break
-		}
+		limited := opts.Limit > 0 && len(allPackets) >= opts.Limit
+		opts.progress(pages, len(allPackets))
 
-		if contToken == "" {
+		// Stop if we've reached the limit, keeping the continuation token
+		// to indicate more are available
+		if limited || contToken == "" {
 			break
 		}
 	}
@@ -110,28 +194,315 @@ func (c *Client) RetrievePacketsWithPagination(ctx context.Context, opts Retriev
 	}, nil
 }
 
+// progress calls OnPage, if set.
+func (opts RetrievePacketsOptions) progress(page, fetched int) {
+	if opts.OnPage != nil {
+		opts.OnPage(page, fetched)
+	}
+}
+
+// PacketsPage is one page of packets sent by RetrievePacketsPages.
+type PacketsPage struct {
+	Packets           []models.RetrievedPacket
+	ContinuationToken string // Token for the next page; empty on the last page
+}
+
+// RetrievePacketsPages fetches packets like RetrievePacketsWithPagination
+// but sends each page as soon as it arrives instead of returning them all
+// at the end. Retrieval stops after the last page or once Limit packets
+// have been sent, keeping the continuation token of the final page.
+//
+// Limit is sent with each request as the number of packets still wanted, so
+// the server ends the page there. A server returning more anyway has its
+// whole page sent: trimming it would lose the surplus, since the page's
+// continuation token resumes after it.
+//
+// Both channels are closed when retrieval stops. A failed page sends its
+// error on the error channel first; the continuation token of the last
+// page received resumes from the failed page. Cancelling ctx stops
+// retrieval and closes the channels without sending an error.
+// AllowPartial is ignored since every page is delivered as it arrives.
+func (c *Client) RetrievePacketsPages(ctx context.Context, opts RetrievePacketsOptions) (<-chan PacketsPage, <-chan error) {
+	pages := make(chan PacketsPage)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(pages)
+
+		opts := opts.withStart(time.Now())
+		contToken := opts.ContinuationToken
+		sent := 0
+		for n := 1; ; n++ {
+			page, nextToken, err := c.retrievePacketsPage(ctx, c.pagePath(opts, sent), contToken)
+			if err != nil {
+				if ctx.Err() == nil {
+					errs <- err
+				}
+				return
+			}
+
+			page = opts.before(page)
+			sent += len(page)
+			contToken = nextToken
+			opts.progress(n, sent)
+
+			// A page with nothing in the range isn't worth showing
+			if len(page) == 0 && contToken != "" {
+				continue
+			}
+
+			select {
+			case pages <- PacketsPage{Packets: page, ContinuationToken: contToken}:
+			case <-ctx.Done():
+				return
+			}
+
+			if contToken == "" || (opts.Limit > 0 && sent >= opts.Limit) {
+				return
+			}
+		}
+	}()
+
+	return pages, errs
+}
+
+// RetrievePacketsStream fetches packets like RetrievePacketsPages but sends
+// them one at a time. Both channels are closed when retrieval stops, after
+// sending any error on the error channel; cancelling ctx closes them
+// without an error.
+func (c *Client) RetrievePacketsStream(ctx context.Context, opts RetrievePacketsOptions) (<-chan models.RetrievedPacket, <-chan error) {
+	pages, pageErrs := c.RetrievePacketsPages(ctx, opts)
+	packets := make(chan models.RetrievedPacket)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(packets)
+
+		for page := range pages {
+			for _, p := range page.Packets {
+				select {
+				case packets <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+		if err := <-pageErrs; err != nil {
+			errs <- err
+		}
+	}()
+
+	return packets, errs
+}
+
+// retrievePacketsPage fetches and decodes a single page of packets, returning
+// the continuation token for the next page.
+func (c *Client) retrievePacketsPage(ctx context.Context, path, contToken string) ([]models.RetrievedPacket, string, error) {
+	resp, err := c.getWithContToken(ctx, path, contToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// API returns {"packets": [...]}
+	var page struct {
+		Packets []models.RetrievedPacket `json:"packets"`
+	}
+	if err := resp.decode(&page, "packets"); err != nil {
+		return nil, "", err
+	}
+
+	// Check for continuation token in response header
+	return page.Packets, c.continuationToken(resp), nil
+}
+
 // IngestPacket uploads encrypted BLE packets to the cloud for processing.
+//
+// Ingest requests use their own timeout and retry count (see
+// WithIngestTimeout and WithIngestRetries). The API does not deduplicate
+// uploads, so a request is only retried when the server cannot have accepted
+// it: a 429 or 503 response, or a refused connection. Other failures, such as
+// timeouts or 500 responses, are returned without retrying and the error
+// notes that the packets may already have been ingested.
 func (c *Client) IngestPacket(ctx context.Context, req models.IngestPacketRequest) error {
 	path := fmt.Sprintf("/org/%s/packets", c.orgID)
-	_, _, err := c.post(ctx, path, req)
-	return err
+
+	httpClient := *c.httpClient
+	httpClient.Timeout = c.ingestTimeout
+
+	for attempt := 0; ; attempt++ {
+		_, err := c.doWith(ctx, &httpClient, http.MethodPost, path, req, "")
+		if err == nil {
+			return nil
+		}
+
+		retryable, uncertain := classifyIngestError(err)
+		if uncertain {
+			return fmt.Errorf("%w (packets may have been ingested; not retried to avoid duplicates)", err)
+		}
+		if !retryable || attempt >= c.ingestRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(c.ingestRetryDelay):
+		}
+	}
+}
+
+// classifyIngestError reports whether a failed ingest request is safe to
+// retry, because the server cannot have accepted it, and whether its outcome
+// is uncertain, because the server may have accepted it before failing.
+func classifyIngestError(err error) (retryable, uncertain bool) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrMissingCredentials) {
+		return false, false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode == http.StatusServiceUnavailable:
+			return true, false
+		case apiErr.StatusCode >= 500:
+			return false, true
+		}
+		return false, false
+	}
+
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return false, false
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true, false
+	}
+
+	// Timeouts, resets and other transport errors may happen after the
+	// request body was sent.
+	return false, true
+}
+
+// Limits on packet timestamps accepted for ingestion. Anything outside them
+// is almost certainly a bad clock or an unset field.
+const (
+	maxIngestFutureSkew = 24 * time.Hour
+	maxIngestAge        = 365 * 24 * time.Hour
+)
+
+// maxIngestBatch is how many packets one ingest request carries. Larger
+// batches are split, so a failure only has to resend the requests that
+// were not accepted.
+const maxIngestBatch = 100
+
+// maxIngestLedger is how many acknowledged packets a client remembers. The
+// oldest are forgotten first; by then they are long out of any capture
+// still being retried.
+const maxIngestLedger = 50000
+
+// IngestReport describes what IngestEncryptedPacketsWithReport sent.
+type IngestReport struct {
+	Sent               int // Packets the API accepted
+	TimestampsReplaced int // Packets with a zero timestamp that were sent with the current time
+	Rejected           int // Packets skipped because their timestamp was out of range
+	AlreadyIngested    int // Packets skipped because this client already uploaded them
+}
+
+// ingestLedger records the packets the API has acknowledged during this
+// session, so submitting a batch again after a failure only uploads the
+// packets that were not accepted. It holds at most max keys.
+type ingestLedger struct {
+	mu    sync.Mutex
+	max   int
+	keys  map[string]struct{}
+	order []string // Keys oldest first, for forgetting beyond max
+}
+
+func newIngestLedger() *ingestLedger {
+	return &ingestLedger{max: maxIngestLedger, keys: make(map[string]struct{})}
+}
+
+// has reports whether the packet with the given key was acknowledged.
+func (l *ingestLedger) has(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.keys[key]
+	return ok
+}
+
+// add marks the packets with the given keys as acknowledged.
+func (l *ingestLedger) add(keys []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, k := range keys {
+		if _, ok := l.keys[k]; ok {
+			continue
+		}
+		l.keys[k] = struct{}{}
+		l.order = append(l.order, k)
+	}
+	for len(l.order) > l.max {
+		delete(l.keys, l.order[0])
+		l.order = l.order[1:]
+	}
 }
 
 // IngestEncryptedPackets is a convenience method to ingest multiple EncryptedPacket structs.
 func (c *Client) IngestEncryptedPackets(ctx context.Context, packets []models.EncryptedPacket) error {
+	_, err := c.IngestEncryptedPacketsWithReport(ctx, packets)
+	return err
+}
+
+// IngestEncryptedPacketsWithReport ingests packets after checking their
+// timestamps. Zero timestamps are replaced with the current time and packets
+// timestamped more than a day in the future or a year in the past are
+// skipped, so one bad packet doesn't fail the whole batch. If every packet is
+// rejected nothing is sent and an error wrapping ErrTimestampRange is returned.
+//
+// Packets are sent maxIngestBatch at a time. Those in accepted requests are
+// remembered and skipped when submitted again, so retrying a batch after a
+// failure does not upload them twice. On failure the report counts the
+// packets accepted before it.
+func (c *Client) IngestEncryptedPacketsWithReport(ctx context.Context, packets []models.EncryptedPacket) (IngestReport, error) {
+	var report IngestReport
 	if len(packets) == 0 {
-		return nil
+		return report, nil
 	}
 
+	now := time.Now()
+
 	// Group packets by location (for now, treat each packet as its own location)
 	var bleLocations []models.BLELocation
+	var keys []string
 
 	for _, p := range packets {
+		key := p.Key()
+		if c.ingested.has(key) {
+			report.AlreadyIngested++
+			continue
+		}
+
+		ts, ok := checkIngestTimestamp(p.Timestamp, now)
+		if !ok {
+			report.Rejected++
+			continue
+		}
+		if p.Timestamp.IsZero() {
+			report.TimestampsReplaced++
+		}
+
+		locTS := p.Location.Timestamp
+		if locTS.IsZero() {
+			locTS = ts
+		}
+
 		loc := models.BLELocation{
 			Location: models.LocationPayload{
 				Latitude:           p.Location.Latitude,
 				Longitude:          p.Location.Longitude,
-				Timestamp:          p.Location.Timestamp.Unix(),
+				Timestamp:          locTS.Unix(),
 				HorizontalAccuracy: p.Location.HorizontalAccuracy,
 				Altitude:           p.Location.Altitude,
 				VerticalAccuracy:   p.Location.VerticalAccuracy,
@@ -140,16 +511,47 @@ func (c *Client) IngestEncryptedPackets(ctx context.Context, packets []models.En
 				{
 					Payload:   encodeBase64(p.Payload),
 					RSSI:      p.RSSI,
-					Timestamp: p.Timestamp.Unix(),
+					Timestamp: ts.Unix(),
 				},
 			},
 		}
 		bleLocations = append(bleLocations, loc)
+		keys = append(keys, key)
 	}
 
-	return c.IngestPacket(ctx, models.IngestPacketRequest{
-		BLELocations: bleLocations,
-	})
+	if len(bleLocations) == 0 {
+		if report.Rejected == 0 {
+			return report, nil
+		}
+		return report, fmt.Errorf("%w: all %d packet(s) rejected", ErrTimestampRange, report.Rejected)
+	}
+
+	for start := 0; start < len(bleLocations); start += maxIngestBatch {
+		end := min(start+maxIngestBatch, len(bleLocations))
+		if err := c.IngestPacket(ctx, models.IngestPacketRequest{
+			BLELocations: bleLocations[start:end],
+		}); err != nil {
+			if report.Sent > 0 {
+				err = fmt.Errorf("%d of %d packet(s) ingested before failing: %w", report.Sent, len(bleLocations), err)
+			}
+			return report, err
+		}
+		c.ingested.add(keys[start:end])
+		report.Sent += end - start
+	}
+	return report, nil
+}
+
+// checkIngestTimestamp returns the timestamp to send for a packet and whether
+// it is acceptable. A zero timestamp is replaced with now.
+func checkIngestTimestamp(ts, now time.Time) (time.Time, bool) {
+	if ts.IsZero() {
+		return now, true
+	}
+	if ts.After(now.Add(maxIngestFutureSkew)) || ts.Before(now.Add(-maxIngestAge)) {
+		return ts, false
+	}
+	return ts, true
 }
 
 // encodeBase64 encodes bytes to base64 string.
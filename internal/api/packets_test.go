@@ -3,10 +3,13 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -257,3 +260,689 @@ func TestClient_IngestEncryptedPackets(t *testing.T) {
 		assert.False(t, serverCalled)
 	})
 }
+
+func TestClient_RetrievePackets_DecodeErrorContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"packets": "not-a-list", "key": "secret-key"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+	_, err := client.RetrievePackets(context.Background(), RetrievePacketsOptions{})
+
+	var decodeErr *DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, "packets", decodeErr.What)
+	assert.True(t, strings.HasPrefix(decodeErr.Endpoint, "GET /org/test-org/packets"))
+	assert.Equal(t, http.StatusOK, decodeErr.StatusCode)
+	assert.Contains(t, decodeErr.Snippet, "not-a-list")
+	assert.NotContains(t, err.Error(), "secret-key")
+}
+
+func TestClient_PacketsURL(t *testing.T) {
+	client := NewClient("test-org", "test-token", WithBaseURL("https://api.example.com"))
+	deviceID := "dev-001"
+	start := time.Unix(1700000000, 0)
+
+	got := client.PacketsURL(RetrievePacketsOptions{DeviceID: &deviceID, Start: &start})
+
+	assert.Equal(t, "https://api.example.com/org/test-org/packets?device_id=dev-001&start=1700000000", got)
+	assert.NotContains(t, got, "test-token")
+}
+
+func TestClient_PacketsURL_MatchesRequest(t *testing.T) {
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.RequestURI()
+		w.Write([]byte(`{"packets": []}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+	start := time.Unix(1700000000, 0)
+	opts := RetrievePacketsOptions{Start: &start}
+
+	_, err := client.RetrievePackets(context.Background(), opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, client.PacketsURL(opts), server.URL+requested)
+}
+
+func TestClient_RetrievePackets_PartialResult(t *testing.T) {
+	newServer := func() *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Header.Get("Continuation-Token") {
+			case "":
+				w.Header().Set("Continuation-Token", "page2")
+				w.Write([]byte(`{"packets": [{"device": {"id": "dev-001"}}]}`))
+			case "page2":
+				w.Header().Set("Continuation-Token", "page3")
+				w.Write([]byte(`{"packets": [{"device": {"id": "dev-002"}}]}`))
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		}))
+	}
+
+	t.Run("returns fetched pages", func(t *testing.T) {
+		server := newServer()
+		defer server.Close()
+
+		client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+		result, err := client.RetrievePacketsWithPagination(context.Background(), RetrievePacketsOptions{AllowPartial: true})
+
+		assert.Nil(t, result)
+		var partial *PartialResult
+		require.ErrorAs(t, err, &partial)
+		assert.Len(t, partial.Packets, 2)
+		assert.Equal(t, "page3", partial.ContinuationToken)
+		assert.ErrorIs(t, err, ErrBadRequest)
+	})
+
+	t.Run("discards pages without AllowPartial", func(t *testing.T) {
+		server := newServer()
+		defer server.Close()
+
+		client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+		result, err := client.RetrievePacketsWithPagination(context.Background(), RetrievePacketsOptions{})
+
+		assert.Nil(t, result)
+		var partial *PartialResult
+		assert.False(t, errors.As(err, &partial))
+		assert.ErrorIs(t, err, ErrBadRequest)
+	})
+
+	t.Run("first page failure is not partial", func(t *testing.T) {
+		server := newServer()
+		defer server.Close()
+
+		client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+		_, err := client.RetrievePacketsWithPagination(context.Background(), RetrievePacketsOptions{
+			AllowPartial:      true,
+			ContinuationToken: "bad",
+		})
+
+		var partial *PartialResult
+		assert.False(t, errors.As(err, &partial))
+		assert.ErrorIs(t, err, ErrBadRequest)
+	})
+}
+
+func TestCheckIngestTimestamp(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	ts, ok := checkIngestTimestamp(time.Time{}, now)
+	assert.True(t, ok)
+	assert.Equal(t, now, ts)
+
+	ts, ok = checkIngestTimestamp(now.Add(-time.Hour), now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(-time.Hour), ts)
+
+	_, ok = checkIngestTimestamp(now.Add(48*time.Hour), now)
+	assert.False(t, ok)
+
+	_, ok = checkIngestTimestamp(now.AddDate(-2, 0, 0), now)
+	assert.False(t, ok)
+}
+
+func TestClient_IngestEncryptedPacketsWithReport(t *testing.T) {
+	t.Run("replaces zero and skips out of range", func(t *testing.T) {
+		var req models.IngestPacketRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			w.Write([]byte(`{}`))
+		}))
+		defer server.Close()
+
+		client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+		packets := []models.EncryptedPacket{
+			{Payload: []byte{0x01}, Timestamp: time.Now()},
+			{Payload: []byte{0x02}}, // Unset timestamp
+			{Payload: []byte{0x03}, Timestamp: time.Now().AddDate(5, 0, 0)},
+		}
+
+		report, err := client.IngestEncryptedPacketsWithReport(context.Background(), packets)
+
+		require.NoError(t, err)
+		assert.Equal(t, IngestReport{Sent: 2, TimestampsReplaced: 1, Rejected: 1}, report)
+		require.Len(t, req.BLELocations, 2)
+		for _, loc := range req.BLELocations {
+			assert.Greater(t, loc.Adverstments[0].Timestamp, int64(0))
+			assert.Greater(t, loc.Location.Timestamp, int64(0))
+		}
+	})
+
+	t.Run("all rejected sends nothing", func(t *testing.T) {
+		serverCalled := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serverCalled = true
+		}))
+		defer server.Close()
+
+		client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+		report, err := client.IngestEncryptedPacketsWithReport(context.Background(), []models.EncryptedPacket{
+			{Payload: []byte{0x01}, Timestamp: time.Unix(1, 0)},
+		})
+
+		assert.ErrorIs(t, err, ErrTimestampRange)
+		assert.Equal(t, 1, report.Rejected)
+		assert.False(t, serverCalled)
+	})
+
+	t.Run("retry skips acknowledged packets", func(t *testing.T) {
+		var sent []int
+		fail := true
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req models.IngestPacketRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			sent = append(sent, len(req.BLELocations))
+			if fail {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{}`))
+		}))
+		defer server.Close()
+
+		client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+		now := time.Now()
+		first := []models.EncryptedPacket{
+			{Payload: []byte{0x01}, Timestamp: now},
+			{Payload: []byte{0x02}, Timestamp: now},
+		}
+
+		// A failed upload is not acknowledged, so it is sent again
+		_, err := client.IngestEncryptedPacketsWithReport(context.Background(), first)
+		require.Error(t, err)
+		fail = false
+		report, err := client.IngestEncryptedPacketsWithReport(context.Background(), first)
+		require.NoError(t, err)
+		assert.Equal(t, IngestReport{Sent: 2}, report)
+
+		// Only the new packet goes out once the first two are acknowledged
+		report, err = client.IngestEncryptedPacketsWithReport(context.Background(),
+			append(first, models.EncryptedPacket{Payload: []byte{0x03}, Timestamp: now}))
+		require.NoError(t, err)
+		assert.Equal(t, IngestReport{Sent: 1, AlreadyIngested: 2}, report)
+
+		// Nothing is sent when every packet was acknowledged
+		report, err = client.IngestEncryptedPacketsWithReport(context.Background(), first)
+		require.NoError(t, err)
+		assert.Equal(t, IngestReport{AlreadyIngested: 2}, report)
+
+		assert.Equal(t, []int{2, 2, 1}, sent)
+	})
+}
+
+func TestClient_IngestEncryptedPackets_Batches(t *testing.T) {
+	var sent []int
+	failSecond := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.IngestPacketRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		sent = append(sent, len(req.BLELocations))
+		if failSecond && len(sent) == 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	packets := make([]models.EncryptedPacket, 250)
+	for i := range packets {
+		packets[i] = models.EncryptedPacket{Payload: []byte{byte(i), byte(i >> 8)}, Timestamp: now}
+	}
+
+	// The first batch is accepted before the second fails
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+	report, err := client.IngestEncryptedPacketsWithReport(context.Background(), packets)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "100 of 250 packet(s) ingested before failing")
+	assert.Equal(t, 100, report.Sent)
+
+	// A client rebuilt with new settings only resends the rest
+	failSecond = false
+	rebuilt := NewClient("test-org", "test-token", WithBaseURL(server.URL), WithIngestHistory(client))
+	report, err = rebuilt.IngestEncryptedPacketsWithReport(context.Background(), packets)
+	require.NoError(t, err)
+	assert.Equal(t, IngestReport{Sent: 150, AlreadyIngested: 100}, report)
+
+	assert.Equal(t, []int{100, 100, 100, 50}, sent)
+}
+
+func TestIngestLedger_Cap(t *testing.T) {
+	l := newIngestLedger()
+	l.max = 2
+
+	l.add([]string{"a", "b"})
+	l.add([]string{"b", "c"})
+	assert.False(t, l.has("a"), "oldest key is forgotten")
+	assert.True(t, l.has("b"))
+	assert.True(t, l.has("c"))
+}
+
+func TestClient_IngestPacket_Retries(t *testing.T) {
+	ingest := func(t *testing.T, handler http.HandlerFunc, opts ...ClientOption) (int, error) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			handler(w, r)
+		}))
+		defer server.Close()
+
+		client := NewClient("test-org", "test-token", append([]ClientOption{WithBaseURL(server.URL)}, opts...)...)
+		client.ingestRetryDelay = 0
+		err := client.IngestPacket(context.Background(), models.IngestPacketRequest{})
+		return int(calls.Load()), err
+	}
+
+	t.Run("retries unavailable then succeeds", func(t *testing.T) {
+		var attempt int
+		calls, err := ingest(t, func(w http.ResponseWriter, r *http.Request) {
+			attempt++
+			if attempt == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{}`))
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("gives up after configured retries", func(t *testing.T) {
+		calls, err := ingest(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}, WithIngestRetries(1))
+
+		assert.ErrorIs(t, err, ErrRateLimited)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("does not retry server errors", func(t *testing.T) {
+		calls, err := ingest(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+
+		assert.ErrorIs(t, err, ErrServerError)
+		assert.Contains(t, err.Error(), "may have been ingested")
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("does not retry bad requests", func(t *testing.T) {
+		calls, err := ingest(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		})
+
+		assert.ErrorIs(t, err, ErrBadRequest)
+		assert.NotContains(t, err.Error(), "may have been ingested")
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("uses ingest timeout", func(t *testing.T) {
+		calls, err := ingest(t, func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+			w.Write([]byte(`{}`))
+		}, WithIngestTimeout(10*time.Millisecond))
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "may have been ingested")
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestClient_IngestPacket_ConnectionRefused(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	client := NewClient("test-org", "test-token", WithBaseURL(url), WithIngestRetries(1))
+	client.ingestRetryDelay = 0
+	err := client.IngestPacket(context.Background(), models.IngestPacketRequest{})
+
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "may have been ingested")
+}
+
+func TestClient_RetrievePackets_LowercaseContinuationHeader(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Continuation-Token") == "" {
+			// Bypass canonicalization so the header goes out lowercased
+			w.Header()["continuation-token"] = []string{"page2"}
+			w.Write([]byte(`{"packets": [{"device": {"id": "dev-001"}}]}`))
+			return
+		}
+		assert.Equal(t, "page2", r.Header.Get("Continuation-Token"))
+		w.Write([]byte(`{"packets": [{"device": {"id": "dev-002"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+	packets, err := client.RetrievePackets(context.Background(), RetrievePacketsOptions{})
+
+	require.NoError(t, err)
+	assert.Len(t, packets, 2)
+	assert.Equal(t, 2, calls)
+}
+
+func TestClient_WithContinuationHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Next-Page") == "" {
+			w.Header().Set("X-Next-Page", "page2")
+			w.Write([]byte(`{"packets": [{"device": {"id": "dev-001"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"packets": [{"device": {"id": "dev-002"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL), WithContinuationHeader("x-next-page"))
+	packets, err := client.RetrievePackets(context.Background(), RetrievePacketsOptions{})
+
+	require.NoError(t, err)
+	assert.Len(t, packets, 2)
+}
+
+// newPagingServer serves pages of packets with the given device IDs,
+// numbered from 1 and linked by "page<N>" continuation tokens. A page with
+// nil IDs fails with 400. Before serving page n, it waits for gates[n] to
+// be closed, if there is one.
+func newPagingServer(pages map[int][]string, gates map[int]chan struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := 1
+		if token := r.Header.Get("Continuation-Token"); token != "" {
+			n, _ = strconv.Atoi(strings.TrimPrefix(token, "page"))
+		}
+		if gate, ok := gates[n]; ok {
+			select {
+			case <-gate:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		ids := pages[n]
+		if ids == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if _, ok := pages[n+1]; ok {
+			w.Header().Set("Continuation-Token", "page"+strconv.Itoa(n+1))
+		}
+		packets := make([]map[string]any, len(ids))
+		for i, id := range ids {
+			packets[i] = map[string]any{"device": map[string]any{"id": id}}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"packets": packets})
+	}))
+}
+
+// receive returns the next value from ch, failing the test if none arrives
+// in time. ok is false once ch is closed.
+func receive[T any](t *testing.T, ch <-chan T) (v T, ok bool) {
+	t.Helper()
+	select {
+	case v, ok = <-ch:
+		return v, ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the stream")
+		return v, false
+	}
+}
+
+func TestClient_RetrievePacketsStream(t *testing.T) {
+	// The second page is held back until the first has been received, so
+	// the stream can't be buffering the whole query
+	release := make(chan struct{})
+	server := newPagingServer(map[int][]string{
+		1: {"dev-001", "dev-002"},
+		2: {"dev-003"},
+		3: {"dev-004"},
+	}, map[int]chan struct{}{2: release})
+	defer server.Close()
+
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+	packets, errs := client.RetrievePacketsStream(context.Background(), RetrievePacketsOptions{})
+
+	var ids []string
+	p, ok := receive(t, packets)
+	require.True(t, ok)
+	ids = append(ids, p.DeviceID())
+	close(release)
+
+	for {
+		p, ok := receive(t, packets)
+		if !ok {
+			break
+		}
+		ids = append(ids, p.DeviceID())
+	}
+	assert.Equal(t, []string{"dev-001", "dev-002", "dev-003", "dev-004"}, ids)
+
+	err, _ := receive(t, errs)
+	assert.NoError(t, err)
+}
+
+func TestClient_RetrievePacketsPages_Limit(t *testing.T) {
+	paging := newPagingServer(map[int][]string{
+		1: {"dev-001", "dev-002"},
+		2: {"dev-003", "dev-004"},
+		3: {"dev-005"},
+	}, nil)
+	defer paging.Close()
+
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		paging.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+	pages, errs := client.RetrievePacketsPages(context.Background(), RetrievePacketsOptions{Limit: 3})
+
+	page, ok := receive(t, pages)
+	require.True(t, ok)
+	assert.Len(t, page.Packets, 2)
+	assert.Equal(t, "page2", page.ContinuationToken)
+
+	// The server ignores the limit, so the last page is kept whole rather
+	// than losing the packet its token skips
+	page, ok = receive(t, pages)
+	require.True(t, ok)
+	require.Len(t, page.Packets, 2)
+	assert.Equal(t, "dev-004", page.Packets[1].DeviceID())
+	assert.Equal(t, "page3", page.ContinuationToken)
+
+	_, ok = receive(t, pages)
+	assert.False(t, ok)
+	err, _ := receive(t, errs)
+	assert.NoError(t, err)
+
+	// Each request asks for the packets still wanted, over the same window
+	require.Len(t, queries, 2)
+	assert.Equal(t, "3", queries[0].Get("limit"))
+	assert.Equal(t, "1", queries[1].Get("limit"))
+	assert.Equal(t, queries[0].Get("start"), queries[1].Get("start"))
+}
+
+func TestClient_PacketsURL_NoLimit(t *testing.T) {
+	client := NewClient("test-org", "test-token", WithBaseURL("https://api.example.com"))
+	assert.NotContains(t, client.PacketsURL(RetrievePacketsOptions{Limit: 25}), "limit")
+}
+
+func TestClient_RetrievePackets_DeviceFilterPages(t *testing.T) {
+	paging := newPagingServer(map[int][]string{
+		1: {"dev-001", "dev-001"},
+		2: {"dev-001", "dev-001"},
+		3: {"dev-001"},
+	}, nil)
+	defer paging.Close()
+
+	var queries []url.Values
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		tokens = append(tokens, r.Header.Get("Continuation-Token"))
+		paging.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+	deviceID := "dev-001"
+	opts := RetrievePacketsOptions{DeviceID: &deviceID, Days: 3, Limit: 3}
+
+	// Tokens page through the device's packets as they do without a filter
+	result, err := client.RetrievePacketsWithPagination(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Len(t, result.Packets, 4) // The second page is kept whole
+	assert.Equal(t, "page3", result.ContinuationToken)
+
+	opts.ContinuationToken = result.ContinuationToken
+	result, err = client.RetrievePacketsWithPagination(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Len(t, result.Packets, 1)
+	assert.Empty(t, result.ContinuationToken)
+
+	// Streaming pages resumes from the same token
+	pages, errs := client.RetrievePacketsPages(context.Background(), opts)
+	page, ok := receive(t, pages)
+	require.True(t, ok)
+	assert.Len(t, page.Packets, 1)
+	assert.Empty(t, page.ContinuationToken)
+	_, ok = receive(t, pages)
+	assert.False(t, ok)
+	err, _ = receive(t, errs)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"", "page2", "page3", "page3"}, tokens)
+	for _, q := range queries {
+		assert.Equal(t, "dev-001", q.Get("device_id"))
+	}
+}
+
+func TestClient_RetrievePacketsPages_Error(t *testing.T) {
+	server := newPagingServer(map[int][]string{1: {"dev-001"}, 2: nil}, nil)
+	defer server.Close()
+
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+	pages, errs := client.RetrievePacketsPages(context.Background(), RetrievePacketsOptions{})
+
+	// Pages before the failure arrive, then the error
+	page, ok := receive(t, pages)
+	require.True(t, ok)
+	assert.Len(t, page.Packets, 1)
+	assert.Equal(t, "page2", page.ContinuationToken)
+
+	_, ok = receive(t, pages)
+	assert.False(t, ok)
+	err, _ := receive(t, errs)
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestClient_RetrievePacketsStream_Cancel(t *testing.T) {
+	// The second page never arrives on its own
+	server := newPagingServer(map[int][]string{1: {"dev-001"}, 2: {"dev-002"}}, map[int]chan struct{}{2: make(chan struct{})})
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+	packets, errs := client.RetrievePacketsStream(ctx, RetrievePacketsOptions{})
+
+	_, ok := receive(t, packets)
+	require.True(t, ok)
+	cancel()
+
+	// Both channels close without reporting the cancellation
+	_, ok = receive(t, packets)
+	assert.False(t, ok)
+	err, ok := receive(t, errs)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+}
+
+func TestClient_RetrievePackets_OnPage(t *testing.T) {
+	server := newPagingServer(map[int][]string{
+		1: {"dev-001", "dev-002"},
+		2: {"dev-003", "dev-004"},
+		3: {"dev-005"},
+	}, nil)
+	defer server.Close()
+
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+
+	type progress struct{ page, fetched int }
+	record := func(calls *[]progress) func(page, fetched int) {
+		return func(page, fetched int) {
+			*calls = append(*calls, progress{page, fetched})
+		}
+	}
+
+	t.Run("once per page", func(t *testing.T) {
+		var calls []progress
+		_, err := client.RetrievePacketsWithPagination(context.Background(), RetrievePacketsOptions{OnPage: record(&calls)})
+
+		require.NoError(t, err)
+		assert.Equal(t, []progress{{1, 2}, {2, 4}, {3, 5}}, calls)
+	})
+
+	t.Run("stops at the limit", func(t *testing.T) {
+		var calls []progress
+		_, err := client.RetrievePacketsWithPagination(context.Background(), RetrievePacketsOptions{Limit: 3, OnPage: record(&calls)})
+
+		require.NoError(t, err)
+		assert.Equal(t, []progress{{1, 2}, {2, 4}}, calls)
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		var calls []progress
+		pages, errs := client.RetrievePacketsPages(context.Background(), RetrievePacketsOptions{OnPage: record(&calls)})
+		for range pages {
+		}
+		err, _ := receive(t, errs)
+
+		require.NoError(t, err)
+		assert.Equal(t, []progress{{1, 2}, {2, 4}, {3, 5}}, calls)
+	})
+}
+
+func TestClient_RetrievePacketsPages_End(t *testing.T) {
+	end := time.Unix(2000, 0)
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		// The server ignores the end, newest first
+		if r.Header.Get("Continuation-Token") == "" {
+			w.Header().Set("Continuation-Token", "page2")
+			w.Write([]byte(`{"packets": [{"device": {"id": "late", "timestamp": 3000}}]}`))
+			return
+		}
+		w.Write([]byte(`{"packets": [{"device": {"id": "late", "timestamp": 2000}}, {"device": {"id": "in-range", "timestamp": 1999}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+	pages, errs := client.RetrievePacketsPages(context.Background(), RetrievePacketsOptions{End: &end})
+
+	// The page with nothing in range is skipped
+	page, ok := receive(t, pages)
+	require.True(t, ok)
+	require.Len(t, page.Packets, 1)
+	assert.Equal(t, "in-range", page.Packets[0].DeviceID())
+	_, ok = receive(t, pages)
+	assert.False(t, ok)
+	err, _ := receive(t, errs)
+	assert.NoError(t, err)
+
+	require.Len(t, queries, 2)
+	for _, q := range queries {
+		assert.Equal(t, "2000", q.Get("end"))
+	}
+	assert.Contains(t, client.PacketsURL(RetrievePacketsOptions{End: &end}), "end=2000")
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultMaxConcurrency is the default number of requests a fan-out
+// operation, such as DeleteDevices, has in flight at once.
+const DefaultMaxConcurrency = 8
+
+// forEach calls fn for every index in [0, n), running at most
+// c.maxConcurrency calls at a time. It returns the error from each call,
+// indexed like the input. Once ctx is done no further calls are started and
+// the remaining indexes report ctx.Err().
+func (c *Client) forEach(ctx context.Context, n int, fn func(ctx context.Context, i int) error) []error {
+	errs := make([]error, n)
+
+	limit := c.maxConcurrency
+	if limit <= 0 {
+		limit = DefaultMaxConcurrency
+	}
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for j := i; j < n; j++ {
+				errs[j] = ctx.Err()
+			}
+			wg.Wait()
+			return errs
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = fn(ctx, i)
+		}(i)
+	}
+	wg.Wait()
+
+	return errs
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_ForEach_RespectsLimit(t *testing.T) {
+	client := NewClient("test-org", "test-token", WithMaxConcurrency(3))
+
+	var inFlight, peak, calls atomic.Int32
+	errs := client.forEach(context.Background(), 20, func(ctx context.Context, i int) error {
+		calls.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+
+	assert.Len(t, errs, 20)
+	assert.Equal(t, int32(20), calls.Load())
+	assert.Equal(t, int32(3), peak.Load())
+}
+
+func TestClient_ForEach_DefaultLimit(t *testing.T) {
+	client := NewClient("test-org", "test-token", WithMaxConcurrency(0))
+	assert.Equal(t, DefaultMaxConcurrency, client.maxConcurrency)
+}
+
+func TestClient_ForEach_ErrorsByIndex(t *testing.T) {
+	client := NewClient("test-org", "test-token")
+	errs := client.forEach(context.Background(), 3, func(ctx context.Context, i int) error {
+		if i == 1 {
+			return assert.AnError
+		}
+		return nil
+	})
+
+	assert.Equal(t, []error{nil, assert.AnError, nil}, errs)
+}
+
+func TestClient_ForEach_Canceled(t *testing.T) {
+	client := NewClient("test-org", "test-token", WithMaxConcurrency(1))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errs := client.forEach(ctx, 3, func(ctx context.Context, i int) error {
+		cancel()
+		return nil
+	})
+
+	assert.NoError(t, errs[0])
+	assert.True(t, errors.Is(errs[2], context.Canceled))
+}
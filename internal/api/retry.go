@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Retry settings suggested for interactive use; see WithRetry.
+const (
+	DefaultRetryAttempts  = 3
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+
+	// maxRetryDelay caps the wait between attempts. A Retry-After header
+	// asking for longer than this is not waited for.
+	maxRetryDelay = 30 * time.Second
+)
+
+// WithRetry retries GET requests and device registration that fail with a
+// transient error: a 429, 502, 503 or 504 response, or a refused
+// connection. Each request is tried at most maxAttempts times, waiting
+// baseDelay, then twice that and so on (with jitter) between attempts, or
+// as long as the Retry-After header asks. Other POST, PATCH and DELETE
+// requests are never retried. A maxAttempts of 1 or less disables retries,
+// which is the default.
+func WithRetry(maxAttempts int, baseDelay time.Duration) ClientOption {
+	return func(client *Client) {
+		if maxAttempts < 1 {
+			maxAttempts = 1
+		}
+		client.retryAttempts = maxAttempts
+		client.retryBaseDelay = baseDelay
+	}
+}
+
+// doRetry performs a request that is safe to repeat, retrying transient
+// failures as configured by WithRetry. It stops early if ctx is done.
+func (c *Client) doRetry(ctx context.Context, method, path string, body interface{}, contToken string) (*response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.do(ctx, method, path, body, contToken)
+		if err == nil || attempt >= c.retryAttempts || !isTransient(err) {
+			return resp, err
+		}
+
+		delay, ok := c.retryDelay(attempt, err)
+		if !ok {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryDelay returns how long to wait after the given failed attempt
+// (starting at 1). A Retry-After from the server takes precedence over the
+// backoff; ok is false if it asks for longer than maxRetryDelay.
+func (c *Client) retryDelay(attempt int, err error) (_ time.Duration, ok bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, apiErr.RetryAfter <= maxRetryDelay
+	}
+
+	if c.retryBaseDelay <= 0 {
+		return 0, true
+	}
+	d := c.retryBaseDelay << (attempt - 1)
+	if d <= 0 || d > maxRetryDelay {
+		d = maxRetryDelay // Also catches overflow from the shift
+	}
+
+	// Wait between half and all of the backoff so clients that failed
+	// together don't retry together
+	half := d / 2
+	return half + rand.N(d-half+1), true
+}
+
+// isTransient reports whether a failed request may succeed if repeated
+// unchanged.
+func isTransient(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// parseRetryAfter parses a Retry-After header, given either as a number of
+// seconds or an HTTP date. It returns 0 if the header is absent or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
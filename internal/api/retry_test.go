@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingServer responds with status to the first failures requests and
+// with body afterwards.
+func failingServer(t *testing.T, failures int32, status int, body string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestClient_RetriesTransientGET(t *testing.T) {
+	for _, status := range []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			server, calls := failingServer(t, 2, status, `{"devices":[{"id":"dev-001"}]}`)
+			client := NewClient("test-org", "test-token",
+				WithBaseURL(server.URL),
+				WithRetry(3, time.Millisecond),
+			)
+
+			devices, err := client.ListDevices(context.Background())
+
+			require.NoError(t, err)
+			assert.Len(t, devices, 1)
+			assert.Equal(t, int32(3), calls.Load())
+		})
+	}
+}
+
+func TestClient_RetryGivesUpAfterMaxAttempts(t *testing.T) {
+	server, calls := failingServer(t, 5, http.StatusServiceUnavailable, `{"devices":[]}`)
+	client := NewClient("test-org", "test-token",
+		WithBaseURL(server.URL),
+		WithRetry(3, time.Millisecond),
+	)
+
+	_, err := client.ListDevices(context.Background())
+
+	assert.ErrorIs(t, err, ErrServerError)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestClient_RetrySkipsPermanentErrors(t *testing.T) {
+	server, calls := failingServer(t, 1, http.StatusInternalServerError, `{"devices":[]}`)
+	client := NewClient("test-org", "test-token",
+		WithBaseURL(server.URL),
+		WithRetry(3, time.Millisecond),
+	)
+
+	_, err := client.ListDevices(context.Background())
+
+	assert.ErrorIs(t, err, ErrServerError)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_RetryDisabledByDefault(t *testing.T) {
+	server, calls := failingServer(t, 1, http.StatusServiceUnavailable, `{"devices":[]}`)
+	client := NewClient("test-org", "test-token", WithBaseURL(server.URL))
+
+	_, err := client.ListDevices(context.Background())
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_RetriesRegistration(t *testing.T) {
+	server, calls := failingServer(t, 1, http.StatusBadGateway, `[{"id":"dev-001"}]`)
+	client := NewClient("test-org", "test-token",
+		WithBaseURL(server.URL),
+		WithRetry(3, time.Millisecond),
+	)
+
+	device, err := client.RegisterDevice(context.Background(), models.RegisterDeviceRequest{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "dev-001", device.ID)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestClient_DoesNotRetryOtherWrites(t *testing.T) {
+	server, calls := failingServer(t, 1, http.StatusServiceUnavailable, `{}`)
+	client := NewClient("test-org", "test-token",
+		WithBaseURL(server.URL),
+		WithRetry(3, time.Millisecond),
+	)
+
+	_, err := client.SetDeviceName(context.Background(), "dev-001", "New Name")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_RetryHonorsContext(t *testing.T) {
+	server, calls := failingServer(t, 5, http.StatusServiceUnavailable, `{"devices":[]}`)
+	client := NewClient("test-org", "test-token",
+		WithBaseURL(server.URL),
+		WithRetry(5, time.Hour),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.ListDevices(ctx)
+
+	assert.ErrorIs(t, err, ErrServerError)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_RetryAfterHeader(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"devices":[]}`))
+	}))
+	defer server.Close()
+
+	// The base delay is far shorter than Retry-After, so the wait shows
+	// which one was used
+	client := NewClient("test-org", "test-token",
+		WithBaseURL(server.URL),
+		WithRetry(2, time.Millisecond),
+	)
+
+	start := time.Now()
+	_, err := client.ListDevices(context.Background())
+
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestClient_RetryDelay(t *testing.T) {
+	client := NewClient("test-org", "test-token", WithRetry(5, 100*time.Millisecond))
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		full := 100 * time.Millisecond << (attempt - 1)
+		d, ok := client.retryDelay(attempt, NewAPIError(http.StatusServiceUnavailable, ""))
+		assert.True(t, ok)
+		assert.GreaterOrEqual(t, d, full/2, "attempt %d", attempt)
+		assert.LessOrEqual(t, d, full, "attempt %d", attempt)
+	}
+
+	// Backoff is capped
+	d, ok := client.retryDelay(20, NewAPIError(http.StatusServiceUnavailable, ""))
+	assert.True(t, ok)
+	assert.LessOrEqual(t, d, maxRetryDelay)
+
+	// Retry-After wins, unless it is too long to wait for
+	limited := &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 2 * time.Second}
+	d, ok = client.retryDelay(1, limited)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, d)
+
+	limited.RetryAfter = time.Hour
+	_, ok = client.retryDelay(1, limited)
+	assert.False(t, ok)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, 5*time.Second, parseRetryAfter("5", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-1", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+}
@@ -48,6 +48,32 @@ func GetCredentials() (*models.Credentials, error) {
 	return nil, ErrNoCredentials
 }
 
+// CredentialSource identifies where credentials are stored.
+type CredentialSource string
+
+const (
+	SourceEnv      CredentialSource = "environment"
+	SourceKeychain CredentialSource = "keychain"
+)
+
+// GetCredentialSource reports where GetCredentials finds credentials, checking
+// the sources in the same order. It returns ErrNoCredentials if there are
+// none, and the keychain's error if its credentials can't be read.
+func GetCredentialSource() (CredentialSource, error) {
+	if GetCredentialsFromEnv().IsValid() {
+		return SourceEnv, nil
+	}
+
+	keychainStore := NewKeychainStore()
+	if !keychainStore.Exists() {
+		return "", ErrNoCredentials
+	}
+	if _, err := keychainStore.Get(); err != nil {
+		return SourceKeychain, err
+	}
+	return SourceKeychain, nil
+}
+
 // GetCredentialsFromEnv reads credentials from environment variables.
 func GetCredentialsFromEnv() *models.Credentials {
 	return &models.Credentials{
@@ -4,6 +4,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/hubblenetwork/hubcli/internal/models"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -58,6 +59,15 @@ func TestHasCredentials_WithEnvVars(t *testing.T) {
 	assert.True(t, HasCredentials())
 }
 
+func TestGetCredentialSource_WithEnvVars(t *testing.T) {
+	t.Setenv(EnvOrgID, "test-org")
+	t.Setenv(EnvToken, "test-token")
+
+	source, err := GetCredentialSource()
+	assert.NoError(t, err)
+	assert.Equal(t, SourceEnv, source)
+}
+
 func TestHasCredentials_WithoutEnvVars(t *testing.T) {
 	os.Unsetenv(EnvOrgID)
 	os.Unsetenv(EnvToken)
@@ -66,3 +76,33 @@ func TestHasCredentials_WithoutEnvVars(t *testing.T) {
 	// Just verify it doesn't panic
 	_ = HasCredentials()
 }
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(nil)
+	assert.False(t, store.Exists())
+
+	_, err := store.Get()
+	assert.ErrorIs(t, err, ErrNoCredentials)
+
+	creds := &models.Credentials{OrgID: "test-org", Token: "test-token"}
+	assert.NoError(t, store.Save(creds))
+	assert.True(t, store.Exists())
+
+	// Stored credentials are a copy
+	creds.Token = "changed"
+	got, err := store.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "test-token", got.Token)
+
+	assert.NoError(t, store.Delete())
+	assert.False(t, store.Exists())
+}
+
+func TestNewMemoryStore_Prepopulated(t *testing.T) {
+	store := NewMemoryStore(&models.Credentials{OrgID: "test-org", Token: "test-token"})
+
+	assert.True(t, store.Exists())
+	got, err := store.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "test-org", got.OrgID)
+}
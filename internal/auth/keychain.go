@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"errors"
+
 	"github.com/hubblenetwork/hubcli/internal/models"
 	"github.com/zalando/go-keyring"
 )
@@ -9,8 +11,9 @@ const (
 	// KeychainService is the service name used in the macOS Keychain.
 	KeychainService = "hubcli"
 	// Keychain item names
-	keychainOrgID = "org_id"
-	keychainToken = "api_token"
+	keychainOrgID       = "org_id"
+	keychainToken       = "api_token"
+	keychainEnvironment = "environment"
 )
 
 // KeychainStore implements CredentialStore using the macOS Keychain.
@@ -33,9 +36,21 @@ func (s *KeychainStore) Get() (*models.Credentials, error) {
 		return nil, err
 	}
 
+	// Credentials saved before environments were selectable have none;
+	// they were always for production
+	env := models.EnvProduction
+	stored, err := keyring.Get(KeychainService, keychainEnvironment)
+	switch {
+	case err == nil && stored != "":
+		env = models.Environment(stored)
+	case err != nil && !errors.Is(err, keyring.ErrNotFound):
+		return nil, err
+	}
+
 	return &models.Credentials{
-		OrgID: orgID,
-		Token: token,
+		OrgID:       orgID,
+		Token:       token,
+		Environment: env,
 	}, nil
 }
 
@@ -51,7 +66,11 @@ func (s *KeychainStore) Save(creds *models.Credentials) error {
 		return err
 	}
 
-	return nil
+	if creds.Environment == "" {
+		_ = keyring.Delete(KeychainService, keychainEnvironment)
+		return nil
+	}
+	return keyring.Set(KeychainService, keychainEnvironment, string(creds.Environment))
 }
 
 // Delete removes credentials from the keychain.
@@ -59,6 +78,7 @@ func (s *KeychainStore) Delete() error {
 	// Delete both, ignoring errors if they don't exist
 	_ = keyring.Delete(KeychainService, keychainOrgID)
 	_ = keyring.Delete(KeychainService, keychainToken)
+	_ = keyring.Delete(KeychainService, keychainEnvironment)
 	return nil
 }
 
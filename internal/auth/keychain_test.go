@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func TestKeychainStore_RoundTripsEnvironment(t *testing.T) {
+	keyring.MockInit()
+	store := NewKeychainStore()
+	t.Cleanup(func() { _ = store.Delete() })
+
+	creds := &models.Credentials{OrgID: "test-org", Token: "test-token", Environment: models.EnvStaging}
+	require.NoError(t, store.Save(creds))
+
+	got, err := store.Get()
+	require.NoError(t, err)
+	assert.Equal(t, creds, got)
+
+	// Saving production afterwards replaces the stored environment
+	creds.Environment = models.EnvProduction
+	require.NoError(t, store.Save(creds))
+
+	got, err = store.Get()
+	require.NoError(t, err)
+	assert.Equal(t, models.EnvProduction, got.Environment)
+}
+
+func TestKeychainStore_MissingEnvironmentIsProduction(t *testing.T) {
+	keyring.MockInit()
+	store := NewKeychainStore()
+	t.Cleanup(func() { _ = store.Delete() })
+
+	// Credentials stored by older versions have no environment item
+	require.NoError(t, keyring.Set(KeychainService, keychainOrgID, "test-org"))
+	require.NoError(t, keyring.Set(KeychainService, keychainToken, "test-token"))
+
+	got, err := store.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "test-org", got.OrgID)
+	assert.Equal(t, models.EnvProduction, got.Environment)
+
+	// Saving without an environment leaves none stored
+	require.NoError(t, store.Save(&models.Credentials{OrgID: "test-org", Token: "test-token"}))
+	_, err = keyring.Get(KeychainService, keychainEnvironment)
+	assert.ErrorIs(t, err, keyring.ErrNotFound)
+}
+
+func TestKeychainStore_DeleteRemovesEnvironment(t *testing.T) {
+	keyring.MockInit()
+	store := NewKeychainStore()
+
+	require.NoError(t, store.Save(&models.Credentials{OrgID: "test-org", Token: "test-token", Environment: models.EnvDevelopment}))
+	require.NoError(t, store.Delete())
+
+	assert.False(t, store.Exists())
+	_, err := keyring.Get(KeychainService, keychainEnvironment)
+	assert.ErrorIs(t, err, keyring.ErrNotFound)
+}
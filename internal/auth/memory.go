@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"sync"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+// MemoryStore implements CredentialStore in memory. It is intended for tests
+// that must not touch the system keychain.
+type MemoryStore struct {
+	mu    sync.Mutex
+	creds *models.Credentials
+}
+
+// NewMemoryStore creates a MemoryStore, optionally pre-populated with creds.
+func NewMemoryStore(creds *models.Credentials) *MemoryStore {
+	s := &MemoryStore{}
+	if creds != nil {
+		c := *creds
+		s.creds = &c
+	}
+	return s
+}
+
+// Get returns the stored credentials or ErrNoCredentials.
+func (s *MemoryStore) Get() (*models.Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.creds == nil {
+		return nil, ErrNoCredentials
+	}
+	c := *s.creds
+	return &c, nil
+}
+
+// Save stores a copy of creds.
+func (s *MemoryStore) Save(creds *models.Credentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *creds
+	s.creds = &c
+	return nil
+}
+
+// Delete removes the stored credentials.
+func (s *MemoryStore) Delete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.creds = nil
+	return nil
+}
+
+// Exists returns true if credentials are stored.
+func (s *MemoryStore) Exists() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.creds != nil
+}
@@ -0,0 +1,55 @@
+package ble
+
+import (
+	"errors"
+	"sync"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// ErrAdapterSelectionUnsupported indicates the platform's bluetooth backend
+// can only use its default adapter
+var ErrAdapterSelectionUnsupported = errors.New("bluetooth adapter selection not supported on this platform")
+
+// AdapterInfo describes a Bluetooth adapter that can be scanned with
+type AdapterInfo struct {
+	// ID identifies the adapter to NewScannerWithAdapter, e.g. "hci1"
+	ID string
+
+	// Default is set for the adapter NewScanner uses
+	Default bool
+}
+
+// Adapters enabled by NewScannerWithAdapter, by ID, so each is only
+// enabled once
+var (
+	namedAdapters   = make(map[string]*bluetooth.Adapter)
+	namedAdaptersMu sync.Mutex
+)
+
+// NewScannerWithAdapter creates a scanner using the adapter with the given
+// ID, as listed by Adapters. An empty ID or the default adapter's ID uses
+// the same adapter as NewScanner. On platforms without adapter selection,
+// other IDs fail with ErrAdapterSelectionUnsupported.
+func NewScannerWithAdapter(id string) (*Scanner, error) {
+	if id == "" || id == defaultAdapterID {
+		return NewScanner()
+	}
+
+	namedAdaptersMu.Lock()
+	defer namedAdaptersMu.Unlock()
+
+	if adapter, ok := namedAdapters[id]; ok {
+		return &Scanner{adapter: adapter}, nil
+	}
+
+	adapter, err := openAdapter(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := adapter.Enable(); err != nil {
+		return nil, errors.Join(ErrAdapterNotEnabled, err)
+	}
+	namedAdapters[id] = adapter
+	return &Scanner{adapter: adapter}, nil
+}
@@ -0,0 +1,54 @@
+//go:build linux
+
+package ble
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// defaultAdapterID is the adapter BlueZ-backed bluetooth.DefaultAdapter uses
+const defaultAdapterID = "hci0"
+
+// sysfsBluetooth lists the kernel's Bluetooth devices; a variable so tests
+// can point it elsewhere
+var sysfsBluetooth = "/sys/class/bluetooth"
+
+// Adapters lists the HCI adapters known to the kernel, in ID order
+func Adapters() ([]AdapterInfo, error) {
+	entries, err := os.ReadDir(sysfsBluetooth)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // No Bluetooth support loaded, so no adapters
+		}
+		return nil, err
+	}
+
+	var adapters []AdapterInfo
+	for _, e := range entries {
+		// Connections show up as e.g. "hci0:11"; only adapters are wanted
+		n, ok := strings.CutPrefix(e.Name(), "hci")
+		if !ok {
+			continue
+		}
+		if _, err := strconv.Atoi(n); err != nil {
+			continue
+		}
+		adapters = append(adapters, AdapterInfo{ID: e.Name(), Default: e.Name() == defaultAdapterID})
+	}
+
+	sort.Slice(adapters, func(i, j int) bool {
+		a, _ := strconv.Atoi(strings.TrimPrefix(adapters[i].ID, "hci"))
+		b, _ := strconv.Atoi(strings.TrimPrefix(adapters[j].ID, "hci"))
+		return a < b
+	})
+	return adapters, nil
+}
+
+func openAdapter(id string) (*bluetooth.Adapter, error) {
+	return bluetooth.NewAdapter(id), nil
+}
@@ -0,0 +1,42 @@
+//go:build linux
+
+package ble
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdapters(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"hci10", "hci0", "hci0:11", "hci1", "rfcomm0"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, name), 0o755))
+	}
+	old := sysfsBluetooth
+	sysfsBluetooth = dir
+	t.Cleanup(func() { sysfsBluetooth = old })
+
+	adapters, err := Adapters()
+
+	require.NoError(t, err)
+	assert.Equal(t, []AdapterInfo{
+		{ID: "hci0", Default: true},
+		{ID: "hci1"},
+		{ID: "hci10"},
+	}, adapters)
+}
+
+func TestAdapters_NoBluetooth(t *testing.T) {
+	old := sysfsBluetooth
+	sysfsBluetooth = filepath.Join(t.TempDir(), "missing")
+	t.Cleanup(func() { sysfsBluetooth = old })
+
+	adapters, err := Adapters()
+
+	assert.NoError(t, err)
+	assert.Empty(t, adapters)
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package ble
+
+import "tinygo.org/x/bluetooth"
+
+// defaultAdapterID is empty because only the default adapter can be used
+const defaultAdapterID = ""
+
+// Adapters is not supported on this platform; it returns
+// ErrAdapterSelectionUnsupported and scanning uses the default adapter
+func Adapters() ([]AdapterInfo, error) {
+	return nil, ErrAdapterSelectionUnsupported
+}
+
+func openAdapter(id string) (*bluetooth.Adapter, error) {
+	return nil, ErrAdapterSelectionUnsupported
+}
@@ -0,0 +1,237 @@
+package ble
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+// CaptureVersion is the capture file format written by SaveCapture
+const CaptureVersion = 1
+
+// ErrInvalidCapture indicates a capture file could not be replayed
+var ErrInvalidCapture = errors.New("invalid capture file")
+
+// Capture is a recorded BLE scan: each advertisement together with the
+// packet parsed from it, in the order they were received.
+type Capture struct {
+	Version        int                      `json:"version"`
+	Advertisements []RawAdvertisement       `json:"advertisements"`
+	Packets        []models.EncryptedPacket `json:"packets"`
+}
+
+// SaveCapture writes the advertisements and their packets to path; see
+// WriteCapture
+func SaveCapture(path string, raw []RawAdvertisement, packets []models.EncryptedPacket) error {
+	if err := checkParallel(len(raw), len(packets)); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = WriteCapture(f, raw, packets)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// WriteCapture writes the advertisements and their packets as JSON. raw and
+// packets are parallel slices, as kept by the BLE scan screen.
+func WriteCapture(w io.Writer, raw []RawAdvertisement, packets []models.EncryptedPacket) error {
+	if err := checkParallel(len(raw), len(packets)); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Capture{
+		Version:        CaptureVersion,
+		Advertisements: raw,
+		Packets:        packets,
+	})
+}
+
+// LoadCapture reads a capture written by SaveCapture
+func LoadCapture(path string) (*Capture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Capture
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCapture, err)
+	}
+	if c.Version != CaptureVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidCapture, c.Version)
+	}
+	if err := checkParallel(len(c.Advertisements), len(c.Packets)); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// checkParallel reports an error unless there is one packet per
+// advertisement
+func checkParallel(advertisements, packets int) error {
+	if advertisements != packets {
+		return fmt.Errorf("%w: %d advertisements for %d packets", ErrInvalidCapture, advertisements, packets)
+	}
+	return nil
+}
+
+// FileScanner replays a saved capture in place of a Bluetooth adapter, so
+// the scan screen can be exercised without hardware or nearby devices.
+// Packets are replayed as recorded, including their location; the
+// Location scan option is ignored.
+type FileScanner struct {
+	capture  *Capture
+	realtime bool
+	scanning bool
+	stopCh   chan struct{}
+	mu       sync.Mutex
+}
+
+// NewFileScanner loads the capture at path for replay. With realtime set,
+// packets are spaced out by the gaps between their original timestamps;
+// otherwise they are all sent immediately.
+func NewFileScanner(path string, realtime bool) (*FileScanner, error) {
+	c, err := LoadCapture(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewCaptureScanner(c, realtime), nil
+}
+
+// NewCaptureScanner replays an already loaded capture; see NewFileScanner
+func NewCaptureScanner(c *Capture, realtime bool) *FileScanner {
+	return &FileScanner{capture: c, realtime: realtime}
+}
+
+// IsScanning returns whether a replay is in progress
+func (f *FileScanner) IsScanning() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.scanning
+}
+
+// Stop ends the current replay
+func (f *FileScanner) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.scanning {
+		close(f.stopCh)
+		f.scanning = false
+	}
+}
+
+// Scan replays the capture and returns the packets it contains
+func (f *FileScanner) Scan(ctx context.Context, opts ScanOptions) ([]models.EncryptedPacket, error) {
+	results, err := f.ScanStream(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var packets []models.EncryptedPacket
+	for result := range results {
+		if result.Packet != nil {
+			packets = append(packets, *result.Packet)
+		}
+	}
+	return packets, nil
+}
+
+// ScanSingle returns the first packet in the capture
+func (f *FileScanner) ScanSingle(ctx context.Context, opts ScanOptions) (*models.EncryptedPacket, error) {
+	opts.MaxPackets = 1
+	packets, err := f.Scan(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(packets) == 0 {
+		return nil, ErrScanTimeout
+	}
+	return &packets[0], nil
+}
+
+// ScanStream replays the capture on the returned channel, which is closed
+// at the end of the capture or when the scan is stopped or times out
+func (f *FileScanner) ScanStream(ctx context.Context, opts ScanOptions) (<-chan ScanResult, error) {
+	f.mu.Lock()
+	if f.scanning {
+		f.mu.Unlock()
+		return nil, ErrScanInProgress
+	}
+	f.scanning = true
+	stopCh := make(chan struct{})
+	f.stopCh = stopCh
+	f.mu.Unlock()
+
+	var cancel context.CancelFunc
+	if opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	results := make(chan ScanResult)
+
+	go func() {
+		defer func() {
+			cancel()
+			f.mu.Lock()
+			if f.stopCh == stopCh {
+				f.scanning = false
+			}
+			f.mu.Unlock()
+			close(results)
+		}()
+
+		var last time.Time
+		sent := 0
+		for i, raw := range f.capture.Advertisements {
+			if opts.MaxPackets > 0 && sent >= opts.MaxPackets {
+				return
+			}
+			if opts.belowMinRSSI(raw.RSSI) || (opts.FilterHubbleOnly && !ContainsHubbleService(raw)) {
+				continue
+			}
+
+			// Wait out the recorded gap since the previous advertisement
+			if f.realtime && !last.IsZero() {
+				if gap := raw.Timestamp.Sub(last); gap > 0 {
+					select {
+					case <-stopCh:
+						return
+					case <-ctx.Done():
+						return
+					case <-time.After(gap):
+					}
+				}
+			}
+			last = raw.Timestamp
+
+			packet := f.capture.Packets[i] // Copy to avoid reference issues
+			select {
+			case <-stopCh:
+				return
+			case <-ctx.Done():
+				return
+			case results <- ScanResult{Packet: &packet, Raw: raw}:
+				sent++
+			}
+		}
+	}()
+
+	return opts.dedupe(results), nil
+}
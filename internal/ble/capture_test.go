@@ -0,0 +1,167 @@
+package ble
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCapture returns advertisements 100ms apart and the packets parsed
+// from them
+func testCapture(t *testing.T) ([]RawAdvertisement, []models.EncryptedPacket) {
+	t.Helper()
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	loc := models.Location{Latitude: 37.7749, Longitude: -122.4194, Fake: true}
+
+	raw := []RawAdvertisement{
+		{
+			ServiceUUIDs: []string{"fca6"},
+			ServiceData:  map[string][]byte{"fca6": {0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}},
+			RSSI:         -60,
+			Address:      "AA:BB:CC:DD:EE:01",
+			Timestamp:    start,
+		},
+		{
+			LocalName:        "tracker",
+			ManufacturerData: []byte{0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19},
+			ServiceUUIDs:     []string{HubbleServiceUUID},
+			RSSI:             -72,
+			Address:          "AA:BB:CC:DD:EE:02",
+			Timestamp:        start.Add(100 * time.Millisecond),
+		},
+		{
+			ServiceData: map[string][]byte{HubbleServiceUUID: {0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28}},
+			RSSI:        -80,
+			Address:     "AA:BB:CC:DD:EE:03",
+			Timestamp:   start.Add(200 * time.Millisecond),
+		},
+	}
+
+	var packets []models.EncryptedPacket
+	for _, adv := range raw {
+		p, err := ParseAdvertisement(adv, loc)
+		require.NoError(t, err)
+		packets = append(packets, *p)
+	}
+	return raw, packets
+}
+
+func TestSaveCapture_Replay(t *testing.T) {
+	raw, packets := testCapture(t)
+	path := filepath.Join(t.TempDir(), "capture.json")
+	require.NoError(t, SaveCapture(path, raw, packets))
+
+	scanner, err := NewFileScanner(path, false)
+	require.NoError(t, err)
+
+	results, err := scanner.ScanStream(context.Background(), DefaultScanOptions())
+	require.NoError(t, err)
+
+	var replayed []ScanResult
+	for r := range results {
+		replayed = append(replayed, r)
+	}
+
+	require.Len(t, replayed, len(packets))
+	for i, r := range replayed {
+		require.NotNil(t, r.Packet)
+		assert.Equal(t, packets[i].Payload, r.Packet.Payload)
+		assert.Equal(t, packets[i].RSSI, r.Packet.RSSI)
+		assert.True(t, packets[i].Timestamp.Equal(r.Packet.Timestamp))
+		assert.Equal(t, packets[i].Location, r.Packet.Location)
+		assert.Equal(t, raw[i].Address, r.Raw.Address)
+		assert.Equal(t, raw[i].ServiceData, r.Raw.ServiceData)
+		assert.Equal(t, raw[i].ManufacturerData, r.Raw.ManufacturerData)
+	}
+	assert.False(t, scanner.IsScanning())
+}
+
+func TestSaveCapture_MismatchedSlices(t *testing.T) {
+	raw, packets := testCapture(t)
+	path := filepath.Join(t.TempDir(), "capture.json")
+
+	err := SaveCapture(path, raw, packets[:1])
+	assert.ErrorIs(t, err, ErrInvalidCapture)
+}
+
+func TestLoadCapture_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := LoadCapture(filepath.Join(dir, "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	garbage := filepath.Join(dir, "garbage.json")
+	require.NoError(t, os.WriteFile(garbage, []byte("not json"), 0o644))
+	_, err = LoadCapture(garbage)
+	assert.ErrorIs(t, err, ErrInvalidCapture)
+
+	future := filepath.Join(dir, "future.json")
+	require.NoError(t, os.WriteFile(future, []byte(`{"version":99}`), 0o644))
+	_, err = LoadCapture(future)
+	assert.ErrorIs(t, err, ErrInvalidCapture)
+}
+
+func TestFileScanner_Realtime(t *testing.T) {
+	raw, packets := testCapture(t)
+	scanner := NewCaptureScanner(&Capture{Version: CaptureVersion, Advertisements: raw, Packets: packets}, true)
+
+	start := time.Now()
+	replayed, err := scanner.Scan(context.Background(), DefaultScanOptions())
+
+	require.NoError(t, err)
+	assert.Len(t, replayed, 3)
+	// Two 100ms gaps between the three advertisements
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestFileScanner_MaxPacketsAndFilter(t *testing.T) {
+	raw, packets := testCapture(t)
+	raw = append(raw, RawAdvertisement{ManufacturerData: []byte{0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38}})
+	packets = append(packets, models.EncryptedPacket{Payload: raw[3].ManufacturerData})
+	scanner := NewCaptureScanner(&Capture{Version: CaptureVersion, Advertisements: raw, Packets: packets}, false)
+
+	opts := DefaultScanOptions()
+	all, err := scanner.Scan(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Len(t, all, 3, "non-Hubble advertisement should be filtered")
+
+	opts.FilterHubbleOnly = false
+	all, err = scanner.Scan(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	opts.MaxPackets = 2
+	limited, err := scanner.Scan(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Len(t, limited, 2)
+
+	single, err := scanner.ScanSingle(context.Background(), DefaultScanOptions())
+	require.NoError(t, err)
+	assert.Equal(t, packets[0].Payload, single.Payload)
+}
+
+func TestFileScanner_Stop(t *testing.T) {
+	raw, packets := testCapture(t)
+	scanner := NewCaptureScanner(&Capture{Version: CaptureVersion, Advertisements: raw, Packets: packets}, true)
+
+	results, err := scanner.ScanStream(context.Background(), DefaultScanOptions())
+	require.NoError(t, err)
+	assert.True(t, scanner.IsScanning())
+
+	_, err = scanner.ScanStream(context.Background(), DefaultScanOptions())
+	assert.ErrorIs(t, err, ErrScanInProgress)
+
+	<-results
+	scanner.Stop()
+	assert.False(t, scanner.IsScanning())
+
+	// The channel closes without sending the remaining packets
+	for range results {
+	}
+}
@@ -0,0 +1,137 @@
+package ble
+
+import (
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/crypto"
+)
+
+// EphemeralID returns the ephemeral device ID carried in bytes 2-5 of a
+// Hubble payload, or false if the payload is too short. Beacons repeat the
+// same advertisement, and so the same ID, many times per second.
+func EphemeralID(payload []byte) (string, bool) {
+	end := crypto.HeaderSize + crypto.ReservedSize
+	if len(payload) < end {
+		return "", false
+	}
+	return string(payload[crypto.HeaderSize:end]), true
+}
+
+// heldResult is the strongest copy of a device's advertisement seen since
+// its window opened
+type heldResult struct {
+	result   ScanResult
+	deadline time.Time
+}
+
+// deduper collapses repeated advertisements from the same device. The
+// first sighting of a device opens a window; copies received during it are
+// held back and only the strongest is released when the window closes.
+type deduper struct {
+	window time.Duration
+	held   map[string]*heldResult
+	order  []string // IDs of held results, oldest window first
+}
+
+func newDeduper(window time.Duration) *deduper {
+	return &deduper{window: window, held: make(map[string]*heldResult)}
+}
+
+// add holds r back and reports true, or reports false if r can't be
+// deduplicated and should be sent now.
+func (d *deduper) add(r ScanResult, now time.Time) bool {
+	if r.Packet == nil {
+		return false
+	}
+	id, ok := EphemeralID(r.Packet.Payload)
+	if !ok {
+		return false
+	}
+
+	if h, ok := d.held[id]; ok {
+		if r.Packet.RSSI > h.result.Packet.RSSI {
+			h.result = r
+		}
+		return true
+	}
+	d.held[id] = &heldResult{result: r, deadline: now.Add(d.window)}
+	d.order = append(d.order, id)
+	return true
+}
+
+// next returns when the oldest window closes, or false if nothing is held
+func (d *deduper) next() (time.Time, bool) {
+	if len(d.order) == 0 {
+		return time.Time{}, false
+	}
+	return d.held[d.order[0]].deadline, true
+}
+
+// due releases the results whose window has closed by now
+func (d *deduper) due(now time.Time) []ScanResult {
+	var out []ScanResult
+	for len(d.order) > 0 {
+		h := d.held[d.order[0]]
+		if h.deadline.After(now) {
+			break
+		}
+		out = append(out, h.result)
+		delete(d.held, d.order[0])
+		d.order = d.order[1:]
+	}
+	return out
+}
+
+// flush releases everything still held
+func (d *deduper) flush() []ScanResult {
+	var out []ScanResult
+	for _, id := range d.order {
+		out = append(out, d.held[id].result)
+	}
+	d.held = make(map[string]*heldResult)
+	d.order = nil
+	return out
+}
+
+// dedupeResults passes results from in through a deduper with the given
+// window. Held results are released when their window closes, or all at
+// once when in is closed; the returned channel is closed after that.
+func dedupeResults(in <-chan ScanResult, window time.Duration) <-chan ScanResult {
+	out := make(chan ScanResult, cap(in))
+
+	go func() {
+		defer close(out)
+
+		d := newDeduper(window)
+		timer := time.NewTimer(window)
+		timer.Stop()
+		defer timer.Stop()
+
+		for {
+			var expired <-chan time.Time
+			if deadline, ok := d.next(); ok {
+				timer.Reset(time.Until(deadline))
+				expired = timer.C
+			}
+
+			select {
+			case r, ok := <-in:
+				if !ok {
+					for _, held := range d.flush() {
+						out <- held
+					}
+					return
+				}
+				if !d.add(r, time.Now()) {
+					out <- r
+				}
+			case <-expired:
+				for _, held := range d.due(time.Now()) {
+					out <- held
+				}
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,107 @@
+package ble
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dedupePacket returns a packet whose ephemeral ID (bytes 2-5) is id
+func dedupePacket(id byte, rssi int) models.EncryptedPacket {
+	return models.EncryptedPacket{
+		Payload: []byte{0x00, 0x01, id, id, id, id, 0xaa, 0xbb, 0xcc, 0xdd},
+		RSSI:    rssi,
+	}
+}
+
+func TestEphemeralID(t *testing.T) {
+	id, ok := EphemeralID([]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06})
+	assert.True(t, ok)
+	assert.Equal(t, string([]byte{0x02, 0x03, 0x04, 0x05}), id)
+
+	_, ok = EphemeralID([]byte{0x00, 0x01, 0x02})
+	assert.False(t, ok)
+}
+
+func TestDeduper(t *testing.T) {
+	d := newDeduper(time.Second)
+	start := time.Now()
+
+	weak, strong, other := dedupePacket(1, -80), dedupePacket(1, -50), dedupePacket(2, -70)
+	assert.True(t, d.add(ScanResult{Packet: &weak}, start))
+	assert.True(t, d.add(ScanResult{Packet: &strong}, start.Add(100*time.Millisecond)))
+	assert.True(t, d.add(ScanResult{Packet: &other}, start.Add(500*time.Millisecond)))
+
+	// Results without a usable device ID are never held
+	assert.False(t, d.add(ScanResult{Error: ErrNotHubblePacket}, start))
+	short := models.EncryptedPacket{Payload: []byte{0x01}}
+	assert.False(t, d.add(ScanResult{Packet: &short}, start))
+
+	next, ok := d.next()
+	require.True(t, ok)
+	assert.Equal(t, start.Add(time.Second), next)
+	assert.Empty(t, d.due(start.Add(900*time.Millisecond)))
+
+	// The first device's window closes with its strongest copy
+	released := d.due(start.Add(time.Second))
+	require.Len(t, released, 1)
+	assert.Equal(t, -50, released[0].Packet.RSSI)
+
+	// A new sighting opens a new window
+	again := dedupePacket(1, -90)
+	assert.True(t, d.add(ScanResult{Packet: &again}, start.Add(1200*time.Millisecond)))
+
+	released = d.flush()
+	require.Len(t, released, 2)
+	assert.Equal(t, -70, released[0].Packet.RSSI)
+	assert.Equal(t, -90, released[1].Packet.RSSI)
+	_, ok = d.next()
+	assert.False(t, ok)
+}
+
+func TestMockScanner_ScanStream_WithDedupeWindow(t *testing.T) {
+	scanner := NewMockScanner()
+	scanner.SetPackets([]models.EncryptedPacket{
+		dedupePacket(1, -80),
+		dedupePacket(2, -60),
+		dedupePacket(1, -55),
+		dedupePacket(1, -70),
+		dedupePacket(2, -65),
+	})
+
+	opts := DefaultScanOptions()
+	opts.DedupeWindow = time.Minute
+
+	resultCh, err := scanner.ScanStream(context.Background(), opts)
+	require.NoError(t, err)
+
+	var rssi []int
+	for result := range resultCh {
+		rssi = append(rssi, result.Packet.RSSI)
+	}
+
+	// One result per device, the strongest, in order of first sighting
+	assert.Equal(t, []int{-55, -60}, rssi)
+}
+
+func TestMockScanner_ScanStream_DedupeWindowCloses(t *testing.T) {
+	scanner := NewMockScanner()
+	scanner.SetPackets([]models.EncryptedPacket{dedupePacket(1, -80), dedupePacket(1, -60)})
+
+	opts := DefaultScanOptions()
+	opts.DedupeWindow = time.Millisecond
+
+	resultCh, err := scanner.ScanStream(context.Background(), opts)
+	require.NoError(t, err)
+
+	// The mock spaces packets 10ms apart, so each gets its own window
+	var count int
+	for range resultCh {
+		count++
+	}
+	assert.Equal(t, 2, count)
+}
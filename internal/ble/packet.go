@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hubblenetwork/hubcli/internal/crypto"
 	"github.com/hubblenetwork/hubcli/internal/models"
 )
 
@@ -38,39 +39,37 @@ var (
 // RawAdvertisement represents a raw BLE advertisement received from scanning
 type RawAdvertisement struct {
 	// LocalName is the advertised device name (if any)
-	LocalName string
+	LocalName string `json:"local_name,omitempty"`
 
 	// ServiceUUIDs contains the advertised service UUIDs
-	ServiceUUIDs []string
+	ServiceUUIDs []string `json:"service_uuids,omitempty"`
 
 	// ServiceData maps service UUIDs to their data
-	ServiceData map[string][]byte
+	ServiceData map[string][]byte `json:"service_data,omitempty"`
 
 	// ManufacturerData contains manufacturer-specific data
-	ManufacturerData []byte
+	ManufacturerData []byte `json:"manufacturer_data,omitempty"`
 
 	// RSSI is the received signal strength indicator
-	RSSI int
+	RSSI int `json:"rssi"`
 
 	// Address is the BLE device address
-	Address string
+	Address string `json:"address"`
 
 	// Timestamp when the advertisement was received
-	Timestamp time.Time
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // ParseAdvertisement extracts a Hubble packet from a raw BLE advertisement
 func ParseAdvertisement(adv RawAdvertisement, loc models.Location) (*models.EncryptedPacket, error) {
 	// Look for Hubble service data first
-	for uuid, data := range adv.ServiceData {
-		if isHubbleUUID(uuid) && len(data) >= MinPayloadLength {
-			return &models.EncryptedPacket{
-				Payload:   data,
-				RSSI:      adv.RSSI,
-				Timestamp: adv.Timestamp,
-				Location:  loc,
-			}, nil
-		}
+	if data := hubbleServiceData(adv.ServiceData); data != nil {
+		return &models.EncryptedPacket{
+			Payload:   data,
+			RSSI:      adv.RSSI,
+			Timestamp: adv.Timestamp,
+			Location:  loc,
+		}, nil
 	}
 
 	// Fall back to manufacturer data if present
@@ -86,6 +85,24 @@ func ParseAdvertisement(adv RawAdvertisement, loc models.Location) (*models.Encr
 	return nil, ErrNotHubblePacket
 }
 
+// hubbleServiceData returns the Hubble service data entry long enough to be
+// a packet, or nil if there is none. An advertisement can carry the Hubble
+// UUID in more than one form (16-bit and 128-bit); since map order is random,
+// the longest payload wins and ties go to the lowest UUID.
+func hubbleServiceData(serviceData map[string][]byte) []byte {
+	var bestUUID string
+	var best []byte
+	for uuid, data := range serviceData {
+		if !isHubbleUUID(uuid) || len(data) < MinPayloadLength {
+			continue
+		}
+		if best == nil || len(data) > len(best) || (len(data) == len(best) && uuid < bestUUID) {
+			bestUUID, best = uuid, data
+		}
+	}
+	return best
+}
+
 // isHubbleUUID checks if a UUID string matches the Hubble service UUID
 func isHubbleUUID(uuid string) bool {
 	// Normalize to lowercase for comparison
@@ -123,20 +140,58 @@ func ContainsHubbleService(adv RawAdvertisement) bool {
 	return false
 }
 
-// ExtractDeviceID attempts to extract a device ID from the packet payload
-// The device ID is typically in the first 4 bytes of the payload
+// PayloadStatus classifies a Hubble payload by whether it can be decrypted
+type PayloadStatus int
+
+const (
+	// PayloadComplete is long enough to hold the header, auth tag and data
+	PayloadComplete PayloadStatus = iota
+
+	// PayloadTruncated is present but shorter than crypto.MinPacketSize, so it
+	// cannot be parsed or decrypted
+	PayloadTruncated
+)
+
+func (s PayloadStatus) String() string {
+	switch s {
+	case PayloadTruncated:
+		return "truncated"
+	default:
+		return "complete"
+	}
+}
+
+// ClassifyPayload reports whether a payload accepted by ParseAdvertisement is
+// long enough to decrypt. Payloads between MinPayloadLength and
+// crypto.MinPacketSize bytes are Hubble advertisements but are missing part of
+// the auth tag, which usually points at a firmware bug.
+func ClassifyPayload(payload []byte) PayloadStatus {
+	if len(payload) < crypto.MinPacketSize {
+		return PayloadTruncated
+	}
+	return PayloadComplete
+}
+
+// ExtractDeviceID returns the ephemeral device ID from bytes 2-5 of the
+// payload, read big-endian like the header
 func ExtractDeviceID(payload []byte) (uint32, error) {
-	if len(payload) < 4 {
+	fields, _ := models.ParseHubblePayload(payload)
+	if fields.DeviceID == nil {
 		return 0, ErrPayloadTooShort
 	}
 
-	// Device ID is stored in little-endian format
-	return binary.LittleEndian.Uint32(payload[:4]), nil
+	return binary.BigEndian.Uint32(fields.DeviceID), nil
 }
 
 // PacketInfo contains parsed information about a BLE packet
 type PacketInfo struct {
-	// DeviceIDBytes is the raw device ID portion
+	// Version is the protocol version from the header
+	Version uint8
+
+	// Sequence is the sequence number from the header
+	Sequence uint16
+
+	// DeviceIDBytes is the raw ephemeral device ID portion
 	DeviceIDBytes []byte
 
 	// EncryptedData is the encrypted payload portion
@@ -149,30 +204,23 @@ type PacketInfo struct {
 	FullPayload []byte
 }
 
-// ParsePacketStructure breaks down a raw payload into its components
-// Hubble packet structure:
-// - Bytes 0-3: Device ID (4 bytes)
-// - Bytes 4-N: Encrypted data + auth tag
+// ParsePacketStructure breaks down a raw payload into its components, laid
+// out as described by models.ParseHubblePayload. A truncated payload (see
+// ClassifyPayload) has no auth tag or encrypted data.
 func ParsePacketStructure(payload []byte) (*PacketInfo, error) {
 	if len(payload) < MinPayloadLength {
 		return nil, ErrPayloadTooShort
 	}
 
-	info := &PacketInfo{
-		DeviceIDBytes: payload[:4],
-		FullPayload:   payload,
-	}
+	// Truncated payloads still hold the header and device ID
+	fields, _ := models.ParseHubblePayload(payload)
 
-	// The remaining bytes are encrypted data
-	if len(payload) > 4 {
-		// Last 4 bytes are typically the auth tag
-		if len(payload) > 8 {
-			info.EncryptedData = payload[4 : len(payload)-4]
-			info.AuthTag = payload[len(payload)-4:]
-		} else {
-			info.EncryptedData = payload[4:]
-		}
-	}
-
-	return info, nil
+	return &PacketInfo{
+		Version:       fields.Version,
+		Sequence:      fields.Sequence,
+		DeviceIDBytes: fields.DeviceID,
+		EncryptedData: fields.Encrypted,
+		AuthTag:       fields.AuthTag,
+		FullPayload:   payload,
+	}, nil
 }
@@ -1,11 +1,16 @@
 package ble
 
 import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/hubblenetwork/hubcli/internal/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsHubbleUUID(t *testing.T) {
@@ -117,6 +122,34 @@ func TestParseAdvertisement(t *testing.T) {
 				assert.Equal(t, -70, p.RSSI)
 			},
 		},
+		{
+			name: "multiple hubble entries picks longest",
+			adv: RawAdvertisement{
+				ServiceData: map[string][]byte{
+					"fca6":            {0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+					HubbleServiceUUID: {0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19},
+					"0xfca6":          {0x21, 0x22},
+				},
+			},
+			loc: loc,
+			checkPacket: func(t *testing.T, p *models.EncryptedPacket) {
+				assert.Equal(t, []byte{0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19}, p.Payload)
+			},
+		},
+		{
+			name: "multiple hubble entries of equal length picks lowest uuid",
+			adv: RawAdvertisement{
+				ServiceData: map[string][]byte{
+					"fca6":            {0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+					HubbleServiceUUID: {0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18},
+				},
+			},
+			loc: loc,
+			checkPacket: func(t *testing.T, p *models.EncryptedPacket) {
+				// "0000fca6-..." sorts before "fca6"
+				assert.Equal(t, []byte{0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18}, p.Payload)
+			},
+		},
 		{
 			name: "service data too short",
 			adv: RawAdvertisement{
@@ -172,17 +205,17 @@ func TestExtractDeviceID(t *testing.T) {
 	}{
 		{
 			name:     "valid device ID",
-			payload:  []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06},
-			expected: 0x04030201, // Little endian
+			payload:  []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+			expected: 0x03040506, // Bytes 2-5, big endian
 		},
 		{
 			name:     "minimum length",
-			payload:  []byte{0xFF, 0xFF, 0xFF, 0xFF},
+			payload:  []byte{0x00, 0x00, 0xFF, 0xFF, 0xFF, 0xFF},
 			expected: 0xFFFFFFFF,
 		},
 		{
 			name:        "payload too short",
-			payload:     []byte{0x01, 0x02, 0x03},
+			payload:     []byte{0x01, 0x02, 0x03, 0x04, 0x05},
 			expectError: ErrPayloadTooShort,
 		},
 		{
@@ -215,20 +248,23 @@ func TestParsePacketStructure(t *testing.T) {
 	}{
 		{
 			name:    "valid packet with auth tag",
-			payload: []byte{0x01, 0x02, 0x03, 0x04, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF, 0x11, 0x22},
+			payload: []byte{0x00, 0x2A, 0x03, 0x04, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF, 0x11, 0x22},
 			check: func(t *testing.T, info *PacketInfo) {
-				assert.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, info.DeviceIDBytes)
-				assert.Equal(t, []byte{0xAA, 0xBB, 0xCC, 0xDD}, info.EncryptedData)
-				assert.Equal(t, []byte{0xEE, 0xFF, 0x11, 0x22}, info.AuthTag)
+				assert.Equal(t, uint8(0), info.Version)
+				assert.Equal(t, uint16(42), info.Sequence)
+				assert.Equal(t, []byte{0x03, 0x04, 0xAA, 0xBB}, info.DeviceIDBytes)
+				assert.Equal(t, []byte{0xCC, 0xDD, 0xEE, 0xFF}, info.AuthTag)
+				assert.Equal(t, []byte{0x11, 0x22}, info.EncryptedData)
 			},
 		},
 		{
 			name:    "minimum valid packet",
 			payload: []byte{0x01, 0x02, 0x03, 0x04, 0xAA, 0xBB, 0xCC, 0xDD},
 			check: func(t *testing.T, info *PacketInfo) {
-				assert.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, info.DeviceIDBytes)
-				assert.Equal(t, []byte{0xAA, 0xBB, 0xCC, 0xDD}, info.EncryptedData)
+				// Too short for an auth tag, so only the device ID is known
+				assert.Equal(t, []byte{0x03, 0x04, 0xAA, 0xBB}, info.DeviceIDBytes)
 				assert.Empty(t, info.AuthTag)
+				assert.Empty(t, info.EncryptedData)
 			},
 		},
 		{
@@ -256,6 +292,28 @@ func TestParsePacketStructure(t *testing.T) {
 	}
 }
 
+func TestClassifyPayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		length   int
+		expected PayloadStatus
+	}{
+		{"min advertisement length", MinPayloadLength, PayloadTruncated},
+		{"one byte short of packet", 9, PayloadTruncated},
+		{"header and auth tag only", 10, PayloadComplete},
+		{"max length", MaxPayloadLength, PayloadComplete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ClassifyPayload(make([]byte, tt.length)))
+		})
+	}
+
+	assert.Equal(t, "truncated", PayloadTruncated.String())
+	assert.Equal(t, "complete", PayloadComplete.String())
+}
+
 func TestConstants(t *testing.T) {
 	// Verify constants are set correctly
 	assert.Equal(t, uint16(0xFCA6), uint16(HubbleServiceUUID16))
@@ -263,3 +321,42 @@ func TestConstants(t *testing.T) {
 	assert.Equal(t, 8, MinPayloadLength)
 	assert.Equal(t, 31, MaxPayloadLength)
 }
+
+// advertisementVector is the shared synthetic test vector in
+// testdata/advertisement_v0.json, also checked by the crypto package
+type advertisementVector struct {
+	Payload   string `json:"payload"`
+	Version   uint8  `json:"version"`
+	Sequence  uint16 `json:"sequence"`
+	DeviceID  string `json:"device_id"`
+	AuthTag   string `json:"auth_tag"`
+	Encrypted string `json:"encrypted"`
+}
+
+func TestParsePacketStructure_SharedVector(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/advertisement_v0.json")
+	require.NoError(t, err)
+	var v advertisementVector
+	require.NoError(t, json.Unmarshal(data, &v))
+
+	unhex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		require.NoError(t, err)
+		return b
+	}
+
+	payload := unhex(v.Payload)
+	assert.Equal(t, PayloadComplete, ClassifyPayload(payload))
+
+	info, err := ParsePacketStructure(payload)
+	require.NoError(t, err)
+	assert.Equal(t, v.Version, info.Version)
+	assert.Equal(t, v.Sequence, info.Sequence)
+	assert.Equal(t, unhex(v.DeviceID), info.DeviceIDBytes)
+	assert.Equal(t, unhex(v.AuthTag), info.AuthTag)
+	assert.Equal(t, unhex(v.Encrypted), info.EncryptedData)
+
+	deviceID, err := ExtractDeviceID(payload)
+	require.NoError(t, err)
+	assert.Equal(t, binary.BigEndian.Uint32(unhex(v.DeviceID)), deviceID)
+}
@@ -3,6 +3,7 @@ package ble
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -22,8 +23,38 @@ var (
 
 	// ErrScanStopped indicates the scan was stopped
 	ErrScanStopped = errors.New("scan stopped")
+
+	// ErrScanModeUnsupported indicates the backend cannot scan in the requested mode
+	ErrScanModeUnsupported = errors.New("scan mode not supported by bluetooth backend")
+)
+
+// ScanMode selects between active and passive BLE scanning.
+//
+// Active scanning sends scan requests to advertisers and receives their scan
+// responses, so it discovers more data per device at the cost of extra radio
+// time and power. Passive scanning only listens for advertisements, which uses
+// less power and is invisible to advertisers, but misses anything that is only
+// sent in scan responses. Hubble packets are carried in the primary
+// advertisement, so either mode captures them.
+type ScanMode int
+
+const (
+	// ScanModeActive sends scan requests (default)
+	ScanModeActive ScanMode = iota
+
+	// ScanModePassive only listens for advertisements
+	ScanModePassive
 )
 
+func (m ScanMode) String() string {
+	switch m {
+	case ScanModePassive:
+		return "passive"
+	default:
+		return "active"
+	}
+}
+
 // ScannerInterface defines the interface for BLE scanners
 type ScannerInterface interface {
 	IsScanning() bool
@@ -53,6 +84,49 @@ type ScanOptions struct {
 
 	// MaxPackets limits the number of packets to capture (0 = unlimited)
 	MaxPackets int
+
+	// MinRSSI drops advertisements weaker than this many dBm, e.g. -90
+	// (0 = no threshold)
+	MinRSSI int
+
+	// DedupeWindow collapses repeats of a device's advertisement, matched
+	// by ephemeral ID, into the strongest copy received within this long of
+	// the first. That copy is sent when the window closes, so results are
+	// delayed by up to the window. Only ScanStream deduplicates. (0 = off)
+	DedupeWindow time.Duration
+
+	// Mode selects active or passive scanning. The tinygo bluetooth backend
+	// used by Scanner always scans actively on desktop platforms, so
+	// ScanModePassive is rejected with ErrScanModeUnsupported.
+	Mode ScanMode
+}
+
+// belowMinRSSI reports whether a signal of rssi dBm falls under the MinRSSI
+// threshold
+func (o ScanOptions) belowMinRSSI(rssi int) bool {
+	return o.MinRSSI != 0 && rssi < o.MinRSSI
+}
+
+// dedupe wraps a ScanStream result channel to apply DedupeWindow
+func (o ScanOptions) dedupe(results <-chan ScanResult) <-chan ScanResult {
+	if o.DedupeWindow <= 0 {
+		return results
+	}
+	return dedupeResults(results, o.DedupeWindow)
+}
+
+// filterRSSI returns the packets at or above the MinRSSI threshold
+func (o ScanOptions) filterRSSI(packets []models.EncryptedPacket) []models.EncryptedPacket {
+	if o.MinRSSI == 0 {
+		return packets
+	}
+	var kept []models.EncryptedPacket
+	for _, p := range packets {
+		if !o.belowMinRSSI(p.RSSI) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
 }
 
 // DefaultScanOptions returns sensible default scan options
@@ -64,6 +138,7 @@ func DefaultScanOptions() ScanOptions {
 			Fake: true, // Mark as local scan by default
 		},
 		MaxPackets: 0,
+		Mode:       ScanModeActive,
 	}
 }
 
@@ -82,7 +157,7 @@ var (
 	globalAdapterErr  error
 )
 
-// NewScanner creates a new BLE scanner
+// NewScanner creates a new BLE scanner using the default adapter
 func NewScanner() (*Scanner, error) {
 	// Only enable the adapter once globally
 	globalAdapterOnce.Do(func() {
@@ -120,6 +195,10 @@ func (s *Scanner) Stop() {
 
 // Scan scans for Hubble BLE advertisements and returns all found packets
 func (s *Scanner) Scan(ctx context.Context, opts ScanOptions) ([]models.EncryptedPacket, error) {
+	if err := checkScanMode(opts.Mode); err != nil {
+		return nil, err
+	}
+
 	s.mu.Lock()
 	if s.scanning {
 		s.mu.Unlock()
@@ -169,7 +248,10 @@ func (s *Scanner) Scan(ctx context.Context, opts ScanOptions) ([]models.Encrypte
 
 			raw := convertScanResult(result)
 
-			// Apply Hubble filter if enabled
+			// Drop weak signals and, if enabled, non-Hubble advertisements
+			if opts.belowMinRSSI(raw.RSSI) {
+				return
+			}
 			if opts.FilterHubbleOnly && !ContainsHubbleService(raw) {
 				return
 			}
@@ -228,6 +310,10 @@ func (s *Scanner) ScanSingle(ctx context.Context, opts ScanOptions) (*models.Enc
 
 // ScanStream returns a channel that streams packets as they are discovered
 func (s *Scanner) ScanStream(ctx context.Context, opts ScanOptions) (<-chan ScanResult, error) {
+	if err := checkScanMode(opts.Mode); err != nil {
+		return nil, err
+	}
+
 	s.mu.Lock()
 	if s.scanning {
 		s.mu.Unlock()
@@ -252,6 +338,11 @@ func (s *Scanner) ScanStream(ctx context.Context, opts ScanOptions) (<-chan Scan
 		go func() {
 			<-scanCtx.Done()
 			cancel()
+			// The scan callback only sees the timeout when an advertisement
+			// arrives, so end a quiet scan here
+			if errors.Is(scanCtx.Err(), context.DeadlineExceeded) {
+				s.adapter.StopScan()
+			}
 		}()
 	}
 
@@ -279,7 +370,10 @@ func (s *Scanner) ScanStream(ctx context.Context, opts ScanOptions) (<-chan Scan
 
 			raw := convertScanResult(result)
 
-			// Apply Hubble filter if enabled
+			// Drop weak signals and, if enabled, non-Hubble advertisements
+			if opts.belowMinRSSI(raw.RSSI) {
+				return
+			}
 			if opts.FilterHubbleOnly && !ContainsHubbleService(raw) {
 				return
 			}
@@ -316,7 +410,15 @@ func (s *Scanner) ScanStream(ctx context.Context, opts ScanOptions) (<-chan Scan
 		}
 	}()
 
-	return results, nil
+	return opts.dedupe(results), nil
+}
+
+// checkScanMode returns an error if the bluetooth backend can't scan in mode
+func checkScanMode(mode ScanMode) error {
+	if mode != ScanModeActive {
+		return fmt.Errorf("%w: %s", ErrScanModeUnsupported, mode)
+	}
+	return nil
 }
 
 // convertScanResult converts a bluetooth.ScanResult to our RawAdvertisement type
@@ -355,6 +457,7 @@ type MockScanner struct {
 	scanning  bool
 	mu        sync.Mutex
 	callbacks []func(ScanResult)
+	lastOpts  ScanOptions
 }
 
 // NewMockScanner creates a mock scanner for testing
@@ -376,6 +479,13 @@ func (m *MockScanner) SetError(err error) {
 	m.Error = err
 }
 
+// LastOptions returns the options passed to the most recent scan
+func (m *MockScanner) LastOptions() ScanOptions {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastOpts
+}
+
 // IsScanning returns whether a mock scan is in progress
 func (m *MockScanner) IsScanning() bool {
 	m.mu.Lock()
@@ -393,12 +503,13 @@ func (m *MockScanner) Stop() {
 // Scan returns the pre-configured packets or error
 func (m *MockScanner) Scan(ctx context.Context, opts ScanOptions) ([]models.EncryptedPacket, error) {
 	m.mu.Lock()
+	m.lastOpts = opts
 	if m.Error != nil {
 		err := m.Error
 		m.mu.Unlock()
 		return nil, err
 	}
-	packets := m.Packets
+	packets := opts.filterRSSI(m.Packets)
 	m.mu.Unlock()
 
 	// Simulate scan time if timeout is set
@@ -432,12 +543,13 @@ func (m *MockScanner) ScanSingle(ctx context.Context, opts ScanOptions) (*models
 // ScanStream returns a channel with pre-configured packets
 func (m *MockScanner) ScanStream(ctx context.Context, opts ScanOptions) (<-chan ScanResult, error) {
 	m.mu.Lock()
+	m.lastOpts = opts
 	if m.Error != nil {
 		err := m.Error
 		m.mu.Unlock()
 		return nil, err
 	}
-	packets := m.Packets
+	packets := opts.filterRSSI(m.Packets)
 	m.scanning = true
 	m.mu.Unlock()
 
@@ -467,5 +579,5 @@ func (m *MockScanner) ScanStream(ctx context.Context, opts ScanOptions) (<-chan
 		}
 	}()
 
-	return results, nil
+	return opts.dedupe(results), nil
 }
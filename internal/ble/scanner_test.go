@@ -201,6 +201,60 @@ func TestMockScanner_ScanStream_WithMaxPackets(t *testing.T) {
 	assert.Len(t, results, 2)
 }
 
+func TestMockScanner_Scan_WithMinRSSI(t *testing.T) {
+	scanner := NewMockScanner()
+	scanner.SetPackets([]models.EncryptedPacket{
+		{Payload: []byte{0x01}, RSSI: -60},
+		{Payload: []byte{0x02}, RSSI: -95},
+		{Payload: []byte{0x03}, RSSI: -90},
+	})
+
+	opts := DefaultScanOptions()
+	opts.MinRSSI = -90
+
+	packets, err := scanner.Scan(context.Background(), opts)
+
+	assert.NoError(t, err)
+	assert.Len(t, packets, 2)
+	assert.Equal(t, []byte{0x01}, packets[0].Payload)
+	assert.Equal(t, []byte{0x03}, packets[1].Payload, "packets at the floor are kept")
+}
+
+func TestMockScanner_ScanStream_WithMinRSSI(t *testing.T) {
+	scanner := NewMockScanner()
+	scanner.SetPackets([]models.EncryptedPacket{
+		{Payload: []byte{0x01}, RSSI: -95},
+		{Payload: []byte{0x02}, RSSI: -70},
+		{Payload: []byte{0x03}, RSSI: -92},
+		{Payload: []byte{0x04}, RSSI: -50},
+	})
+
+	opts := DefaultScanOptions()
+	opts.MinRSSI = -90
+	opts.MaxPackets = 2
+
+	resultCh, err := scanner.ScanStream(context.Background(), opts)
+	assert.NoError(t, err)
+
+	var payloads [][]byte
+	for result := range resultCh {
+		payloads = append(payloads, result.Packet.Payload)
+	}
+
+	// MaxPackets counts only packets that pass the threshold
+	assert.Equal(t, [][]byte{{0x02}, {0x04}}, payloads)
+}
+
+func TestScanOptions_BelowMinRSSI(t *testing.T) {
+	opts := DefaultScanOptions()
+	assert.False(t, opts.belowMinRSSI(-120), "no threshold by default")
+
+	opts.MinRSSI = -80
+	assert.True(t, opts.belowMinRSSI(-81))
+	assert.False(t, opts.belowMinRSSI(-80))
+	assert.False(t, opts.belowMinRSSI(-40))
+}
+
 func TestMockScanner_ScanStream_WithError(t *testing.T) {
 	scanner := NewMockScanner()
 	scanner.SetError(assert.AnError)
@@ -307,3 +361,17 @@ func TestErrors(t *testing.T) {
 		seen[msg] = true
 	}
 }
+
+func TestScanMode_String(t *testing.T) {
+	assert.Equal(t, "active", ScanModeActive.String())
+	assert.Equal(t, "passive", ScanModePassive.String())
+	assert.Equal(t, ScanModeActive, DefaultScanOptions().Mode)
+}
+
+func TestCheckScanMode(t *testing.T) {
+	assert.NoError(t, checkScanMode(ScanModeActive))
+
+	err := checkScanMode(ScanModePassive)
+	assert.ErrorIs(t, err, ErrScanModeUnsupported)
+	assert.Contains(t, err.Error(), "passive")
+}
@@ -0,0 +1,53 @@
+// Package clipboard copies text to the system clipboard. Where there is no
+// clipboard, such as SSH sessions or containers without a display, copying
+// does nothing and reports ErrUnavailable.
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/atotto/clipboard"
+)
+
+// ErrUnavailable indicates there is no clipboard to copy to
+var ErrUnavailable = errors.New("clipboard not available")
+
+// write and available are replaced in tests
+var (
+	write     = clipboard.WriteAll
+	available = systemAvailable
+)
+
+// Copy writes text to the system clipboard. It returns an error wrapping
+// ErrUnavailable, and leaves the clipboard alone, if there is no clipboard
+// or writing to it fails.
+func Copy(text string) error {
+	if !available() {
+		return ErrUnavailable
+	}
+	if err := write(text); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return nil
+}
+
+// Available reports whether a clipboard appears to be reachable
+func Available() bool {
+	return available()
+}
+
+// systemAvailable reports whether a clipboard tool is installed and, on X11
+// and Wayland desktops, whether there is a display for it to talk to
+func systemAvailable() bool {
+	if clipboard.Unsupported {
+		return false
+	}
+	switch runtime.GOOS {
+	case "darwin", "windows", "plan9":
+		return true
+	}
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
@@ -0,0 +1,62 @@
+package clipboard
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClipboard replaces the system clipboard for the duration of a test
+func fakeClipboard(t *testing.T, ok bool, err error) *string {
+	t.Helper()
+	var copied string
+	origWrite, origAvailable := write, available
+	t.Cleanup(func() { write, available = origWrite, origAvailable })
+
+	available = func() bool { return ok }
+	write = func(text string) error {
+		if err != nil {
+			return err
+		}
+		copied = text
+		return nil
+	}
+	return &copied
+}
+
+func TestCopy(t *testing.T) {
+	copied := fakeClipboard(t, true, nil)
+
+	require.NoError(t, Copy("hello"))
+	assert.Equal(t, "hello", *copied)
+	assert.True(t, Available())
+}
+
+func TestCopy_Unavailable(t *testing.T) {
+	copied := fakeClipboard(t, false, nil)
+
+	assert.ErrorIs(t, Copy("hello"), ErrUnavailable)
+	assert.Empty(t, *copied, "nothing is written without a clipboard")
+	assert.False(t, Available())
+}
+
+func TestCopy_WriteFails(t *testing.T) {
+	fakeClipboard(t, true, errors.New("exit status 1"))
+
+	err := Copy("hello")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Contains(t, err.Error(), "exit status 1")
+}
+
+func TestSystemAvailable_Headless(t *testing.T) {
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+
+	if systemAvailable() {
+		// Only desktop platforms without a display variable get here
+		assert.Contains(t, []string{"darwin", "windows", "plan9"}, runtime.GOOS)
+	}
+}
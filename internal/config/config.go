@@ -0,0 +1,217 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+const (
+	// dirName is the directory under the user config dir holding hubcli files.
+	dirName = "hubcli"
+	// fileName is the name of the preferences file.
+	fileName = "config.json"
+)
+
+// Config holds user preferences that persist between sessions.
+// Credentials are not stored here; they live in the keychain.
+type Config struct {
+	// HighContrast renders the selected table row with a high-contrast
+	// style instead of the default primary-color highlight.
+	HighContrast bool `json:"high_contrast,omitempty"`
+
+	// UTCTimestamps displays packet and device timestamps in UTC instead
+	// of local time.
+	UTCTimestamps bool `json:"utc_timestamps,omitempty"`
+
+	// ScanRedrawIntervalMS is the minimum time between BLE scan table
+	// redraws, in milliseconds. 0 uses the built-in default; a negative
+	// value redraws on every packet.
+	ScanRedrawIntervalMS int `json:"scan_redraw_interval_ms,omitempty"`
+
+	// ScanDedupeWindowMS is how long, in milliseconds, BLE scans collapse
+	// repeats of a device's advertisement into its strongest copy. 0 uses
+	// the built-in default; a negative value keeps every copy.
+	ScanDedupeWindowMS int `json:"scan_dedupe_window_ms,omitempty"`
+
+	// ScanLocation is attached to packets captured by local BLE scans
+	// instead of the placeholder location.
+	ScanLocation *ScanLocation `json:"scan_location,omitempty"`
+
+	// PacketsSortOrder is the initial order of the packets table:
+	// "newest_first" (the default) or "oldest_first".
+	PacketsSortOrder string `json:"packets_sort_order,omitempty"`
+
+	// PacketsPageSize is how many packets the packets screen loads at a
+	// time. 0 uses the built-in default.
+	PacketsPageSize int `json:"packets_page_size,omitempty"`
+
+	// RequestTimeoutSeconds is the timeout for API requests other than
+	// uploads, in seconds. 0 uses the built-in default.
+	RequestTimeoutSeconds int `json:"request_timeout_seconds,omitempty"`
+
+	// IngestTimeoutSeconds is the timeout for uploading scanned packets,
+	// in seconds. 0 uses the built-in default.
+	IngestTimeoutSeconds int `json:"ingest_timeout_seconds,omitempty"`
+
+	// IngestRetries is how many times a failed upload is retried when the
+	// server cannot have accepted it. nil uses the built-in default.
+	IngestRetries *int `json:"ingest_retries,omitempty"`
+
+	// MaxConcurrency limits how many API requests bulk operations, such as
+	// deleting the selected devices, send at once. 0 uses the built-in
+	// default.
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+}
+
+// Packets sort orders accepted by PacketsSortOrder.
+const (
+	PacketsNewestFirst = "newest_first"
+	PacketsOldestFirst = "oldest_first"
+)
+
+// ScanLocation is a fixed position for local BLE scans.
+type ScanLocation struct {
+	Latitude           float64 `json:"latitude"`
+	Longitude          float64 `json:"longitude"`
+	Altitude           float64 `json:"altitude,omitempty"`
+	HorizontalAccuracy float64 `json:"horizontal_accuracy,omitempty"`
+}
+
+// Location converts the scan location to a models.Location. It returns false
+// if l is nil or the coordinates are out of range.
+func (l *ScanLocation) Location() (models.Location, bool) {
+	if l == nil || l.Latitude < -90 || l.Latitude > 90 || l.Longitude < -180 || l.Longitude > 180 {
+		return models.Location{}, false
+	}
+
+	return models.Location{
+		Latitude:           l.Latitude,
+		Longitude:          l.Longitude,
+		Altitude:           l.Altitude,
+		HorizontalAccuracy: l.HorizontalAccuracy,
+	}, true
+}
+
+// ScanRedrawInterval returns ScanRedrawIntervalMS as a duration and whether
+// it was set.
+func (c Config) ScanRedrawInterval() (time.Duration, bool) {
+	if c.ScanRedrawIntervalMS == 0 {
+		return 0, false
+	}
+	if c.ScanRedrawIntervalMS < 0 {
+		return 0, true
+	}
+	return time.Duration(c.ScanRedrawIntervalMS) * time.Millisecond, true
+}
+
+// ScanDedupeWindow returns ScanDedupeWindowMS as a duration and whether it
+// was set.
+func (c Config) ScanDedupeWindow() (time.Duration, bool) {
+	if c.ScanDedupeWindowMS == 0 {
+		return 0, false
+	}
+	if c.ScanDedupeWindowMS < 0 {
+		return 0, true
+	}
+	return time.Duration(c.ScanDedupeWindowMS) * time.Millisecond, true
+}
+
+// RequestTimeout returns RequestTimeoutSeconds as a duration and whether it
+// was set to a positive value.
+func (c Config) RequestTimeout() (time.Duration, bool) {
+	if c.RequestTimeoutSeconds <= 0 {
+		return 0, false
+	}
+	return time.Duration(c.RequestTimeoutSeconds) * time.Second, true
+}
+
+// IngestTimeout returns IngestTimeoutSeconds as a duration and whether it was
+// set to a positive value.
+func (c Config) IngestTimeout() (time.Duration, bool) {
+	if c.IngestTimeoutSeconds <= 0 {
+		return 0, false
+	}
+	return time.Duration(c.IngestTimeoutSeconds) * time.Second, true
+}
+
+// PacketsSortAscending reports whether packets should be listed oldest
+// first. Unknown values fall back to newest first.
+func (c Config) PacketsSortAscending() bool {
+	return c.PacketsSortOrder == PacketsOldestFirst
+}
+
+// Default returns the configuration used when no file exists.
+func Default() Config {
+	return Config{}
+}
+
+// Path returns the location of the config file.
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, dirName, fileName), nil
+}
+
+// Load reads the config from the default location.
+// A missing file is not an error; defaults are returned instead.
+func Load() (Config, error) {
+	path, err := Path()
+	if err != nil {
+		return Default(), err
+	}
+	return LoadFrom(path)
+}
+
+// LoadFrom reads the config from the given path.
+func LoadFrom(path string) (Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Default(), fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Save writes the config to the default location.
+func Save(cfg Config) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	return SaveTo(path, cfg)
+}
+
+// SaveTo writes the config to the given path, creating parent directories.
+func SaveTo(path string, cfg Config) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFrom_MissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.json")
+
+	cfg, err := LoadFrom(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestSaveTo_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.json")
+
+	cfg := Default()
+	cfg.HighContrast = true
+
+	require.NoError(t, SaveTo(path, cfg))
+
+	loaded, err := LoadFrom(path)
+	require.NoError(t, err)
+	assert.Equal(t, cfg, loaded)
+}
+
+func TestLoadFrom_InvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o644))
+
+	cfg, err := LoadFrom(path)
+
+	assert.Error(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestConfig_ScanRedrawInterval(t *testing.T) {
+	_, ok := Config{}.ScanRedrawInterval()
+	assert.False(t, ok)
+
+	d, ok := Config{ScanRedrawIntervalMS: 500}.ScanRedrawInterval()
+	assert.True(t, ok)
+	assert.Equal(t, 500*time.Millisecond, d)
+
+	d, ok = Config{ScanRedrawIntervalMS: -1}.ScanRedrawInterval()
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), d)
+}
+
+func TestConfig_ScanDedupeWindow(t *testing.T) {
+	_, ok := Config{}.ScanDedupeWindow()
+	assert.False(t, ok)
+
+	d, ok := Config{ScanDedupeWindowMS: 2000}.ScanDedupeWindow()
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, d)
+
+	d, ok = Config{ScanDedupeWindowMS: -1}.ScanDedupeWindow()
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), d)
+}
+
+func TestConfig_RequestTimeout(t *testing.T) {
+	_, ok := Config{}.RequestTimeout()
+	assert.False(t, ok)
+
+	d, ok := Config{RequestTimeoutSeconds: 120}.RequestTimeout()
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, d)
+
+	_, ok = Config{RequestTimeoutSeconds: -1}.RequestTimeout()
+	assert.False(t, ok)
+}
+
+func TestConfig_IngestTimeout(t *testing.T) {
+	_, ok := Config{}.IngestTimeout()
+	assert.False(t, ok)
+
+	d, ok := Config{IngestTimeoutSeconds: 90}.IngestTimeout()
+	assert.True(t, ok)
+	assert.Equal(t, 90*time.Second, d)
+
+	_, ok = Config{IngestTimeoutSeconds: -5}.IngestTimeout()
+	assert.False(t, ok)
+}
+
+func TestScanLocation_Location(t *testing.T) {
+	_, ok := Config{}.ScanLocation.Location()
+	assert.False(t, ok)
+
+	loc, ok := (&ScanLocation{Latitude: 37.7749, Longitude: -122.4194, HorizontalAccuracy: 15}).Location()
+	assert.True(t, ok)
+	assert.Equal(t, 37.7749, loc.Latitude)
+	assert.Equal(t, -122.4194, loc.Longitude)
+	assert.Equal(t, 15.0, loc.HorizontalAccuracy)
+	assert.False(t, loc.Fake)
+
+	_, ok = (&ScanLocation{Latitude: 91}).Location()
+	assert.False(t, ok)
+}
+
+func TestConfig_PacketsSortAscending(t *testing.T) {
+	assert.False(t, Config{}.PacketsSortAscending())
+	assert.False(t, Config{PacketsSortOrder: PacketsNewestFirst}.PacketsSortAscending())
+	assert.True(t, Config{PacketsSortOrder: PacketsOldestFirst}.PacketsSortAscending())
+	assert.False(t, Config{PacketsSortOrder: "sideways"}.PacketsSortAscending())
+}
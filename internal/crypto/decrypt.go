@@ -1,9 +1,10 @@
 package crypto
 
 import (
-	"encoding/binary"
+	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/hubblenetwork/hubcli/internal/models"
@@ -15,22 +16,23 @@ const (
 
 	// MinPacketSize is the minimum valid packet size.
 	// 2 bytes header + 4 bytes reserved + 4 bytes auth tag = 10 bytes minimum
-	MinPacketSize = 10
+	MinPacketSize = models.HubbleMinPayloadSize
 
 	// HeaderSize is the size of the packet header (sequence number bytes).
-	HeaderSize = 2
+	HeaderSize = models.HubbleHeaderSize
 
-	// ReservedSize is the size of reserved bytes before the auth tag.
-	ReservedSize = 4
+	// ReservedSize is the size of reserved bytes before the auth tag, which
+	// hold the ephemeral device ID.
+	ReservedSize = models.HubbleDeviceIDSize
 
 	// AuthTagOffset is the byte offset where the auth tag starts.
-	AuthTagOffset = HeaderSize + ReservedSize // 6
+	AuthTagOffset = models.HubbleAuthTagOffset // 6
 
 	// PayloadOffset is the byte offset where the encrypted payload starts.
-	PayloadOffset = AuthTagOffset + AuthTagSize // 10
+	PayloadOffset = models.HubbleDataOffset // 10
 
 	// SequenceNumberMask extracts the 10-bit sequence number.
-	SequenceNumberMask = 0x3FF
+	SequenceNumberMask = models.HubbleSequenceMask
 
 	// DefaultSearchWindowDays is the default number of days to search in each direction.
 	DefaultSearchWindowDays = 2
@@ -53,29 +55,22 @@ type ParsedPacket struct {
 }
 
 // ParsePacket extracts the components from a BLE advertisement payload.
+// The protocol version is not checked.
 func ParsePacket(payload []byte) (*ParsedPacket, error) {
-	if len(payload) < MinPacketSize {
+	fields, err := models.ParseHubblePayload(payload)
+	if err != nil {
 		return nil, fmt.Errorf("%w: got %d bytes, need at least %d", ErrPacketTooShort, len(payload), MinPacketSize)
 	}
 
-	// Extract 10-bit sequence number from first 2 bytes (big-endian)
-	seqRaw := binary.BigEndian.Uint16(payload[0:2])
-	seqNum := seqRaw & SequenceNumberMask
-
-	// Extract 4-byte auth tag at offset 6
-	authTag := make([]byte, AuthTagSize)
-	copy(authTag, payload[AuthTagOffset:AuthTagOffset+AuthTagSize])
-
-	// Extract encrypted payload starting at offset 10
+	// Copy the fields so they don't alias the caller's payload
 	var encPayload []byte
-	if len(payload) > PayloadOffset {
-		encPayload = make([]byte, len(payload)-PayloadOffset)
-		copy(encPayload, payload[PayloadOffset:])
+	if len(fields.Encrypted) > 0 {
+		encPayload = append([]byte(nil), fields.Encrypted...)
 	}
 
 	return &ParsedPacket{
-		SequenceNumber:   seqNum,
-		AuthTag:          authTag,
+		SequenceNumber:   fields.Sequence,
+		AuthTag:          append([]byte(nil), fields.AuthTag...),
 		EncryptedPayload: encPayload,
 		RawPacket:        payload,
 	}, nil
@@ -90,13 +85,19 @@ type DecryptResult struct {
 
 // DecryptOptions configures the decryption behavior.
 type DecryptOptions struct {
-	// SearchWindowDays is the number of days to search in each direction.
-	// Default is 2 (searches -2 to +2 days from expected time).
-	SearchWindowDays int
+	// SearchDaysBefore and SearchDaysAfter are the number of days to search
+	// before and after the expected time. Both default to 2 (searches -2 to
+	// +2 days from expected time).
+	SearchDaysBefore int
+	SearchDaysAfter  int
 
 	// ExpectedTime is the expected timestamp for the packet.
 	// If zero, uses the packet's timestamp or current time.
 	ExpectedTime time.Time
+
+	// Concurrency is the number of goroutines used to search the time
+	// counter range. 0 or 1 searches sequentially.
+	Concurrency int
 }
 
 // DecryptOption is a functional option for configuring decryption.
@@ -104,8 +105,17 @@ type DecryptOption func(*DecryptOptions)
 
 // WithSearchWindow sets the number of days to search in each direction.
 func WithSearchWindow(days int) DecryptOption {
+	return WithSearchRange(days, days)
+}
+
+// WithSearchRange sets the number of days to search before and after the
+// expected time separately. Device clocks tend to drift in one direction, so
+// e.g. WithSearchRange(7, 1) covers a lagging clock without searching a week
+// into the future.
+func WithSearchRange(before, after int) DecryptOption {
 	return func(o *DecryptOptions) {
-		o.SearchWindowDays = days
+		o.SearchDaysBefore = before
+		o.SearchDaysAfter = after
 	}
 }
 
@@ -116,6 +126,15 @@ func WithExpectedTime(t time.Time) DecryptOption {
 	}
 }
 
+// WithConcurrency searches the time counter range with up to n goroutines.
+// The result is the same as a sequential search; this only pays off for
+// wide search windows.
+func WithConcurrency(n int) DecryptOption {
+	return func(o *DecryptOptions) {
+		o.Concurrency = n
+	}
+}
+
 // TimeToCounter converts a Unix timestamp to a time counter (days since epoch).
 func TimeToCounter(t time.Time) uint32 {
 	return uint32(t.Unix() / SecondsPerDay)
@@ -126,16 +145,11 @@ func CounterToTime(counter uint32) time.Time {
 	return time.Unix(int64(counter)*SecondsPerDay, 0).UTC()
 }
 
-// Decrypt attempts to decrypt an encrypted packet using the provided key.
-// It searches a time window around the expected time to find the correct counter.
-func Decrypt(key []byte, packet models.EncryptedPacket, opts ...DecryptOption) (*DecryptResult, error) {
-	if len(key) != AES128KeySize && len(key) != AES256KeySize {
-		return nil, ErrInvalidKey
-	}
-
-	// Apply options
+// resolveOptions applies opts over the defaults for packet.
+func resolveOptions(packet models.EncryptedPacket, opts []DecryptOption) DecryptOptions {
 	options := DecryptOptions{
-		SearchWindowDays: DefaultSearchWindowDays,
+		SearchDaysBefore: DefaultSearchWindowDays,
+		SearchDaysAfter:  DefaultSearchWindowDays,
 		ExpectedTime:     packet.Timestamp,
 	}
 	for _, opt := range opts {
@@ -146,27 +160,58 @@ func Decrypt(key []byte, packet models.EncryptedPacket, opts ...DecryptOption) (
 		options.ExpectedTime = time.Now().UTC()
 	}
 
+	return options
+}
+
+// searchOrder returns the time counters to search, closest to the expected
+// time first. Of two counters equally far from it, the earlier comes first.
+func (o DecryptOptions) searchOrder() []uint32 {
+	baseCounter := TimeToCounter(o.ExpectedTime)
+	order := make([]uint32, 0, o.SearchDaysBefore+o.SearchDaysAfter+1)
+	order = append(order, baseCounter)
+	for d := 1; d <= o.SearchDaysBefore || d <= o.SearchDaysAfter; d++ {
+		if d <= o.SearchDaysBefore {
+			order = append(order, baseCounter-uint32(d))
+		}
+		if d <= o.SearchDaysAfter {
+			order = append(order, baseCounter+uint32(d))
+		}
+	}
+	return order
+}
+
+// findCounter returns the first counter in the search order for which match
+// returns true, searching on o.Concurrency goroutines.
+func (o DecryptOptions) findCounter(match func(uint32) bool) (uint32, bool) {
+	return searchCounters(o.searchOrder(), o.Concurrency, match)
+}
+
+// Decrypt attempts to decrypt an encrypted packet using the provided key.
+// It searches a time window around the expected time to find the correct
+// counter. Should more than one counter authenticate the packet, the one
+// closest to the expected time is used.
+func Decrypt(key []byte, packet models.EncryptedPacket, opts ...DecryptOption) (*DecryptResult, error) {
+	if len(key) != AES128KeySize && len(key) != AES256KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	// Apply options
+	options := resolveOptions(packet, opts)
+
 	// Parse the packet
 	parsed, err := ParsePacket(packet.Payload)
 	if err != nil {
 		return nil, err
 	}
 
-	// Calculate the base time counter and search range
-	baseCounter := TimeToCounter(options.ExpectedTime)
-	minCounter := baseCounter - uint32(options.SearchWindowDays)
-	maxCounter := baseCounter + uint32(options.SearchWindowDays)
-
 	// Search for a valid time counter
-	for tc := minCounter; tc <= maxCounter; tc++ {
-		result, err := tryDecrypt(key, parsed, tc)
-		if err == nil {
-			return result, nil
-		}
-		// Continue searching if authentication failed
+	tc, ok := options.findCounter(func(tc uint32) bool {
+		return verifyCounter(key, parsed, tc)
+	})
+	if !ok {
+		return nil, ErrDecryptionFailed
 	}
-
-	return nil, ErrDecryptionFailed
+	return tryDecrypt(key, parsed, tc)
 }
 
 // tryDecrypt attempts decryption with a specific time counter.
@@ -232,48 +277,93 @@ func DecryptWithKnownCounter(key []byte, packet models.EncryptedPacket, timeCoun
 	return tryDecrypt(key, parsed, timeCounter)
 }
 
-// FindTimeCounter searches for the correct time counter without decrypting.
+// FindTimeCounter searches for the correct time counter without decrypting,
+// in the same order as Decrypt.
 // Returns the time counter if found, or an error if no valid counter is found.
 func FindTimeCounter(key []byte, packet models.EncryptedPacket, opts ...DecryptOption) (uint32, error) {
 	if len(key) != AES128KeySize && len(key) != AES256KeySize {
 		return 0, ErrInvalidKey
 	}
 
-	options := DecryptOptions{
-		SearchWindowDays: DefaultSearchWindowDays,
-		ExpectedTime:     packet.Timestamp,
-	}
-	for _, opt := range opts {
-		opt(&options)
-	}
-
-	if options.ExpectedTime.IsZero() {
-		options.ExpectedTime = time.Now().UTC()
-	}
+	options := resolveOptions(packet, opts)
 
 	parsed, err := ParsePacket(packet.Payload)
 	if err != nil {
 		return 0, err
 	}
 
-	baseCounter := TimeToCounter(options.ExpectedTime)
-	minCounter := baseCounter - uint32(options.SearchWindowDays)
-	maxCounter := baseCounter + uint32(options.SearchWindowDays)
+	tc, ok := options.findCounter(func(tc uint32) bool {
+		return verifyCounter(key, parsed, tc)
+	})
+	if !ok {
+		return 0, ErrDecryptionFailed
+	}
+	return tc, nil
+}
 
-	for tc := minCounter; tc <= maxCounter; tc++ {
-		seqCounter := uint32(parsed.SequenceNumber)
+// verifyCounter reports whether the packet's auth tag verifies with the keys
+// derived for timeCounter.
+func verifyCounter(key []byte, parsed *ParsedPacket, timeCounter uint32) bool {
+	encKey, err := FullEncryptionKeyDerivation(key, timeCounter, uint32(parsed.SequenceNumber))
+	if err != nil {
+		return false
+	}
+	valid, err := VerifyAuthTag(encKey, parsed.RawPacket[:AuthTagOffset], parsed.AuthTag)
+	return err == nil && valid
+}
 
-		encKey, err := FullEncryptionKeyDerivation(key, tc, seqCounter)
-		if err != nil {
-			continue
+// searchCounters returns the first counter in order for which match returns
+// true. With more than one worker, counters are checked on up to workers
+// goroutines. They are handed out in order and no new ones are started once
+// a match is found, so every counter before the match has been checked when
+// the workers finish and the result equals a sequential search.
+func searchCounters(order []uint32, workers int, match func(uint32) bool) (uint32, bool) {
+	if workers <= 1 {
+		for _, tc := range order {
+			if match(tc) {
+				return tc, true
+			}
 		}
+		return 0, false
+	}
 
-		authData := parsed.RawPacket[:AuthTagOffset]
-		valid, err := VerifyAuthTag(encKey, authData, parsed.AuthTag)
-		if err == nil && valid {
-			return tc, nil
-		}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu   sync.Mutex
+		next int
+		best = len(order) // Index in order of the earliest match
+		wg   sync.WaitGroup
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				mu.Lock()
+				i := next
+				next++
+				mu.Unlock()
+				if i >= len(order) {
+					return
+				}
+
+				if match(order[i]) {
+					mu.Lock()
+					best = min(best, i)
+					mu.Unlock()
+					cancel()
+					return
+				}
+			}
+		}()
 	}
+	wg.Wait()
 
-	return 0, ErrDecryptionFailed
+	if best == len(order) {
+		return 0, false
+	}
+	return order[best], true
 }
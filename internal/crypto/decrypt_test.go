@@ -1,6 +1,10 @@
 package crypto
 
 import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"slices"
 	"testing"
 	"time"
 
@@ -132,7 +136,15 @@ func TestDecryptOptions(t *testing.T) {
 	t.Run("WithSearchWindow sets days", func(t *testing.T) {
 		opts := DecryptOptions{}
 		WithSearchWindow(5)(&opts)
-		assert.Equal(t, 5, opts.SearchWindowDays)
+		assert.Equal(t, 5, opts.SearchDaysBefore)
+		assert.Equal(t, 5, opts.SearchDaysAfter)
+	})
+
+	t.Run("WithSearchRange sets each direction", func(t *testing.T) {
+		opts := DecryptOptions{}
+		WithSearchRange(7, 1)(&opts)
+		assert.Equal(t, 7, opts.SearchDaysBefore)
+		assert.Equal(t, 1, opts.SearchDaysAfter)
 	})
 
 	t.Run("WithExpectedTime sets time", func(t *testing.T) {
@@ -141,6 +153,12 @@ func TestDecryptOptions(t *testing.T) {
 		WithExpectedTime(expected)(&opts)
 		assert.Equal(t, expected, opts.ExpectedTime)
 	})
+
+	t.Run("WithConcurrency sets workers", func(t *testing.T) {
+		opts := DecryptOptions{}
+		WithConcurrency(4)(&opts)
+		assert.Equal(t, 4, opts.Concurrency)
+	})
 }
 
 func TestDecrypt_Errors(t *testing.T) {
@@ -455,3 +473,180 @@ func TestDecryptIntegration(t *testing.T) {
 		assert.ErrorIs(t, err, ErrDecryptionFailed)
 	})
 }
+
+// buildTestPacket encrypts plaintext into a BLE payload for the given counters.
+func buildTestPacket(t testing.TB, key []byte, timeCounter, seqCounter uint32, plaintext []byte) []byte {
+	t.Helper()
+
+	encKey, err := FullEncryptionKeyDerivation(key, timeCounter, seqCounter)
+	require.NoError(t, err)
+	nonce, err := FullNonceDerivation(key, timeCounter, seqCounter)
+	require.NoError(t, err)
+	ciphertext, err := AESCTREncrypt(encKey, nonce, plaintext)
+	require.NoError(t, err)
+
+	header := make([]byte, 6)
+	header[0] = byte(seqCounter >> 8)
+	header[1] = byte(seqCounter & 0xFF)
+	authTag, err := ComputeAuthTag(encKey, header)
+	require.NoError(t, err)
+
+	packet := append(header, authTag...)
+	return append(packet, ciphertext...)
+}
+
+func TestDecrypt_Concurrent(t *testing.T) {
+	key := make([]byte, 16)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	plaintext := []byte("wide window")
+	packet := models.EncryptedPacket{
+		Payload:   buildTestPacket(t, key, 20021, 7, plaintext),
+		Timestamp: CounterToTime(20000),
+	}
+
+	sequential, err := Decrypt(key, packet, WithSearchWindow(30))
+	require.NoError(t, err)
+
+	for _, workers := range []int{1, 2, 8, 100} {
+		result, err := Decrypt(key, packet, WithSearchWindow(30), WithConcurrency(workers))
+		require.NoError(t, err, "workers=%d", workers)
+		assert.Equal(t, sequential, result, "workers=%d", workers)
+	}
+
+	tc, err := FindTimeCounter(key, packet, WithSearchWindow(30), WithConcurrency(4))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(20021), tc)
+
+	_, err = Decrypt(key, packet, WithSearchWindow(10), WithConcurrency(4))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestSearchCounters(t *testing.T) {
+	matches := map[uint32]bool{105: true, 109: true, 112: true}
+	match := func(tc uint32) bool { return matches[tc] }
+	order := []uint32{110, 109, 111, 108, 112, 105}
+
+	// The first match in order wins regardless of scheduling, like a
+	// sequential search
+	for _, workers := range []int{0, 1, 2, 8} {
+		for i := 0; i < 50; i++ {
+			tc, ok := searchCounters(order, workers, match)
+			require.True(t, ok)
+			assert.Equal(t, uint32(109), tc, "workers=%d", workers)
+		}
+	}
+
+	_, ok := searchCounters([]uint32{113, 114, 115}, 4, match)
+	assert.False(t, ok)
+
+	_, ok = searchCounters(nil, 4, match)
+	assert.False(t, ok)
+}
+
+func TestDecryptOptions_FindCounterClosestToExpected(t *testing.T) {
+	opts := DecryptOptions{ExpectedTime: CounterToTime(20000), SearchDaysBefore: 5, SearchDaysAfter: 5}
+
+	tests := []struct {
+		name    string
+		matches []uint32
+		want    uint32
+	}{
+		{"later counter closer", []uint32{19997, 20002}, 20002},
+		{"earlier counter closer", []uint32{19999, 20003}, 19999},
+		{"equally close picks the earlier", []uint32{19998, 20002}, 19998},
+		{"expected counter wins", []uint32{19995, 20000, 20005}, 20000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := func(tc uint32) bool { return slices.Contains(tt.matches, tc) }
+			for _, workers := range []int{1, 4} {
+				opts.Concurrency = workers
+				tc, ok := opts.findCounter(match)
+				require.True(t, ok)
+				assert.Equal(t, tt.want, tc, "workers=%d", workers)
+			}
+		})
+	}
+}
+
+func TestDecrypt_AsymmetricRange(t *testing.T) {
+	key := make([]byte, 16)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	plaintext := []byte("lagging clock")
+
+	// The device clock lags: the packet was sent 5 days before the receiver
+	// thought it was
+	packet := models.EncryptedPacket{
+		Payload:   buildTestPacket(t, key, 19995, 9, plaintext),
+		Timestamp: CounterToTime(20000),
+	}
+
+	_, err := Decrypt(key, packet, WithSearchWindow(2))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	result, err := Decrypt(key, packet, WithSearchRange(7, 1))
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, result.Payload)
+	assert.Equal(t, uint32(19995), result.TimeCounter)
+
+	tc, err := FindTimeCounter(key, packet, WithSearchRange(7, 1))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(19995), tc)
+
+	// The forward bound is honored too
+	_, err = FindTimeCounter(key, packet, WithSearchRange(4, 7))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	_, err = FindTimeCounter(key, packet, WithSearchRange(7, 1), WithConcurrency(4))
+	assert.NoError(t, err)
+}
+
+func TestDecryptOptions_SearchOrder(t *testing.T) {
+	opts := DecryptOptions{ExpectedTime: CounterToTime(20000), SearchDaysBefore: 3, SearchDaysAfter: 1}
+	assert.Equal(t, []uint32{20000, 19999, 20001, 19998, 19997}, opts.searchOrder())
+
+	opts = DecryptOptions{ExpectedTime: CounterToTime(20000)}
+	assert.Equal(t, []uint32{20000}, opts.searchOrder())
+}
+
+// advertisementVector is the shared synthetic test vector in
+// testdata/advertisement_v0.json, also checked by the ble package
+type advertisementVector struct {
+	Payload   string    `json:"payload"`
+	Key       string    `json:"key"`
+	Timestamp time.Time `json:"timestamp"`
+	Sequence  uint16    `json:"sequence"`
+	AuthTag   string    `json:"auth_tag"`
+	Encrypted string    `json:"encrypted"`
+	Plaintext string    `json:"plaintext"`
+}
+
+func TestParsePacket_SharedVector(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/advertisement_v0.json")
+	require.NoError(t, err)
+	var v advertisementVector
+	require.NoError(t, json.Unmarshal(data, &v))
+
+	unhex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		require.NoError(t, err)
+		return b
+	}
+
+	payload := unhex(v.Payload)
+	parsed, err := ParsePacket(payload)
+	require.NoError(t, err)
+	assert.Equal(t, v.Sequence, parsed.SequenceNumber)
+	assert.Equal(t, unhex(v.AuthTag), parsed.AuthTag)
+	assert.Equal(t, unhex(v.Encrypted), parsed.EncryptedPayload)
+
+	// The auth tag only verifies if the layout is read as it was written
+	result, err := Decrypt(unhex(v.Key), models.EncryptedPacket{Payload: payload, Timestamp: v.Timestamp})
+	require.NoError(t, err)
+	assert.Equal(t, unhex(v.Plaintext), result.Payload)
+	assert.Equal(t, TimeToCounter(v.Timestamp), result.TimeCounter)
+}
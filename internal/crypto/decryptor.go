@@ -0,0 +1,146 @@
+package crypto
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+// Decryptor decrypts packets from one device, caching the intermediate keys
+// derived for each time counter. Packets from the same day share those keys,
+// so a batch only runs the per-day SP 800-108 derivations once instead of
+// once per packet and counter tried. A Decryptor is safe for concurrent use.
+type Decryptor struct {
+	key  []byte
+	opts []DecryptOption
+
+	// The Decryptor is bound to one master key, so the caches are keyed by
+	// time counter alone.
+	mu        sync.RWMutex
+	encKeys   map[uint32][]byte // Intermediate encryption keys
+	nonceKeys map[uint32][]byte
+}
+
+// BatchResult is the outcome of decrypting one packet in DecryptBatch.
+type BatchResult struct {
+	Result *DecryptResult
+	Err    error
+}
+
+// NewDecryptor returns a Decryptor for the given master key. The options
+// apply to every packet it decrypts, as they would for Decrypt.
+func NewDecryptor(key []byte, opts ...DecryptOption) (*Decryptor, error) {
+	if len(key) != AES128KeySize && len(key) != AES256KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	return &Decryptor{
+		key:       key,
+		opts:      opts,
+		encKeys:   make(map[uint32][]byte),
+		nonceKeys: make(map[uint32][]byte),
+	}, nil
+}
+
+// Decrypt decrypts a single packet. It returns the same result as the
+// package-level Decrypt with the Decryptor's key and options.
+func (d *Decryptor) Decrypt(packet models.EncryptedPacket) (*DecryptResult, error) {
+	options := resolveOptions(packet, d.opts)
+
+	parsed, err := ParsePacket(packet.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	tc, ok := options.findCounter(func(tc uint32) bool {
+		return d.verifyCounter(parsed, tc)
+	})
+	if !ok {
+		return nil, ErrDecryptionFailed
+	}
+
+	return d.decryptWithCounter(parsed, tc)
+}
+
+// DecryptBatch decrypts packets in order, returning one result per packet.
+// A packet that fails to decrypt does not stop the rest.
+func (d *Decryptor) DecryptBatch(packets []models.EncryptedPacket) []BatchResult {
+	results := make([]BatchResult, len(packets))
+	for i, p := range packets {
+		results[i].Result, results[i].Err = d.Decrypt(p)
+	}
+	return results
+}
+
+// verifyCounter reports whether the packet's auth tag verifies with the keys
+// for timeCounter.
+func (d *Decryptor) verifyCounter(parsed *ParsedPacket, timeCounter uint32) bool {
+	encKey, err := d.encryptionKey(timeCounter, uint32(parsed.SequenceNumber))
+	if err != nil {
+		return false
+	}
+	valid, err := VerifyAuthTag(encKey, parsed.RawPacket[:AuthTagOffset], parsed.AuthTag)
+	return err == nil && valid
+}
+
+// decryptWithCounter decrypts a packet whose auth tag verified at timeCounter.
+func (d *Decryptor) decryptWithCounter(parsed *ParsedPacket, timeCounter uint32) (*DecryptResult, error) {
+	seqCounter := uint32(parsed.SequenceNumber)
+
+	encKey, err := d.encryptionKey(timeCounter, seqCounter)
+	if err != nil {
+		return nil, fmt.Errorf("key derivation failed: %w", err)
+	}
+
+	nonceKey, err := d.cached(d.nonceKeys, DeriveNonceKey, timeCounter)
+	if err != nil {
+		return nil, fmt.Errorf("nonce derivation failed: %w", err)
+	}
+	nonce, err := DeriveNonce(nonceKey, seqCounter)
+	if err != nil {
+		return nil, fmt.Errorf("nonce derivation failed: %w", err)
+	}
+
+	plaintext, err := AESCTRDecrypt(encKey, nonce, parsed.EncryptedPayload)
+	if err != nil {
+		return nil, fmt.Errorf("decryption failed: %w", err)
+	}
+
+	return &DecryptResult{
+		Payload:     plaintext,
+		TimeCounter: timeCounter,
+		SeqCounter:  seqCounter,
+	}, nil
+}
+
+// encryptionKey returns the final encryption key, deriving it from the
+// cached intermediate key for timeCounter.
+func (d *Decryptor) encryptionKey(timeCounter, seqCounter uint32) ([]byte, error) {
+	intermediate, err := d.cached(d.encKeys, DeriveEncryptionKeyIntermediate, timeCounter)
+	if err != nil {
+		return nil, err
+	}
+	return DeriveEncryptionKey(intermediate, seqCounter)
+}
+
+// cached returns the key for timeCounter from cache, deriving and storing it
+// on a miss.
+func (d *Decryptor) cached(cache map[uint32][]byte, derive func([]byte, uint32) ([]byte, error), timeCounter uint32) ([]byte, error) {
+	d.mu.RLock()
+	k, ok := cache[timeCounter]
+	d.mu.RUnlock()
+	if ok {
+		return k, nil
+	}
+
+	k, err := derive(d.key, timeCounter)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	cache[timeCounter] = k
+	d.mu.Unlock()
+	return k, nil
+}
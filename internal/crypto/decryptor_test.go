@@ -0,0 +1,128 @@
+package crypto
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sameDayPackets returns n packets from one device, all sent on timeCounter.
+func sameDayPackets(t testing.TB, key []byte, timeCounter uint32, n int) []models.EncryptedPacket {
+	packets := make([]models.EncryptedPacket, n)
+	for i := range packets {
+		packets[i] = models.EncryptedPacket{
+			Payload:   buildTestPacket(t, key, timeCounter, uint32(i), []byte("batch payload")),
+			Timestamp: CounterToTime(timeCounter - 1),
+		}
+	}
+	return packets
+}
+
+func TestNewDecryptor_InvalidKey(t *testing.T) {
+	_, err := NewDecryptor(make([]byte, 24))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestDecryptor_MatchesDecrypt(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	packets := sameDayPackets(t, key, 20000, 5)
+	packets = append(packets, models.EncryptedPacket{Payload: make([]byte, MinPacketSize-1)})
+
+	d, err := NewDecryptor(key, WithSearchWindow(2))
+	require.NoError(t, err)
+
+	results := d.DecryptBatch(packets)
+	require.Len(t, results, len(packets))
+	for i, p := range packets {
+		want, wantErr := Decrypt(key, p, WithSearchWindow(2))
+		assert.Equal(t, want, results[i].Result, "packet %d", i)
+		assert.Equal(t, wantErr, results[i].Err, "packet %d", i)
+	}
+
+	// One intermediate key per counter tried (19999, 19998 then 20000),
+	// not per packet
+	assert.Len(t, d.encKeys, 3)
+	assert.Len(t, d.nonceKeys, 1)
+}
+
+func TestDecryptor_NoMatch(t *testing.T) {
+	key := make([]byte, 16)
+	packet := models.EncryptedPacket{
+		Payload:   buildTestPacket(t, key, 20010, 1, []byte("x")),
+		Timestamp: CounterToTime(20000),
+	}
+
+	d, err := NewDecryptor(key)
+	require.NoError(t, err)
+
+	_, err = d.Decrypt(packet)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestDecryptor_Concurrent(t *testing.T) {
+	key := make([]byte, 16)
+	packets := sameDayPackets(t, key, 20000, 8)
+
+	d, err := NewDecryptor(key, WithConcurrency(4))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for _, p := range packets {
+		wg.Add(1)
+		go func(p models.EncryptedPacket) {
+			defer wg.Done()
+			result, err := d.Decrypt(p)
+			assert.NoError(t, err)
+			if result != nil {
+				assert.Equal(t, uint32(20000), result.TimeCounter)
+			}
+		}(p)
+	}
+	wg.Wait()
+}
+
+func benchmarkPackets(b *testing.B) ([]byte, []models.EncryptedPacket) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	packets := sameDayPackets(b, key, 20000, 100)
+	return key, packets
+}
+
+func BenchmarkDecrypt_Repeated(b *testing.B) {
+	key, packets := benchmarkPackets(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, p := range packets {
+			if _, err := Decrypt(key, p); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkDecryptor_DecryptBatch(b *testing.B) {
+	key, packets := benchmarkPackets(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		// A fresh Decryptor per iteration, so the cache starts cold each time
+		d, err := NewDecryptor(key)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, r := range d.DecryptBatch(packets) {
+			if r.Err != nil {
+				b.Fatal(r.Err)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+// ErrNoDeviceKey is returned when a device has no key to decrypt with.
+var ErrNoDeviceKey = errors.New("device has no key")
+
+// PacketError records why one packet in a batch could not be decrypted.
+type PacketError struct {
+	Index int // Position of the packet in the input slice
+	Err   error
+}
+
+func (e PacketError) Error() string {
+	return fmt.Sprintf("packet %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the underlying decryption error.
+func (e PacketError) Unwrap() error {
+	return e.Err
+}
+
+// DeviceKey decodes a device's base64 key, accepting the same encodings as
+// models.DecodePayload, and checks that its length matches the device's
+// encryption type. A device without an encryption type may use either key
+// size.
+func DeviceKey(device models.Device) ([]byte, error) {
+	if device.Key == "" {
+		return nil, ErrNoDeviceKey
+	}
+
+	key, err := models.DecodePayload(device.Key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key is not valid base64: %v", ErrInvalidKey, err)
+	}
+
+	want := 0
+	switch device.Encryption {
+	case models.EncryptionAES128CTR:
+		want = AES128KeySize
+	case models.EncryptionAES256CTR:
+		want = AES256KeySize
+	}
+
+	switch {
+	case want != 0 && len(key) != want:
+		return nil, fmt.Errorf("%w: %s needs a %d-byte key, got %d", ErrInvalidKey, device.Encryption, want, len(key))
+	case len(key) != AES128KeySize && len(key) != AES256KeySize:
+		return nil, fmt.Errorf("%w: got %d bytes", ErrInvalidKey, len(key))
+	}
+
+	return key, nil
+}
+
+// DecryptPacketsForDevice decrypts packets with the device's key. Packets
+// that fail to decrypt (usually because they belong to another device) are
+// left out of the result and reported in the returned PacketErrors instead
+// of aborting the batch. The error is non-nil only if the device key itself
+// is unusable.
+func DecryptPacketsForDevice(device models.Device, packets []models.EncryptedPacket, opts ...DecryptOption) ([]models.DecryptedPacket, []PacketError, error) {
+	key, err := DeviceKey(device)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	d, err := NewDecryptor(key, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var decrypted []models.DecryptedPacket
+	var failed []PacketError
+	for i, r := range d.DecryptBatch(packets) {
+		if r.Err != nil {
+			failed = append(failed, PacketError{Index: i, Err: r.Err})
+			continue
+		}
+		decrypted = append(decrypted, models.DecryptedPacket{
+			DeviceID:    device.ID,
+			Payload:     r.Result.Payload,
+			TimeCounter: r.Result.TimeCounter,
+			Timestamp:   packets[i].Timestamp,
+			Location:    packets[i].Location,
+		})
+	}
+
+	return decrypted, failed, nil
+}
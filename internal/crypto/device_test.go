@@ -0,0 +1,97 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceKey(t *testing.T) {
+	key128 := base64.StdEncoding.EncodeToString(make([]byte, 16))
+	key256 := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	tests := []struct {
+		name    string
+		device  models.Device
+		wantLen int
+		wantErr error
+	}{
+		{"no key", models.Device{}, 0, ErrNoDeviceKey},
+		{"bad base64", models.Device{Key: "not base64!"}, 0, ErrInvalidKey},
+		{"AES-128", models.Device{Key: key128, Encryption: models.EncryptionAES128CTR}, 16, nil},
+		{"AES-256", models.Device{Key: key256, Encryption: models.EncryptionAES256CTR}, 32, nil},
+		{"size mismatch", models.Device{Key: key128, Encryption: models.EncryptionAES256CTR}, 0, ErrInvalidKey},
+		{"unknown type accepts either size", models.Device{Key: key128}, 16, nil},
+		{"URL-safe unpadded", models.Device{Key: base64.RawURLEncoding.EncodeToString(bytes16(0xff)), Encryption: models.EncryptionAES128CTR}, 16, nil},
+		{"unknown type rejects odd size", models.Device{Key: base64.StdEncoding.EncodeToString(make([]byte, 24))}, 0, ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := DeviceKey(tt.device)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, key, tt.wantLen)
+		})
+	}
+}
+
+// bytes16 returns 16 bytes of b
+func bytes16(b byte) []byte {
+	key := make([]byte, 16)
+	for i := range key {
+		key[i] = b
+	}
+	return key
+}
+
+func TestDecryptPacketsForDevice(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	otherKey := make([]byte, 32)
+	for i := range otherKey {
+		otherKey[i] = byte(255 - i)
+	}
+
+	device := models.Device{
+		ID:         "mine",
+		Key:        base64.StdEncoding.EncodeToString(key),
+		Encryption: models.EncryptionAES256CTR,
+	}
+	location := models.NewFakeLocation()
+	packets := []models.EncryptedPacket{
+		{Payload: buildTestPacket(t, key, 20000, 1, []byte("first")), Timestamp: CounterToTime(20000), Location: location},
+		{Payload: buildTestPacket(t, otherKey, 20000, 2, []byte("other")), Timestamp: CounterToTime(20000)},
+		{Payload: []byte{0x01}},
+		{Payload: buildTestPacket(t, key, 20001, 3, []byte("second")), Timestamp: CounterToTime(20001)},
+	}
+
+	decrypted, failed, err := DecryptPacketsForDevice(device, packets, WithSearchWindow(1))
+	require.NoError(t, err)
+
+	require.Len(t, decrypted, 2)
+	assert.Equal(t, "mine", decrypted[0].DeviceID)
+	assert.Equal(t, []byte("first"), decrypted[0].Payload)
+	assert.Equal(t, uint32(20000), decrypted[0].TimeCounter)
+	assert.Equal(t, location, decrypted[0].Location)
+	assert.Equal(t, []byte("second"), decrypted[1].Payload)
+
+	require.Len(t, failed, 2)
+	assert.Equal(t, 1, failed[0].Index)
+	assert.ErrorIs(t, failed[0], ErrDecryptionFailed)
+	assert.Equal(t, 2, failed[1].Index)
+	assert.ErrorIs(t, failed[1], ErrPacketTooShort)
+}
+
+func TestDecryptPacketsForDevice_BadKey(t *testing.T) {
+	_, _, err := DecryptPacketsForDevice(models.Device{ID: "no-key"}, []models.EncryptedPacket{{}})
+	assert.ErrorIs(t, err, ErrNoDeviceKey)
+}
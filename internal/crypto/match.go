@@ -0,0 +1,28 @@
+package crypto
+
+import "github.com/hubblenetwork/hubcli/internal/models"
+
+// MatchDevice finds the registered device that produced a packet.
+// The device identifier in a BLE advertisement is ephemeral, so it can't be
+// compared with registered device IDs directly. Instead each device's key is
+// tried until one authenticates the packet. Devices without a usable key, as
+// DeviceKey decides, are skipped. It also returns the time counter the packet authenticated at, so
+// the packet can be decrypted with DecryptWithKnownCounter without searching
+// again. Returns nil if no device matches.
+func MatchDevice(packet models.EncryptedPacket, devices []models.Device, opts ...DecryptOption) (*models.Device, uint32) {
+	if len(packet.Payload) < MinPacketSize {
+		return nil, 0
+	}
+
+	for i := range devices {
+		key, err := DeviceKey(devices[i])
+		if err != nil {
+			continue
+		}
+		if tc, err := FindTimeCounter(key, packet, opts...); err == nil {
+			return &devices[i], tc
+		}
+	}
+
+	return nil, 0
+}
@@ -0,0 +1,61 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchDevice(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	otherKey := make([]byte, 32)
+	for i := range otherKey {
+		otherKey[i] = byte(255 - i)
+	}
+
+	timeCounter := uint32(20000)
+	seqCounter := uint32(7)
+
+	encKey, err := FullEncryptionKeyDerivation(key, timeCounter, seqCounter)
+	require.NoError(t, err)
+
+	header := make([]byte, 6)
+	header[1] = byte(seqCounter)
+	authTag, err := ComputeAuthTag(encKey, header)
+	require.NoError(t, err)
+
+	packet := models.EncryptedPacket{
+		Payload:   append(append(header, authTag...), 0x01, 0x02),
+		Timestamp: CounterToTime(timeCounter),
+	}
+
+	devices := []models.Device{
+		{ID: "no-key"},
+		{ID: "bad-key", Key: "not base64!"},
+		{ID: "other", Key: base64.StdEncoding.EncodeToString(otherKey)},
+		// The right key, but the wrong size for the device's encryption
+		{ID: "mismatched", Key: base64.StdEncoding.EncodeToString(key), Encryption: models.EncryptionAES128CTR},
+		{ID: "mine", Name: "Sensor", Key: base64.StdEncoding.EncodeToString(key)},
+	}
+
+	match, tc := MatchDevice(packet, devices, WithSearchWindow(1))
+	require.NotNil(t, match)
+	assert.Equal(t, "mine", match.ID)
+	assert.Equal(t, timeCounter, tc)
+
+	// The counter decrypts the packet without another search
+	result, err := DecryptWithKnownCounter(key, packet, tc)
+	require.NoError(t, err)
+	assert.Equal(t, timeCounter, result.TimeCounter)
+
+	match, _ = MatchDevice(packet, devices[:4], WithSearchWindow(1))
+	assert.Nil(t, match)
+	match, _ = MatchDevice(models.EncryptedPacket{Payload: []byte{0x01}}, devices)
+	assert.Nil(t, match)
+}
@@ -0,0 +1,48 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+// Format is a file format for exported packets. Its value is also the file
+// extension.
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ErrUnsupportedFormat is returned when data can't be written in the
+// requested format.
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// WritePackets writes retrieved packets in format f.
+func WritePackets(w io.Writer, f Format, packets []models.RetrievedPacket) error {
+	switch f {
+	case FormatCSV:
+		return WritePacketsCSV(w, packets)
+	case FormatJSON:
+		return WritePacketsJSON(w, packets)
+	case FormatNDJSON:
+		return WritePacketsNDJSON(w, packets)
+	}
+	return fmt.Errorf("%w: %q", ErrUnsupportedFormat, f)
+}
+
+// WriteEncryptedPackets writes packets captured by a BLE scan in format f.
+// Only JSON and NDJSON are supported.
+func WriteEncryptedPackets(w io.Writer, f Format, packets []models.EncryptedPacket) error {
+	switch f {
+	case FormatJSON:
+		return WriteEncryptedPacketsJSON(w, packets)
+	case FormatNDJSON:
+		return WriteEncryptedPacketsNDJSON(w, packets)
+	}
+	return fmt.Errorf("%w: %q", ErrUnsupportedFormat, f)
+}
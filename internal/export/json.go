@@ -0,0 +1,168 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+// Packet is the JSON form of a retrieved packet. It carries the fields of
+// models.RetrievedPacket with the Unix timestamps as RFC 3339 times.
+type Packet struct {
+	DeviceID       string            `json:"device_id"`
+	DeviceName     string            `json:"device_name,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+	Timestamp      time.Time         `json:"timestamp"`
+	RSSI           int               `json:"rssi"`
+	SequenceNumber int               `json:"sequence_number"`
+	Counter        int               `json:"counter"`
+	Payload        string            `json:"payload"` // Base64-encoded
+	NetworkType    string            `json:"network_type,omitempty"`
+	Location       *PacketLocation   `json:"location,omitempty"` // Nil when unknown
+}
+
+// PacketLocation is the JSON form of a retrieved packet's location.
+type PacketLocation struct {
+	Timestamp          time.Time `json:"timestamp"`
+	Latitude           float64   `json:"latitude"`
+	Longitude          float64   `json:"longitude"`
+	Altitude           float64   `json:"altitude,omitempty"`
+	HorizontalAccuracy float64   `json:"horizontal_accuracy,omitempty"`
+	VerticalAccuracy   float64   `json:"vertical_accuracy,omitempty"`
+}
+
+// NewPacket converts a retrieved packet to its JSON form. Zero timestamps
+// become zero times and an unknown location (0, 0) is omitted.
+func NewPacket(p models.RetrievedPacket) Packet {
+	out := Packet{
+		DeviceID:       p.Device.ID,
+		DeviceName:     p.Device.Name,
+		Tags:           p.Device.Tags,
+		Timestamp:      unixTime(p.Device.Timestamp),
+		RSSI:           p.Device.RSSI,
+		SequenceNumber: p.Device.SequenceNumber,
+		Counter:        p.Device.Counter,
+		Payload:        p.Device.Payload,
+		NetworkType:    p.NetworkType,
+	}
+	if loc := p.Location; loc.Latitude != 0 || loc.Longitude != 0 {
+		out.Location = &PacketLocation{
+			Timestamp:          unixTime(loc.Timestamp),
+			Latitude:           loc.Latitude,
+			Longitude:          loc.Longitude,
+			Altitude:           loc.Altitude,
+			HorizontalAccuracy: loc.HorizontalAccuracy,
+			VerticalAccuracy:   loc.VerticalAccuracy,
+		}
+	}
+	return out
+}
+
+// RetrievedPacket converts the packet back to the API form.
+func (p Packet) RetrievedPacket() models.RetrievedPacket {
+	out := models.RetrievedPacket{
+		Device: models.RetrievedDevice{
+			ID:             p.DeviceID,
+			Name:           p.DeviceName,
+			Tags:           p.Tags,
+			Payload:        p.Payload,
+			Timestamp:      unixSeconds(p.Timestamp),
+			RSSI:           p.RSSI,
+			SequenceNumber: p.SequenceNumber,
+			Counter:        p.Counter,
+		},
+		NetworkType: p.NetworkType,
+	}
+	if loc := p.Location; loc != nil {
+		out.Location = models.RetrievedLocation{
+			Timestamp:          unixSeconds(loc.Timestamp),
+			Latitude:           loc.Latitude,
+			Longitude:          loc.Longitude,
+			Altitude:           loc.Altitude,
+			HorizontalAccuracy: loc.HorizontalAccuracy,
+			VerticalAccuracy:   loc.VerticalAccuracy,
+		}
+	}
+	return out
+}
+
+// WritePacketsJSON writes the packets as an indented JSON array.
+func WritePacketsJSON(w io.Writer, packets []models.RetrievedPacket) error {
+	return writeJSON(w, newPackets(packets))
+}
+
+// WritePacketsNDJSON writes the packets as newline-delimited JSON, one
+// object per line.
+func WritePacketsNDJSON(w io.Writer, packets []models.RetrievedPacket) error {
+	enc := json.NewEncoder(w)
+	for _, p := range packets {
+		if err := enc.Encode(NewPacket(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteEncryptedPacketsJSON writes packets captured by a BLE scan as an
+// indented JSON array. Payloads are base64 encoded and times are RFC 3339
+// in UTC.
+func WriteEncryptedPacketsJSON(w io.Writer, packets []models.EncryptedPacket) error {
+	return writeJSON(w, utcPackets(packets))
+}
+
+// WriteEncryptedPacketsNDJSON writes packets captured by a BLE scan as
+// newline-delimited JSON, one object per line.
+func WriteEncryptedPacketsNDJSON(w io.Writer, packets []models.EncryptedPacket) error {
+	enc := json.NewEncoder(w)
+	for _, p := range utcPackets(packets) {
+		if err := enc.Encode(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func newPackets(packets []models.RetrievedPacket) []Packet {
+	out := make([]Packet, len(packets))
+	for i, p := range packets {
+		out[i] = NewPacket(p)
+	}
+	return out
+}
+
+// utcPackets returns copies of packets with their times in UTC.
+func utcPackets(packets []models.EncryptedPacket) []models.EncryptedPacket {
+	out := make([]models.EncryptedPacket, len(packets))
+	for i, p := range packets {
+		p.Timestamp = p.Timestamp.UTC()
+		p.Location.Timestamp = p.Location.Timestamp.UTC()
+		out[i] = p
+	}
+	return out
+}
+
+// unixTime converts API Unix seconds to a UTC time, keeping 0 as the zero
+// time.
+func unixTime(secs float64) time.Time {
+	if secs == 0 {
+		return time.Time{}
+	}
+	whole := int64(secs)
+	return time.Unix(whole, int64((secs-float64(whole))*1e9)).UTC()
+}
+
+// unixSeconds is the inverse of unixTime.
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.Unix()) + float64(t.Nanosecond())/1e9
+}
@@ -0,0 +1,175 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRetrievedPackets returns packets with and without a location.
+// Fractions of a second are exact in binary so they survive the round trip.
+func testRetrievedPackets() []models.RetrievedPacket {
+	ts := float64(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC).Unix())
+	return []models.RetrievedPacket{
+		{
+			Location: models.RetrievedLocation{
+				Timestamp:          ts,
+				Latitude:           37.7749,
+				Longitude:          -122.4194,
+				Altitude:           12.5,
+				HorizontalAccuracy: 10,
+				VerticalAccuracy:   5,
+			},
+			Device: models.RetrievedDevice{
+				ID:             "dev-001",
+				Name:           "Sensor",
+				Tags:           map[string]string{"site": "lab"},
+				Payload:        "AQID",
+				Timestamp:      ts + 0.5,
+				RSSI:           -70,
+				SequenceNumber: 42,
+				Counter:        19737,
+			},
+			NetworkType: "terrestrial",
+		},
+		{
+			Device: models.RetrievedDevice{
+				ID:             "dev-002",
+				Payload:        "BAUG",
+				Timestamp:      ts + 0.25,
+				RSSI:           -85,
+				SequenceNumber: 1023,
+			},
+		},
+	}
+}
+
+func TestWritePacketsNDJSON_RoundTrip(t *testing.T) {
+	packets := testRetrievedPackets()
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePacketsNDJSON(&buf, packets))
+
+	var got []models.RetrievedPacket
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var p Packet
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &p))
+		got = append(got, p.RetrievedPacket())
+	}
+	require.NoError(t, scanner.Err())
+
+	assert.Equal(t, packets, got)
+}
+
+func TestWritePacketsNDJSON_RFC3339(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WritePacketsNDJSON(&buf, testRetrievedPackets()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var first map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "2024-01-15T10:30:00.5Z", first["timestamp"])
+	assert.Equal(t, "2024-01-15T10:30:00Z", first["location"].(map[string]interface{})["timestamp"])
+
+	// Unknown locations are left out
+	var second map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.NotContains(t, second, "location")
+}
+
+func TestWritePacketsJSON(t *testing.T) {
+	packets := testRetrievedPackets()
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePacketsJSON(&buf, packets))
+	assert.True(t, strings.HasPrefix(buf.String(), "[\n  {"), "output is indented")
+
+	var got []Packet
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	require.Len(t, got, 2)
+	assert.Equal(t, packets[0], got[0].RetrievedPacket())
+	assert.Equal(t, packets[1], got[1].RetrievedPacket())
+}
+
+func TestWritePacketsJSON_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WritePacketsJSON(&buf, nil))
+
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestWriteEncryptedPacketsNDJSON_RoundTrip(t *testing.T) {
+	pst := time.FixedZone("PST", -8*60*60)
+	packets := []models.EncryptedPacket{
+		{
+			Payload:   []byte{0x01, 0x02, 0x03},
+			RSSI:      -60,
+			Timestamp: time.Date(2024, 1, 15, 2, 30, 0, 123000000, pst),
+			Location:  models.Location{Latitude: 90, Timestamp: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), Fake: true},
+		},
+		{
+			Payload:   []byte{0xff},
+			RSSI:      -90,
+			Timestamp: time.Date(2024, 1, 15, 10, 31, 0, 0, time.UTC),
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteEncryptedPacketsNDJSON(&buf, packets))
+
+	// Times are written in UTC
+	assert.Contains(t, buf.String(), `"timestamp":"2024-01-15T10:30:00.123Z"`)
+
+	var got []models.EncryptedPacket
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var p models.EncryptedPacket
+		require.NoError(t, dec.Decode(&p))
+		got = append(got, p)
+	}
+
+	require.Len(t, got, 2)
+	for i := range packets {
+		assert.Equal(t, packets[i].Payload, got[i].Payload)
+		assert.Equal(t, packets[i].RSSI, got[i].RSSI)
+		assert.True(t, packets[i].Timestamp.Equal(got[i].Timestamp))
+		assert.True(t, packets[i].Location.Timestamp.Equal(got[i].Location.Timestamp))
+		assert.Equal(t, packets[i].Location.Fake, got[i].Location.Fake)
+	}
+}
+
+func TestWriteEncryptedPacketsJSON(t *testing.T) {
+	packets := []models.EncryptedPacket{{Payload: []byte{0x01}, RSSI: -60}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteEncryptedPacketsJSON(&buf, packets))
+
+	var got []models.EncryptedPacket
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	require.Len(t, got, 1)
+	assert.Equal(t, []byte{0x01}, got[0].Payload)
+}
+
+func TestWritePackets_Formats(t *testing.T) {
+	packets := testRetrievedPackets()
+
+	for _, f := range []Format{FormatCSV, FormatJSON, FormatNDJSON} {
+		var buf bytes.Buffer
+		assert.NoError(t, WritePackets(&buf, f, packets), f)
+		assert.NotEmpty(t, buf.String(), f)
+	}
+
+	assert.ErrorIs(t, WritePackets(&bytes.Buffer{}, "xml", packets), ErrUnsupportedFormat)
+	assert.ErrorIs(t, WriteEncryptedPackets(&bytes.Buffer{}, FormatCSV, nil), ErrUnsupportedFormat)
+	assert.NoError(t, WriteEncryptedPackets(&bytes.Buffer{}, FormatNDJSON, nil))
+}
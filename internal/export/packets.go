@@ -0,0 +1,76 @@
+// Package export writes API data in formats for use outside the CLI.
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+// PacketCSVHeader returns the CSV column names written by WritePacketsCSV.
+func PacketCSVHeader() []string {
+	return []string{"device_id", "timestamp", "lat", "lon", "altitude", "accuracy", "rssi", "sequence_number", "payload"}
+}
+
+// WritePacketsCSV writes a header row followed by one row per packet.
+// Timestamps are RFC 3339 in UTC. The API reports unknown location fields
+// as zero, so location columns are empty when the location is unknown (0, 0)
+// and altitude and accuracy are empty when zero. The payload is left base64
+// encoded.
+func WritePacketsCSV(w io.Writer, packets []models.RetrievedPacket) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(PacketCSVHeader()); err != nil {
+		return err
+	}
+	for _, p := range packets {
+		if err := cw.Write(packetRecord(p)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// packetRecord returns a packet as a CSV row in PacketCSVHeader order.
+func packetRecord(p models.RetrievedPacket) []string {
+	timestamp := ""
+	if p.Device.Timestamp > 0 {
+		timestamp = p.Timestamp().UTC().Format(time.RFC3339)
+	}
+
+	var lat, lon, altitude, accuracy string
+	if loc := p.Location; loc.Latitude != 0 || loc.Longitude != 0 {
+		lat = formatFloat(loc.Latitude)
+		lon = formatFloat(loc.Longitude)
+		altitude = formatNonZero(loc.Altitude)
+		accuracy = formatNonZero(loc.HorizontalAccuracy)
+	}
+
+	return []string{
+		p.Device.ID,
+		timestamp,
+		lat,
+		lon,
+		altitude,
+		accuracy,
+		strconv.Itoa(p.Device.RSSI),
+		strconv.Itoa(p.Device.SequenceNumber),
+		p.Device.Payload,
+	}
+}
+
+// formatFloat formats v with as few digits as represent it exactly.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatNonZero formats v, or returns "" if it is zero.
+func formatNonZero(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return formatFloat(v)
+}
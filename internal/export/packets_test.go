@@ -0,0 +1,91 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePacketsCSV(t *testing.T) {
+	ts := float64(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC).Unix())
+	packets := []models.RetrievedPacket{
+		{
+			Location: models.RetrievedLocation{
+				Latitude:           37.7749,
+				Longitude:          -122.4194,
+				Altitude:           12.5,
+				HorizontalAccuracy: 10,
+			},
+			Device: models.RetrievedDevice{
+				ID:             "dev-001",
+				Payload:        "AQID",
+				Timestamp:      ts,
+				RSSI:           -70,
+				SequenceNumber: 42,
+			},
+		},
+		{
+			// Unknown location: the API reports zeros
+			Device: models.RetrievedDevice{
+				ID:             "dev-002",
+				Payload:        "BAUG",
+				Timestamp:      ts + 0.25,
+				RSSI:           -85,
+				SequenceNumber: 1023,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePacketsCSV(&buf, packets))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	assert.Equal(t, PacketCSVHeader(), records[0])
+	assert.Equal(t, []string{"dev-001", "2024-01-15T10:30:00Z", "37.7749", "-122.4194", "12.5", "10", "-70", "42", "AQID"}, records[1])
+	assert.Equal(t, []string{"dev-002", "2024-01-15T10:30:00Z", "", "", "", "", "-85", "1023", "BAUG"}, records[2])
+}
+
+func TestWritePacketsCSV_KnownLocationWithoutAltitude(t *testing.T) {
+	packets := []models.RetrievedPacket{{
+		Location: models.RetrievedLocation{Latitude: 51.5, Longitude: 0},
+		Device:   models.RetrievedDevice{ID: "dev-001"},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePacketsCSV(&buf, packets))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	// Longitude 0 is a real meridian, not an unknown location
+	assert.Equal(t, []string{"dev-001", "", "51.5", "0", "", "", "0", "0", ""}, records[1])
+}
+
+func TestWritePacketsCSV_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WritePacketsCSV(&buf, nil))
+
+	assert.Equal(t, "device_id,timestamp,lat,lon,altitude,accuracy,rssi,sequence_number,payload\n", buf.String())
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWritePacketsCSV_WriteError(t *testing.T) {
+	err := WritePacketsCSV(failingWriter{}, []models.RetrievedPacket{{}})
+
+	assert.EqualError(t, err, "disk full")
+}
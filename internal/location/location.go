@@ -0,0 +1,106 @@
+// Package location provides the position attached to packets captured by
+// local BLE scans, so ingested packets carry real coordinates.
+package location
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+var (
+	// ErrUnavailable indicates a provider could not determine a location
+	ErrUnavailable = errors.New("location unavailable")
+
+	// ErrInvalidCoordinates indicates a latitude or longitude out of range
+	// or not a number
+	ErrInvalidCoordinates = errors.New("invalid coordinates")
+)
+
+// Provider is a source of the current location
+type Provider interface {
+	// Location returns the current location, or an error wrapping
+	// ErrUnavailable if it can't be determined
+	Location(ctx context.Context) (models.Location, error)
+}
+
+// Manual is a fixed location entered by the user
+type Manual struct {
+	Latitude           float64
+	Longitude          float64
+	Altitude           float64
+	HorizontalAccuracy float64
+}
+
+// NewManual returns a fixed location at lat, lon after checking both are in
+// range
+func NewManual(lat, lon float64) (Manual, error) {
+	if lat < -90 || lat > 90 {
+		return Manual{}, fmt.Errorf("%w: latitude %g is not between -90 and 90", ErrInvalidCoordinates, lat)
+	}
+	if lon < -180 || lon > 180 {
+		return Manual{}, fmt.Errorf("%w: longitude %g is not between -180 and 180", ErrInvalidCoordinates, lon)
+	}
+	return Manual{Latitude: lat, Longitude: lon}, nil
+}
+
+// ParseManual parses a location typed as "lat, lon", e.g.
+// "37.7749, -122.4194". The comma is optional.
+func ParseManual(s string) (Manual, error) {
+	fields := strings.Fields(strings.ReplaceAll(s, ",", " "))
+	if len(fields) != 2 {
+		return Manual{}, fmt.Errorf("%w: expected \"latitude, longitude\"", ErrInvalidCoordinates)
+	}
+
+	lat, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return Manual{}, fmt.Errorf("%w: latitude %q is not a number", ErrInvalidCoordinates, fields[0])
+	}
+	lon, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return Manual{}, fmt.Errorf("%w: longitude %q is not a number", ErrInvalidCoordinates, fields[1])
+	}
+	return NewManual(lat, lon)
+}
+
+// Location returns the fixed location, timestamped now
+func (m Manual) Location(ctx context.Context) (models.Location, error) {
+	return models.Location{
+		Latitude:           m.Latitude,
+		Longitude:          m.Longitude,
+		Altitude:           m.Altitude,
+		HorizontalAccuracy: m.HorizontalAccuracy,
+		Timestamp:          time.Now().UTC(),
+	}, nil
+}
+
+// String formats the location as ParseManual accepts it
+func (m Manual) String() string {
+	return fmt.Sprintf("%.4f, %.4f", m.Latitude, m.Longitude)
+}
+
+// First returns a provider that tries each of providers in order and
+// returns the first location found
+func First(providers ...Provider) Provider {
+	return firstProvider(providers)
+}
+
+type firstProvider []Provider
+
+func (f firstProvider) Location(ctx context.Context) (models.Location, error) {
+	for _, p := range f {
+		loc, err := p.Location(ctx)
+		if err == nil {
+			return loc, nil
+		}
+		if ctx.Err() != nil {
+			return models.Location{}, fmt.Errorf("%w: %v", ErrUnavailable, ctx.Err())
+		}
+	}
+	return models.Location{}, ErrUnavailable
+}
@@ -0,0 +1,56 @@
+package location
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManual(t *testing.T) {
+	for _, input := range []string{"37.7749, -122.4194", "37.7749 -122.4194", "  37.7749,-122.4194  "} {
+		m, err := ParseManual(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, Manual{Latitude: 37.7749, Longitude: -122.4194}, m, input)
+	}
+
+	for _, input := range []string{"", "37.7749", "1, 2, 3", "north, west", "91, 0", "0, -181"} {
+		_, err := ParseManual(input)
+		assert.ErrorIs(t, err, ErrInvalidCoordinates, input)
+	}
+}
+
+func TestManual_Location(t *testing.T) {
+	m, err := NewManual(37.7749, -122.4194)
+	require.NoError(t, err)
+
+	loc, err := m.Location(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 37.7749, loc.Latitude)
+	assert.Equal(t, -122.4194, loc.Longitude)
+	assert.False(t, loc.Fake)
+	assert.False(t, loc.Timestamp.IsZero())
+
+	assert.Equal(t, "37.7749, -122.4194", m.String())
+}
+
+func TestSystem_Unavailable(t *testing.T) {
+	_, err := System().Location(context.Background())
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestFirst(t *testing.T) {
+	manual := Manual{Latitude: 1, Longitude: 2}
+
+	loc, err := First(System(), manual).Location(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, models.Location{Latitude: 1, Longitude: 2, Timestamp: loc.Timestamp}, loc)
+
+	_, err = First(System()).Location(context.Background())
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	_, err = First().Location(context.Background())
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
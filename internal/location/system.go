@@ -0,0 +1,20 @@
+package location
+
+import (
+	"context"
+
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+// System returns a provider backed by the platform's location service. It
+// is best effort: no platform service is wired up yet, so it always reports
+// ErrUnavailable and callers should fall back to a manual location.
+func System() Provider {
+	return systemProvider{}
+}
+
+type systemProvider struct{}
+
+func (systemProvider) Location(ctx context.Context) (models.Location, error) {
+	return models.Location{}, ErrUnavailable
+}
@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
 
 // EncryptionType represents supported encryption algorithms.
 type EncryptionType string
@@ -34,6 +40,60 @@ type Device struct {
 	CreatedAt        time.Time             `json:"-"`                            // Computed from CreatedTS
 }
 
+// LastSeen returns the time of the device's most recent terrestrial packet.
+func (d Device) LastSeen() (time.Time, bool) {
+	if d.MostRecentPacket == nil || d.MostRecentPacket.Terrestrial == nil || d.MostRecentPacket.Terrestrial.Timestamp <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(d.MostRecentPacket.Terrestrial.Timestamp), 0), true
+}
+
+// DeviceCSVHeader returns the CSV column names matching Device.CSVRecord.
+func DeviceCSVHeader() []string {
+	return []string{"id", "name", "created", "last_seen", "encryption", "tags"}
+}
+
+// CSVRecord returns the device as a CSV row in DeviceCSVHeader order.
+// Times are RFC 3339 in UTC and empty when unknown; tags are key=value pairs
+// sorted by key and separated by semicolons.
+func (d Device) CSVRecord() []string {
+	created := ""
+	if d.CreatedTS > 0 {
+		created = time.Unix(d.CreatedTS, 0).UTC().Format(time.RFC3339)
+	}
+	lastSeen := ""
+	if t, ok := d.LastSeen(); ok {
+		lastSeen = t.UTC().Format(time.RFC3339)
+	}
+
+	keys := make([]string, 0, len(d.Tags))
+	for k := range d.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tags := make([]string, len(keys))
+	for i, k := range keys {
+		tags[i] = k + "=" + d.Tags[k]
+	}
+
+	return []string{d.ID, d.Name, created, lastSeen, string(d.Encryption), strings.Join(tags, ";")}
+}
+
+// WriteDevicesCSV writes a header row followed by one row per device.
+func WriteDevicesCSV(w io.Writer, devices []Device) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(DeviceCSVHeader()); err != nil {
+		return err
+	}
+	for _, d := range devices {
+		if err := cw.Write(d.CSVRecord()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
 // RegisterDeviceRequest is the payload for registering a new device.
 type RegisterDeviceRequest struct {
 	NDevices   int            `json:"n_devices,omitempty"`
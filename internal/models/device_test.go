@@ -0,0 +1,45 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevice_CSVRecord(t *testing.T) {
+	d := Device{
+		ID:         "device-1",
+		Name:       "Pump, north",
+		Encryption: EncryptionAES256CTR,
+		Tags:       map[string]string{"site": "a", "floor": "2"},
+		CreatedTS:  1700000000,
+		MostRecentPacket: &MostRecentPacketInfo{
+			Terrestrial: &PacketTimestamp{Timestamp: 1700003600},
+		},
+	}
+
+	record := d.CSVRecord()
+	assert.Len(t, record, len(DeviceCSVHeader()))
+	assert.Equal(t, []string{
+		"device-1",
+		"Pump, north",
+		"2023-11-14T22:13:20Z",
+		"2023-11-14T23:13:20Z",
+		"AES-256-CTR",
+		"floor=2;site=a",
+	}, record)
+}
+
+func TestDevice_CSVRecord_Unknown(t *testing.T) {
+	assert.Equal(t, []string{"device-1", "", "", "", "", ""}, Device{ID: "device-1"}.CSVRecord())
+}
+
+func TestWriteDevicesCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteDevicesCSV(&buf, []Device{{ID: "device-1", Name: "Pump, north"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "id,name,created,last_seen,encryption,tags\ndevice-1,\"Pump, north\",,,,\n", buf.String())
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidPayload is returned by DecodePayload for malformed input.
+var ErrInvalidPayload = errors.New("invalid base64 payload")
+
+// DecodePayload decodes a base64 payload as returned by the API. Standard and
+// URL-safe alphabets are accepted, with or without padding.
+func DecodePayload(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	enc := base64.RawStdEncoding
+	if strings.ContainsAny(s, "-_") {
+		enc = base64.RawURLEncoding
+	}
+
+	data, err := enc.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodePayload(t *testing.T) {
+	// 0xfb 0xff 0xfe encodes to "+//+" (standard) or "-__-" (URL-safe)
+	tests := []struct {
+		name     string
+		input    string
+		expected []byte
+	}{
+		{"padded", "AQID", []byte{0x01, 0x02, 0x03}},
+		{"padded with equals", "AQI=", []byte{0x01, 0x02}},
+		{"unpadded", "AQI", []byte{0x01, 0x02}},
+		{"standard alphabet", "+//+", []byte{0xfb, 0xff, 0xfe}},
+		{"url-safe alphabet", "-__-", []byte{0xfb, 0xff, 0xfe}},
+		{"surrounding whitespace", " AQID\n", []byte{0x01, 0x02, 0x03}},
+		{"empty", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodePayload(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestDecodePayload_Invalid(t *testing.T) {
+	for _, input := range []string{"not base64!", "A", "+/-_"} {
+		_, err := DecodePayload(input)
+		assert.ErrorIs(t, err, ErrInvalidPayload, "input %q", input)
+	}
+}
+
+func TestRetrievedPacket_ToEncryptedPacket(t *testing.T) {
+	p := RetrievedPacket{
+		Location: RetrievedLocation{Latitude: 37.7749, Longitude: -122.4194, Timestamp: 1700000000},
+		Device:   RetrievedDevice{Payload: "AQID", RSSI: -60, Timestamp: 1700000001},
+	}
+
+	enc, err := p.ToEncryptedPacket()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, enc.Payload)
+	assert.Equal(t, -60, enc.RSSI)
+	assert.Equal(t, int64(1700000001), enc.Timestamp.Unix())
+	assert.Equal(t, 37.7749, enc.Location.Latitude)
+
+	p.Device.Payload = "%%%"
+	_, err = p.ToEncryptedPacket()
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+}
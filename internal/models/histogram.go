@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PacketsByHour counts packets by the hour of day (0-23) of their device
+// timestamp in loc. A nil loc uses time.Local.
+func PacketsByHour(packets []RetrievedPacket, loc *time.Location) [24]int {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	var buckets [24]int
+	for _, p := range packets {
+		buckets[p.Timestamp().In(loc).Hour()]++
+	}
+	return buckets
+}
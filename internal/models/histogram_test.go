@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func packetAt(t time.Time) RetrievedPacket {
+	return RetrievedPacket{Device: RetrievedDevice{Timestamp: float64(t.Unix())}}
+}
+
+func TestPacketsByHour(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	packets := []RetrievedPacket{
+		packetAt(day.Add(2 * time.Hour)),
+		packetAt(day.Add(2*time.Hour + 59*time.Minute)),
+		packetAt(day.Add(23*time.Hour + 30*time.Minute)),
+		packetAt(day.Add(24*time.Hour + 2*time.Hour)), // Next day, same hour
+	}
+
+	buckets := PacketsByHour(packets, time.UTC)
+
+	assert.Equal(t, 3, buckets[2])
+	assert.Equal(t, 1, buckets[23])
+	assert.Equal(t, 0, buckets[0])
+}
+
+func TestPacketsByHour_Location(t *testing.T) {
+	loc := time.FixedZone("UTC+5", 5*60*60)
+	packets := []RetrievedPacket{packetAt(time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC))}
+
+	buckets := PacketsByHour(packets, loc)
+
+	assert.Equal(t, 1, buckets[3])
+}
+
+func TestPacketsByHour_Empty(t *testing.T) {
+	assert.Equal(t, [24]int{}, PacketsByHour(nil, time.UTC))
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Layout of a Hubble BLE advertisement payload (protocol version 0):
+//
+//	Bytes 0-1: protocol version (6 bits) | sequence number (10 bits), big-endian
+//	Bytes 2-5: ephemeral device ID
+//	Bytes 6-9: auth tag (truncated AES-CMAC)
+//	Bytes 10-: encrypted data
+//
+// testdata/advertisement_v0.json is a synthetic test vector for this layout,
+// built with this repo's own encryption code, that the ble and crypto tests
+// both check. It keeps the two parsers in agreement; it is not a real
+// capture, so it can't catch a layout both have wrong.
+const (
+	HubbleHeaderSize     = 2
+	HubbleDeviceIDOffset = HubbleHeaderSize
+	HubbleDeviceIDSize   = 4
+	HubbleAuthTagOffset  = HubbleDeviceIDOffset + HubbleDeviceIDSize
+	HubbleAuthTagSize    = 4
+	HubbleDataOffset     = HubbleAuthTagOffset + HubbleAuthTagSize
+
+	// HubbleMinPayloadSize is the size of a payload with no encrypted data.
+	HubbleMinPayloadSize = HubbleDataOffset
+
+	// HubbleMaxPayloadSize is the most a legacy BLE advertisement can carry.
+	HubbleMaxPayloadSize = 31
+
+	// HubbleSequenceMask extracts the sequence number from the header.
+	HubbleSequenceMask = 0x3FF
+)
+
+// ErrHubblePayloadTooShort is returned by ParseHubblePayload for payloads
+// shorter than HubbleMinPayloadSize.
+var ErrHubblePayloadTooShort = errors.New("hubble payload too short")
+
+// HubblePayload holds the fields of a Hubble advertisement payload. The
+// byte slices share memory with the parsed payload.
+type HubblePayload struct {
+	Version   uint8  // 6-bit protocol version
+	Sequence  uint16 // 10-bit sequence number
+	DeviceID  []byte // Ephemeral device ID
+	AuthTag   []byte
+	Encrypted []byte
+}
+
+// KnownVersion reports whether the payload's protocol version uses the
+// layout ParseHubblePayload understands. Fields of other versions are
+// parsed with the same layout but may not mean anything.
+func (p HubblePayload) KnownVersion() bool {
+	return p.Version == 0
+}
+
+// ParseHubblePayload splits a Hubble advertisement payload into its fields.
+// A payload shorter than HubbleMinPayloadSize returns an error wrapping
+// ErrHubblePayloadTooShort along with whichever fields it holds in full.
+func ParseHubblePayload(payload []byte) (HubblePayload, error) {
+	var p HubblePayload
+	if len(payload) < HubbleHeaderSize {
+		return p, fmt.Errorf("%w: got %d bytes, need at least %d", ErrHubblePayloadTooShort, len(payload), HubbleMinPayloadSize)
+	}
+
+	header := binary.BigEndian.Uint16(payload[0:HubbleHeaderSize])
+	p.Version = uint8(header >> 10)
+	p.Sequence = header & HubbleSequenceMask
+
+	if len(payload) >= HubbleDeviceIDOffset+HubbleDeviceIDSize {
+		p.DeviceID = payload[HubbleDeviceIDOffset : HubbleDeviceIDOffset+HubbleDeviceIDSize]
+	}
+	if len(payload) < HubbleMinPayloadSize {
+		return p, fmt.Errorf("%w: got %d bytes, need at least %d", ErrHubblePayloadTooShort, len(payload), HubbleMinPayloadSize)
+	}
+	p.AuthTag = payload[HubbleAuthTagOffset : HubbleAuthTagOffset+HubbleAuthTagSize]
+	p.Encrypted = payload[HubbleDataOffset:]
+
+	return p, nil
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHubblePayload(t *testing.T) {
+	// payload builds a payload of size bytes with the given header and a
+	// counting pattern after it
+	payload := func(header [2]byte, size int) []byte {
+		p := make([]byte, size)
+		copy(p, header[:])
+		for i := HubbleHeaderSize; i < size; i++ {
+			p[i] = byte(i)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name          string
+		payload       []byte
+		wantErr       bool
+		wantVersion   uint8
+		wantSequence  uint16
+		wantDeviceID  []byte
+		wantAuthTag   []byte
+		wantEncrypted []byte
+	}{
+		{
+			name:    "empty",
+			payload: nil,
+			wantErr: true,
+		},
+		{
+			name:         "header only",
+			payload:      []byte{0x00, 0x2A},
+			wantErr:      true,
+			wantSequence: 42,
+		},
+		{
+			name:         "header and device ID only",
+			payload:      payload([2]byte{0x00, 0x2A}, 8),
+			wantErr:      true,
+			wantSequence: 42,
+			wantDeviceID: []byte{2, 3, 4, 5},
+		},
+		{
+			name:         "minimum size",
+			payload:      payload([2]byte{0x00, 0x01}, HubbleMinPayloadSize),
+			wantSequence: 1,
+			wantDeviceID: []byte{2, 3, 4, 5},
+			wantAuthTag:  []byte{6, 7, 8, 9},
+		},
+		{
+			name:          "one encrypted byte",
+			payload:       payload([2]byte{0x03, 0xFF}, HubbleMinPayloadSize+1),
+			wantSequence:  1023,
+			wantDeviceID:  []byte{2, 3, 4, 5},
+			wantAuthTag:   []byte{6, 7, 8, 9},
+			wantEncrypted: []byte{10},
+		},
+		{
+			name:          "maximum size",
+			payload:       payload([2]byte{0x00, 0x05}, HubbleMaxPayloadSize),
+			wantSequence:  5,
+			wantDeviceID:  []byte{2, 3, 4, 5},
+			wantAuthTag:   []byte{6, 7, 8, 9},
+			wantEncrypted: payload([2]byte{}, HubbleMaxPayloadSize)[HubbleDataOffset:],
+		},
+		{
+			name:          "version bits",
+			payload:       payload([2]byte{0xFC, 0x00}, HubbleMinPayloadSize+2),
+			wantVersion:   63,
+			wantDeviceID:  []byte{2, 3, 4, 5},
+			wantAuthTag:   []byte{6, 7, 8, 9},
+			wantEncrypted: []byte{10, 11},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHubblePayload(tt.payload)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrHubblePayloadTooShort)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantVersion, got.Version)
+			assert.Equal(t, tt.wantSequence, got.Sequence)
+			assert.Equal(t, tt.wantDeviceID, got.DeviceID)
+			assert.Equal(t, tt.wantAuthTag, got.AuthTag)
+			if len(tt.wantEncrypted) == 0 {
+				assert.Empty(t, got.Encrypted)
+			} else {
+				assert.Equal(t, tt.wantEncrypted, got.Encrypted)
+			}
+		})
+	}
+}
+
+func TestParseHubblePayload_SharesMemory(t *testing.T) {
+	p := bytes.Repeat([]byte{0}, HubbleMinPayloadSize+1)
+	got, err := ParseHubblePayload(p)
+	require.NoError(t, err)
+
+	p[HubbleDataOffset] = 0xAB
+	assert.Equal(t, byte(0xAB), got.Encrypted[0])
+}
+
+func TestHubblePayload_KnownVersion(t *testing.T) {
+	assert.True(t, HubblePayload{Version: 0}.KnownVersion())
+	assert.False(t, HubblePayload{Version: 1}.KnownVersion())
+}
@@ -1,15 +1,38 @@
 package models
 
+import "fmt"
+
 // Organization represents Hubble organization metadata.
+// Settings fields are optional; nil or empty means the API didn't return them.
 type Organization struct {
 	ID   string `json:"org_id"`
 	Name string `json:"name"`
+
+	RetentionDays          *int             `json:"retention_days,omitempty"`
+	AllowedEncryptionTypes []EncryptionType `json:"allowed_encryption_types,omitempty"`
+	DeviceQuota            *int             `json:"device_quota,omitempty"`
 }
 
 // Credentials holds authentication data for the Hubble API.
 type Credentials struct {
-	OrgID string
-	Token string
+	OrgID       string
+	Token       string
+	Environment Environment // API environment; empty means production
+}
+
+// String describes the credentials without the token, so printing or
+// logging them can't leak it.
+func (c Credentials) String() string {
+	token := "<empty>"
+	if c.Token != "" {
+		token = "[redacted]"
+	}
+	return fmt.Sprintf("{OrgID:%s Token:%s Environment:%s}", c.OrgID, token, c.Environment)
+}
+
+// GoString is like String, for the %#v verb.
+func (c Credentials) GoString() string {
+	return "models.Credentials" + c.String()
 }
 
 // IsValid returns true if both OrgID and Token are non-empty.
@@ -26,6 +49,9 @@ const (
 	EnvDevelopment Environment = "development"
 )
 
+// Environments lists the selectable environments, production first.
+var Environments = []Environment{EnvProduction, EnvStaging, EnvDevelopment}
+
 // BaseURL returns the API base URL for the environment.
 func (e Environment) BaseURL() string {
 	switch e {
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentials_String(t *testing.T) {
+	creds := Credentials{OrgID: "test-org", Token: "s3cr3t-t0ken", Environment: EnvStaging}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		out := fmt.Sprintf(format, creds)
+		assert.NotContains(t, out, "s3cr3t-t0ken", format)
+		assert.Contains(t, out, "test-org", format)
+	}
+	assert.NotContains(t, fmt.Sprintf("%v", &creds), "s3cr3t-t0ken")
+	assert.Equal(t, "{OrgID:test-org Token:[redacted] Environment:staging}", creds.String())
+	assert.Contains(t, Credentials{}.String(), "Token:<empty>")
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/hex"
+	"fmt"
 	"time"
 )
 
@@ -18,6 +19,12 @@ func (p EncryptedPacket) PayloadHex() string {
 	return hex.EncodeToString(p.Payload)
 }
 
+// Key identifies the packet by payload and capture time, so the same
+// advertisement can be recognized when it is submitted again.
+func (p EncryptedPacket) Key() string {
+	return fmt.Sprintf("%s@%d", p.PayloadHex(), p.Timestamp.UnixNano())
+}
+
 // DecryptedPacket represents a successfully decrypted packet.
 type DecryptedPacket struct {
 	DeviceID    string    `json:"device_id"`
@@ -77,6 +84,16 @@ type RetrievedLocation struct {
 	VerticalAccuracy   float64 `json:"vertical_accuracy"`
 }
 
+// MapURL returns an OpenStreetMap link centered on the location, or "" if
+// the location is unknown (0, 0).
+func (l RetrievedLocation) MapURL() string {
+	if l.Latitude == 0 && l.Longitude == 0 {
+		return ""
+	}
+	return fmt.Sprintf("https://www.openstreetmap.org/?mlat=%.6f&mlon=%.6f#map=16/%.6f/%.6f",
+		l.Latitude, l.Longitude, l.Latitude, l.Longitude)
+}
+
 // RetrievedDevice is the device data in a retrieved packet.
 type RetrievedDevice struct {
 	ID             string            `json:"id"`
@@ -99,6 +116,27 @@ func (p RetrievedPacket) Payload() string {
 	return p.Device.Payload
 }
 
+// PayloadBytes decodes the base64 payload.
+func (p RetrievedPacket) PayloadBytes() ([]byte, error) {
+	return DecodePayload(p.Device.Payload)
+}
+
+// ToEncryptedPacket converts a retrieved packet back into the form produced
+// by a local scan, so it can be decrypted or re-analyzed.
+func (p RetrievedPacket) ToEncryptedPacket() (EncryptedPacket, error) {
+	payload, err := p.PayloadBytes()
+	if err != nil {
+		return EncryptedPacket{}, err
+	}
+
+	return EncryptedPacket{
+		Payload:   payload,
+		RSSI:      p.Device.RSSI,
+		Timestamp: p.Timestamp(),
+		Location:  p.GetLocation(),
+	}, nil
+}
+
 // Timestamp returns the timestamp as time.Time.
 func (p RetrievedPacket) Timestamp() time.Time {
 	return time.Unix(int64(p.Device.Timestamp), int64((p.Device.Timestamp-float64(int64(p.Device.Timestamp)))*1e9))
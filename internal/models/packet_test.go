@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetrievedLocation_MapURL(t *testing.T) {
+	loc := RetrievedLocation{Latitude: 37.7749, Longitude: -122.4194}
+	assert.Equal(t,
+		"https://www.openstreetmap.org/?mlat=37.774900&mlon=-122.419400#map=16/37.774900/-122.419400",
+		loc.MapURL())
+}
+
+func TestRetrievedLocation_MapURL_Unknown(t *testing.T) {
+	assert.Empty(t, RetrievedLocation{}.MapURL())
+}
+
+func TestEncryptedPacket_Key(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	p := EncryptedPacket{Payload: []byte{0xab, 0xcd}, RSSI: -60, Timestamp: ts}
+
+	// RSSI and location don't identify the advertisement
+	same := EncryptedPacket{Payload: []byte{0xab, 0xcd}, RSSI: -80, Timestamp: ts}
+	assert.Equal(t, p.Key(), same.Key())
+
+	later := EncryptedPacket{Payload: []byte{0xab, 0xcd}, Timestamp: ts.Add(time.Second)}
+	assert.NotEqual(t, p.Key(), later.Key())
+
+	other := EncryptedPacket{Payload: []byte{0xab, 0xce}, Timestamp: ts}
+	assert.NotEqual(t, p.Key(), other.Key())
+}
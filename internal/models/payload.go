@@ -0,0 +1,107 @@
+package models
+
+import (
+	"math"
+)
+
+// PayloadKind is a best-effort guess at what a payload contains.
+type PayloadKind int
+
+const (
+	// PayloadKindEmpty means there is no payload data.
+	PayloadKindEmpty PayloadKind = iota
+	// PayloadKindEncrypted means the bytes look random (high entropy).
+	PayloadKindEncrypted
+	// PayloadKindText means the bytes are mostly printable ASCII.
+	PayloadKindText
+	// PayloadKindBinary means structured, low-entropy binary data.
+	PayloadKindBinary
+)
+
+// Thresholds used by AnalyzePayload.
+const (
+	// TextPrintableRatio is the minimum share of printable bytes for text.
+	TextPrintableRatio = 0.9
+	// EncryptedEntropyRatio is the minimum normalized entropy for encrypted data.
+	EncryptedEntropyRatio = 0.9
+)
+
+// Badge returns a short label for the payload kind.
+func (k PayloadKind) Badge() string {
+	switch k {
+	case PayloadKindEncrypted:
+		return "enc"
+	case PayloadKindText:
+		return "txt"
+	case PayloadKindBinary:
+		return "bin"
+	default:
+		return "-"
+	}
+}
+
+// PayloadAnalysis holds heuristics describing a payload.
+type PayloadAnalysis struct {
+	// Entropy is the Shannon entropy in bits per byte (0-8).
+	Entropy float64
+	// NormalizedEntropy is Entropy divided by the maximum possible entropy
+	// for a payload of this length (0-1), so short payloads compare fairly.
+	NormalizedEntropy float64
+	// PrintableRatio is the share of bytes that are printable ASCII (0-1).
+	PrintableRatio float64
+	// Kind is the resulting classification.
+	Kind PayloadKind
+}
+
+// AnalyzePayload computes entropy and printable-ratio heuristics for a
+// payload and guesses whether it is still encrypted or looks like plaintext.
+// The result is a debugging aid only; short plaintext payloads with distinct
+// bytes can look encrypted.
+func AnalyzePayload(data []byte) PayloadAnalysis {
+	if len(data) == 0 {
+		return PayloadAnalysis{Kind: PayloadKindEmpty}
+	}
+
+	var counts [256]int
+	printable := 0
+	for _, b := range data {
+		counts[b]++
+		if b >= 0x20 && b <= 0x7e {
+			printable++
+		}
+	}
+
+	n := float64(len(data))
+	entropy := 0.0
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+
+	// A payload of n bytes can't exceed log2(n) bits of entropy per byte
+	maxEntropy := math.Log2(math.Min(n, 256))
+	normalized := 0.0
+	if maxEntropy > 0 {
+		normalized = entropy / maxEntropy
+	}
+
+	a := PayloadAnalysis{
+		Entropy:           entropy,
+		NormalizedEntropy: normalized,
+		PrintableRatio:    float64(printable) / n,
+	}
+
+	switch {
+	case a.PrintableRatio >= TextPrintableRatio:
+		a.Kind = PayloadKindText
+	case a.NormalizedEntropy >= EncryptedEntropyRatio:
+		a.Kind = PayloadKindEncrypted
+	default:
+		a.Kind = PayloadKindBinary
+	}
+
+	return a
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzePayload(t *testing.T) {
+	// Ciphertext captured from an AES-CTR encrypted packet
+	encrypted, _ := hex.DecodeString("8f3ac1d45e9b0277f6e813a4c95d")
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected PayloadKind
+	}{
+		{"empty", nil, PayloadKindEmpty},
+		{"encrypted", encrypted, PayloadKindEncrypted},
+		{"text", []byte("temp=21.5C"), PayloadKindText},
+		{"zero padded", []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, PayloadKindBinary},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, AnalyzePayload(tt.data).Kind)
+		})
+	}
+}
+
+func TestAnalyzePayload_Metrics(t *testing.T) {
+	a := AnalyzePayload([]byte{0x00, 0x01, 0x02, 0x03})
+	assert.InDelta(t, 2.0, a.Entropy, 1e-9)
+	assert.InDelta(t, 1.0, a.NormalizedEntropy, 1e-9)
+	assert.Equal(t, 0.0, a.PrintableRatio)
+
+	a = AnalyzePayload([]byte("aaaa"))
+	assert.Equal(t, 0.0, a.Entropy)
+	assert.Equal(t, 1.0, a.PrintableRatio)
+
+	// A single byte has no meaningful entropy range
+	a = AnalyzePayload([]byte{0xff})
+	assert.Equal(t, 0.0, a.NormalizedEntropy)
+}
+
+func TestPayloadKind_Badge(t *testing.T) {
+	assert.Equal(t, "enc", PayloadKindEncrypted.Badge())
+	assert.Equal(t, "txt", PayloadKindText.Badge())
+	assert.Equal(t, "bin", PayloadKindBinary.Badge())
+	assert.Equal(t, "-", PayloadKindEmpty.Badge())
+}
@@ -0,0 +1,5 @@
+package models
+
+// SequenceModulus is the number of distinct packet sequence numbers. The
+// 10-bit counter wraps from 1023 back to 0.
+const SequenceModulus = 1024
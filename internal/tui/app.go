@@ -2,11 +2,16 @@ package tui
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/hubblenetwork/hubcli/internal/api"
 	"github.com/hubblenetwork/hubcli/internal/auth"
+	"github.com/hubblenetwork/hubcli/internal/ble"
+	"github.com/hubblenetwork/hubcli/internal/config"
+	"github.com/hubblenetwork/hubcli/internal/location"
 	"github.com/hubblenetwork/hubcli/internal/models"
 	"github.com/hubblenetwork/hubcli/internal/tui/common"
 	"github.com/hubblenetwork/hubcli/internal/tui/screens"
@@ -23,28 +28,42 @@ const (
 	ScreenBLEScan
 	ScreenOrgInfo
 	ScreenSettings
+	ScreenDiagnostics
 )
 
 // App is the main application model.
 type App struct {
 	screen      Screen
-	prevScreen  Screen
+	screenData  screens.NavigationData // Data the current screen was opened with
+	navStack    []navEntry             // Screens to return to with "back"
 	width       int
 	height      int
 	ready       bool
 	err         error
 	credentials *models.Credentials
 	orgName     string
-	client      *api.Client
+	client      api.APIClient
+	cfg         config.Config
+
+	// scanner, if set, replaces the Bluetooth scanner on the BLE scan
+	// screen with a saved capture or offline sample data
+	scanner ble.ScannerInterface
+
+	// requestLogger, if set, is called with every API request
+	requestLogger func(api.RequestLog)
+
+	// confirmingQuit is set while the quit confirmation prompt is shown
+	confirmingQuit bool
 
 	// Screen models
-	loginModel    screens.LoginModel
-	homeModel     screens.HomeModel
-	devicesModel  screens.DevicesModel
-	packetsModel  screens.PacketsModel
-	orgInfoModel  screens.OrgInfoModel
-	bleScanModel  screens.BLEScanModel
-	settingsModel screens.SettingsModel
+	loginModel       screens.LoginModel
+	homeModel        screens.HomeModel
+	devicesModel     screens.DevicesModel
+	packetsModel     screens.PacketsModel
+	orgInfoModel     screens.OrgInfoModel
+	bleScanModel     screens.BLEScanModel
+	settingsModel    screens.SettingsModel
+	diagnosticsModel screens.DiagnosticsModel
 }
 
 // NewApp creates a new application instance.
@@ -54,11 +73,19 @@ func NewApp() *App {
 		loginModel: screens.NewLoginModel(),
 	}
 
+	// Load user preferences (defaults are returned if the file is missing or unreadable)
+	app.cfg, _ = config.Load()
+	common.SetHighContrast(app.cfg.HighContrast)
+	common.SetUTC(app.cfg.UTCTimestamps)
+	if d, ok := app.cfg.RequestTimeout(); ok {
+		common.SetRequestTimeout(d)
+	}
+
 	// Check for existing credentials
 	creds, err := auth.GetCredentials()
 	if err == nil && creds != nil && creds.IsValid() {
 		app.credentials = creds
-		app.client = api.NewClientFromCredentials(*creds)
+		app.client = api.NewClientFromCredentials(*creds, app.clientOptions()...)
 		app.screen = ScreenHome
 		app.homeModel = screens.NewHomeModel("")
 	}
@@ -77,7 +104,7 @@ func (a *App) Init() tea.Cmd {
 	case ScreenHome:
 		cmds = append(cmds, a.homeModel.Init())
 		// Fetch org name in background
-		if a.credentials != nil {
+		if a.client != nil {
 			cmds = append(cmds, a.fetchOrgName())
 		}
 	}
@@ -89,6 +116,17 @@ func (a *App) Init() tea.Cmd {
 func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	// The quit prompt captures all key presses until it is answered
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && a.confirmingQuit {
+		return a.handleQuitConfirm(keyMsg)
+	}
+
+	// Any key dismisses an internal error
+	if _, ok := msg.(tea.KeyMsg); ok && a.err != nil {
+		a.err = nil
+		return a, nil
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		a.width = msg.Width
@@ -100,7 +138,7 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case screens.LoginSuccessMsg:
 		// Login was successful, switch to home screen
 		a.credentials = &msg.Credentials
-		a.client = api.NewClientFromCredentials(msg.Credentials)
+		a.client = api.NewClientFromCredentials(msg.Credentials, a.clientOptions()...)
 		a.orgName = msg.OrgName
 		a.homeModel = screens.NewHomeModel(msg.OrgName)
 		a.screen = ScreenHome
@@ -113,10 +151,29 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case screens.NavigateMsg:
 		return a.handleNavigation(msg.Screen, msg.Data)
 
+	case screens.QuitMsg:
+		if a.currentScreenBusy() {
+			a.confirmingQuit = true
+			return a, nil
+		}
+		return a, a.quit()
+
 	case orgNameMsg:
 		a.orgName = msg.Name
 		a.homeModel.SetOrgName(msg.Name)
 		return a, nil
+
+	case screens.ScanLocationSetMsg:
+		a.setScanLocation(msg.Location)
+		return a, nil
+
+	case screens.PacketsPageSizeSetMsg:
+		a.setPacketsPageSize(msg.Size)
+		return a, nil
+
+	case screens.RequestTimeoutSetMsg:
+		a.setRequestTimeout(msg.Timeout)
+		return a, nil
 	}
 
 	// Forward message to current screen
@@ -151,13 +208,101 @@ func (a *App) View() string {
 		content = a.orgInfoModel.View()
 	case ScreenSettings:
 		content = a.settingsModel.View()
+	case ScreenDiagnostics:
+		content = a.diagnosticsModel.View()
 	default:
 		content = "Unknown screen"
 	}
 
+	if a.confirmingQuit {
+		return a.renderQuitConfirm()
+	}
+	if a.err != nil {
+		return a.renderError()
+	}
+
 	return content
 }
 
+// currentScreen returns the active screen model
+func (a *App) currentScreen() screens.BusyReporter {
+	switch a.screen {
+	case ScreenLogin:
+		return a.loginModel
+	case ScreenHome:
+		return a.homeModel
+	case ScreenDevices:
+		return a.devicesModel
+	case ScreenPackets:
+		return a.packetsModel
+	case ScreenBLEScan:
+		return a.bleScanModel
+	case ScreenOrgInfo:
+		return a.orgInfoModel
+	case ScreenSettings:
+		return a.settingsModel
+	case ScreenDiagnostics:
+		return a.diagnosticsModel
+	}
+	return nil
+}
+
+// currentScreenBusy reports whether the active screen has an operation in flight
+func (a *App) currentScreenBusy() bool {
+	screen := a.currentScreen()
+	return screen != nil && screen.Busy()
+}
+
+// handleQuitConfirm handles key presses while the quit prompt is shown
+func (a *App) handleQuitConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y", "ctrl+c":
+		a.confirmingQuit = false
+		return a, a.quit()
+	case "n", "N", "esc":
+		a.confirmingQuit = false
+	}
+	return a, nil
+}
+
+// quit cancels every screen's work in flight, including any running scan,
+// and exits the program
+func (a *App) quit() tea.Cmd {
+	a.bleScanModel.Stop()
+	a.packetsModel.Stop()
+	a.devicesModel.Stop()
+	a.orgInfoModel.Stop()
+	return tea.Quit
+}
+
+func (a *App) renderQuitConfirm() string {
+	content := common.WarningTextStyle.Bold(true).Render("An operation is still in progress.") + "\n\n" +
+		"Quit anyway?" + "\n\n" +
+		common.FormatHelp("y", "quit") + "  " + common.FormatHelp("n/esc", "cancel")
+
+	return lipgloss.Place(
+		a.width,
+		a.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		common.BoxStyle.Render(content),
+	)
+}
+
+func (a *App) renderError() string {
+	content := common.ErrorTextStyle.Bold(true).Render("Something went wrong") + "\n\n" +
+		a.err.Error() + "\n\n" +
+		common.MutedTextStyle.Render("Press any key to continue")
+
+	return lipgloss.Place(
+		a.width,
+		a.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		common.BoxStyle.Width(min(a.width-4, 60)).Render(content),
+	)
+}
+
 func (a *App) forwardToCurrentScreen(msg tea.Msg) tea.Cmd {
 	var cmd tea.Cmd
 
@@ -176,23 +321,151 @@ func (a *App) forwardToCurrentScreen(msg tea.Msg) tea.Cmd {
 		a.bleScanModel, cmd = a.bleScanModel.Update(msg)
 	case ScreenSettings:
 		a.settingsModel, cmd = a.settingsModel.Update(msg)
+	case ScreenDiagnostics:
+		a.diagnosticsModel, cmd = a.diagnosticsModel.Update(msg)
 	}
 
 	return cmd
 }
 
-func (a *App) handleNavigation(screen string, data interface{}) (tea.Model, tea.Cmd) {
-	// Handle "back" separately to avoid overwriting prevScreen
-	if screen == "back" {
-		a.screen = a.prevScreen
+// maxNavDepth bounds the navigation stack; the oldest entries are dropped
+const maxNavDepth = 32
+
+// navEntry is a screen on the navigation stack along with the data it was
+// opened with, so "back" can reopen it as it was.
+type navEntry struct {
+	screen string
+	data   screens.NavigationData
+
+	// model is a snapshot of the screen model (its filters, time window and
+	// loaded rows). It is restored on "back" instead of building a fresh model.
+	model screens.BusyReporter
+}
+
+func (a *App) handleNavigation(screen string, data screens.NavigationData) (tea.Model, tea.Cmd) {
+	// A packets retrieval in flight would wait for its pages to be read, and
+	// auto-refresh ticks aren't delivered to a hidden screen
+	if a.screen == ScreenPackets {
+		a.packetsModel.Stop()
+	}
+
+	switch screen {
+	case "back":
+		return a.navigateBack()
+	case "home":
+		// Home is the root, so there is nothing to go back to
+		a.navStack = nil
+	default:
+		if screenNeedsClient(screen) && a.client == nil {
+			return a.handleMissingClient(screen)
+		}
+		a.pushNav(navEntry{
+			screen: screenName(a.screen),
+			data:   a.screenData,
+			model:  a.snapshotScreen(),
+		})
+	}
+
+	return a.openScreen(screen, data)
+}
+
+// handleMissingClient handles navigation to a screen that needs the API
+// while there is no client. Without credentials the user is sent to log in;
+// with credentials it is a bug, so it is reported as one instead of letting
+// the screen fail its first load with a cryptic error.
+func (a *App) handleMissingClient(screen string) (tea.Model, tea.Cmd) {
+	if a.credentials == nil || !a.credentials.IsValid() {
+		a.navStack = nil
+		a.screenData = nil
+		a.screen = ScreenLogin
+		a.loginModel = screens.NewLoginModel()
+		return a, tea.Batch(a.loginModel.Init(), a.forwardToCurrentScreen(tea.WindowSizeMsg{
+			Width:  a.width,
+			Height: a.height,
+		}))
+	}
+
+	a.err = fmt.Errorf("internal error: no API client is available for the %s screen. Restart hubcli and report this if it keeps happening", screen)
+	return a, nil
+}
+
+// navigateBack pops the navigation stack and reopens the previous screen
+func (a *App) navigateBack() (tea.Model, tea.Cmd) {
+	if len(a.navStack) == 0 {
+		a.navStack = nil
+		return a.openScreen("home", nil)
+	}
+
+	entry := a.navStack[len(a.navStack)-1]
+	a.navStack = a.navStack[:len(a.navStack)-1]
+
+	if a.restoreScreen(entry) {
+		a.screenData = entry.data
 		return a, a.forwardToCurrentScreen(tea.WindowSizeMsg{
 			Width:  a.width,
 			Height: a.height,
 		})
 	}
+	return a.openScreen(entry.screen, entry.data)
+}
+
+// snapshotScreen returns a copy of the current screen model for the
+// navigation stack, or nil if the screen should be rebuilt on return
+func (a *App) snapshotScreen() screens.BusyReporter {
+	switch a.screen {
+	case ScreenDevices:
+		return a.devicesModel
+	case ScreenPackets:
+		return a.packetsModel
+	case ScreenOrgInfo:
+		return a.orgInfoModel
+	case ScreenSettings:
+		return a.settingsModel
+	}
+	// Home has no state worth keeping and a BLE scan is stopped on leave
+	return nil
+}
+
+// restoreScreen reinstates a snapshotted screen model. A snapshot taken
+// mid-load is discarded since its result went to another screen.
+func (a *App) restoreScreen(entry navEntry) bool {
+	if entry.model == nil || entry.model.Busy() {
+		return false
+	}
 
-	// Save current screen before navigating
-	a.prevScreen = a.screen
+	switch m := entry.model.(type) {
+	case screens.DevicesModel:
+		a.screen = ScreenDevices
+		a.devicesModel = m
+	case screens.PacketsModel:
+		a.screen = ScreenPackets
+		a.packetsModel = m
+	case screens.OrgInfoModel:
+		a.screen = ScreenOrgInfo
+		a.orgInfoModel = m
+	case screens.SettingsModel:
+		a.screen = ScreenSettings
+		a.settingsModel = m
+	default:
+		return false
+	}
+	return true
+}
+
+// pushNav records a screen on the navigation stack
+func (a *App) pushNav(entry navEntry) {
+	if entry.screen == "" {
+		return
+	}
+	a.navStack = append(a.navStack, entry)
+	if len(a.navStack) > maxNavDepth {
+		a.navStack = a.navStack[len(a.navStack)-maxNavDepth:]
+	}
+}
+
+// openScreen switches to the named screen, creating a fresh model for it
+func (a *App) openScreen(screen string, data screens.NavigationData) (tea.Model, tea.Cmd) {
+	a.screenData = data
 
 	var initCmd tea.Cmd
 
@@ -202,18 +475,31 @@ func (a *App) handleNavigation(screen string, data interface{}) (tea.Model, tea.
 		a.devicesModel = screens.NewDevicesModel(a.client)
 		initCmd = a.devicesModel.Init()
 	case "packets":
-		deviceID := ""
-		if data != nil {
-			if id, ok := data.(string); ok {
-				deviceID = id
-			}
+		var nav screens.PacketsNavData
+		if d, ok := data.(screens.PacketsNavData); ok {
+			nav = d
 		}
 		a.screen = ScreenPackets
-		a.packetsModel = screens.NewPacketsModel(a.client, deviceID)
+		a.packetsModel = screens.NewPacketsModel(a.client, nav.DeviceID)
+		a.packetsModel.SetDays(nav.Days)
+		a.packetsModel.SetSortAscending(a.cfg.PacketsSortAscending())
+		a.packetsModel.SetPageSize(a.cfg.PacketsPageSize)
 		initCmd = a.packetsModel.Init()
 	case "ble_scan":
 		a.screen = ScreenBLEScan
 		a.bleScanModel = screens.NewBLEScanModel(a.client)
+		if d, ok := a.cfg.ScanRedrawInterval(); ok {
+			a.bleScanModel.SetRedrawInterval(d)
+		}
+		if d, ok := a.cfg.ScanDedupeWindow(); ok {
+			a.bleScanModel.SetDedupeWindow(d)
+		}
+		if loc, ok := a.cfg.ScanLocation.Location(); ok {
+			a.bleScanModel.SetScanLocation(loc)
+		}
+		if a.scanner != nil {
+			a.bleScanModel.SetScanner(a.scanner)
+		}
 		initCmd = a.bleScanModel.Init()
 	case "org_info":
 		a.screen = ScreenOrgInfo
@@ -222,7 +508,12 @@ func (a *App) handleNavigation(screen string, data interface{}) (tea.Model, tea.
 	case "settings":
 		a.screen = ScreenSettings
 		a.settingsModel = screens.NewSettingsModel()
+		a.settingsModel.SetScanLocation(a.manualScanLocation())
 		initCmd = a.settingsModel.Init()
+	case "diagnostics":
+		a.screen = ScreenDiagnostics
+		a.diagnosticsModel = screens.NewDiagnosticsModel(a.client)
+		initCmd = a.diagnosticsModel.Init()
 	case "home":
 		a.screen = ScreenHome
 	}
@@ -239,6 +530,37 @@ func (a *App) handleNavigation(screen string, data interface{}) (tea.Model, tea.
 	return a, sizeCmd
 }
 
+// screenNeedsClient reports whether a screen loads its data from the API
+func screenNeedsClient(screen string) bool {
+	switch screen {
+	case "devices", "packets", "org_info":
+		return true
+	}
+	return false
+}
+
+// screenName returns the navigation name for a screen, or "" if the screen
+// can't be navigated to
+func screenName(s Screen) string {
+	switch s {
+	case ScreenHome:
+		return "home"
+	case ScreenDevices:
+		return "devices"
+	case ScreenPackets:
+		return "packets"
+	case ScreenBLEScan:
+		return "ble_scan"
+	case ScreenOrgInfo:
+		return "org_info"
+	case ScreenSettings:
+		return "settings"
+	case ScreenDiagnostics:
+		return "diagnostics"
+	}
+	return ""
+}
+
 func (a *App) renderPlaceholder(title, description string) string {
 	content := common.TitleStyle.Render(title) + "\n\n" +
 		common.SubtitleStyle.Render(description) + "\n\n" +
@@ -259,13 +581,121 @@ type orgNameMsg struct {
 	Name string
 }
 
-func (a *App) fetchOrgName() tea.Cmd {
-	return func() tea.Msg {
-		if a.credentials == nil {
-			return nil
+// SetReplayScanner makes the BLE scan screen replay a saved capture instead
+// of scanning with Bluetooth.
+func (a *App) SetReplayScanner(scanner ble.ScannerInterface) {
+	a.scanner = scanner
+}
+
+// SetRequestLogger makes the API client call fn with every request it
+// sends, for verbose logging. Call it before the program starts.
+func (a *App) SetRequestLogger(fn func(api.RequestLog)) {
+	a.requestLogger = fn
+	if a.credentials != nil {
+		a.rebuildClient()
+	}
+}
+
+// rebuildClient recreates the client with the current settings, keeping
+// its record of ingested packets so a retried upload doesn't resend them.
+// a.credentials must be set.
+func (a *App) rebuildClient() {
+	opts := a.clientOptions()
+	if prev, ok := a.client.(*api.Client); ok {
+		opts = append(opts, api.WithIngestHistory(prev))
+	}
+	a.client = api.NewClientFromCredentials(*a.credentials, opts...)
+}
+
+// SetOffline runs the app against client instead of the Hubble API, starting
+// at the home screen without logging in, and makes the BLE scan screen use
+// scanner instead of Bluetooth. Call it before the program starts.
+func (a *App) SetOffline(client api.APIClient, scanner ble.ScannerInterface) {
+	a.credentials = nil
+	a.client = client
+	a.scanner = scanner
+	a.screen = ScreenHome
+	a.homeModel = screens.NewHomeModel("")
+}
+
+// clientOptions returns the API client options derived from the config.
+func (a *App) clientOptions() []api.ClientOption {
+	opts := []api.ClientOption{
+		api.WithRetry(api.DefaultRetryAttempts, api.DefaultRetryBaseDelay),
+		api.WithTimeout(common.RequestTimeout()),
+	}
+	if d, ok := a.cfg.IngestTimeout(); ok {
+		opts = append(opts, api.WithIngestTimeout(d))
+	}
+	if a.cfg.IngestRetries != nil {
+		opts = append(opts, api.WithIngestRetries(*a.cfg.IngestRetries))
+	}
+	if a.cfg.MaxConcurrency > 0 {
+		opts = append(opts, api.WithMaxConcurrency(a.cfg.MaxConcurrency))
+	}
+	if a.requestLogger != nil {
+		opts = append(opts, api.WithLogger(a.requestLogger))
+	}
+	return opts
+}
+
+// manualScanLocation returns the configured scan location, or nil if none is
+// set or it is out of range.
+func (a *App) manualScanLocation() *location.Manual {
+	if _, ok := a.cfg.ScanLocation.Location(); !ok {
+		return nil
+	}
+	return &location.Manual{
+		Latitude:           a.cfg.ScanLocation.Latitude,
+		Longitude:          a.cfg.ScanLocation.Longitude,
+		Altitude:           a.cfg.ScanLocation.Altitude,
+		HorizontalAccuracy: a.cfg.ScanLocation.HorizontalAccuracy,
+	}
+}
+
+// setScanLocation saves the scan location entered on a screen to the config,
+// so later scans and sessions use it. nil clears it.
+func (a *App) setScanLocation(loc *location.Manual) {
+	a.cfg.ScanLocation = nil
+	if loc != nil {
+		a.cfg.ScanLocation = &config.ScanLocation{
+			Latitude:           loc.Latitude,
+			Longitude:          loc.Longitude,
+			Altitude:           loc.Altitude,
+			HorizontalAccuracy: loc.HorizontalAccuracy,
 		}
+	}
+	if err := config.Save(a.cfg); err != nil {
+		a.err = fmt.Errorf("scan location is set for this session but could not be saved: %w", err)
+	}
+}
 
-		client := api.NewClientFromCredentials(*a.credentials)
+// setPacketsPageSize saves the page size picked on the packets screen to the
+// config, so later sessions use it
+func (a *App) setPacketsPageSize(size int) {
+	a.cfg.PacketsPageSize = size
+	if err := config.Save(a.cfg); err != nil {
+		a.err = fmt.Errorf("page size is set for this session but could not be saved: %w", err)
+	}
+}
+
+// setRequestTimeout applies the request timeout picked on the settings
+// screen to later requests and saves it to the config, so later sessions
+// use it
+func (a *App) setRequestTimeout(d time.Duration) {
+	common.SetRequestTimeout(d)
+	if a.credentials != nil {
+		a.rebuildClient()
+	}
+	a.cfg.RequestTimeoutSeconds = int(d / time.Second)
+	if err := config.Save(a.cfg); err != nil {
+		a.err = fmt.Errorf("request timeout is set for this session but could not be saved: %w", err)
+	}
+}
+
+func (a *App) fetchOrgName() tea.Cmd {
+	client := a.client
+	return func() tea.Msg {
 		org, err := client.GetOrganization(context.Background())
 		if err != nil {
 			return nil
@@ -1,14 +1,35 @@
 package tui
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hubblenetwork/hubcli/internal/api"
+	"github.com/hubblenetwork/hubcli/internal/auth"
+	"github.com/hubblenetwork/hubcli/internal/ble"
+	"github.com/hubblenetwork/hubcli/internal/config"
+	"github.com/hubblenetwork/hubcli/internal/location"
 	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/hubblenetwork/hubcli/internal/tui/common"
 	"github.com/hubblenetwork/hubcli/internal/tui/screens"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// newTestApp returns an app that is logged in with test credentials
+func newTestApp() *App {
+	app := NewApp()
+	creds := models.Credentials{OrgID: "test-org", Token: "test-token"}
+	app.credentials = &creds
+	app.client = api.NewClientFromCredentials(creds)
+	return app
+}
+
 func TestNewApp(t *testing.T) {
 	// NewApp checks for credentials in env/keychain
 	// Without credentials, it should start at login screen
@@ -48,7 +69,7 @@ func TestApp_View_NotReady(t *testing.T) {
 }
 
 func TestApp_HandleNavigation(t *testing.T) {
-	app := NewApp()
+	app := newTestApp()
 	app.screen = ScreenHome
 	app.ready = true
 	app.width = 80
@@ -63,6 +84,7 @@ func TestApp_HandleNavigation(t *testing.T) {
 		{"ble_scan", ScreenBLEScan},
 		{"org_info", ScreenOrgInfo},
 		{"settings", ScreenSettings},
+		{"diagnostics", ScreenDiagnostics},
 		{"home", ScreenHome},
 	}
 
@@ -74,10 +96,10 @@ func TestApp_HandleNavigation(t *testing.T) {
 }
 
 func TestApp_HandleNavigation_Back(t *testing.T) {
-	app := NewApp()
+	app := newTestApp()
 	// Simulate navigating from Home to Devices
 	app.screen = ScreenHome
-	app.handleNavigation("devices", nil) // This sets prevScreen = ScreenHome
+	app.handleNavigation("devices", nil) // This pushes home onto the nav stack
 
 	// Now navigate back
 	model, _ := app.handleNavigation("back", nil)
@@ -110,7 +132,7 @@ func TestApp_LoginSuccessMsg(t *testing.T) {
 }
 
 func TestApp_NavigateMsg(t *testing.T) {
-	app := NewApp()
+	app := newTestApp()
 	app.screen = ScreenHome
 	app.ready = true
 	app.width = 80
@@ -124,6 +146,46 @@ func TestApp_NavigateMsg(t *testing.T) {
 	assert.Equal(t, ScreenDevices, updatedApp.screen)
 }
 
+func TestApp_NavigateMsg_PacketsData(t *testing.T) {
+	app := newTestApp()
+	app.screen = ScreenHome
+
+	// No days shows the default window
+	app.handleNavigation("packets", screens.PacketsNavData{DeviceID: "device-x"})
+	assert.Equal(t, "device-x", app.packetsModel.DeviceFilter())
+	assert.Equal(t, 7, app.packetsModel.Days())
+
+	app.handleNavigation("packets", screens.PacketsNavData{DeviceID: "device-y", Days: 1})
+	assert.Equal(t, ScreenPackets, app.screen)
+	assert.Equal(t, "device-y", app.packetsModel.DeviceFilter())
+	assert.Equal(t, 1, app.packetsModel.Days())
+}
+
+func TestApp_DevicesToPacketsHandoff(t *testing.T) {
+	app := newTestApp()
+	app.handleNavigation("devices", nil)
+	app.Update(screens.DevicesLoadedMsg{Devices: []models.Device{{ID: "device-1"}}})
+
+	for _, tt := range []struct {
+		key  tea.KeyMsg
+		days int
+	}{
+		{tea.KeyMsg{Type: tea.KeyEnter}, 7},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}}, 1},
+	} {
+		_, cmd := app.Update(tt.key)
+		require.NotNil(t, cmd)
+		app.Update(cmd())
+
+		assert.Equal(t, ScreenPackets, app.screen)
+		assert.Equal(t, "device-1", app.packetsModel.DeviceFilter())
+		assert.Equal(t, tt.days, app.packetsModel.Days())
+
+		app.handleNavigation("back", nil)
+		require.Equal(t, ScreenDevices, app.screen)
+	}
+}
+
 func TestApp_OrgNameMsg(t *testing.T) {
 	app := NewApp()
 	app.screen = ScreenHome
@@ -159,6 +221,7 @@ func TestScreenConstants(t *testing.T) {
 		ScreenBLEScan,
 		ScreenOrgInfo,
 		ScreenSettings,
+		ScreenDiagnostics,
 	}
 
 	seen := make(map[Screen]bool)
@@ -167,3 +230,363 @@ func TestScreenConstants(t *testing.T) {
 		seen[s] = true
 	}
 }
+
+func TestApp_QuitMsg_NotBusy(t *testing.T) {
+	app := NewApp()
+	app.screen = ScreenHome
+
+	model, cmd := app.Update(screens.QuitMsg{})
+	updatedApp := model.(*App)
+
+	assert.False(t, updatedApp.confirmingQuit)
+	assert.NotNil(t, cmd)
+	assert.Equal(t, tea.QuitMsg{}, cmd())
+}
+
+func TestApp_QuitMsg_BusyAsksForConfirmation(t *testing.T) {
+	app := NewApp()
+	app.ready = true
+	app.width = 80
+	app.height = 24
+	app.screen = ScreenDevices
+	app.devicesModel = screens.NewDevicesModel(nil) // Starts in loading state
+
+	model, cmd := app.Update(screens.QuitMsg{})
+	updatedApp := model.(*App)
+
+	assert.True(t, updatedApp.confirmingQuit)
+	assert.Nil(t, cmd)
+	assert.Contains(t, updatedApp.View(), "Quit anyway?")
+
+	// Declining returns to the screen
+	model, cmd = updatedApp.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	updatedApp = model.(*App)
+	assert.False(t, updatedApp.confirmingQuit)
+	assert.Nil(t, cmd)
+
+	// Confirming quits
+	updatedApp.Update(screens.QuitMsg{})
+	model, cmd = updatedApp.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	updatedApp = model.(*App)
+	assert.False(t, updatedApp.confirmingQuit)
+	assert.NotNil(t, cmd)
+	assert.Equal(t, tea.QuitMsg{}, cmd())
+}
+
+func TestApp_CurrentScreenBusy(t *testing.T) {
+	app := NewApp()
+
+	app.screen = ScreenHome
+	assert.False(t, app.currentScreenBusy())
+
+	app.screen = ScreenPackets
+	app.packetsModel = screens.NewPacketsModel(nil, "")
+	assert.True(t, app.currentScreenBusy())
+
+	app.screen = ScreenSettings
+	app.settingsModel = screens.NewSettingsModelWithStore(auth.NewMemoryStore(nil))
+	assert.False(t, app.currentScreenBusy())
+}
+
+func TestApp_HandleNavigation_BackThroughStack(t *testing.T) {
+	app := newTestApp()
+	app.screen = ScreenHome
+
+	app.handleNavigation("packets", nil)
+	app.handleNavigation("devices", nil)
+	app.handleNavigation("packets", screens.PacketsNavData{DeviceID: "device-x"})
+
+	app.handleNavigation("back", nil)
+	assert.Equal(t, ScreenDevices, app.screen)
+
+	app.handleNavigation("back", nil)
+	assert.Equal(t, ScreenPackets, app.screen)
+	assert.Nil(t, app.screenData)
+
+	app.handleNavigation("back", nil)
+	assert.Equal(t, ScreenHome, app.screen)
+
+	// Back with an empty stack stays home
+	app.handleNavigation("back", nil)
+	assert.Equal(t, ScreenHome, app.screen)
+	assert.Empty(t, app.navStack)
+}
+
+func TestApp_HandleNavigation_HomeClearsStack(t *testing.T) {
+	app := newTestApp()
+	app.screen = ScreenHome
+
+	app.handleNavigation("devices", nil)
+	app.handleNavigation("packets", screens.PacketsNavData{DeviceID: "device-x"})
+	app.handleNavigation("home", nil)
+
+	assert.Equal(t, ScreenHome, app.screen)
+	assert.Empty(t, app.navStack)
+}
+
+func TestApp_HandleNavigation_BoundedStack(t *testing.T) {
+	app := NewApp()
+	app.screen = ScreenHome
+
+	for i := 0; i < maxNavDepth+10; i++ {
+		app.handleNavigation("settings", nil)
+	}
+
+	assert.Len(t, app.navStack, maxNavDepth)
+}
+
+func TestApp_HandleNavigation_BackRestoresPacketsFilter(t *testing.T) {
+	app := newTestApp()
+	app.screen = ScreenHome
+	app.width = 80
+	app.height = 24
+
+	// Open packets for a device, narrow the window to 1 day and let it load
+	app.handleNavigation("packets", screens.PacketsNavData{DeviceID: "device-x"})
+	app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}})
+	app.Update(screens.PacketsLoadedMsg{
+		Packets: []models.RetrievedPacket{{Device: models.RetrievedDevice{ID: "device-x"}}},
+	})
+	require.False(t, app.packetsModel.Busy())
+
+	// Go deeper, then come back
+	app.handleNavigation("devices", nil)
+	app.handleNavigation("back", nil)
+
+	assert.Equal(t, ScreenPackets, app.screen)
+	assert.Equal(t, "device-x", app.packetsModel.DeviceFilter())
+	assert.Equal(t, 1, app.packetsModel.Days())
+	assert.False(t, app.packetsModel.Busy(), "restored screen should not reload")
+	assert.Equal(t, screens.PacketsNavData{DeviceID: "device-x"}, app.screenData)
+}
+
+func TestApp_HandleNavigation_BackRebuildsBusySnapshot(t *testing.T) {
+	app := newTestApp()
+	app.screen = ScreenHome
+
+	// Leave packets while it is still loading
+	app.handleNavigation("packets", screens.PacketsNavData{DeviceID: "device-x"})
+	app.handleNavigation("devices", nil)
+
+	_, cmd := app.handleNavigation("back", nil)
+
+	assert.Equal(t, ScreenPackets, app.screen)
+	assert.Equal(t, "device-x", app.packetsModel.DeviceFilter())
+	assert.NotNil(t, cmd) // Fresh model reloads
+}
+
+func TestApp_HandleNavigation_NoClientRoutesToLogin(t *testing.T) {
+	app := NewApp()
+	app.credentials = nil
+	app.client = nil
+	app.screen = ScreenHome
+
+	for _, screen := range []string{"devices", "packets", "org_info"} {
+		app.screen = ScreenHome
+		app.handleNavigation(screen, nil)
+		assert.Equal(t, ScreenLogin, app.screen, "navigation to %s", screen)
+		assert.Empty(t, app.navStack)
+	}
+
+	// Screens that don't need the API still open
+	app.screen = ScreenHome
+	app.handleNavigation("ble_scan", nil)
+	assert.Equal(t, ScreenBLEScan, app.screen)
+}
+
+func TestApp_ReplayScanner(t *testing.T) {
+	scanner := ble.NewMockScanner()
+	scanner.SetPackets([]models.EncryptedPacket{{Payload: []byte{0x01}}})
+
+	app := NewApp()
+	app.SetReplayScanner(scanner)
+	_, cmd := app.handleNavigation("ble_scan", nil)
+	require.NotNil(t, cmd)
+
+	// The scan started by the screen runs on the replay scanner
+	var started bool
+	for _, c := range cmd().(tea.BatchMsg) {
+		if c == nil {
+			continue
+		}
+		if msg, ok := c().(screens.BLEScanStartedMsg); ok {
+			started = true
+			result := <-msg.Results
+			require.NotNil(t, result.Packet)
+			assert.Equal(t, []byte{0x01}, result.Packet.Payload)
+		}
+	}
+	assert.True(t, started)
+}
+
+func TestApp_Offline(t *testing.T) {
+	client := api.NewFakeClient(time.Date(2025, 10, 16, 12, 0, 0, 0, time.UTC))
+
+	app := NewApp()
+	app.SetOffline(client, ble.NewMockScanner())
+	assert.Equal(t, ScreenHome, app.screen)
+	assert.Nil(t, app.credentials)
+
+	// The org name comes from the fake client
+	app.Update(app.fetchOrgName()())
+	assert.Equal(t, "Demo Organization", app.orgName)
+
+	// Screens that need the API load from the fake client
+	_, cmd := app.handleNavigation("devices", nil)
+	assert.Equal(t, ScreenDevices, app.screen)
+	require.NotNil(t, cmd)
+	var loaded bool
+	for _, c := range cmd().(tea.BatchMsg) {
+		if c == nil {
+			continue
+		}
+		if msg, ok := c().(screens.DevicesLoadedMsg); ok {
+			loaded = true
+			assert.NotEmpty(t, msg.Devices)
+		}
+	}
+	assert.True(t, loaded)
+}
+
+func TestApp_HandleNavigation_NoClientWithCredentials(t *testing.T) {
+	app := newTestApp()
+	app.client = nil
+	app.screen = ScreenHome
+	app.ready = true
+
+	app.handleNavigation("devices", nil)
+
+	assert.Equal(t, ScreenHome, app.screen)
+	require.Error(t, app.err)
+	assert.Contains(t, app.View(), "internal error")
+
+	// Any key dismisses the error
+	app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	assert.NoError(t, app.err)
+}
+
+func TestApp_PacketsPageSizeSetMsg(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	app := newTestApp()
+	app.Update(screens.PacketsPageSizeSetMsg{Size: 250})
+	assert.Equal(t, 250, app.cfg.PacketsPageSize)
+
+	saved, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 250, saved.PacketsPageSize)
+
+	// The packets screen starts with the saved size
+	app.handleNavigation("packets", nil)
+	assert.Equal(t, 250, app.packetsModel.PageSize())
+}
+
+func TestApp_RequestTimeoutSetMsg(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { common.SetRequestTimeout(0) })
+
+	app := newTestApp()
+	client := app.client
+	app.Update(screens.RequestTimeoutSetMsg{Timeout: 2 * time.Minute})
+	assert.Equal(t, 120, app.cfg.RequestTimeoutSeconds)
+	assert.Equal(t, 2*time.Minute, common.RequestTimeout())
+
+	// The client is rebuilt so its requests use the new timeout
+	assert.NotSame(t, client, app.client)
+
+	saved, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 120, saved.RequestTimeoutSeconds)
+}
+
+func TestApp_ScanLocationSetMsg(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	app := newTestApp()
+	app.Update(screens.ScanLocationSetMsg{Location: &location.Manual{Latitude: 37.7749, Longitude: -122.4194}})
+	require.NotNil(t, app.cfg.ScanLocation)
+	assert.Equal(t, 37.7749, app.cfg.ScanLocation.Latitude)
+
+	saved, err := config.Load()
+	require.NoError(t, err)
+	require.NotNil(t, saved.ScanLocation)
+	assert.Equal(t, -122.4194, saved.ScanLocation.Longitude)
+
+	// The settings screen shows the saved location
+	app.handleNavigation("settings", nil)
+	app.Update(tea.WindowSizeMsg{Width: 100, Height: 60})
+	assert.Contains(t, app.View(), "37.7749, -122.4194")
+
+	app.Update(screens.ScanLocationSetMsg{})
+	assert.Nil(t, app.cfg.ScanLocation)
+	saved, err = config.Load()
+	require.NoError(t, err)
+	assert.Nil(t, saved.ScanLocation)
+}
+
+func TestApp_MaxConcurrencyLimitsBulkDelete(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	app := newTestApp()
+	app.cfg.MaxConcurrency = 2
+	client := api.NewClient("test-org", "test-token", append(app.clientOptions(), api.WithBaseURL(server.URL))...)
+
+	errs := client.DeleteDevices(context.Background(), []string{"d1", "d2", "d3", "d4", "d5", "d6"})
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestApp_QuitCancelsLoads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	app := newTestApp()
+	client := api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL))
+	app.devicesModel = screens.NewDevicesModel(client)
+	app.orgInfoModel = screens.NewOrgInfoModel(client)
+
+	// Each screen's Init batches its spinner tick with its load
+	var loads []tea.Cmd
+	for _, start := range []tea.Cmd{app.devicesModel.Init(), app.orgInfoModel.Init()} {
+		batch, ok := start().(tea.BatchMsg)
+		require.True(t, ok)
+		require.Len(t, batch, 2)
+		loads = append(loads, batch[1])
+	}
+
+	results := make(chan tea.Msg, len(loads))
+	for _, load := range loads {
+		go func() { results <- load() }()
+	}
+
+	cmd := app.quit()
+	assert.Equal(t, tea.QuitMsg{}, cmd())
+	for range loads {
+		select {
+		case msg := <-results:
+			assert.Equal(t, common.RequestCancelledMsg{}, msg)
+		case <-time.After(5 * time.Second):
+			t.Fatal("load was not cancelled")
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package common
+
+import "strings"
+
+// barBlocks are the partial block characters used for the fractional end of
+// a bar, from 1/8 to 7/8 of a cell.
+var barBlocks = []rune("▏▎▍▌▋▊▉")
+
+// RenderBar renders value as a horizontal bar scaled so that max fills width
+// cells. Fractional cells use partial block characters; a non-zero value
+// always gets at least a sliver so it isn't mistaken for zero.
+func RenderBar(value, max, width int) string {
+	if value <= 0 || max <= 0 || width <= 0 {
+		return ""
+	}
+	if value > max {
+		value = max
+	}
+
+	eighths := value * width * 8 / max
+	if eighths == 0 {
+		eighths = 1
+	}
+
+	bar := strings.Repeat("█", eighths/8)
+	if rem := eighths % 8; rem > 0 {
+		bar += string(barBlocks[rem-1])
+	}
+	return bar
+}
@@ -0,0 +1,29 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderBar(t *testing.T) {
+	tests := []struct {
+		name            string
+		value, max, wid int
+		expected        string
+	}{
+		{"full", 10, 10, 4, "████"},
+		{"half", 5, 10, 4, "██"},
+		{"fraction", 1, 8, 4, "▌"},
+		{"tiny value shows sliver", 1, 1000, 4, "▏"},
+		{"zero", 0, 10, 4, ""},
+		{"clamped", 20, 10, 2, "██"},
+		{"no max", 3, 0, 4, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RenderBar(tt.value, tt.max, tt.wid))
+		})
+	}
+}
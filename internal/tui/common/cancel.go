@@ -0,0 +1,65 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/api"
+)
+
+// ErrCancelled is shown when the user cancels a request before it finishes
+var ErrCancelled = errors.New("cancelled")
+
+// requestTimeout bounds each API request a screen makes.
+var requestTimeout = api.DefaultTimeout
+
+// SetRequestTimeout sets the timeout for API requests made by screens. A
+// value of 0 or less restores the default, api.DefaultTimeout.
+func SetRequestTimeout(d time.Duration) {
+	if d <= 0 {
+		d = api.DefaultTimeout
+	}
+	requestTimeout = d
+}
+
+// RequestTimeout returns the timeout for API requests made by screens.
+func RequestTimeout() time.Duration {
+	return requestTimeout
+}
+
+// RequestCancelledMsg is sent by a command whose request was cancelled.
+// The screen has already moved on when the user cancelled, so it needs no
+// handling.
+type RequestCancelledMsg struct{}
+
+// Canceller lets the user cancel a screen's request in flight rather than
+// waiting for it to time out. Screen models hold a *Canceller so the copy
+// that builds a command in Init shares it with later copies.
+type Canceller struct {
+	cancel context.CancelFunc
+}
+
+// WithTimeout returns the context for a new request, which Cancel cancels.
+// The command must call the returned cancel func when it finishes.
+func (c *Canceller) WithTimeout(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	if c != nil {
+		c.cancel = cancel
+	}
+	return ctx, cancel
+}
+
+// Cancel cancels the latest request, if any
+func (c *Canceller) Cancel() {
+	if c != nil && c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+}
+
+// Cancelled reports whether err came from a request whose context was
+// cancelled, as opposed to one that failed or timed out
+func Cancelled(ctx context.Context, err error) bool {
+	return err != nil && errors.Is(ctx.Err(), context.Canceled)
+}
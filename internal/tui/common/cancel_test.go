@@ -0,0 +1,61 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hubblenetwork/hubcli/internal/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanceller(t *testing.T) {
+	var c Canceller
+
+	first, cancelFirst := c.WithTimeout(time.Minute)
+	defer cancelFirst()
+	second, cancelSecond := c.WithTimeout(time.Minute)
+	defer cancelSecond()
+
+	// Only the latest request is cancelled
+	c.Cancel()
+	assert.NoError(t, first.Err())
+	assert.ErrorIs(t, second.Err(), context.Canceled)
+
+	// Cancelling again, or a nil Canceller, does nothing
+	c.Cancel()
+	var none *Canceller
+	none.Cancel()
+	ctx, cancel := none.WithTimeout(time.Minute)
+	defer cancel()
+	assert.NoError(t, ctx.Err())
+}
+
+func TestCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	failed := errors.New("request failed")
+	assert.False(t, Cancelled(ctx, failed))
+
+	cancel()
+	assert.True(t, Cancelled(ctx, failed))
+	assert.False(t, Cancelled(ctx, nil))
+
+	// A timeout is a failure, not a cancellation
+	expired, cancelExpired := context.WithTimeout(context.Background(), 0)
+	defer cancelExpired()
+	<-expired.Done()
+	assert.False(t, Cancelled(expired, failed))
+}
+
+func TestSetRequestTimeout(t *testing.T) {
+	t.Cleanup(func() { SetRequestTimeout(0) })
+
+	assert.Equal(t, api.DefaultTimeout, RequestTimeout())
+
+	SetRequestTimeout(2 * time.Minute)
+	assert.Equal(t, 2*time.Minute, RequestTimeout())
+
+	SetRequestTimeout(-time.Second)
+	assert.Equal(t, api.DefaultTimeout, RequestTimeout())
+}
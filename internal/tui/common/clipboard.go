@@ -0,0 +1,67 @@
+package common
+
+import (
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hubblenetwork/hubcli/internal/clipboard"
+)
+
+// ClipboardCopiedMsg is sent after a CopyToClipboard command completes.
+// Label describes what was copied, for use in a status message.
+type ClipboardCopiedMsg struct {
+	Label string
+	Err   error
+}
+
+// CopyToClipboard returns a command that writes text to the system clipboard.
+func CopyToClipboard(label, text string) tea.Cmd {
+	return func() tea.Msg {
+		return ClipboardCopiedMsg{Label: label, Err: clipboard.Copy(text)}
+	}
+}
+
+// FlashDuration is how long a Flash stays on screen
+const FlashDuration = 3 * time.Second
+
+// FlashExpiredMsg is sent when the Flash set with ID expires
+type FlashExpiredMsg struct {
+	ID int
+}
+
+// lastFlashID keeps flash IDs unique across screens
+var lastFlashID atomic.Int64
+
+// Flash is a brief status message, such as a copy confirmation, that
+// clears itself after FlashDuration. Screens pass FlashExpiredMsg to Expire.
+type Flash struct {
+	id   int
+	text string
+}
+
+// Set shows text and returns the command that expires it
+func (f *Flash) Set(text string) tea.Cmd {
+	id := int(lastFlashID.Add(1))
+	f.id, f.text = id, text
+	return tea.Tick(FlashDuration, func(time.Time) tea.Msg {
+		return FlashExpiredMsg{ID: id}
+	})
+}
+
+// Expire clears the text if msg is for the text still shown
+func (f *Flash) Expire(msg FlashExpiredMsg) {
+	if msg.ID == f.id {
+		f.text = ""
+	}
+}
+
+// ID identifies the text currently set; its FlashExpiredMsg carries the same ID
+func (f Flash) ID() int {
+	return f.id
+}
+
+// Text returns the text to show, "" once expired
+func (f Flash) Text() string {
+	return f.text
+}
@@ -0,0 +1,25 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlash(t *testing.T) {
+	var f Flash
+	assert.Empty(t, f.Text())
+
+	assert.NotNil(t, f.Set("Copied device ID"))
+	assert.Equal(t, "Copied device ID", f.Text())
+	first := f.ID()
+
+	// A newer flash isn't cleared by the older one expiring
+	f.Set("Copied org ID")
+	assert.NotEqual(t, first, f.ID())
+	f.Expire(FlashExpiredMsg{ID: first})
+	assert.Equal(t, "Copied org ID", f.Text())
+
+	f.Expire(FlashExpiredMsg{ID: f.ID()})
+	assert.Empty(t, f.Text())
+}
@@ -0,0 +1,106 @@
+package common
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// Span is a run of display columns [Start, End) in a rendered line.
+type Span struct {
+	Start int
+	End   int
+}
+
+// LineText returns the text shown in display columns [start, end) of a
+// rendered line, without its escape sequences.
+func LineText(line string, start, end int) string {
+	var b strings.Builder
+	col := 0
+	walkLine(line, func(seg string, esc bool) {
+		if esc {
+			return
+		}
+		if col >= start && col < end {
+			b.WriteString(seg)
+		}
+		col += runewidth.StringWidth(seg)
+	})
+	return b.String()
+}
+
+// HighlightSpans renders the display columns of line covered by spans in
+// style. Spans must be sorted and must not overlap. Since a rendered style
+// ends by resetting all attributes, the escape sequences that open the line,
+// such as a selected table row's style, are repeated after each span.
+func HighlightSpans(line string, spans []Span, style lipgloss.Style) string {
+	if len(spans) == 0 {
+		return line
+	}
+
+	var out, lead, match strings.Builder
+	flush := func() {
+		if match.Len() > 0 {
+			out.WriteString(style.Render(match.String()))
+			out.WriteString(lead.String())
+			match.Reset()
+		}
+	}
+
+	col, next := 0, 0
+	started := false
+	walkLine(line, func(seg string, esc bool) {
+		if esc {
+			if !started {
+				lead.WriteString(seg)
+			}
+			// Styling inside a span is replaced by the highlight
+			if match.Len() == 0 {
+				out.WriteString(seg)
+			}
+			return
+		}
+		started = true
+
+		if next < len(spans) && col >= spans[next].Start && col < spans[next].End {
+			match.WriteString(seg)
+		} else {
+			out.WriteString(seg)
+		}
+		col += runewidth.StringWidth(seg)
+
+		if next < len(spans) && col >= spans[next].End {
+			flush()
+			next++
+		}
+	})
+	flush()
+
+	return out.String()
+}
+
+// walkLine calls fn with each escape sequence and each rune of line in
+// order. Only CSI sequences, which is all lipgloss emits, are recognized in
+// full; other escapes are taken to be two bytes long.
+func walkLine(line string, fn func(seg string, esc bool)) {
+	for i := 0; i < len(line); {
+		if line[i] != '\x1b' {
+			_, size := utf8.DecodeRuneInString(line[i:])
+			fn(line[i:i+size], false)
+			i += size
+			continue
+		}
+
+		end := min(i+2, len(line))
+		if end == i+2 && line[i+1] == '[' {
+			for end < len(line) && (line[end] < 0x40 || line[end] > 0x7e) {
+				end++
+			}
+			end = min(end+1, len(line))
+		}
+		fn(line[i:end], true)
+		i = end
+	}
+}
@@ -0,0 +1,48 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineText(t *testing.T) {
+	line := "\x1b[1;48;2;1;2;3m ab  ✓ cd \x1b[0m"
+
+	assert.Equal(t, "ab", LineText(line, 1, 3))
+	assert.Equal(t, "✓ cd", LineText(line, 5, 9))
+	assert.Equal(t, " ab  ✓ cd ", LineText(line, 0, 100))
+	assert.Equal(t, "", LineText(line, 20, 30))
+}
+
+func TestHighlightSpans(t *testing.T) {
+	// Transform applies without a color profile, so it stands in for styling
+	upper := lipgloss.NewStyle().Transform(strings.ToUpper)
+
+	tests := []struct {
+		name     string
+		line     string
+		spans    []Span
+		expected string
+	}{
+		{"no spans", "device-1", nil, "device-1"},
+		{"one span", "device-1 pump", []Span{{9, 13}}, "device-1 PUMP"},
+		{"two spans", "abc def", []Span{{0, 1}, {4, 6}}, "Abc DEf"},
+		{"span past end", "abc", []Span{{1, 10}}, "aBC"},
+		{"wide runes", "✓ ab", []Span{{2, 3}}, "✓ Ab"},
+		{
+			"line style repeated after span",
+			"\x1b[1mab cd\x1b[0m",
+			[]Span{{0, 2}},
+			"\x1b[1mAB\x1b[1m cd\x1b[0m",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, HighlightSpans(tt.line, tt.spans, upper))
+		})
+	}
+}
@@ -175,18 +175,20 @@ func (k MenuKeyMap) FullHelp() [][]key.Binding {
 
 // ListKeyMap defines key bindings for list/table screens
 type ListKeyMap struct {
-	Up      key.Binding
-	Down    key.Binding
-	Left    key.Binding
-	Right   key.Binding
-	Top     key.Binding
-	Bottom  key.Binding
-	Select  key.Binding
-	Back    key.Binding
-	Refresh key.Binding
-	Search  key.Binding
-	Quit    key.Binding
-	Help    key.Binding
+	Up       key.Binding
+	Down     key.Binding
+	Left     key.Binding
+	Right    key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Select   key.Binding
+	Back     key.Binding
+	Refresh  key.Binding
+	Search   key.Binding
+	Quit     key.Binding
+	Help     key.Binding
 }
 
 // DefaultListKeyMap returns key bindings for list screens
@@ -209,12 +211,20 @@ func DefaultListKeyMap() ListKeyMap {
 			key.WithHelp("→/l", "right"),
 		),
 		Top: key.NewBinding(
-			key.WithKeys("g"),
-			key.WithHelp("g", "top"),
+			key.WithKeys("g", "home"),
+			key.WithHelp("g/home", "top"),
 		),
 		Bottom: key.NewBinding(
-			key.WithKeys("G"),
-			key.WithHelp("G", "bottom"),
+			key.WithKeys("G", "end"),
+			key.WithHelp("G/end", "bottom"),
+		),
+		PageUp: key.NewBinding(
+			key.WithKeys("pgup"),
+			key.WithHelp("pgup", "page up"),
+		),
+		PageDown: key.NewBinding(
+			key.WithKeys("pgdown"),
+			key.WithHelp("pgdn", "page down"),
 		),
 		Select: key.NewBinding(
 			key.WithKeys("enter"),
@@ -251,7 +261,7 @@ func (k ListKeyMap) ShortHelp() []key.Binding {
 // FullHelp returns full help for list screen
 func (k ListKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.Top, k.Bottom},
+		{k.Up, k.Down, k.Top, k.Bottom, k.PageUp, k.PageDown},
 		{k.Select, k.Back, k.Refresh},
 		{k.Search, k.Quit, k.Help},
 	}
@@ -139,6 +139,20 @@ var (
 	TableSelectedRowStyle = lipgloss.NewStyle().
 				Background(ColorPrimary).
 				Foreground(ColorForeground)
+
+	// TableMatchStyle marks the part of a cell that matched a filter. It
+	// avoids colors so it stays visible on the selected row.
+	TableMatchStyle = lipgloss.NewStyle().
+			Bold(true).
+			Underline(true)
+
+	// TableHighContrastSelectedRowStyle is used for the selected row when
+	// high-contrast mode is enabled.
+	TableHighContrastSelectedRowStyle = lipgloss.NewStyle().
+						Background(ColorWarning).
+						Foreground(lipgloss.Color("#000000")).
+						Bold(true).
+						Underline(true)
 )
 
 // Logo returns the Hubble CLI ASCII art logo
@@ -0,0 +1,227 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SelectionMarker is drawn in front of the selected table row so the
+// selection stays visible without relying on color alone.
+const SelectionMarker = "▸ "
+
+// SelectionMarkerWidth is the width RenderTable adds to the first column to
+// make room for SelectionMarker. Screens subtract it when sizing columns.
+var SelectionMarkerWidth = lipgloss.Width(SelectionMarker)
+
+// DefaultTableHeight is the table height, including the header, used until
+// a screen knows the terminal size.
+const DefaultTableHeight = 10
+
+// MinTableHeight is the smallest height FitTableHeight returns, so the
+// header and a few rows stay visible on very small terminals.
+const MinTableHeight = 5
+
+// highContrast selects the high-contrast table selection style.
+var highContrast bool
+
+// SetHighContrast enables or disables the high-contrast selection style.
+// Tables pick up the change the next time their styles are built.
+func SetHighContrast(enabled bool) {
+	highContrast = enabled
+}
+
+// HighContrast reports whether the high-contrast selection style is enabled.
+func HighContrast() bool {
+	return highContrast
+}
+
+// MoveTableCursor handles the jump and page keys of keys for t: to the
+// first or last row, or up or down by a screenful of rows. It reports
+// whether msg was one of them.
+func MoveTableCursor(t *table.Model, keys ListKeyMap, msg tea.KeyMsg) bool {
+	switch {
+	case key.Matches(msg, keys.Top):
+		t.GotoTop()
+	case key.Matches(msg, keys.Bottom):
+		t.GotoBottom()
+	case key.Matches(msg, keys.PageUp):
+		t.MoveUp(t.Height())
+	case key.Matches(msg, keys.PageDown):
+		t.MoveDown(t.Height())
+	default:
+		return false
+	}
+	return true
+}
+
+// TableStyles returns the shared table styles used by all screens.
+func TableStyles() table.Styles {
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(ColorBorder).
+		BorderBottom(true).
+		Bold(true).
+		Foreground(ColorSecondary)
+	s.Selected = TableSelectedStyle()
+	return s
+}
+
+// TableSelectedStyle returns the selected-row style for the current
+// contrast setting.
+func TableSelectedStyle() lipgloss.Style {
+	if highContrast {
+		return TableHighContrastSelectedRowStyle
+	}
+	return table.DefaultStyles().Selected.
+		Foreground(ColorForeground).
+		Background(ColorPrimary).
+		Bold(true)
+}
+
+// FitTableHeight returns the table height, including the header, that fills
+// the total lines left over by the content rendered above and below the
+// table. above is everything written before the table and below everything
+// written after it, exactly as they appear in the view; both are wrapped to
+// width (if positive) and measured, so adding a subtitle or a help line
+// shrinks the table instead of pushing it off screen.
+func FitTableHeight(total, width int, above, below string) int {
+	h := total - chromeHeight(above, width) - chromeHeight(below, width)
+	if h < MinTableHeight {
+		return MinTableHeight
+	}
+	return h
+}
+
+// TableFrameWidth returns the width a table drawn with styles adds to the sum
+// of its column widths. Rows fit within the column widths, but each header
+// cell adds its padding and border, making the header the widest line.
+// Screens subtract it, and SelectionMarkerWidth, when sizing columns so the
+// header doesn't wrap.
+func TableFrameWidth(styles table.Styles, columns int) int {
+	return columns * styles.Header.GetHorizontalFrameSize()
+}
+
+// chromeHeight returns the number of lines s takes up next to a table. The
+// last line of text above a table and the first line of text below it share
+// a line with the table, so they are not counted.
+func chromeHeight(s string, width int) int {
+	if s == "" {
+		return 0
+	}
+	if width > 0 {
+		s = lipgloss.NewStyle().Width(width).Render(s)
+	}
+	return lipgloss.Height(s) - 1
+}
+
+// SortableColumnTitles decorates column titles for a sortable table: the
+// sorted column gets a ↑/↓ direction indicator and the column selected for
+// sorting is wrapped in brackets. Out-of-range indexes are ignored, so -1
+// disables either decoration. The input slice is not modified.
+func SortableColumnTitles(titles []string, sortColumn int, sortAsc bool, selectedColumn int) []string {
+	out := make([]string, len(titles))
+	copy(out, titles)
+
+	if sortColumn >= 0 && sortColumn < len(out) {
+		if sortAsc {
+			out[sortColumn] += " ↑"
+		} else {
+			out[sortColumn] += " ↓"
+		}
+	}
+
+	if selectedColumn >= 0 && selectedColumn < len(out) {
+		out[selectedColumn] = "[" + out[selectedColumn] + "]"
+	}
+
+	return out
+}
+
+// ColumnSort is the sort state of a table whose columns are selected with
+// left/right and sorted with 's'
+type ColumnSort struct {
+	Column   int  // Column currently being sorted
+	Asc      bool // Whether Column is sorted ascending
+	Selected int  // Column selected for sorting, shown in brackets
+	Columns  int  // Number of sortable columns
+}
+
+// SelectLeft moves the selection one column left, wrapping to the last
+func (s *ColumnSort) SelectLeft() {
+	if s.Columns > 0 {
+		s.Selected = (s.Selected + s.Columns - 1) % s.Columns
+	}
+}
+
+// SelectRight moves the selection one column right, wrapping to the first
+func (s *ColumnSort) SelectRight() {
+	if s.Columns > 0 {
+		s.Selected = (s.Selected + 1) % s.Columns
+	}
+}
+
+// SortSelected sorts by the selected column. Picking the column already
+// sorted flips its direction; any other column starts in direction asc.
+func (s *ColumnSort) SortSelected(asc bool) {
+	if s.Column == s.Selected {
+		s.Asc = !s.Asc
+		return
+	}
+	s.Column = s.Selected
+	s.Asc = asc
+}
+
+// Titles decorates titles with the sort indicator and selection brackets
+func (s ColumnSort) Titles(titles []string) []string {
+	return SortableColumnTitles(titles, s.Column, s.Asc, s.Selected)
+}
+
+// RenderTable renders the table with SelectionMarker in front of the
+// selected row. The first column is widened by SelectionMarkerWidth to make
+// room for the marker, so its cells aren't cut short. The underlying rows
+// and columns are left untouched, so SelectedRow keeps returning the
+// original cell values.
+func RenderTable(t table.Model) string {
+	// t is a copy, so replacing its columns and rows does not affect the
+	// caller's model
+	columns := t.Columns()
+	if len(columns) > 0 {
+		widened := make([]table.Column, len(columns))
+		copy(widened, columns)
+		widened[0].Width += SelectionMarkerWidth
+		t.SetColumns(widened)
+		if t.Width() > 0 {
+			t.SetWidth(t.Width() + SelectionMarkerWidth)
+		}
+	}
+
+	rows := t.Rows()
+	cursor := t.Cursor()
+	if cursor < 0 || cursor >= len(rows) {
+		return t.View()
+	}
+
+	padding := strings.Repeat(" ", SelectionMarkerWidth)
+	marked := make([]table.Row, len(rows))
+	for i, row := range rows {
+		if len(row) == 0 {
+			marked[i] = row
+			continue
+		}
+		prefix := padding
+		if i == cursor {
+			prefix = SelectionMarker
+		}
+		r := make(table.Row, len(row))
+		copy(r, row)
+		r[0] = prefix + r[0]
+		marked[i] = r
+	}
+	t.SetRows(marked)
+	return t.View()
+}
@@ -0,0 +1,165 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTable() table.Model {
+	t := table.New(
+		table.WithColumns([]table.Column{{Title: "ID", Width: 10}}),
+		table.WithRows([]table.Row{{"first"}, {"second"}}),
+		table.WithHeight(5),
+	)
+	t.SetStyles(TableStyles())
+	return t
+}
+
+func TestRenderTable_MarksSelectedRow(t *testing.T) {
+	tbl := newTestTable()
+	tbl.SetCursor(1)
+
+	view := RenderTable(tbl)
+
+	assert.Contains(t, view, SelectionMarker+"second")
+	assert.NotContains(t, view, SelectionMarker+"first")
+}
+
+func TestRenderTable_LeavesRowsUntouched(t *testing.T) {
+	tbl := newTestTable()
+
+	_ = RenderTable(tbl)
+
+	assert.Equal(t, table.Row{"first"}, tbl.SelectedRow())
+	for _, row := range tbl.Rows() {
+		assert.False(t, strings.HasPrefix(row[0], SelectionMarker))
+	}
+}
+
+func TestRenderTable_WidensFirstColumn(t *testing.T) {
+	tbl := table.New(
+		table.WithColumns([]table.Column{{Title: "ID", Width: 10}, {Title: "Name", Width: 6}}),
+		table.WithRows([]table.Row{{"0123456789", "pump"}, {"abcdefghij", "valve"}}),
+		table.WithHeight(5),
+		table.WithWidth(20), // Column widths plus each cell's padding
+	)
+	tbl.SetCursor(1)
+
+	view := RenderTable(tbl)
+
+	// The first column's cells are shown in full next to the marker, and
+	// the table is widened so the last column isn't cut off instead
+	assert.Contains(t, view, "   0123456789  pump")
+	assert.Contains(t, view, SelectionMarker+"abcdefghij  valve")
+	assert.Equal(t, 10, tbl.Columns()[0].Width)
+	assert.Equal(t, 20, tbl.Width())
+}
+
+func TestTableSelectedStyle_HighContrast(t *testing.T) {
+	defer SetHighContrast(false)
+
+	SetHighContrast(false)
+	assert.NotEqual(t, TableHighContrastSelectedRowStyle.GetBackground(), TableSelectedStyle().GetBackground())
+
+	SetHighContrast(true)
+	assert.True(t, HighContrast())
+	assert.Equal(t, TableHighContrastSelectedRowStyle.GetBackground(), TableSelectedStyle().GetBackground())
+}
+
+func TestSortableColumnTitles(t *testing.T) {
+	titles := []string{"ID", "Name", "Created"}
+
+	assert.Equal(t, []string{"ID ↑", "[Name]", "Created"}, SortableColumnTitles(titles, 0, true, 1))
+	assert.Equal(t, []string{"ID", "Name", "[Created ↓]"}, SortableColumnTitles(titles, 2, false, 2))
+	assert.Equal(t, []string{"ID", "Name", "Created"}, SortableColumnTitles(titles, -1, true, 5))
+
+	// Input is left untouched
+	assert.Equal(t, []string{"ID", "Name", "Created"}, titles)
+}
+
+func TestColumnSort(t *testing.T) {
+	s := ColumnSort{Column: 2, Selected: 2, Columns: 3}
+
+	// Selection wraps both ways
+	s.SelectRight()
+	assert.Equal(t, 0, s.Selected)
+	s.SelectLeft()
+	assert.Equal(t, 2, s.Selected)
+	s.SelectLeft()
+	assert.Equal(t, 1, s.Selected)
+
+	// A new column starts in the given direction
+	s.SortSelected(true)
+	assert.Equal(t, 1, s.Column)
+	assert.True(t, s.Asc)
+	assert.Equal(t, []string{"ID", "[Name ↑]", "Created"}, s.Titles([]string{"ID", "Name", "Created"}))
+
+	// Sorting it again flips the direction
+	s.SortSelected(true)
+	assert.False(t, s.Asc)
+}
+
+func TestFitTableHeight(t *testing.T) {
+	// Title and a blank line above, a blank line and help below
+	assert.Equal(t, 36, FitTableHeight(40, 0, "Title\n\n", "\n\nhelp"))
+
+	// An extra help line takes a row from the table
+	assert.Equal(t, 35, FitTableHeight(40, 0, "Title\n\n", "\n\nhelp\nmore help"))
+
+	// Text wider than width wraps and is measured as rendered
+	assert.Equal(t, 35, FitTableHeight(40, 10, "Title\n\n", "\n\nhelp help help"))
+
+	// Nothing around the table
+	assert.Equal(t, 40, FitTableHeight(40, 0, "", ""))
+
+	// Tiny terminals keep a usable table
+	assert.Equal(t, MinTableHeight, FitTableHeight(3, 0, "Title\n\n", "\n\nhelp"))
+}
+
+func TestTableFrameWidth(t *testing.T) {
+	// One column of header padding either side of each cell
+	assert.Equal(t, 0, TableFrameWidth(TableStyles(), 0))
+	assert.Equal(t, 8, TableFrameWidth(TableStyles(), 4))
+
+	// Matches the rendered header
+	tbl := newTestTable()
+	header := strings.Split(tbl.View(), "\n")[0]
+	assert.Equal(t, 10+TableFrameWidth(TableStyles(), 1), lipgloss.Width(header))
+}
+
+func TestMoveTableCursor(t *testing.T) {
+	rows := make([]table.Row, 30)
+	for i := range rows {
+		rows[i] = table.Row{strings.Repeat("x", i)}
+	}
+	tbl := table.New(
+		table.WithColumns([]table.Column{{Title: "ID", Width: 10}}),
+		table.WithRows(rows),
+		table.WithHeight(DefaultTableHeight),
+	)
+	keys := DefaultListKeyMap()
+	page := tbl.Height()
+	press := func(k tea.KeyMsg) bool { return MoveTableCursor(&tbl, keys, k) }
+
+	assert.True(t, press(tea.KeyMsg{Type: tea.KeyPgDown}))
+	assert.Equal(t, page, tbl.Cursor())
+	assert.True(t, press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'G'}}))
+	assert.Equal(t, 29, tbl.Cursor())
+	assert.True(t, press(tea.KeyMsg{Type: tea.KeyPgUp}))
+	assert.Equal(t, 29-page, tbl.Cursor())
+	assert.True(t, press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}}))
+	assert.Equal(t, 0, tbl.Cursor())
+	assert.True(t, press(tea.KeyMsg{Type: tea.KeyEnd}))
+	assert.Equal(t, 29, tbl.Cursor())
+	assert.True(t, press(tea.KeyMsg{Type: tea.KeyHome}))
+	assert.Equal(t, 0, tbl.Cursor())
+
+	// Other keys are left to the caller
+	assert.False(t, press(tea.KeyMsg{Type: tea.KeyDown}))
+	assert.Equal(t, 0, tbl.Cursor())
+}
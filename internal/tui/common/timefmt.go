@@ -0,0 +1,35 @@
+package common
+
+import "time"
+
+// utcTimes selects UTC instead of local time for displayed timestamps.
+var utcTimes bool
+
+// SetUTC switches displayed timestamps between UTC and local time.
+// Screens pick up the change the next time they render their rows.
+func SetUTC(enabled bool) {
+	utcTimes = enabled
+}
+
+// UTC reports whether timestamps are displayed in UTC.
+func UTC() bool {
+	return utcTimes
+}
+
+// DisplayLocation returns the time zone timestamps are displayed in.
+func DisplayLocation() *time.Location {
+	if utcTimes {
+		return time.UTC
+	}
+	return time.Local
+}
+
+// TimeZoneWidth is the room FormatTime needs beyond the layout for the zone
+// suffix, e.g. " UTC" or " +0530".
+const TimeZoneWidth = 6
+
+// FormatTime formats t with layout in the display time zone and appends the
+// zone abbreviation, so it is always clear which zone a timestamp is in.
+func FormatTime(t time.Time, layout string) string {
+	return t.In(DisplayLocation()).Format(layout + " MST")
+}
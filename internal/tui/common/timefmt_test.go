@@ -0,0 +1,20 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatTime(t *testing.T) {
+	defer SetUTC(false)
+	ts := time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("X", 2*60*60))
+
+	SetUTC(true)
+	assert.True(t, UTC())
+	assert.Equal(t, "2024-03-01 10:30 UTC", FormatTime(ts, "2006-01-02 15:04"))
+
+	SetUTC(false)
+	assert.Equal(t, ts.Local().Format("2006-01-02 15:04 MST"), FormatTime(ts, "2006-01-02 15:04"))
+}
@@ -2,8 +2,10 @@ package screens
 
 import (
 	"context"
-	"encoding/binary"
 	"fmt"
+	"io"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,14 +13,44 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/hubblenetwork/hubcli/internal/api"
 	"github.com/hubblenetwork/hubcli/internal/ble"
+	"github.com/hubblenetwork/hubcli/internal/crypto"
+	"github.com/hubblenetwork/hubcli/internal/export"
+	"github.com/hubblenetwork/hubcli/internal/location"
 	"github.com/hubblenetwork/hubcli/internal/models"
 	"github.com/hubblenetwork/hubcli/internal/tui/common"
 )
 
+// DefaultScanRedrawInterval is the default minimum time between scan table
+// rebuilds while packets are streaming in
+const DefaultScanRedrawInterval = 200 * time.Millisecond
+
+// DefaultScanDedupeWindow is the default window in which repeats of a
+// device's advertisement are collapsed into one captured packet. Beacons
+// repeat an advertisement many times per second.
+const DefaultScanDedupeWindow = time.Second
+
+// scanDurations maps the duration keys to the length of a timed scan; 0
+// scans continuously
+var scanDurations = map[string]time.Duration{
+	"1": 10 * time.Second,
+	"3": 30 * time.Second,
+	"6": 60 * time.Second,
+	"0": 0,
+}
+
+// Range and step of the adjustable RSSI floor, in dBm. Lowering the floor
+// past minRSSIFloor turns it off.
+const (
+	minRSSIFloor  = -100
+	maxRSSIFloor  = -30
+	rssiFloorStep = 5
+)
+
 // BLEScanState represents the current state of the BLE scan screen
 type BLEScanState int
 
@@ -26,6 +58,7 @@ const (
 	BLEScanStateInit BLEScanState = iota // Initial state before scanning starts
 	BLEScanStateScanning
 	BLEScanStateError
+	BLEScanStateIngesting // Uploading captured packets
 )
 
 // BLE scan messages
@@ -44,15 +77,80 @@ type (
 	// BLEScanStoppedMsg indicates scanning has stopped
 	BLEScanStoppedMsg struct {
 		Error error
+
+		// Results is the channel that closed, if the stop came from the
+		// scan ending. Stops from a scan that has since been restarted are
+		// ignored.
+		Results <-chan ble.ScanResult
 	}
 
 	// BLEScanTickMsg is sent periodically during scanning
 	BLEScanTickMsg struct{}
+
+	// BLEScanFleetLoadedMsg is sent when the org's devices have been fetched
+	// for identifying captured packets
+	BLEScanFleetLoadedMsg struct {
+		Devices []models.Device
+		Err     error
+		Reload  bool // Requested with the reload keys action
+	}
+
+	// BLEScanIdentifiedMsg is sent when a captured packet has been matched
+	// (or not) against the fleet
+	BLEScanIdentifiedMsg struct {
+		Generation int // Capture generation, bumped when packets are cleared
+		Index      int
+		Name       string
+		Decrypted  []byte // Decrypted payload, nil if no device key matched
+	}
+
+	// BLEScanExportedMsg is sent when the captured packets have been
+	// written to a file
+	BLEScanExportedMsg struct {
+		Path  string
+		Count int
+		Err   error
+	}
+
+	// BLEScanAdaptersMsg is sent with the Bluetooth adapters available to
+	// scan with
+	BLEScanAdaptersMsg struct {
+		Adapters []ble.AdapterInfo
+		Err      error
+	}
+
+	// BLEScanAdapterOpenedMsg is sent when a selected adapter is ready to
+	// scan with
+	BLEScanAdapterOpenedMsg struct {
+		ID      string
+		Scanner ble.ScannerInterface
+		Err     error
+	}
+
+	// BLEIngestCompleteMsg is sent when captured packets have been uploaded
+	BLEIngestCompleteMsg struct {
+		Report api.IngestReport
+		Err    error
+	}
+
+	// ScanLocationSetMsg is sent when the user sets the capture location,
+	// or clears it when Location is nil, so it can be saved to the config
+	ScanLocationSetMsg struct {
+		Location *location.Manual
+	}
+
+	// BLEScanCaptureSavedMsg is sent when the capture has been saved for
+	// replay with --replay
+	BLEScanCaptureSavedMsg struct {
+		Path  string
+		Count int
+		Err   error
+	}
 )
 
 // BLEScanModel is the model for the BLE scan screen
 type BLEScanModel struct {
-	client      *api.Client
+	client      api.APIClient
 	scanner     ble.ScannerInterface
 	packets     []models.EncryptedPacket
 	rawPackets  []ble.RawAdvertisement
@@ -69,6 +167,60 @@ type BLEScanModel struct {
 	height      int
 	scannerErr  error // Error from initializing scanner
 	resultsChan <-chan ble.ScanResult
+
+	// Fleet identification
+	fleet       []models.Device
+	fleetLoaded bool
+	fleetErr    error
+	deviceNames []string // Matched device name per packet ("" while pending)
+	decrypted   [][]byte // Decrypted payload per packet, nil if not matched
+	generation  int      // Bumped on clear so stale identifications are dropped
+	showDecrypt bool     // Show the Decrypted column
+	grouped     bool     // One row per ephemeral device ID instead of per packet
+
+	// Table redraws are coalesced so high packet rates don't re-render
+	// constantly; packets are still captured as they arrive.
+	redrawInterval time.Duration // Minimum time between table rebuilds (0 = every packet)
+	dedupeWindow   time.Duration // Repeats of an advertisement within it are collapsed (0 = off)
+	lastRedraw     time.Time
+	tableDirty     bool // Packets changed since the last rebuild
+	frozen         bool // Table display is frozen; capture continues
+	now            func() time.Time
+
+	// Location attached to captured packets. The manual location wins;
+	// without one the platform provider is tried, then a placeholder.
+	scanLocation     *location.Manual
+	locationProvider location.Provider
+	editingLocation  bool
+	locationInput    textinput.Model
+	locationErr      string
+
+	// Advertisements weaker than this many dBm are dropped (0 = off)
+	minRSSI int
+
+	// Timed scans stop by themselves after scanDuration (0 = continuous)
+	scanDuration   time.Duration
+	scanDeadline   time.Time // When the running timed scan stops
+	scanStartCount int       // Packets captured before the timed scan began
+
+	// rowIndex maps each table row to its index in packets/rawPackets.
+	// Rows are displayed newest first, so row 0 is the last packet.
+	rowIndex []int
+
+	// Raw advertisement detail
+	showDetail  bool
+	detailIndex int    // Index into packets/rawPackets of the packet shown
+	notice      string // Status message, e.g. after copying
+
+	exportPrompt bool // Waiting for the export format key
+
+	// Adapter selection
+	adapterID      string // Adapter in use; "" is the default
+	adapters       []ble.AdapterInfo
+	pickingAdapter bool
+	adapterCursor  int
+	listAdapters   func() ([]ble.AdapterInfo, error)
+	openAdapter    func(id string) (ble.ScannerInterface, error)
 }
 
 // bleScanKeyMap defines key bindings for the BLE scan screen
@@ -76,6 +228,20 @@ type bleScanKeyMap struct {
 	Pause  key.Binding
 	Resume key.Binding
 	Clear  key.Binding
+	Freeze key.Binding
+	Reload  key.Binding
+	Decrypt key.Binding
+	Export  key.Binding
+	Save    key.Binding
+	Group     key.Binding
+	Adapter   key.Binding
+	Location  key.Binding
+	Duration  key.Binding
+	Ingest    key.Binding
+	RaiseRSSI key.Binding
+	LowerRSSI key.Binding
+	Detail  key.Binding
+	Copy   key.Binding
 	Back   key.Binding
 	Quit   key.Binding
 }
@@ -94,6 +260,62 @@ func defaultBLEScanKeyMap() bleScanKeyMap {
 			key.WithKeys("c"),
 			key.WithHelp("c", "clear"),
 		),
+		Freeze: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "freeze"),
+		),
+		Reload: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "reload keys"),
+		),
+		Decrypt: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "decrypt"),
+		),
+		Export: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "export"),
+		),
+		Save: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "save capture"),
+		),
+		Group: key.NewBinding(
+			key.WithKeys("g"),
+			key.WithHelp("g", "group by device"),
+		),
+		Adapter: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "adapter"),
+		),
+		Location: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "set location"),
+		),
+		Duration: key.NewBinding(
+			key.WithKeys("1", "3", "6", "0"),
+			key.WithHelp("1/3/6/0", "scan 10/30/60s or continuously"),
+		),
+		Ingest: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "ingest"),
+		),
+		RaiseRSSI: key.NewBinding(
+			key.WithKeys("+", "="),
+			key.WithHelp("+", "raise min RSSI"),
+		),
+		LowerRSSI: key.NewBinding(
+			key.WithKeys("-"),
+			key.WithHelp("-", "lower min RSSI"),
+		),
+		Detail: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "details"),
+		),
+		Copy: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy hex"),
+		),
 		Back: key.NewBinding(
 			key.WithKeys("esc"),
 			key.WithHelp("esc", "back"),
@@ -105,15 +327,29 @@ func defaultBLEScanKeyMap() bleScanKeyMap {
 	}
 }
 
+// bleScanTableStyles returns the styles for the scan table
+func bleScanTableStyles() table.Styles {
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		Bold(true).
+		Foreground(common.ColorSecondary).
+		BorderStyle(lipgloss.HiddenBorder())
+	s.Cell = s.Cell.
+		BorderStyle(lipgloss.HiddenBorder())
+	s.Selected = common.TableSelectedStyle()
+	return s
+}
+
 // NewBLEScanModel creates a new BLE scan screen model
-func NewBLEScanModel(client *api.Client) BLEScanModel {
+func NewBLEScanModel(client api.APIClient) BLEScanModel {
 	columns := []table.Column{
 		{Title: "#", Width: 4},
-		{Title: "Time", Width: 13},
+		{Title: "Time", Width: 18},
 		{Title: "RSSI", Width: 7},
 		{Title: "Ver", Width: 4},
 		{Title: "Seq", Width: 5},
 		{Title: "Device ID", Width: 10},
+		{Title: "Name", Width: 14},
 		{Title: "Auth Tag", Width: 10},
 		{Title: "Encrypted Payload", Width: 18},
 	}
@@ -121,26 +357,22 @@ func NewBLEScanModel(client *api.Client) BLEScanModel {
 	t := table.New(
 		table.WithColumns(columns),
 		table.WithFocused(true),
-		table.WithHeight(10),
+		table.WithHeight(common.DefaultTableHeight),
 	)
 
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		Bold(true).
-		Foreground(common.ColorSecondary).
-		BorderStyle(lipgloss.HiddenBorder())
-	s.Cell = s.Cell.
-		BorderStyle(lipgloss.HiddenBorder())
-	s.Selected = s.Selected.
-		Foreground(common.ColorForeground).
-		Background(common.ColorPrimary).
-		Bold(true)
-	t.SetStyles(s)
+	t.SetStyles(bleScanTableStyles())
 
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
 	sp.Style = lipgloss.NewStyle().Foreground(common.ColorPrimary)
 
+	li := textinput.New()
+	li.Placeholder = "37.7749, -122.4194"
+	li.CharLimit = 48
+	li.Width = 30
+	li.PromptStyle = lipgloss.NewStyle().Foreground(common.ColorSecondary)
+	li.TextStyle = lipgloss.NewStyle().Foreground(common.ColorForeground)
+
 	// Try to create a real scanner
 	var scanner ble.ScannerInterface
 	var scannerErr error
@@ -161,16 +393,40 @@ func NewBLEScanModel(client *api.Client) BLEScanModel {
 		help:       help.New(),
 		keys:       defaultBLEScanKeyMap(),
 		state:      BLEScanStateInit,
+
+		redrawInterval:   DefaultScanRedrawInterval,
+		dedupeWindow:     DefaultScanDedupeWindow,
+		now:              time.Now,
+		listAdapters:     ble.Adapters,
+		openAdapter:      openScannerAdapter,
+		locationProvider: location.System(),
+		locationInput:    li,
 	}
 }
 
+// openScannerAdapter creates a scanner on the adapter with the given ID
+func openScannerAdapter(id string) (ble.ScannerInterface, error) {
+	scanner, err := ble.NewScannerWithAdapter(id)
+	if err != nil {
+		return nil, err
+	}
+	return scanner, nil
+}
+
 // Init initializes the BLE scan model and starts scanning automatically
 func (m BLEScanModel) Init() tea.Cmd {
-	return tea.Batch(m.spinner.Tick, m.startScan())
+	return tea.Batch(m.spinner.Tick, m.startScan(), m.loadFleet(false))
 }
 
 // Update handles messages for the BLE scan screen
 func (m BLEScanModel) Update(msg tea.Msg) (BLEScanModel, tea.Cmd) {
+	m, cmd := m.update(msg)
+	// Anything above or below the table may have changed height
+	m.fitTable()
+	return m, cmd
+}
+
+func (m BLEScanModel) update(msg tea.Msg) (BLEScanModel, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
@@ -178,25 +434,56 @@ func (m BLEScanModel) Update(msg tea.Msg) (BLEScanModel, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.help.Width = msg.Width
-		m.table.SetHeight(m.height - 20)
 		m.updateTableColumns()
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.showDetail {
+			return m.updateDetail(msg)
+		}
+
+		if m.pickingAdapter {
+			return m.updateAdapterPicker(msg)
+		}
+
+		if m.editingLocation {
+			return m.updateLocationInput(msg)
+		}
+
+		// Any key other than a format cancels the export
+		if m.exportPrompt {
+			m.exportPrompt = false
+			if f, ok := exportFormatForKey(msg.String(), captureExportFormats); ok {
+				return m, exportCaptures(m.packets, f)
+			}
+			return m, nil
+		}
+
+		// Only leaving the screen is possible until the upload finishes
+		if m.state == BLEScanStateIngesting && !key.Matches(msg, m.keys.Back) && !key.Matches(msg, m.keys.Quit) {
+			return m, nil
+		}
+
 		switch {
+		case key.Matches(msg, m.keys.Detail):
+			if idx, ok := m.selectedIndex(); ok {
+				m.detailIndex = idx
+				m.showDetail = true
+				m.notice = ""
+				return m, nil
+			}
+
 		case key.Matches(msg, m.keys.Back):
 			if m.state == BLEScanStateScanning {
 				m.stopScan()
 			}
 			return m, func() tea.Msg {
-				return NavigateMsg{Screen: "home"}
+				return NavigateMsg{Screen: "back"}
 			}
 
 		case key.Matches(msg, m.keys.Quit):
-			if m.state == BLEScanStateScanning {
-				m.stopScan()
-			}
-			return m, tea.Quit
+			// App stops the scan once the quit is confirmed
+			return m, RequestQuit
 
 		case key.Matches(msg, m.keys.Pause) || key.Matches(msg, m.keys.Resume):
 			// Toggle between scanning and paused states
@@ -208,38 +495,258 @@ func (m BLEScanModel) Update(msg tea.Msg) (BLEScanModel, tea.Cmd) {
 				return m, m.startScan()
 			}
 
+		case key.Matches(msg, m.keys.Freeze):
+			// Freeze only holds the display still; capture keeps running
+			m.frozen = !m.frozen
+			if !m.frozen {
+				m.flushTable()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Reload):
+			// Re-fetch device keys without interrupting the scan
+			if cmd := m.loadFleet(true); cmd != nil {
+				m.notice = "Reloading device keys..."
+				return m, cmd
+			}
+
+		case key.Matches(msg, m.keys.Decrypt):
+			if m.client != nil {
+				m.showDecrypt = !m.showDecrypt
+				// Drop the rows first so they never have more cells than
+				// the new columns
+				m.table.SetRows(nil)
+				m.updateTableColumns()
+				m.flushTable()
+				return m, nil
+			}
+
+		case key.Matches(msg, m.keys.Group):
+			m.grouped = !m.grouped
+			// As with the Decrypted column, drop the rows before the
+			// columns change
+			m.table.SetRows(nil)
+			m.updateTableColumns()
+			m.flushTable()
+			m.table.SetCursor(0)
+			return m, nil
+
+		case key.Matches(msg, m.keys.Export):
+			// Capture keeps running while the format is picked
+			if len(m.packets) > 0 {
+				m.exportPrompt = true
+				m.notice = ""
+				return m, nil
+			}
+
+		case key.Matches(msg, m.keys.Save):
+			if len(m.packets) > 0 {
+				m.notice = ""
+				return m, saveCapture(m.rawPackets, m.packets)
+			}
+
+		case key.Matches(msg, m.keys.Adapter):
+			// Switching adapters mid-scan would drop the running scan
+			if m.state != BLEScanStateScanning {
+				m.notice = ""
+				return m, m.loadAdapters()
+			}
+
+		case key.Matches(msg, m.keys.Location):
+			// Packets from a running scan keep the location it started with
+			if m.state != BLEScanStateScanning && m.state != BLEScanStateIngesting {
+				m.notice = ""
+				m.editingLocation = true
+				m.locationErr = ""
+				m.locationInput.SetValue("")
+				if m.scanLocation != nil {
+					m.locationInput.SetValue(m.scanLocation.String())
+				}
+				m.locationInput.CursorEnd()
+				m.locationInput.Focus()
+				return m, textinput.Blink
+			}
+
+		case key.Matches(msg, m.keys.Duration):
+			// Set before starting; a running scan keeps its duration
+			if m.state != BLEScanStateScanning {
+				m.scanDuration = scanDurations[msg.String()]
+				return m, nil
+			}
+
+		case key.Matches(msg, m.keys.RaiseRSSI):
+			return m, m.setMinRSSI(raiseRSSIFloor(m.minRSSI))
+
+		case key.Matches(msg, m.keys.LowerRSSI):
+			return m, m.setMinRSSI(lowerRSSIFloor(m.minRSSI))
+
+		case key.Matches(msg, m.keys.Ingest):
+			// Upload from a paused scan so the batch is complete
+			if m.client != nil && m.state == BLEScanStateInit && len(m.packets) > 0 {
+				m.state = BLEScanStateIngesting
+				m.notice = ""
+				return m, tea.Batch(m.spinner.Tick, m.ingestPackets())
+			}
+
 		case key.Matches(msg, m.keys.Clear):
-			m.packets = nil
-			m.rawPackets = nil
-			m.updateTable()
+			m.clearCapture()
 			return m, nil
 		}
 
 	case BLEScanStartedMsg:
+		restarted := m.state == BLEScanStateScanning
 		m.state = BLEScanStateScanning
 		m.resultsChan = msg.Results // Store the channel from the message
+		m.scanDeadline = time.Time{}
+		if m.scanDuration > 0 {
+			m.scanDeadline = m.now().Add(m.scanDuration)
+		}
+		if restarted {
+			// The tick loop from the previous scan picks up the new channel
+			return m, nil
+		}
+		m.scanStartCount = len(m.packets)
 		// Start tick loop for continuous polling
 		return m, tea.Batch(m.spinner.Tick, m.tickCmd())
 
 	case BLEScanPacketMsg:
 		m.packets = append(m.packets, msg.Packet)
 		m.rawPackets = append(m.rawPackets, msg.Raw)
-		m.updateTable()
+		m.deviceNames = append(m.deviceNames, "")
+		m.decrypted = append(m.decrypted, nil)
+		m.refreshTable()
+		identifyCmd := m.identifyPacket(len(m.packets) - 1)
 		// Continue polling for more results
 		if m.state == BLEScanStateScanning {
-			return m, m.pollResults()
+			return m, tea.Batch(m.pollResults(), identifyCmd)
+		}
+		return m, identifyCmd
+
+	case common.ClipboardCopiedMsg:
+		if msg.Err != nil {
+			m.notice = "Copy failed: " + msg.Err.Error()
+		} else {
+			m.notice = "Copied " + msg.Label
+		}
+		return m, nil
+
+	case BLEScanExportedMsg:
+		if msg.Err != nil {
+			m.notice = "Export failed: " + msg.Err.Error()
+		} else {
+			m.notice = fmt.Sprintf("Exported %d packet(s) to %s", msg.Count, msg.Path)
+		}
+		return m, nil
+
+	case BLEScanAdaptersMsg:
+		switch {
+		case msg.Err != nil:
+			m.notice = "Adapter selection unavailable (" + msg.Err.Error() + "); using the default adapter"
+		case len(msg.Adapters) == 0:
+			m.notice = "No Bluetooth adapters found"
+		default:
+			m.adapters = msg.Adapters
+			m.pickingAdapter = true
+			m.adapterCursor = 0
+			for i, a := range msg.Adapters {
+				if a.ID == m.adapterID || (m.adapterID == "" && a.Default) {
+					m.adapterCursor = i
+				}
+			}
+		}
+		return m, nil
+
+	case BLEScanAdapterOpenedMsg:
+		if msg.Err != nil {
+			m.notice = fmt.Sprintf("Could not use adapter %s: %v", msg.ID, msg.Err)
+			return m, nil
+		}
+		m.scanner = msg.Scanner
+		m.scannerErr = nil
+		m.adapterID = msg.ID
+		if m.state == BLEScanStateError {
+			m.state = BLEScanStateInit
+		}
+		m.notice = "Using adapter " + msg.ID
+		return m, nil
+
+	case BLEIngestCompleteMsg:
+		m.state = BLEScanStateInit
+		if msg.Err != nil {
+			// Keep the packets so the upload can be retried; packets the
+			// API already accepted are skipped next time
+			m.notice = "Ingest failed: " + errorText(msg.Err)
+			return m, nil
+		}
+		m.notice = ingestNotice(msg.Report)
+		m.clearCapture()
+		return m, nil
+
+	case BLEScanCaptureSavedMsg:
+		if msg.Err != nil {
+			m.notice = "Save failed: " + msg.Err.Error()
+		} else {
+			m.notice = fmt.Sprintf("Saved %d packet(s) to %s", msg.Count, msg.Path)
+		}
+		return m, nil
+
+	case BLEScanFleetLoadedMsg:
+		if msg.Reload && m.fleetLoaded && m.fleetErr == nil {
+			return m.mergeFleet(msg)
+		}
+		m.fleetLoaded = true
+		m.fleet = msg.Devices
+		m.fleetErr = msg.Err
+		if msg.Reload {
+			if msg.Err != nil {
+				m.notice = "Key reload failed: " + msg.Err.Error()
+			} else {
+				m.notice = fmt.Sprintf("Loaded %d device key(s)", len(m.fleet))
+			}
+		}
+		// Identify packets captured before the fleet arrived
+		var identifyCmds []tea.Cmd
+		for i := range m.packets {
+			identifyCmds = append(identifyCmds, m.identifyPacket(i))
+		}
+		m.refreshTable()
+		return m, tea.Batch(identifyCmds...)
+
+	case BLEScanIdentifiedMsg:
+		if msg.Generation == m.generation && msg.Index < len(m.deviceNames) {
+			m.deviceNames[msg.Index] = msg.Name
+			if msg.Index < len(m.decrypted) {
+				m.decrypted[msg.Index] = msg.Decrypted
+			}
+			m.refreshTable()
 		}
 		return m, nil
 
 	case BLEScanStoppedMsg:
+		if msg.Results != nil && msg.Results != m.resultsChan {
+			return m, nil
+		}
+		if !m.frozen {
+			m.flushTable()
+		}
 		m.state = BLEScanStateInit
 		if msg.Error != nil && msg.Error != ble.ErrScanStopped {
 			m.state = BLEScanStateError
 			m.err = msg.Error
+		} else if msg.Results != nil && !m.scanDeadline.IsZero() {
+			// The scanner ended a timed scan; summarize it
+			m.notice = fmt.Sprintf("Timed scan finished: %d new packet(s), %d unique device(s) captured",
+				len(m.packets)-m.scanStartCount, m.uniqueDeviceCount())
 		}
+		m.scanDeadline = time.Time{}
 		return m, nil
 
 	case BLEScanTickMsg:
+		// Catch up on table changes held back by the redraw interval
+		if m.tableDirty && !m.frozen && m.redrawDue() {
+			m.flushTable()
+		}
+
 		// Continuous polling while scanning
 		if m.state == BLEScanStateScanning {
 			// Poll for results and schedule next tick
@@ -255,7 +762,7 @@ func (m BLEScanModel) Update(msg tea.Msg) (BLEScanModel, tea.Cmd) {
 		return m, nil
 
 	case spinner.TickMsg:
-		if m.state == BLEScanStateScanning {
+		if m.state == BLEScanStateScanning || m.state == BLEScanStateIngesting {
 			var cmd tea.Cmd
 			m.spinner, cmd = m.spinner.Update(msg)
 			return m, cmd
@@ -276,50 +783,45 @@ func (m BLEScanModel) Update(msg tea.Msg) (BLEScanModel, tea.Cmd) {
 func (m BLEScanModel) View() string {
 	var content strings.Builder
 
-	// Helper to center text within terminal width
-	centerText := func(s string) string {
-		return lipgloss.NewStyle().Width(m.width).Align(lipgloss.Center).Render(s)
-	}
+	content.WriteString(m.renderHeader())
 
-	// Header (centered)
-	content.WriteString(centerText(common.TitleStyle.Render("BLE Scanner")))
-	content.WriteString("\n")
-	content.WriteString(centerText(common.SubtitleStyle.Render("Scan for Hubble BLE advertisements")))
-	content.WriteString("\n\n")
+	// Main content
+	switch {
+	case m.showDetail:
+		content.WriteString(m.renderDetail())
 
-	// Status bar (centered)
-	content.WriteString(centerText(m.renderStatus()))
-	content.WriteString("\n\n")
+	case m.pickingAdapter:
+		content.WriteString(m.renderAdapterPicker())
 
-	// Main content
-	switch m.state {
-	case BLEScanStateScanning:
-		content.WriteString(centerText(fmt.Sprintf("%s Scanning...", m.spinner.View())))
-		content.WriteString("\n\n")
-		content.WriteString(centerText(fmt.Sprintf("Found %d packet(s)", len(m.packets))))
-		content.WriteString("\n\n")
-		content.WriteString(m.table.View())
+	case m.editingLocation:
+		content.WriteString(m.renderLocationInput())
 
-	case BLEScanStateError:
-		content.WriteString(centerText(common.ErrorTextStyle.Render("Error: " + m.err.Error())))
+	case m.state == BLEScanStateScanning:
+		content.WriteString(m.renderTableCaption())
+		content.WriteString(common.RenderTable(m.table))
+
+	case m.state == BLEScanStateIngesting:
+		content.WriteString(m.centerText(fmt.Sprintf("%s Ingesting %d packet(s)...", m.spinner.View(), len(m.packets))))
+
+	case m.state == BLEScanStateError:
+		content.WriteString(m.centerText(common.ErrorTextStyle.Render("Error: " + m.err.Error())))
 		content.WriteString("\n\n")
-		content.WriteString(centerText(common.MutedTextStyle.Render("Press 'r' to retry")))
+		content.WriteString(m.centerText(common.MutedTextStyle.Render("Press 'r' to retry")))
 
-	case BLEScanStateInit:
+	case m.state == BLEScanStateInit:
 		if m.scannerErr != nil {
-			content.WriteString(centerText(common.ErrorTextStyle.Render("Scanner Error: " + m.scannerErr.Error())))
+			content.WriteString(m.centerText(common.ErrorTextStyle.Render("Scanner Error: " + m.scannerErr.Error())))
 			content.WriteString("\n\n")
-			content.WriteString(centerText(common.MutedTextStyle.Render("BLE scanning may not be available.")))
+			content.WriteString(m.centerText(common.MutedTextStyle.Render("BLE scanning may not be available.")))
 		} else {
-			content.WriteString(centerText(fmt.Sprintf("Scan paused. %d packet(s) captured", len(m.packets))))
-			content.WriteString("\n\n")
-			content.WriteString(m.table.View())
+			content.WriteString(m.renderTableCaption())
+			content.WriteString(common.RenderTable(m.table))
 		}
 	}
 
 	// Help (centered)
 	content.WriteString("\n\n")
-	content.WriteString(centerText(m.renderHelp()))
+	content.WriteString(m.centerText(m.renderHelp()))
 
 	// Center vertically, but use full width
 	return lipgloss.Place(
@@ -331,6 +833,61 @@ func (m BLEScanModel) View() string {
 	)
 }
 
+// centerText centers s within the terminal width
+func (m BLEScanModel) centerText(s string) string {
+	return lipgloss.NewStyle().Width(m.width).Align(lipgloss.Center).Render(s)
+}
+
+// renderHeader renders the title, subtitle and status bar shown above the
+// screen body
+func (m BLEScanModel) renderHeader() string {
+	var content strings.Builder
+
+	content.WriteString(m.centerText(common.TitleStyle.Render("BLE Scanner")))
+	content.WriteString("\n")
+	content.WriteString(m.centerText(common.SubtitleStyle.Render("Scan for Hubble BLE advertisements")))
+	content.WriteString("\n\n")
+
+	// Status bar (centered)
+	content.WriteString(m.centerText(m.renderStatus()))
+	content.WriteString("\n\n")
+
+	// The detail view shows its own notice
+	if m.notice != "" && !m.showDetail {
+		content.WriteString(m.centerText(common.SuccessTextStyle.Render(m.notice)))
+		content.WriteString("\n\n")
+	}
+
+	return content.String()
+}
+
+// renderTableCaption renders the scan progress shown directly above the table
+func (m BLEScanModel) renderTableCaption() string {
+	var content strings.Builder
+
+	if m.state == BLEScanStateScanning {
+		content.WriteString(m.centerText(fmt.Sprintf("%s Scanning...", m.spinner.View())))
+		content.WriteString("\n\n")
+		content.WriteString(m.centerText(fmt.Sprintf("Found %d packet(s)", len(m.packets))))
+	} else {
+		content.WriteString(m.centerText(fmt.Sprintf("Scan paused. %d packet(s) captured", len(m.packets))))
+	}
+	content.WriteString("\n\n")
+
+	return content.String()
+}
+
+// fitTable sizes the table to the lines left over by the header, caption
+// and help as currently rendered
+func (m *BLEScanModel) fitTable() {
+	if m.height == 0 {
+		return
+	}
+	above := m.renderHeader() + m.renderTableCaption()
+	below := "\n\n" + m.centerText(m.renderHelp())
+	m.table.SetHeight(common.FitTableHeight(m.height, m.width, above, below))
+}
+
 func (m BLEScanModel) renderStatus() string {
 	var parts []string
 
@@ -342,6 +899,37 @@ func (m BLEScanModel) renderStatus() string {
 
 	countStr := fmt.Sprintf("Packets: %d", len(m.packets))
 	parts = append(parts, countStyle.Render(countStr))
+	parts = append(parts, countStyle.Render(fmt.Sprintf("Unique devices: %d", m.uniqueDeviceCount())))
+
+	// Truncated payload count (only shown when there are any)
+	if truncated := m.truncatedCount(); truncated > 0 {
+		truncStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#000000")).
+			Background(common.ColorWarning).
+			Padding(0, 1)
+		parts = append(parts, truncStyle.Render(fmt.Sprintf("Truncated: %d", truncated)))
+	}
+
+	// Location attached to captured packets
+	if m.scanLocation != nil {
+		parts = append(parts, countStyle.Render("Location: "+m.scanLocation.String()))
+	}
+
+	if m.minRSSI != 0 {
+		parts = append(parts, countStyle.Render(fmt.Sprintf("Min RSSI: %d dBm", m.minRSSI)))
+	}
+
+	if m.adapterID != "" {
+		parts = append(parts, countStyle.Render("Adapter: "+m.adapterID))
+	}
+
+	// Countdown while a timed scan runs, otherwise the duration to use
+	if m.state == BLEScanStateScanning && !m.scanDeadline.IsZero() {
+		left := max(m.scanDeadline.Sub(m.now()), 0)
+		parts = append(parts, countStyle.Render(fmt.Sprintf("Time left: %ds", int(left.Round(time.Second).Seconds()))))
+	} else if m.state != BLEScanStateScanning && m.scanDuration > 0 {
+		parts = append(parts, countStyle.Render(fmt.Sprintf("Duration: %ds", int(m.scanDuration.Seconds()))))
+	}
 
 	// State indicator
 	var stateStr string
@@ -361,6 +949,13 @@ func (m BLEScanModel) renderStatus() string {
 			Background(common.ColorPrimary).
 			Bold(true).
 			Padding(0, 1)
+	case BLEScanStateIngesting:
+		stateStr = "INGESTING"
+		stateStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFFFF")).
+			Background(common.ColorPrimary).
+			Bold(true).
+			Padding(0, 1)
 	case BLEScanStateError:
 		stateStr = "ERROR"
 		stateStyle = lipgloss.NewStyle().
@@ -372,18 +967,355 @@ func (m BLEScanModel) renderStatus() string {
 
 	parts = append(parts, stateStyle.Render(stateStr))
 
+	if m.frozen {
+		frozenStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#000000")).
+			Background(common.ColorWarning).
+			Bold(true).
+			Padding(0, 1)
+		frozenStr := "FROZEN"
+		if pending := len(m.packets) - len(m.rowIndex); pending > 0 {
+			frozenStr += fmt.Sprintf(" +%d new", pending)
+		}
+		parts = append(parts, frozenStyle.Render(frozenStr))
+	}
+
 	return strings.Join(parts, "  ")
 }
 
+// loadAdapters lists the adapters to pick from
+func (m BLEScanModel) loadAdapters() tea.Cmd {
+	list := m.listAdapters
+	return func() tea.Msg {
+		adapters, err := list()
+		return BLEScanAdaptersMsg{Adapters: adapters, Err: err}
+	}
+}
+
+// updateAdapterPicker handles key presses while the adapter list is shown
+func (m BLEScanModel) updateAdapterPicker(msg tea.KeyMsg) (BLEScanModel, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if m.adapterCursor > 0 {
+			m.adapterCursor--
+		}
+	case "down", "j":
+		if m.adapterCursor < len(m.adapters)-1 {
+			m.adapterCursor++
+		}
+	case "enter":
+		m.pickingAdapter = false
+		id := m.adapters[m.adapterCursor].ID
+		open := m.openAdapter
+		return m, func() tea.Msg {
+			scanner, err := open(id)
+			return BLEScanAdapterOpenedMsg{ID: id, Scanner: scanner, Err: err}
+		}
+	case "esc":
+		m.pickingAdapter = false
+	}
+	return m, nil
+}
+
+// renderAdapterPicker renders the adapter list, marking the cursor row
+func (m BLEScanModel) renderAdapterPicker() string {
+	var content strings.Builder
+	content.WriteString(m.centerText(common.SubtitleStyle.Render("Select a Bluetooth adapter")))
+	content.WriteString("\n\n")
+	for i, a := range m.adapters {
+		line := "  " + a.ID
+		if i == m.adapterCursor {
+			line = "▸ " + a.ID
+		}
+		if a.Default {
+			line += " (default)"
+		}
+		if a.ID == m.adapterID || (m.adapterID == "" && a.Default) {
+			line += " - in use"
+		}
+		content.WriteString(m.centerText(line))
+		content.WriteString("\n")
+	}
+	return content.String()
+}
+
+// selectedIndex returns the packets/rawPackets index of the selected row.
+// In the grouped view that is the device's latest packet.
+func (m BLEScanModel) selectedIndex() (int, bool) {
+	cursor := m.table.Cursor()
+	if cursor < 0 || cursor >= len(m.rowIndex) {
+		return 0, false
+	}
+
+	idx := m.rowIndex[cursor]
+	if idx >= len(m.packets) || idx >= len(m.rawPackets) {
+		return 0, false
+	}
+	return idx, true
+}
+
+// updateDetail handles key presses while the raw advertisement detail is shown
+func (m BLEScanModel) updateDetail(msg tea.KeyMsg) (BLEScanModel, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Back), key.Matches(msg, m.keys.Detail):
+		m.showDetail = false
+		m.notice = ""
+	case key.Matches(msg, m.keys.Copy):
+		if m.detailIndex < len(m.packets) {
+			return m, common.CopyToClipboard("payload hex", m.packets[m.detailIndex].PayloadHex())
+		}
+	case key.Matches(msg, m.keys.Quit):
+		return m, RequestQuit
+	}
+	return m, nil
+}
+
+// renderDetail renders the full raw advertisement for the selected packet
+func (m BLEScanModel) renderDetail() string {
+	if m.detailIndex >= len(m.packets) || m.detailIndex >= len(m.rawPackets) {
+		return common.MutedTextStyle.Render("Packet no longer available.")
+	}
+
+	p := m.packets[m.detailIndex]
+	raw := m.rawPackets[m.detailIndex]
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(common.ColorSecondary)
+	labelStyle := lipgloss.NewStyle().Foreground(common.ColorMuted).Width(18)
+	valueStyle := lipgloss.NewStyle().Foreground(common.ColorForeground)
+
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(fmt.Sprintf("Packet #%d", m.detailIndex+1)))
+	b.WriteString("\n\n")
+
+	line := func(label, value string) {
+		b.WriteString(labelStyle.Render(label))
+		b.WriteString(valueStyle.Render(value))
+		b.WriteString("\n")
+	}
+
+	line("Time:", common.FormatTime(p.Timestamp, "2006-01-02 15:04:05.000"))
+	line("Address:", orDash(raw.Address))
+	line("RSSI:", fmt.Sprintf("%d dBm", p.RSSI))
+	line("Local Name:", orDash(raw.LocalName))
+	line("Service UUIDs:", orDash(strings.Join(raw.ServiceUUIDs, ", ")))
+
+	if len(raw.ServiceData) == 0 {
+		line("Service Data:", "-")
+	} else {
+		uuids := make([]string, 0, len(raw.ServiceData))
+		for uuid := range raw.ServiceData {
+			uuids = append(uuids, uuid)
+		}
+		sort.Strings(uuids)
+		for i, uuid := range uuids {
+			label := ""
+			if i == 0 {
+				label = "Service Data:"
+			}
+			line(label, fmt.Sprintf("%s: %x", uuid, raw.ServiceData[uuid]))
+		}
+	}
+
+	line("Manufacturer Data:", orDash(fmt.Sprintf("%x", raw.ManufacturerData)))
+	line("Payload:", orDash(p.PayloadHex()))
+	line("Payload Length:", fmt.Sprintf("%d bytes", len(p.Payload)))
+	for _, field := range payloadBreakdown(p.Payload) {
+		line("  "+field[0], field[1])
+	}
+	if m.detailIndex < len(m.decrypted) && m.decrypted[m.detailIndex] != nil {
+		line("Decrypted:", orDash(fmt.Sprintf("%x", m.decrypted[m.detailIndex])))
+	}
+
+	if m.notice != "" {
+		b.WriteString("\n")
+		b.WriteString(common.SuccessTextStyle.Render(m.notice))
+	}
+
+	return common.BoxStyle.Render(b.String())
+}
+
+// deviceName returns the fleet match label for packet i
+func (m BLEScanModel) deviceName(i int) string {
+	if !m.fleetLoaded || m.fleetErr != nil {
+		return "-"
+	}
+	if i >= len(m.deviceNames) || m.deviceNames[i] == "" {
+		return "..."
+	}
+	return m.deviceNames[i]
+}
+
+// loadFleet fetches the org's devices for identifying captured packets. It
+// runs once when the screen opens and again on reload.
+func (m BLEScanModel) loadFleet(reload bool) tea.Cmd {
+	if m.client == nil {
+		return nil
+	}
+	client := m.client
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), common.RequestTimeout())
+		defer cancel()
+
+		devices, err := client.ListDevices(ctx)
+		return BLEScanFleetLoadedMsg{Devices: devices, Err: err, Reload: reload}
+	}
+}
+
+// mergeFleet merges reloaded devices into the fleet, keeping devices that
+// are no longer listed, and re-identifies packets that didn't match before.
+// A failed reload keeps the current fleet.
+func (m BLEScanModel) mergeFleet(msg BLEScanFleetLoadedMsg) (BLEScanModel, tea.Cmd) {
+	if msg.Err != nil {
+		m.notice = "Key reload failed: " + msg.Err.Error()
+		return m, nil
+	}
+
+	index := make(map[string]int, len(m.fleet))
+	fleet := make([]models.Device, len(m.fleet))
+	copy(fleet, m.fleet)
+	for i, d := range fleet {
+		index[d.ID] = i
+	}
+	added := 0
+	for _, d := range msg.Devices {
+		if i, ok := index[d.ID]; ok {
+			fleet[i] = d
+			continue
+		}
+		index[d.ID] = len(fleet)
+		fleet = append(fleet, d)
+		added++
+	}
+	m.fleet = fleet
+	m.notice = fmt.Sprintf("Loaded %d device key(s), %d new", len(msg.Devices), added)
+
+	var identifyCmds []tea.Cmd
+	for i := range m.packets {
+		if i < len(m.deviceNames) && m.deviceNames[i] == "unknown" {
+			m.deviceNames[i] = ""
+			identifyCmds = append(identifyCmds, m.identifyPacket(i))
+		}
+	}
+	m.refreshTable()
+	return m, tea.Batch(identifyCmds...)
+}
+
+// identifyPacket matches packet i against the fleet in the background and
+// decrypts it with the matching device's key
+func (m BLEScanModel) identifyPacket(i int) tea.Cmd {
+	if !m.fleetLoaded || m.fleetErr != nil || i >= len(m.packets) {
+		return nil
+	}
+	packet := m.packets[i]
+	fleet := m.fleet
+	generation := m.generation
+	return func() tea.Msg {
+		msg := BLEScanIdentifiedMsg{Generation: generation, Index: i, Name: "unknown"}
+		device, timeCounter := crypto.MatchDevice(packet, fleet)
+		if device == nil {
+			return msg
+		}
+
+		msg.Name = device.Name
+		if msg.Name == "" {
+			msg.Name = device.ID
+		}
+		if key, err := crypto.DeviceKey(*device); err == nil {
+			// The match already found the counter; don't search for it again
+			if result, err := crypto.DecryptWithKnownCounter(key, packet, timeCounter); err == nil {
+				msg.Decrypted = result.Payload
+			}
+		}
+		return msg
+	}
+}
+
+// decryptedText returns the Decrypted column value for packet i
+func (m BLEScanModel) decryptedText(i int) string {
+	if i >= len(m.decrypted) || m.decrypted[i] == nil {
+		return "-"
+	}
+	if len(m.decrypted[i]) == 0 {
+		return "(empty)"
+	}
+	return fmt.Sprintf("%x", m.decrypted[i])
+}
+
+// truncatedCount returns the number of captured payloads too short to decrypt
+func (m BLEScanModel) truncatedCount() int {
+	count := 0
+	for _, p := range m.packets {
+		if ble.ClassifyPayload(p.Payload) == ble.PayloadTruncated {
+			count++
+		}
+	}
+	return count
+}
+
+// uniqueDeviceCount returns the number of distinct ephemeral device IDs
+// among the captured packets
+func (m BLEScanModel) uniqueDeviceCount() int {
+	ids := make(map[string]struct{})
+	for _, p := range m.packets {
+		if id, ok := ble.EphemeralID(p.Payload); ok {
+			ids[id] = struct{}{}
+		}
+	}
+	return len(ids)
+}
+
 func (m BLEScanModel) renderHelp() string {
 	var helpText []string
 
+	if m.showDetail {
+		helpText = []string{
+			common.FormatHelp("y", "copy payload hex"),
+			common.FormatHelp("esc", "close"),
+		}
+		return strings.Join(helpText, "  ")
+	}
+
+	if m.exportPrompt {
+		return "Export as:  " + strings.Join(exportFormatHelp(captureExportFormats), "  ")
+	}
+
+	if m.pickingAdapter {
+		helpText = []string{
+			common.FormatHelp("↑/↓", "move"),
+			common.FormatHelp("enter", "use adapter"),
+			common.FormatHelp("esc", "cancel"),
+		}
+		return strings.Join(helpText, "  ")
+	}
+
+	if m.editingLocation {
+		helpText = []string{
+			common.FormatHelp("enter", "save (empty clears)"),
+			common.FormatHelp("esc", "cancel"),
+		}
+		return strings.Join(helpText, "  ")
+	}
+
+	if m.state == BLEScanStateIngesting {
+		return common.FormatHelp("esc", "back")
+	}
+
 	switch m.state {
 	case BLEScanStateInit:
 		helpText = []string{
 			common.FormatHelp("r/space", "resume"),
 			common.FormatHelp("c", "clear"),
 		}
+		if m.client != nil && len(m.packets) > 0 {
+			helpText = append(helpText, common.FormatHelp("i", "ingest"))
+		}
 	case BLEScanStateScanning:
 		helpText = []string{
 			common.FormatHelp("p/space", "pause"),
@@ -395,38 +1327,105 @@ func (m BLEScanModel) renderHelp() string {
 		}
 	}
 
+	if m.client != nil && m.state != BLEScanStateError {
+		helpText = append(helpText, common.FormatHelp("K", "reload keys"))
+		if m.showDecrypt {
+			helpText = append(helpText, common.FormatHelp("d", "hide decrypted"))
+		} else {
+			helpText = append(helpText, common.FormatHelp("d", "decrypt"))
+		}
+	}
+
+	if m.state != BLEScanStateError && len(m.packets) > 0 {
+		if m.frozen {
+			helpText = append(helpText, common.FormatHelp("f", "unfreeze"))
+		} else {
+			helpText = append(helpText, common.FormatHelp("f", "freeze"))
+		}
+		if m.grouped {
+			helpText = append(helpText, common.FormatHelp("g", "ungroup"))
+		} else {
+			helpText = append(helpText, common.FormatHelp("g", "group"))
+		}
+	}
+	if len(m.packets) > 0 {
+		helpText = append(helpText, common.FormatHelp("x", "export"))
+		helpText = append(helpText, common.FormatHelp("s", "save capture"))
+	}
+	if m.state != BLEScanStateError {
+		helpText = append(helpText, common.FormatHelp("+/-", "min RSSI"))
+	}
+	if m.state != BLEScanStateScanning {
+		helpText = append(helpText, common.FormatHelp("1/3/6", "scan 10/30/60s"))
+		helpText = append(helpText, common.FormatHelp("0", "continuous"))
+		helpText = append(helpText, common.FormatHelp("a", "adapter"))
+		helpText = append(helpText, common.FormatHelp("L", "location"))
+	}
+
 	helpText = append(helpText, common.FormatHelp("esc", "back"))
 
 	return strings.Join(helpText, "  ")
 }
 
-func (m *BLEScanModel) updateTableColumns() {
-	if m.width == 0 {
-		return
+// Minimum widths of the scan table columns
+const (
+	minNum       = 4
+	minTime      = 18 // Time plus zone suffix
+	minRSSI      = 7
+	minVer       = 4
+	minSeq       = 5
+	minDeviceID  = 10
+	minName      = 14
+	minAuthTag   = 10
+	minEncrypted = 18
+	minDecrypted = 18
+
+	// Grouped view
+	minCount    = 8
+	minBestRSSI = 10
+)
+
+// payloadColumnWidths returns the widths of the Encrypted Payload and
+// Decrypted columns. Space beyond the minimum widths goes to the payload
+// columns, split evenly while the Decrypted column is shown.
+func (m BLEScanModel) payloadColumnWidths() (encrypted, decrypted int) {
+	columns := 9
+	fixed := minNum + minTime + minRSSI + minVer + minSeq + minDeviceID + minName + minAuthTag + minEncrypted
+	if m.showDecrypt {
+		columns++
+		fixed += minDecrypted
 	}
 
-	// Fixed minimum widths for each column
-	const (
-		minNum       = 4
-		minTime      = 13
-		minRSSI      = 7
-		minVer       = 4
-		minSeq       = 5
-		minDeviceID  = 10
-		minAuthTag   = 10
-		minEncrypted = 18
-	)
+	extraSpace := 0
+	if m.width > 0 {
+		extraSpace = m.width - common.TableFrameWidth(bleScanTableStyles(), columns) - common.SelectionMarkerWidth - fixed
+		if extraSpace < 0 {
+			extraSpace = 0
+		}
+	}
 
-	// Calculate extra space to distribute
-	minTotal := minNum + minTime + minRSSI + minVer + minSeq + minDeviceID + minAuthTag + minEncrypted
-	extraSpace := m.width - minTotal
+	if !m.showDecrypt {
+		return minEncrypted + extraSpace, 0
+	}
+	return minEncrypted + extraSpace/2, minDecrypted + extraSpace - extraSpace/2
+}
 
-	if extraSpace < 0 {
-		extraSpace = 0
+func (m *BLEScanModel) updateTableColumns() {
+	if m.width == 0 {
+		return
+	}
+	if m.grouped {
+		m.setTableColumns([]table.Column{
+			{Title: "Device ID", Width: minDeviceID},
+			{Title: "Name", Width: minName},
+			{Title: "Packets", Width: minCount},
+			{Title: "Last Seen", Width: minTime},
+			{Title: "Best RSSI", Width: minBestRSSI},
+		})
+		return
 	}
 
-	// Distribute extra space to the Encrypted Payload column
-	colEncrypted := minEncrypted + extraSpace
+	colEncrypted, colDecrypted := m.payloadColumnWidths()
 
 	columns := []table.Column{
 		{Title: "#", Width: minNum},
@@ -435,38 +1434,217 @@ func (m *BLEScanModel) updateTableColumns() {
 		{Title: "Ver", Width: minVer},
 		{Title: "Seq", Width: minSeq},
 		{Title: "Device ID", Width: minDeviceID},
+		{Title: "Name", Width: minName},
 		{Title: "Auth Tag", Width: minAuthTag},
 		{Title: "Encrypted Payload", Width: colEncrypted},
 	}
+	if m.showDecrypt {
+		columns = append(columns, table.Column{Title: "Decrypted", Width: colDecrypted})
+	}
+	m.setTableColumns(columns)
+}
+
+// setTableColumns sets the table columns and sizes the table to fit them
+func (m *BLEScanModel) setTableColumns(columns []table.Column) {
 	m.table.SetColumns(columns)
-	// Set table width to sum of column widths
-	tableWidth := minNum + minTime + minRSSI + minVer + minSeq + minDeviceID + minAuthTag + colEncrypted
-	m.table.SetWidth(tableWidth)
+	// Set table width to sum of column widths plus the cell frames, so the
+	// last columns aren't cut off
+	tableWidth := 0
+	for _, c := range columns {
+		tableWidth += c.Width
+	}
+	m.table.SetWidth(tableWidth + common.TableFrameWidth(bleScanTableStyles(), len(columns)))
 }
 
-func (m *BLEScanModel) updateTable() {
-	rows := make([]table.Row, len(m.packets))
+// SetScanLocation sets the location attached to captured packets, so they
+// carry real coordinates when ingested. Packets captured before the call keep
+// their location.
+func (m *BLEScanModel) SetScanLocation(loc models.Location) {
+	m.scanLocation = &location.Manual{
+		Latitude:           loc.Latitude,
+		Longitude:          loc.Longitude,
+		Altitude:           loc.Altitude,
+		HorizontalAccuracy: loc.HorizontalAccuracy,
+	}
+}
 
-	// Calculate encrypted payload display width based on current terminal width (matches updateTableColumns)
-	const minEncrypted = 18
-	encryptedDisplayWidth := minEncrypted
-	if m.width > 0 {
-		minTotal := 4 + 13 + 7 + 4 + 5 + 10 + 10 + minEncrypted
-		extraSpace := m.width - minTotal
-		if extraSpace < 0 {
-			extraSpace = 0
+// SetLocationProvider sets where the capture location comes from when no
+// location has been entered; nil leaves only the placeholder
+func (m *BLEScanModel) SetLocationProvider(p location.Provider) {
+	m.locationProvider = p
+}
+
+// locationTimeout bounds how long a new scan waits for the platform location
+const locationTimeout = 2 * time.Second
+
+// captureLocation returns the location to attach to packets from a new scan
+func (m BLEScanModel) captureLocation() models.Location {
+	var providers []location.Provider
+	if m.scanLocation != nil {
+		providers = append(providers, *m.scanLocation)
+	}
+	if m.locationProvider != nil {
+		providers = append(providers, m.locationProvider)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), locationTimeout)
+	defer cancel()
+	loc, err := location.First(providers...).Location(ctx)
+	if err != nil {
+		return models.Location{
+			Fake:      true,
+			Timestamp: time.Now(),
 		}
-		encryptedDisplayWidth = minEncrypted + extraSpace
 	}
+	return loc
+}
+
+// updateLocationInput handles key presses while the capture location is
+// being entered. An empty value clears it.
+func (m BLEScanModel) updateLocationInput(msg tea.KeyMsg) (BLEScanModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.editingLocation = false
+		m.locationInput.Blur()
+		return m, nil
+	case "enter":
+		var loc *location.Manual
+		if value := strings.TrimSpace(m.locationInput.Value()); value != "" {
+			manual, err := location.ParseManual(value)
+			if err != nil {
+				m.locationErr = err.Error()
+				return m, nil
+			}
+			loc = &manual
+		}
+		m.editingLocation = false
+		m.locationInput.Blur()
+		m.scanLocation = loc
+		if loc == nil {
+			m.notice = "Capture location cleared"
+		} else {
+			m.notice = "Capture location set to " + loc.String()
+		}
+		return m, func() tea.Msg {
+			return ScanLocationSetMsg{Location: loc}
+		}
+	default:
+		var cmd tea.Cmd
+		m.locationInput, cmd = m.locationInput.Update(msg)
+		m.locationErr = ""
+		return m, cmd
+	}
+}
+
+// renderLocationInput renders the capture location prompt
+func (m BLEScanModel) renderLocationInput() string {
+	var content strings.Builder
+	content.WriteString(m.centerText(common.SubtitleStyle.Render("Capture location (latitude, longitude)")))
+	content.WriteString("\n\n")
+	line := m.locationInput.View()
+	if m.locationErr != "" {
+		line += common.ErrorTextStyle.Render(" ✗ " + m.locationErr)
+	}
+	content.WriteString(m.centerText(line))
+	content.WriteString("\n")
+	return content.String()
+}
+
+// setMinRSSI sets the RSSI floor, in dBm, below which advertisements are
+// dropped (0 = off). A running scan is restarted to apply it; the returned
+// command starts the new scan.
+func (m *BLEScanModel) setMinRSSI(rssi int) tea.Cmd {
+	if rssi == m.minRSSI {
+		return nil
+	}
+	m.minRSSI = rssi
+	if m.state != BLEScanStateScanning {
+		return nil
+	}
+	// The tick loop keeps running and polls the new scan once it starts
+	m.stopScan()
+	return m.startScan()
+}
+
+// raiseRSSIFloor returns the next stricter RSSI floor
+func raiseRSSIFloor(rssi int) int {
+	if rssi == 0 {
+		return minRSSIFloor
+	}
+	return min(rssi+rssiFloorStep, maxRSSIFloor)
+}
+
+// lowerRSSIFloor returns the next looser RSSI floor, or 0 (off) below the
+// lowest one
+func lowerRSSIFloor(rssi int) int {
+	if rssi == 0 || rssi <= minRSSIFloor {
+		return 0
+	}
+	return rssi - rssiFloorStep
+}
+
+// SetRedrawInterval sets the minimum time between table rebuilds while
+// packets stream in. Zero or less rebuilds on every packet.
+func (m *BLEScanModel) SetRedrawInterval(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	m.redrawInterval = d
+}
+
+// SetDedupeWindow sets how long repeats of a device's advertisement are
+// collapsed into the strongest copy. Zero or less keeps every copy.
+func (m *BLEScanModel) SetDedupeWindow(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	m.dedupeWindow = d
+}
+
+// redrawDue reports whether the redraw interval has passed since the last
+// table rebuild
+func (m BLEScanModel) redrawDue() bool {
+	return m.redrawInterval <= 0 || m.now().Sub(m.lastRedraw) >= m.redrawInterval
+}
+
+// refreshTable rebuilds the table now if the redraw interval allows it and
+// the display isn't frozen, or marks it dirty so it is rebuilt later
+func (m *BLEScanModel) refreshTable() {
+	if !m.frozen && m.redrawDue() {
+		m.flushTable()
+		return
+	}
+	m.tableDirty = true
+}
+
+// flushTable rebuilds the table immediately
+func (m *BLEScanModel) flushTable() {
+	m.updateTable()
+	m.lastRedraw = m.now()
+	m.tableDirty = false
+}
+
+func (m *BLEScanModel) updateTable() {
+	if m.grouped {
+		m.updateGroupedTable()
+		return
+	}
+
+	rows := make([]table.Row, len(m.packets))
+	m.rowIndex = make([]int, len(m.packets))
+
+	// Encrypted payload display width for the current terminal width
+	encryptedDisplayWidth, _ := m.payloadColumnWidths()
 
 	// Display newest packets first (time-descending order)
 	for i := len(m.packets) - 1; i >= 0; i-- {
 		p := m.packets[i]
 		rowIdx := len(m.packets) - 1 - i // Row index for the table (0 = newest)
+		m.rowIndex[rowIdx] = i
 
 		rssiStr := fmt.Sprintf("%d", p.RSSI)
 
-		// Parse payload structure:
+		// Parse payload structure (version 0 layout, see models.ParseHubblePayload):
 		// Byte 0–1 : [Protocol Version (6 bits) | SeqNo (10 bits)]
 		// Byte 2–5 : Ephemeral Device Identifier (32 bits)
 		// Byte 6–9 : Authentication Tag (32 bits)
@@ -475,66 +1653,139 @@ func (m *BLEScanModel) updateTable() {
 
 		rows[rowIdx] = table.Row{
 			fmt.Sprintf("%d", i+1), // Keep original packet number for reference
-			p.Timestamp.Format("15:04:05.000"),
+			common.FormatTime(p.Timestamp, "15:04:05.000"),
 			rssiStr,
 			verStr,
 			seqStr,
 			deviceIDStr,
+			truncate(m.deviceName(i), 14),
 			authTagStr,
 			encryptedStr,
 		}
+		if m.showDecrypt {
+			rows[rowIdx] = append(rows[rowIdx], m.decryptedText(i))
+		}
 	}
 	m.table.SetRows(rows)
 }
 
-// parsePayloadFields extracts the structured fields from the payload
-// Byte 0–1 : [Protocol Version (6 bits) | SeqNo (10 bits)]
-// Byte 2–5 : Ephemeral Device Identifier (32 bits)
-// Byte 6–9 : Authentication Tag (32 bits)
-// Bytes 10+: Encrypted payload (0-13 bytes)
-func parsePayloadFields(payload []byte, maxEncryptedWidth int) (ver, seq, deviceID, authTag, encrypted string) {
-	if len(payload) < 2 {
-		return "-", "-", "-", "-", "-"
+// deviceGroup summarizes the captured packets from one ephemeral device ID
+type deviceGroup struct {
+	deviceID string
+	count    int
+	latest   int // Index into packets of the most recent packet
+	bestRSSI int
+}
+
+// groupPackets groups the captured packets by the device ID shown in the
+// table, most recently seen device first. Packets whose device ID can't be
+// parsed are grouped together under "-".
+func (m BLEScanModel) groupPackets() []deviceGroup {
+	byID := make(map[string]*deviceGroup)
+	var groups []*deviceGroup
+	for i, p := range m.packets {
+		_, _, deviceID, _, _ := parsePayloadFields(p.Payload, 0)
+		g, ok := byID[deviceID]
+		if !ok {
+			g = &deviceGroup{deviceID: deviceID, bestRSSI: p.RSSI}
+			byID[deviceID] = g
+			groups = append(groups, g)
+		}
+		g.count++
+		g.latest = i
+		g.bestRSSI = max(g.bestRSSI, p.RSSI)
 	}
 
-	// Byte 0-1: Protocol Version (6 bits) | SeqNo (10 bits)
-	// Big-endian (network byte order):
-	// Byte 0: [V5 V4 V3 V2 V1 V0 S9 S8] - version (6 bits) + seq high (2 bits)
-	// Byte 1: [S7 S6 S5 S4 S3 S2 S1 S0] - seq low (8 bits)
-	header := binary.BigEndian.Uint16(payload[0:2])
-	version := (header >> 10) & 0x3F // Top 6 bits
-	seqNo := header & 0x03FF         // Bottom 10 bits
-	ver = fmt.Sprintf("%d", version)
-	seq = fmt.Sprintf("%d", seqNo)
+	sorted := make([]deviceGroup, len(groups))
+	for i, g := range groups {
+		sorted[i] = *g
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].latest > sorted[j].latest })
+	return sorted
+}
 
-	// Byte 2-5: Ephemeral Device Identifier (32 bits)
-	if len(payload) >= 6 {
-		deviceID = fmt.Sprintf("%08x", payload[2:6])
-	} else {
-		deviceID = "-"
+// updateGroupedTable fills the table with one row per device
+func (m *BLEScanModel) updateGroupedTable() {
+	groups := m.groupPackets()
+	rows := make([]table.Row, len(groups))
+	m.rowIndex = make([]int, len(groups))
+
+	for i, g := range groups {
+		m.rowIndex[i] = g.latest
+		rows[i] = table.Row{
+			g.deviceID,
+			truncate(m.deviceName(g.latest), 14),
+			fmt.Sprintf("%d", g.count),
+			common.FormatTime(m.packets[g.latest].Timestamp, "15:04:05.000"),
+			fmt.Sprintf("%d", g.bestRSSI),
+		}
 	}
+	m.table.SetRows(rows)
+}
 
-	// Byte 6-9: Authentication Tag (32 bits)
-	if len(payload) >= 10 {
-		authTag = fmt.Sprintf("%08x", payload[6:10])
-	} else {
-		authTag = "-"
+// parsePayloadFields formats the fields of a payload parsed by
+// models.ParseHubblePayload. Payloads with an unknown protocol version are
+// shown raw rather than misparsed.
+func parsePayloadFields(payload []byte, maxEncryptedWidth int) (ver, seq, deviceID, authTag, encrypted string) {
+	if len(payload) < models.HubbleHeaderSize {
+		return "-", "-", "-", "-", "-"
 	}
 
-	// Bytes 10+: Encrypted payload (0-13 bytes)
-	if len(payload) > 10 {
-		encPayload := payload[10:]
-		encrypted = fmt.Sprintf("%x", encPayload)
-		if len(encrypted) > maxEncryptedWidth {
-			encrypted = encrypted[:maxEncryptedWidth-3] + "..."
-		}
-	} else {
+	fields, err := models.ParseHubblePayload(payload)
+	ver = fmt.Sprintf("%d", fields.Version)
+	if !fields.KnownVersion() {
+		// Unknown layout: don't guess at field boundaries
+		return ver + "?", "-", "-", "-", truncate(fmt.Sprintf("raw %x", payload), maxEncryptedWidth)
+	}
+
+	seq = fmt.Sprintf("%d", fields.Sequence)
+	deviceID = hexOrDash(fields.DeviceID)
+	authTag = hexOrDash(fields.AuthTag)
+
+	switch {
+	case err != nil:
+		// Flag short payloads explicitly so malformed firmware output stands out
+		encrypted = fmt.Sprintf("truncated (%dB)", len(payload))
+	case len(fields.Encrypted) > 0:
+		encrypted = truncate(fmt.Sprintf("%x", fields.Encrypted), maxEncryptedWidth)
+	default:
 		encrypted = "-"
 	}
 
 	return
 }
 
+// payloadBreakdown returns the fields of a payload labelled with their byte
+// offsets, for the packet detail views
+func payloadBreakdown(payload []byte) [][2]string {
+	if len(payload) < models.HubbleHeaderSize {
+		return nil
+	}
+	ver, seq, deviceID, authTag, encrypted := parsePayloadFields(payload, 2*len(payload)+len("raw "))
+	if strings.HasSuffix(ver, "?") {
+		return [][2]string{{"Version [0]:", ver}, {"Raw:", encrypted}}
+	}
+
+	span := func(offset, size int) string {
+		return fmt.Sprintf("%d-%d", offset, offset+size-1)
+	}
+	return [][2]string{
+		{"Version [0]:", ver},
+		{"Seq [0-1]:", seq},
+		{"Device ID [" + span(models.HubbleDeviceIDOffset, models.HubbleDeviceIDSize) + "]:", deviceID},
+		{"Auth Tag [" + span(models.HubbleAuthTagOffset, models.HubbleAuthTagSize) + "]:", authTag},
+		{fmt.Sprintf("Encrypted [%d-]:", models.HubbleDataOffset), encrypted},
+	}
+}
+
+// hexOrDash returns the hex encoding of b, or "-" if it is empty
+func hexOrDash(b []byte) string {
+	if len(b) == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%x", b)
+}
+
 func (m *BLEScanModel) startScan() tea.Cmd {
 	// Check if scanner initialization failed
 	if m.scannerErr != nil {
@@ -547,12 +1798,11 @@ func (m *BLEScanModel) startScan() tea.Cmd {
 
 	return func() tea.Msg {
 		opts := ble.ScanOptions{
-			Timeout:          0, // No timeout - scan continuously
 			FilterHubbleOnly: true,
-			Location: models.Location{
-				Fake:      true,
-				Timestamp: time.Now(),
-			},
+			Timeout:          m.scanDuration, // 0 scans continuously
+			Location:         m.captureLocation(),
+			MinRSSI:          m.minRSSI,
+			DedupeWindow:     m.dedupeWindow,
 		}
 
 		results, err := m.scanner.ScanStream(m.scanCtx, opts)
@@ -592,7 +1842,7 @@ func (m *BLEScanModel) pollResultsSync() tea.Msg {
 	case result, ok := <-m.resultsChan:
 		if !ok {
 			// Channel closed, scan complete
-			return BLEScanStoppedMsg{}
+			return BLEScanStoppedMsg{Results: m.resultsChan}
 		}
 		if result.Packet != nil {
 			return BLEScanPacketMsg{
@@ -619,7 +1869,7 @@ func (m *BLEScanModel) pollResults() tea.Cmd {
 		case result, ok := <-m.resultsChan:
 			if !ok {
 				// Channel closed, scan complete
-				return BLEScanStoppedMsg{}
+				return BLEScanStoppedMsg{Results: m.resultsChan}
 			}
 			if result.Error != nil {
 				return BLEScanStoppedMsg{Error: result.Error}
@@ -638,8 +1888,95 @@ func (m *BLEScanModel) pollResults() tea.Cmd {
 	}
 }
 
+// Busy reports whether a scan or upload is in progress
+func (m BLEScanModel) Busy() bool {
+	return m.state == BLEScanStateScanning || m.state == BLEScanStateIngesting
+}
+
+// Stop cancels any running scan and releases the adapter
+func (m *BLEScanModel) Stop() {
+	m.stopScan()
+	if m.state == BLEScanStateScanning {
+		m.state = BLEScanStateInit
+	}
+}
+
 // SetScanner allows setting a custom scanner (useful for testing)
 func (m *BLEScanModel) SetScanner(scanner ble.ScannerInterface) {
 	m.scanner = scanner
 	m.scannerErr = nil
 }
+
+// captureExportFormats are the formats offered for captured packets. There
+// is no CSV form of raw captures.
+var captureExportFormats = []exportFormatKey{
+	{"j", export.FormatJSON, "JSON"},
+	{"n", export.FormatNDJSON, "NDJSON"},
+}
+
+// exportCaptures writes captured packets to a timestamped file in the
+// given format
+func exportCaptures(packets []models.EncryptedPacket, format export.Format) tea.Cmd {
+	return func() tea.Msg {
+		path, err := writeExportFile("ble-capture", format, func(w io.Writer) error {
+			return export.WriteEncryptedPackets(w, format, packets)
+		})
+		if err != nil {
+			return BLEScanExportedMsg{Err: err}
+		}
+		return BLEScanExportedMsg{Path: path, Count: len(packets)}
+	}
+}
+
+// clearCapture drops the captured packets and everything derived from them
+func (m *BLEScanModel) clearCapture() {
+	m.frozen = false
+	m.packets = nil
+	m.rawPackets = nil
+	m.showDetail = false
+	m.deviceNames = nil
+	m.decrypted = nil
+	m.generation++
+	m.updateTable()
+}
+
+// ingestPackets uploads the captured packets, with the location attached
+// when they were captured
+func (m BLEScanModel) ingestPackets() tea.Cmd {
+	client := m.client
+	packets := slices.Clone(m.packets)
+	return func() tea.Msg {
+		report, err := client.IngestEncryptedPacketsWithReport(context.Background(), packets)
+		return BLEIngestCompleteMsg{Report: report, Err: err}
+	}
+}
+
+// ingestNotice describes a successful upload
+func ingestNotice(r api.IngestReport) string {
+	notice := fmt.Sprintf("Ingested %d packet(s)", r.Sent)
+	var skipped []string
+	if r.AlreadyIngested > 0 {
+		skipped = append(skipped, fmt.Sprintf("%d already ingested", r.AlreadyIngested))
+	}
+	if r.Rejected > 0 {
+		skipped = append(skipped, fmt.Sprintf("%d with bad timestamps", r.Rejected))
+	}
+	if len(skipped) > 0 {
+		notice += "; skipped " + strings.Join(skipped, ", ")
+	}
+	return notice
+}
+
+// saveCapture writes the captured advertisements and packets to a
+// timestamped file that can be replayed with --replay
+func saveCapture(raw []ble.RawAdvertisement, packets []models.EncryptedPacket) tea.Cmd {
+	return func() tea.Msg {
+		path, err := writeExportFile("ble-replay", export.FormatJSON, func(w io.Writer) error {
+			return ble.WriteCapture(w, raw, packets)
+		})
+		if err != nil {
+			return BLEScanCaptureSavedMsg{Err: err}
+		}
+		return BLEScanCaptureSavedMsg{Path: path, Count: len(packets)}
+	}
+}
@@ -1,13 +1,26 @@
 package screens
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hubblenetwork/hubcli/internal/api"
 	"github.com/hubblenetwork/hubcli/internal/ble"
+	"github.com/hubblenetwork/hubcli/internal/crypto"
+	"github.com/hubblenetwork/hubcli/internal/location"
 	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/hubblenetwork/hubcli/internal/tui/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewBLEScanModel(t *testing.T) {
@@ -74,7 +87,7 @@ func TestBLEScanModel_BackNavigation(t *testing.T) {
 	msg := cmd()
 	navMsg, ok := msg.(NavigateMsg)
 	assert.True(t, ok)
-	assert.Equal(t, "home", navMsg.Screen)
+	assert.Equal(t, "back", navMsg.Screen)
 }
 
 func TestBLEScanModel_QuitKey(t *testing.T) {
@@ -229,6 +242,26 @@ func TestBLEScanModel_ViewWithPackets(t *testing.T) {
 	assert.Contains(t, view, "clear")
 }
 
+func TestBLEScanModel_ViewWithTruncatedPacket(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.width = 130 // Wide enough for every column at its minimum width
+	m.height = 24
+	m.state = BLEScanStateInit
+	m.scannerErr = nil
+	m.packets = []models.EncryptedPacket{
+		{Payload: make([]byte, 9), RSSI: -70, Timestamp: time.Now()},
+		{Payload: make([]byte, 12), RSSI: -60, Timestamp: time.Now()},
+	}
+	m.rawPackets = make([]ble.RawAdvertisement, 2)
+	m.updateTable()
+
+	view := m.View()
+
+	assert.Equal(t, 1, m.truncatedCount())
+	assert.Contains(t, view, "Truncated: 1")
+	assert.Contains(t, view, "truncated (9B)")
+}
+
 func TestBLEScanModel_SetScanner(t *testing.T) {
 	m := NewBLEScanModel(nil)
 	mockScanner := ble.NewMockScanner()
@@ -237,3 +270,922 @@ func TestBLEScanModel_SetScanner(t *testing.T) {
 
 	assert.Equal(t, mockScanner, m.scanner)
 }
+
+func TestBLEScanModel_Busy(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	assert.False(t, m.Busy())
+
+	m.state = BLEScanStateScanning
+	assert.True(t, m.Busy())
+
+	m.Stop()
+	assert.False(t, m.Busy())
+}
+
+func TestBLEScanModel_FleetIdentification(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.width = 140
+	m.height = 30
+	m.scannerErr = nil
+
+	packet := models.EncryptedPacket{Payload: make([]byte, 12), Timestamp: time.Now()}
+	m, _ = m.Update(BLEScanPacketMsg{Packet: packet})
+	assert.Equal(t, "-", m.deviceName(0)) // Fleet not loaded yet
+
+	m, cmd := m.Update(BLEScanFleetLoadedMsg{Devices: []models.Device{{ID: "dev-1", Name: "Sensor"}}})
+	assert.Equal(t, "...", m.deviceName(0))
+	require.NotNil(t, cmd)
+
+	// No device key authenticates the packet
+	m, _ = m.Update(cmd())
+	assert.Equal(t, "unknown", m.deviceName(0))
+}
+
+func TestBLEScanModel_IdentifiedMsg_StaleGeneration(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.fleetLoaded = true
+	m.packets = []models.EncryptedPacket{{Payload: make([]byte, 12)}}
+	m.rawPackets = make([]ble.RawAdvertisement, 1)
+	m.deviceNames = []string{""}
+	m.generation = 2
+
+	m, _ = m.Update(BLEScanIdentifiedMsg{Generation: 1, Index: 0, Name: "Sensor"})
+	assert.Equal(t, "...", m.deviceName(0))
+
+	m, _ = m.Update(BLEScanIdentifiedMsg{Generation: 2, Index: 0, Name: "Sensor"})
+	assert.Equal(t, "Sensor", m.deviceName(0))
+}
+
+func TestBLEScanModel_Detail(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.width = 120
+	m.height = 40
+	m.scannerErr = nil
+	m.packets = []models.EncryptedPacket{
+		{Payload: []byte{0xde, 0xad, 0xbe, 0xef}, RSSI: -55, Timestamp: time.Now()},
+	}
+	m.rawPackets = []ble.RawAdvertisement{
+		{
+			Address:          "AA:BB:CC:DD:EE:FF",
+			ServiceData:      map[string][]byte{"fca6": {0x01, 0x02}},
+			ManufacturerData: []byte{0x4c, 0x00},
+		},
+	}
+	m.updateTable()
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.True(t, m.showDetail)
+	assert.Equal(t, 0, m.detailIndex)
+
+	view := m.View()
+	assert.Contains(t, view, "AA:BB:CC:DD:EE:FF")
+	assert.Contains(t, view, "fca6: 0102")
+	assert.Contains(t, view, "4c00")
+	assert.Contains(t, view, "deadbeef")
+	assert.Contains(t, view, "copy payload hex")
+	// An unknown protocol version is shown raw rather than split into fields
+	assert.Contains(t, view, "55?")
+	assert.Contains(t, view, "raw deadbeef")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	assert.NotNil(t, cmd)
+
+	m, _ = m.Update(common.ClipboardCopiedMsg{Label: "payload hex"})
+	assert.Contains(t, m.View(), "Copied payload hex")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.False(t, m.showDetail)
+}
+
+func TestBLEScanModel_Detail_NoPackets(t *testing.T) {
+	m := NewBLEScanModel(nil)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, m.showDetail)
+}
+
+func TestBLEScanModel_SelectedIndex_NewestFirst(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.width = 140
+	m.height = 40
+	m.scannerErr = nil
+
+	addrs := []string{"00:00:00:00:00:01", "00:00:00:00:00:02", "00:00:00:00:00:03"}
+	for i, addr := range addrs {
+		m.packets = append(m.packets, models.EncryptedPacket{Payload: []byte{byte(i)}, Timestamp: time.Now()})
+		m.rawPackets = append(m.rawPackets, ble.RawAdvertisement{Address: addr})
+	}
+	m.updateTable()
+
+	// Row 0 is the newest packet
+	idx, ok := m.selectedIndex()
+	require.True(t, ok)
+	assert.Equal(t, 2, idx)
+
+	m.table.MoveDown(2)
+	idx, ok = m.selectedIndex()
+	require.True(t, ok)
+	assert.Equal(t, 0, idx)
+
+	m.table.MoveUp(1)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.True(t, m.showDetail)
+	assert.Equal(t, 1, m.detailIndex)
+	assert.Contains(t, m.View(), addrs[1])
+}
+
+func TestBLEScanModel_SelectedIndex_Empty(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.updateTable()
+
+	_, ok := m.selectedIndex()
+	assert.False(t, ok)
+}
+
+func TestParsePayloadFields(t *testing.T) {
+	// Version 0, seq 5, device ID, auth tag, 2 bytes of ciphertext
+	payload := []byte{0x00, 0x05, 0x11, 0x22, 0x33, 0x44, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	ver, seq, deviceID, authTag, encrypted := parsePayloadFields(payload, 40)
+
+	assert.Equal(t, "0", ver)
+	assert.Equal(t, "5", seq)
+	assert.Equal(t, "11223344", deviceID)
+	assert.Equal(t, "aabbccdd", authTag)
+	assert.Equal(t, "eeff", encrypted)
+}
+
+func TestParsePayloadFields_UnknownVersion(t *testing.T) {
+	// Version 3 has no registered layout
+	payload := []byte{0x0c, 0x05, 0x11, 0x22, 0x33, 0x44, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	ver, seq, deviceID, authTag, encrypted := parsePayloadFields(payload, 40)
+
+	assert.Equal(t, "3?", ver)
+	assert.Equal(t, "-", seq)
+	assert.Equal(t, "-", deviceID)
+	assert.Equal(t, "-", authTag)
+	assert.Equal(t, "raw 0c0511223344aabbccddeeff", encrypted)
+}
+
+func TestParsePayloadFields_Short(t *testing.T) {
+	ver, _, deviceID, _, _ := parsePayloadFields([]byte{0x00, 0x01, 0x02}, 40)
+	assert.Equal(t, "0", ver)
+	assert.Equal(t, "-", deviceID)
+
+	ver, _, _, _, _ = parsePayloadFields([]byte{0x00}, 40)
+	assert.Equal(t, "-", ver)
+}
+
+func TestBLEScanModel_RedrawThrottle(t *testing.T) {
+	now := time.Now()
+	m := NewBLEScanModel(nil)
+	m.now = func() time.Time { return now }
+	m.SetRedrawInterval(200 * time.Millisecond)
+
+	packet := models.EncryptedPacket{Payload: make([]byte, 12), Timestamp: now}
+
+	// The first packet redraws immediately
+	m, _ = m.Update(BLEScanPacketMsg{Packet: packet})
+	assert.Len(t, m.table.Rows(), 1)
+
+	// Packets within the interval are captured but not yet drawn
+	now = now.Add(50 * time.Millisecond)
+	m, _ = m.Update(BLEScanPacketMsg{Packet: packet})
+	m, _ = m.Update(BLEScanPacketMsg{Packet: packet})
+	assert.Len(t, m.packets, 3)
+	assert.Len(t, m.table.Rows(), 1)
+	assert.True(t, m.tableDirty)
+
+	// A tick before the interval has passed does nothing
+	m, _ = m.Update(BLEScanTickMsg{})
+	assert.Len(t, m.table.Rows(), 1)
+
+	// Once the interval has passed the next tick catches up
+	now = now.Add(200 * time.Millisecond)
+	m, _ = m.Update(BLEScanTickMsg{})
+	assert.Len(t, m.table.Rows(), 3)
+	assert.False(t, m.tableDirty)
+}
+
+func TestBLEScanModel_RedrawThrottle_Disabled(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.SetRedrawInterval(0)
+
+	packet := models.EncryptedPacket{Payload: make([]byte, 12), Timestamp: time.Now()}
+	for i := 0; i < 3; i++ {
+		m, _ = m.Update(BLEScanPacketMsg{Packet: packet})
+	}
+
+	assert.Len(t, m.table.Rows(), 3)
+}
+
+func TestBLEScanModel_StoppedFlushesTable(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.SetRedrawInterval(time.Hour)
+
+	packet := models.EncryptedPacket{Payload: make([]byte, 12), Timestamp: time.Now()}
+	m, _ = m.Update(BLEScanPacketMsg{Packet: packet})
+	m, _ = m.Update(BLEScanPacketMsg{Packet: packet})
+	require.Len(t, m.table.Rows(), 1)
+
+	m, _ = m.Update(BLEScanStoppedMsg{})
+	assert.Len(t, m.table.Rows(), 2)
+}
+
+func TestBLEScanModel_CaptureLocation(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.scannerErr = nil
+
+	loc := m.captureLocation()
+	assert.True(t, loc.Fake)
+
+	m.SetScanLocation(models.Location{Latitude: 37.7749, Longitude: -122.4194, Fake: true})
+	loc = m.captureLocation()
+	assert.False(t, loc.Fake)
+	assert.Equal(t, 37.7749, loc.Latitude)
+	assert.False(t, loc.Timestamp.IsZero())
+
+	assert.Contains(t, m.View(), "Location: 37.7749, -122.4194")
+}
+
+func TestBLEScanModel_ScanLocationFlowsIntoScanOptions(t *testing.T) {
+	scanner := ble.NewMockScanner()
+
+	m := NewBLEScanModel(nil)
+	m.SetScanner(scanner)
+	m.SetScanLocation(models.Location{Latitude: 37.7749, Longitude: -122.4194})
+
+	_, ok := m.startScan()().(BLEScanStartedMsg)
+	require.True(t, ok)
+	defer m.stopScan()
+
+	loc := scanner.LastOptions().Location
+	assert.Equal(t, 37.7749, loc.Latitude)
+	assert.Equal(t, -122.4194, loc.Longitude)
+	assert.False(t, loc.Fake)
+}
+
+func TestBLEScanModel_DedupesRepeatedAdvertisements(t *testing.T) {
+	advertisement := func(id byte, rssi int) models.EncryptedPacket {
+		return models.EncryptedPacket{Payload: []byte{0x00, 0x01, id, id, id, id, 0x01}, RSSI: rssi}
+	}
+	scanner := ble.NewMockScanner()
+	scanner.SetPackets([]models.EncryptedPacket{
+		advertisement(0xaa, -80),
+		advertisement(0xbb, -70),
+		advertisement(0xaa, -60),
+		advertisement(0xaa, -75),
+		advertisement(0xbb, -72),
+	})
+
+	m := NewBLEScanModel(nil)
+	m.width = 140
+	m.height = 40
+	m.state = BLEScanStateInit
+	m.SetScanner(scanner)
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	require.NotNil(t, cmd)
+	started, ok := cmd().(BLEScanStartedMsg)
+	require.True(t, ok)
+	assert.Equal(t, DefaultScanDedupeWindow, scanner.LastOptions().DedupeWindow)
+	m, _ = m.Update(started)
+
+	for r := range started.Results {
+		m, _ = m.Update(BLEScanPacketMsg{Packet: *r.Packet, Raw: r.Raw})
+	}
+	m, _ = m.Update(BLEScanStoppedMsg{Results: started.Results})
+
+	// One row per beacon, keeping its strongest copy
+	require.Len(t, m.packets, 2)
+	assert.Equal(t, -60, m.packets[0].RSSI)
+	assert.Equal(t, -70, m.packets[1].RSSI)
+	assert.Len(t, m.table.Rows(), 2)
+	assert.Contains(t, m.View(), "Packets: 2")
+}
+
+func TestBLEScanModel_SetLocationKey(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.state = BLEScanStateInit
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'L'}})
+	require.True(t, m.editingLocation)
+	assert.Contains(t, m.View(), "Capture location")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("north")})
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Nil(t, cmd)
+	assert.True(t, m.editingLocation)
+
+	m.locationInput.SetValue("51.5072, -0.1276")
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	assert.False(t, m.editingLocation)
+	msg, ok := cmd().(ScanLocationSetMsg)
+	require.True(t, ok)
+	assert.Equal(t, &location.Manual{Latitude: 51.5072, Longitude: -0.1276}, msg.Location)
+
+	loc := m.captureLocation()
+	assert.False(t, loc.Fake)
+	assert.Equal(t, 51.5072, loc.Latitude)
+	assert.Contains(t, m.View(), "Location: 51.5072, -0.1276")
+
+	// Not while scanning: packets already captured keep their location
+	m.state = BLEScanStateScanning
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'L'}})
+	assert.False(t, m.editingLocation)
+}
+
+func TestBLEScanModel_LocationProviderFallback(t *testing.T) {
+	m := NewBLEScanModel(nil)
+
+	m.SetLocationProvider(location.Manual{Latitude: 10, Longitude: 20})
+	loc := m.captureLocation()
+	assert.False(t, loc.Fake)
+	assert.Equal(t, 10.0, loc.Latitude)
+
+	// An entered location wins over the provider
+	m.SetScanLocation(models.Location{Latitude: 30, Longitude: 40})
+	assert.Equal(t, 30.0, m.captureLocation().Latitude)
+
+	m.scanLocation = nil
+	m.SetLocationProvider(location.System())
+	assert.True(t, m.captureLocation().Fake)
+}
+
+func TestBLEScanModel_UniqueDevices(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.width = 140
+	m.height = 40
+	m.state = BLEScanStateInit
+
+	// Bytes 2-5 are the ephemeral device ID
+	m.packets = []models.EncryptedPacket{
+		{Payload: []byte{0x00, 0x01, 0xaa, 0xaa, 0xaa, 0xaa, 0x01}},
+		{Payload: []byte{0x00, 0x02, 0xaa, 0xaa, 0xaa, 0xaa, 0x02}},
+		{Payload: []byte{0x00, 0x03, 0xbb, 0xbb, 0xbb, 0xbb, 0x03}},
+		{Payload: []byte{0x01}}, // Too short to identify
+	}
+
+	assert.Equal(t, 2, m.uniqueDeviceCount())
+	view := m.View()
+	assert.Contains(t, view, "Packets: 4")
+	assert.Contains(t, view, "Unique devices: 2")
+}
+
+func TestBLEScanModel_GroupByDevice(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.width = 140
+	m.height = 40
+	m.state = BLEScanStateScanning
+	m.SetRedrawInterval(0)
+	base := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	send := func(id byte, rssi int, offset time.Duration) {
+		m, _ = m.Update(BLEScanPacketMsg{
+			Packet: models.EncryptedPacket{
+				Payload:   []byte{0x00, 0x01, id, id, id, id, 0x01, 0x02, 0x03, 0x04, 0x05},
+				RSSI:      rssi,
+				Timestamp: base.Add(offset),
+			},
+		})
+	}
+	send(0xaa, -80, 0)
+	send(0xbb, -70, time.Second)
+	send(0xaa, -60, 2*time.Second)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	require.True(t, m.grouped)
+	assert.Contains(t, m.renderHelp(), "ungroup")
+
+	// Most recently seen device first, with its count and strongest RSSI
+	rows := m.table.Rows()
+	require.Len(t, rows, 2)
+	assert.Equal(t, table.Row{"aaaaaaaa", "-", "2", common.FormatTime(base.Add(2*time.Second), "15:04:05.000"), "-60"}, rows[0])
+	assert.Equal(t, "bbbbbbbb", rows[1][0])
+	assert.Equal(t, "1", rows[1][2])
+	assert.Contains(t, m.View(), "Best RSSI")
+
+	// Live updates regroup
+	send(0xbb, -50, 3*time.Second)
+	rows = m.table.Rows()
+	require.Len(t, rows, 2)
+	assert.Equal(t, "bbbbbbbb", rows[0][0])
+	assert.Equal(t, "2", rows[0][2])
+	assert.Equal(t, "-50", rows[0][4])
+
+	// Details open on the device's latest packet
+	idx, ok := m.selectedIndex()
+	require.True(t, ok)
+	assert.Equal(t, 3, idx)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	assert.False(t, m.grouped)
+	assert.Len(t, m.table.Rows(), 4)
+	assert.NotContains(t, m.View(), "Best RSSI")
+}
+
+func TestBLEScanModel_MinRSSI(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.width = 140
+	m.height = 40
+	m.state = BLEScanStateInit
+	assert.NotContains(t, m.View(), "Min RSSI")
+
+	plus := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'+'}}
+	minus := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'-'}}
+
+	// Raising from off starts at the lowest floor; paused, nothing restarts
+	m, cmd := m.Update(plus)
+	assert.Nil(t, cmd)
+	assert.Equal(t, -100, m.minRSSI)
+	m, _ = m.Update(plus)
+	assert.Equal(t, -95, m.minRSSI)
+	assert.Contains(t, m.View(), "Min RSSI: -95 dBm")
+
+	// Lowering past the lowest floor turns it off
+	m, _ = m.Update(minus)
+	m, _ = m.Update(minus)
+	assert.Equal(t, 0, m.minRSSI)
+	m, _ = m.Update(minus)
+	assert.Equal(t, 0, m.minRSSI)
+	assert.NotContains(t, m.View(), "Min RSSI")
+
+	// The floor is capped
+	for range 20 {
+		m, _ = m.Update(plus)
+	}
+	assert.Equal(t, -30, m.minRSSI)
+}
+
+func TestBLEScanModel_MinRSSIRestartsScan(t *testing.T) {
+	scanner := ble.NewMockScanner()
+	scanner.SetPackets([]models.EncryptedPacket{{Payload: []byte{0x01}, RSSI: -60}})
+
+	m := NewBLEScanModel(nil)
+	m.SetScanner(scanner)
+	started, ok := m.startScan()().(BLEScanStartedMsg)
+	require.True(t, ok)
+	m, _ = m.Update(started)
+	defer m.stopScan()
+	assert.Equal(t, 0, scanner.LastOptions().MinRSSI)
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'+'}})
+	require.NotNil(t, cmd)
+	restarted, ok := cmd().(BLEScanStartedMsg)
+	require.True(t, ok)
+	assert.Equal(t, -100, scanner.LastOptions().MinRSSI)
+
+	// The first scan's channel closing doesn't stop the new scan, and the
+	// existing tick loop carries on polling
+	m, cmd = m.Update(restarted)
+	assert.Nil(t, cmd)
+	m, _ = m.Update(BLEScanStoppedMsg{Results: started.Results})
+	assert.Equal(t, BLEScanStateScanning, m.state)
+
+	m, _ = m.Update(BLEScanStoppedMsg{Results: restarted.Results})
+	assert.Equal(t, BLEScanStateInit, m.state)
+}
+
+func TestBLEScanModel_Freeze(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.width = 140
+	m.height = 40
+	m.scannerErr = nil
+	m.SetRedrawInterval(0)
+
+	packet := models.EncryptedPacket{Payload: make([]byte, 12), Timestamp: time.Now()}
+	m, _ = m.Update(BLEScanPacketMsg{Packet: packet})
+	require.Len(t, m.table.Rows(), 1)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}})
+	require.True(t, m.frozen)
+
+	// Capture continues while the display is frozen
+	m, _ = m.Update(BLEScanPacketMsg{Packet: packet})
+	m, _ = m.Update(BLEScanPacketMsg{Packet: packet})
+	m, _ = m.Update(BLEScanTickMsg{})
+	assert.Len(t, m.packets, 3)
+	assert.Len(t, m.table.Rows(), 1)
+	assert.Contains(t, m.View(), "FROZEN +2 new")
+
+	// Unfreezing catches up
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}})
+	assert.False(t, m.frozen)
+	assert.Len(t, m.table.Rows(), 3)
+	assert.NotContains(t, m.View(), "FROZEN")
+}
+
+func TestBLEScanModel_TableFitsWindow(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.SetRedrawInterval(-1)
+	m.state = BLEScanStateScanning
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 140, Height: 40})
+
+	for i := 0; i < 100; i++ {
+		m, _ = m.Update(BLEScanPacketMsg{
+			Packet: models.EncryptedPacket{Payload: []byte{byte(i)}, Timestamp: time.Now()},
+		})
+	}
+	assert.Equal(t, 40, lipgloss.Height(m.View()))
+
+	m, _ = m.Update(BLEScanStoppedMsg{})
+	assert.Equal(t, 40, lipgloss.Height(m.View()))
+}
+
+func TestBLEScanModel_ReloadKeysMergesFleet(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m, _ = m.Update(BLEScanFleetLoadedMsg{Devices: []models.Device{{ID: "a", Name: "old"}}})
+	m, _ = m.Update(BLEScanPacketMsg{Packet: models.EncryptedPacket{Payload: []byte{0x01}}})
+	m, _ = m.Update(BLEScanIdentifiedMsg{Index: 0, Name: "unknown"})
+
+	m, cmd := m.Update(BLEScanFleetLoadedMsg{
+		Devices: []models.Device{{ID: "a", Name: "renamed"}, {ID: "b"}},
+		Reload:  true,
+	})
+
+	require.Len(t, m.fleet, 2)
+	assert.Equal(t, "renamed", m.fleet[0].Name)
+	assert.Equal(t, "Loaded 2 device key(s), 1 new", m.notice)
+	// Unmatched packets are identified again with the new keys
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "...", m.deviceName(0))
+}
+
+func TestBLEScanModel_ReloadKeysFailureKeepsFleet(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m, _ = m.Update(BLEScanFleetLoadedMsg{Devices: []models.Device{{ID: "a"}}})
+
+	m, _ = m.Update(BLEScanFleetLoadedMsg{Err: errors.New("timeout"), Reload: true})
+
+	assert.Len(t, m.fleet, 1)
+	assert.NoError(t, m.fleetErr)
+	assert.Equal(t, "Key reload failed: timeout", m.notice)
+}
+
+func TestBLEScanModel_ReloadKeysNeedsClient(t *testing.T) {
+	m := NewBLEScanModel(nil)
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'K'}})
+
+	assert.Nil(t, cmd)
+	assert.NotContains(t, m.renderHelp(), "reload keys")
+}
+
+// encryptedScanPacket builds an advertisement for key sent at timeCounter
+func encryptedScanPacket(t *testing.T, key []byte, timeCounter uint32, plaintext []byte) models.EncryptedPacket {
+	t.Helper()
+
+	const seq = 5
+	encKey, err := crypto.FullEncryptionKeyDerivation(key, timeCounter, seq)
+	require.NoError(t, err)
+	nonce, err := crypto.FullNonceDerivation(key, timeCounter, seq)
+	require.NoError(t, err)
+	ciphertext, err := crypto.AESCTREncrypt(encKey, nonce, plaintext)
+	require.NoError(t, err)
+
+	header := []byte{0x00, seq, 0, 0, 0, 0}
+	authTag, err := crypto.ComputeAuthTag(encKey, header)
+	require.NoError(t, err)
+
+	return models.EncryptedPacket{
+		Payload:   append(append(header, authTag...), ciphertext...),
+		Timestamp: crypto.CounterToTime(timeCounter),
+	}
+}
+
+func TestBLEScanModel_DecryptColumn(t *testing.T) {
+	key := make([]byte, 16)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	m := NewBLEScanModel(api.NewClient("test-org", "test-token"))
+	m.SetRedrawInterval(-1)
+	m.scannerErr = nil
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m, _ = m.Update(BLEScanFleetLoadedMsg{Devices: []models.Device{{
+		ID:         "dev-1",
+		Name:       "Sensor",
+		Key:        base64.StdEncoding.EncodeToString(key),
+		Encryption: models.EncryptionAES128CTR,
+	}}})
+
+	m, cmd := m.Update(BLEScanPacketMsg{Packet: encryptedScanPacket(t, key, 20000, []byte{0xca, 0xfe})})
+	require.NotNil(t, cmd)
+	m, _ = m.Update(cmd())
+	m, _ = m.Update(BLEScanPacketMsg{Packet: models.EncryptedPacket{Payload: make([]byte, 12), Timestamp: time.Now()}})
+
+	assert.Equal(t, "Sensor", m.deviceName(0))
+	assert.Equal(t, "cafe", m.decryptedText(0))
+	assert.Equal(t, "-", m.decryptedText(1))
+	assert.NotContains(t, m.View(), "Decrypted")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	assert.True(t, m.showDecrypt)
+	view := m.View()
+	assert.Contains(t, view, "Decrypted")
+	assert.Contains(t, view, "cafe")
+	assert.Contains(t, m.renderHelp(), "hide decrypted")
+	assert.Equal(t, 40, lipgloss.Height(view))
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	assert.NotContains(t, m.View(), "Decrypted")
+}
+
+func TestBLEScanModel_DecryptNeedsClient(t *testing.T) {
+	m := NewBLEScanModel(nil)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+
+	assert.False(t, m.showDecrypt)
+	assert.NotContains(t, m.renderHelp(), "decrypt")
+}
+
+func TestBLEScanModel_Export(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.state = BLEScanStateInit
+	m.packets = []models.EncryptedPacket{{Payload: []byte{0x01}}}
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	assert.Nil(t, cmd)
+	assert.True(t, m.exportPrompt)
+	assert.Contains(t, m.renderHelp(), "NDJSON")
+	assert.NotContains(t, m.renderHelp(), "CSV")
+
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	assert.NotNil(t, cmd)
+	assert.False(t, m.exportPrompt)
+
+	m, _ = m.Update(BLEScanExportedMsg{Path: "ble-capture.json", Count: 1})
+	assert.Equal(t, "Exported 1 packet(s) to ble-capture.json", m.notice)
+}
+
+func TestBLEScanModel_ExportCancelKeepsPackets(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.state = BLEScanStateInit
+	m.packets = []models.EncryptedPacket{{Payload: []byte{0x01}}}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+
+	// c is not a capture format, so it cancels instead of clearing
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	assert.Nil(t, cmd)
+	assert.False(t, m.exportPrompt)
+	assert.Len(t, m.packets, 1)
+}
+
+func TestBLEScanModel_ExportNeedsPackets(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.state = BLEScanStateInit
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+
+	assert.False(t, m.exportPrompt)
+	assert.NotContains(t, m.renderHelp(), "export")
+}
+
+func TestBLEScanModel_SaveCapture(t *testing.T) {
+	t.Chdir(t.TempDir())
+	m := NewBLEScanModel(nil)
+	m.state = BLEScanStateInit
+	m.packets = []models.EncryptedPacket{{Payload: []byte{0x01, 0x02}, RSSI: -60, Timestamp: time.Now()}}
+	m.rawPackets = []ble.RawAdvertisement{{Address: "AA:BB:CC:DD:EE:FF", RSSI: -60}}
+	assert.Contains(t, m.renderHelp(), "save capture")
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	require.NotNil(t, cmd)
+	msg, ok := cmd().(BLEScanCaptureSavedMsg)
+	require.True(t, ok)
+	require.NoError(t, msg.Err)
+	assert.Equal(t, 1, msg.Count)
+	assert.True(t, strings.HasPrefix(msg.Path, "ble-replay-"))
+
+	c, err := ble.LoadCapture(msg.Path)
+	require.NoError(t, err)
+	assert.Equal(t, m.packets[0].Payload, c.Packets[0].Payload)
+	assert.Equal(t, "AA:BB:CC:DD:EE:FF", c.Advertisements[0].Address)
+
+	m, _ = m.Update(msg)
+	assert.Equal(t, "Saved 1 packet(s) to "+msg.Path, m.notice)
+}
+
+func TestBLEScanModel_SelectAdapter(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.width = 140
+	m.height = 40
+	m.state = BLEScanStateInit
+	m.listAdapters = func() ([]ble.AdapterInfo, error) {
+		return []ble.AdapterInfo{{ID: "hci0", Default: true}, {ID: "hci1"}}, nil
+	}
+	replacement := ble.NewMockScanner()
+	var opened string
+	m.openAdapter = func(id string) (ble.ScannerInterface, error) {
+		opened = id
+		return replacement, nil
+	}
+	assert.Contains(t, m.renderHelp(), "adapter")
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	require.NotNil(t, cmd)
+	m, _ = m.Update(cmd())
+	require.True(t, m.pickingAdapter)
+	view := m.View()
+	assert.Contains(t, view, "▸ hci0 (default) - in use")
+	assert.Contains(t, view, "hci1")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	assert.False(t, m.pickingAdapter)
+	m, _ = m.Update(cmd())
+
+	assert.Equal(t, "hci1", opened)
+	assert.Same(t, replacement, m.scanner)
+	assert.Equal(t, "hci1", m.adapterID)
+	assert.Contains(t, m.View(), "Adapter: hci1")
+	assert.Equal(t, "Using adapter hci1", m.notice)
+}
+
+func TestBLEScanModel_SelectAdapterFallsBack(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.state = BLEScanStateInit
+	original := ble.NewMockScanner()
+	m.SetScanner(original)
+
+	// Platforms without enumeration keep the default adapter
+	m, _ = m.Update(BLEScanAdaptersMsg{Err: ble.ErrAdapterSelectionUnsupported})
+	assert.False(t, m.pickingAdapter)
+	assert.Contains(t, m.notice, "using the default adapter")
+
+	// An adapter that fails to open leaves the current scanner in place
+	m, _ = m.Update(BLEScanAdapterOpenedMsg{ID: "hci1", Err: ble.ErrAdapterNotEnabled})
+	assert.Same(t, original, m.scanner)
+	assert.Empty(t, m.adapterID)
+	assert.Contains(t, m.notice, "Could not use adapter hci1")
+
+	// Esc closes the list without changing adapter
+	m, _ = m.Update(BLEScanAdaptersMsg{Adapters: []ble.AdapterInfo{{ID: "hci0", Default: true}}})
+	require.True(t, m.pickingAdapter)
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Nil(t, cmd)
+	assert.False(t, m.pickingAdapter)
+}
+
+func TestBLEScanModel_AdapterNotWhileScanning(t *testing.T) {
+	m := NewBLEScanModel(nil)
+	m.state = BLEScanStateScanning
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+
+	assert.Nil(t, cmd)
+	assert.NotContains(t, m.renderHelp(), "adapter")
+}
+
+func TestBLEScanModel_TimedScan(t *testing.T) {
+	scanner := ble.NewMockScanner()
+	scanner.SetPackets([]models.EncryptedPacket{
+		{Payload: []byte{0x00, 0x01, 0xaa, 0xaa, 0xaa, 0xaa, 0x01}},
+		{Payload: []byte{0x00, 0x02, 0xaa, 0xaa, 0xaa, 0xaa, 0x02}},
+	})
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	m := NewBLEScanModel(nil)
+	m.width = 140
+	m.height = 40
+	m.state = BLEScanStateInit
+	m.now = func() time.Time { return now }
+	m.SetScanner(scanner)
+	m.SetDedupeWindow(0) // Keep both packets from the one beacon
+	assert.Contains(t, m.renderHelp(), "scan 10/30/60s")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'3'}})
+	assert.Equal(t, 30*time.Second, m.scanDuration)
+	assert.Contains(t, m.View(), "Duration: 30s")
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	require.NotNil(t, cmd)
+	started, ok := cmd().(BLEScanStartedMsg)
+	require.True(t, ok)
+	assert.Equal(t, 30*time.Second, scanner.LastOptions().Timeout)
+	m, _ = m.Update(started)
+
+	// Live countdown; duration keys don't change a running scan
+	now = now.Add(12 * time.Second)
+	assert.Contains(t, m.View(), "Time left: 18s")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'6'}})
+	assert.Equal(t, 30*time.Second, m.scanDuration)
+
+	for r := range started.Results {
+		m, _ = m.Update(BLEScanPacketMsg{Packet: *r.Packet, Raw: r.Raw})
+	}
+	m, _ = m.Update(BLEScanStoppedMsg{Results: started.Results})
+
+	assert.Equal(t, BLEScanStateInit, m.state)
+	assert.Equal(t, "Timed scan finished: 2 new packet(s), 1 unique device(s) captured", m.notice)
+	assert.NotContains(t, m.View(), "Time left")
+
+	// Back to continuous
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'0'}})
+	assert.Zero(t, m.scanDuration)
+	assert.NotContains(t, m.View(), "Duration:")
+}
+
+func TestBLEScanModel_ContinuousScanHasNoSummary(t *testing.T) {
+	results := make(chan ble.ScanResult)
+	close(results)
+
+	m := NewBLEScanModel(nil)
+	m, _ = m.Update(BLEScanStartedMsg{Results: results})
+	m, _ = m.Update(BLEScanStoppedMsg{Results: results})
+
+	assert.Equal(t, BLEScanStateInit, m.state)
+	assert.Empty(t, m.notice)
+}
+
+func TestBLEScanModel_Ingest(t *testing.T) {
+	var received models.IngestPacketRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/org/test-org/packets", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	m := NewBLEScanModel(api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)))
+	m.width = 140
+	m.height = 40
+	m.state = BLEScanStateInit
+	m.SetScanLocation(models.Location{Latitude: 37.7749, Longitude: -122.4194})
+	m.packets = []models.EncryptedPacket{
+		{Payload: []byte{0x01, 0x02}, RSSI: -60, Timestamp: time.Now(), Location: m.captureLocation()},
+		{Payload: []byte{0x03, 0x04}, RSSI: -70, Timestamp: time.Now(), Location: m.captureLocation()},
+	}
+	m.rawPackets = make([]ble.RawAdvertisement, 2)
+	assert.Contains(t, m.renderHelp(), "ingest")
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	require.NotNil(t, cmd)
+	assert.Equal(t, BLEScanStateIngesting, m.state)
+	assert.True(t, m.Busy())
+	assert.Contains(t, m.View(), "Ingesting 2 packet(s)...")
+
+	// Other keys wait for the upload
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	assert.Len(t, m.packets, 2)
+
+	msg := m.ingestPackets()()
+	require.IsType(t, BLEIngestCompleteMsg{}, msg)
+	require.Len(t, received.BLELocations, 2)
+	assert.Equal(t, 37.7749, received.BLELocations[0].Location.Latitude)
+
+	m, _ = m.Update(msg)
+	assert.Equal(t, BLEScanStateInit, m.state)
+	assert.Equal(t, "Ingested 2 packet(s)", m.notice)
+	assert.Empty(t, m.packets)
+	assert.Empty(t, m.rawPackets)
+}
+
+func TestBLEScanModel_IngestErrorKeepsPackets(t *testing.T) {
+	m := NewBLEScanModel(api.NewClient("test-org", "test-token"))
+	m.state = BLEScanStateIngesting
+	m.packets = []models.EncryptedPacket{{Payload: []byte{0x01}}}
+
+	m, _ = m.Update(BLEIngestCompleteMsg{Err: api.NewAPIError(http.StatusBadRequest, "bad")})
+
+	assert.Equal(t, BLEScanStateInit, m.state)
+	assert.Len(t, m.packets, 1)
+	assert.Contains(t, m.notice, "Ingest failed")
+}
+
+func TestBLEScanModel_IngestNeedsPausedCapture(t *testing.T) {
+	i := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}}
+
+	// No client
+	m := NewBLEScanModel(nil)
+	m.state = BLEScanStateInit
+	m.packets = []models.EncryptedPacket{{Payload: []byte{0x01}}}
+	m, _ = m.Update(i)
+	assert.Equal(t, BLEScanStateInit, m.state)
+	assert.NotContains(t, m.renderHelp(), "ingest")
+
+	// Still scanning
+	m = NewBLEScanModel(api.NewClient("test-org", "test-token"))
+	m.state = BLEScanStateScanning
+	m.packets = []models.EncryptedPacket{{Payload: []byte{0x01}}}
+	m, _ = m.Update(i)
+	assert.Equal(t, BLEScanStateScanning, m.state)
+
+	// Nothing captured
+	m = NewBLEScanModel(api.NewClient("test-org", "test-token"))
+	m.state = BLEScanStateInit
+	m, _ = m.Update(i)
+	assert.Equal(t, BLEScanStateInit, m.state)
+}
+
+func TestIngestNotice(t *testing.T) {
+	assert.Equal(t, "Ingested 3 packet(s)", ingestNotice(api.IngestReport{Sent: 3}))
+	assert.Equal(t, "Ingested 1 packet(s); skipped 2 already ingested, 1 with bad timestamps",
+		ingestNotice(api.IngestReport{Sent: 1, AlreadyIngested: 2, Rejected: 1}))
+}
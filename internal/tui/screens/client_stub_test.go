@@ -0,0 +1,80 @@
+package screens
+
+import (
+	"context"
+
+	"github.com/hubblenetwork/hubcli/internal/api"
+	"github.com/hubblenetwork/hubcli/internal/models"
+)
+
+// stubClient is an api.APIClient whose methods are set per test. Calling a
+// method that is not set panics on the nil embedded interface.
+type stubClient struct {
+	api.APIClient
+
+	checkCredentials     func(ctx context.Context) error
+	getOrganization      func(ctx context.Context) (*models.Organization, error)
+	listDevices          func(ctx context.Context) ([]models.Device, error)
+	listDevicesPage      func(ctx context.Context, token string) ([]models.Device, string, error)
+	retrievePacketsPages func(ctx context.Context, opts api.RetrievePacketsOptions) (<-chan api.PacketsPage, <-chan error)
+
+	retrievePacketsWithPagination func(ctx context.Context, opts api.RetrievePacketsOptions) (*api.RetrievePacketsResult, error)
+}
+
+func (s *stubClient) CheckCredentials(ctx context.Context) error {
+	if s.checkCredentials == nil {
+		return s.APIClient.CheckCredentials(ctx)
+	}
+	return s.checkCredentials(ctx)
+}
+
+func (s *stubClient) GetOrganization(ctx context.Context) (*models.Organization, error) {
+	if s.getOrganization == nil {
+		return s.APIClient.GetOrganization(ctx)
+	}
+	return s.getOrganization(ctx)
+}
+
+func (s *stubClient) ListDevices(ctx context.Context) ([]models.Device, error) {
+	if s.listDevices == nil {
+		return s.APIClient.ListDevices(ctx)
+	}
+	return s.listDevices(ctx)
+}
+
+func (s *stubClient) ListDevicesPage(ctx context.Context, token string) ([]models.Device, string, error) {
+	if s.listDevicesPage == nil {
+		return s.APIClient.ListDevicesPage(ctx, token)
+	}
+	return s.listDevicesPage(ctx, token)
+}
+
+func (s *stubClient) RetrievePacketsPages(ctx context.Context, opts api.RetrievePacketsOptions) (<-chan api.PacketsPage, <-chan error) {
+	if s.retrievePacketsPages == nil {
+		return s.APIClient.RetrievePacketsPages(ctx, opts)
+	}
+	return s.retrievePacketsPages(ctx, opts)
+}
+
+func (s *stubClient) RetrievePacketsWithPagination(ctx context.Context, opts api.RetrievePacketsOptions) (*api.RetrievePacketsResult, error) {
+	if s.retrievePacketsWithPagination == nil {
+		return s.APIClient.RetrievePacketsWithPagination(ctx, opts)
+	}
+	return s.retrievePacketsWithPagination(ctx, opts)
+}
+
+// stubPages returns channels that send pages and then err, if not nil, the
+// way api.Client.RetrievePacketsPages does.
+func stubPages(pages []api.PacketsPage, err error) (<-chan api.PacketsPage, <-chan error) {
+	pagesCh := make(chan api.PacketsPage, len(pages))
+	errs := make(chan error, 1)
+	for _, page := range pages {
+		pagesCh <- page
+	}
+	if err != nil {
+		errs <- err
+	}
+	close(pagesCh)
+	close(errs)
+	return pagesCh, errs
+}
@@ -2,8 +2,14 @@ package screens
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,6 +35,11 @@ const (
 	DevicesStateRegistering
 	DevicesStateDeleteConfirm
 	DevicesStateDeleting
+	DevicesStateRenameInput
+	DevicesStateRenaming
+	DevicesStateRegisterInput
+	DevicesStateRegistered
+	DevicesStateBulkDeleteConfirm
 )
 
 // SortColumn represents which column to sort by
@@ -60,7 +71,10 @@ func (s SortColumn) String() string {
 type (
 	// DevicesLoadedMsg is sent when devices are fetched
 	DevicesLoadedMsg struct {
-		Devices []models.Device
+		Devices           []models.Device
+		Quota             *int // Org device quota, nil if unknown
+		ContinuationToken string
+		Append            bool // If true, append to existing devices
 	}
 
 	// DevicesErrorMsg is sent when fetching fails
@@ -68,20 +82,68 @@ type (
 		Err error
 	}
 
-	// DeviceRegisteredMsg is sent when a device is registered
+	// DeviceRegisteredMsg is sent when devices are registered
 	DeviceRegisteredMsg struct {
-		Device *models.Device
+		Devices []models.Device
+	}
+
+	// DevicesExportedMsg is sent when the device list has been written to CSV
+	DevicesExportedMsg struct {
+		Path  string
+		Count int
+		Err   error
 	}
 
 	// DeviceDeletedMsg is sent when a device is deleted
 	DeviceDeletedMsg struct {
 		DeviceID string
 	}
+
+	// DevicesBulkDeletedMsg is sent when a bulk delete has tried every
+	// selected device
+	DevicesBulkDeletedMsg struct {
+		Deleted []string         // IDs deleted, including any already gone
+		Failed  map[string]error // Errors keyed by device ID
+	}
+
+	// DeviceGoneMsg is sent when an action targets a device that has
+	// been deleted elsewhere
+	DeviceGoneMsg struct {
+		DeviceID string
+	}
+
+	// DeviceRenamedMsg is sent when a device has been renamed
+	DeviceRenamedMsg struct {
+		Device *models.Device
+	}
+
+	// devicesChangesExpiredMsg clears the change markers from a refresh
+	devicesChangesExpiredMsg struct {
+		seq int
+	}
 )
 
+// deviceChange describes how a device differs from the previous load
+type deviceChange int
+
+const (
+	deviceAdded   deviceChange = iota + 1 // Not in the previous load
+	deviceUpdated                         // Last packet time advanced
+)
+
+// Markers drawn in front of the name of changed devices after a refresh
+const (
+	deviceAddedMarker   = "+ "
+	deviceUpdatedMarker = "* "
+)
+
+// deviceChangesDuration is how long change markers stay visible after a
+// refresh
+const deviceChangesDuration = 3 * time.Second
+
 // DevicesModel is the model for the devices screen
 type DevicesModel struct {
-	client  *api.Client
+	client  api.APIClient
 	devices []models.Device
 	table   table.Model
 	spinner spinner.Model
@@ -93,51 +155,72 @@ type DevicesModel struct {
 	showRegister bool
 	width        int
 	height       int
+	quota        *int   // Org device quota, nil if unknown
+	notice       string // One-off warning shown above the table
+	exported     string // Result of the last CSV export
+	copied       common.Flash
+
+	// Changes since the previous load, keyed by device ID
+	loaded     bool
+	changes    map[string]deviceChange
+	changesSeq int // Identifies the refresh whose markers are shown
+
+	// Paging
+	continuationToken string            // Token for loading more devices
+	hasMore           bool              // Whether more devices are available
+	loadingMore       bool              // Whether currently loading more devices
+	loads             *common.Canceller // Cancels the device load in flight
 
 	// Filtering
-	filterInput   textinput.Model
-	filterActive  bool
-	filterText    string
-	filteredDevs  []models.Device
+	filterInput  textinput.Model
+	filterActive bool
+	filterText   string
+	filteredDevs []models.Device
 
 	// Sorting
-	sortColumn     SortColumn // Column currently being sorted
-	sortAsc        bool
-	selectedColumn SortColumn // Column selected for potential sorting (with brackets)
+	sort common.ColumnSort // Sorted and selected columns are SortColumn values
+
+	// Multi-select for bulk delete, keyed by device ID
+	selected map[string]bool
 
 	// Delete confirmation
 	deleteInput       textinput.Model
 	deleteDevice      *models.Device // Device being deleted
 	deleteConfirmText string         // Text user must type to confirm (first 4 chars of UUID)
+
+	// Rename
+	renameInput  textinput.Model
+	renameDevice *models.Device // Device being renamed
+	renameErr    string         // Validation message shown under the input
+
+	// Registration
+	registerInput      textinput.Model       // Number of devices to create
+	registerEncryption models.EncryptionType // Encryption for new devices
+	registerErr        string                // Validation message shown under the input
+	registerCount      int                   // Devices requested by the registration in flight
+	registered         []models.Device       // Devices just created, with their keys
+	registeredCopied   bool                  // Keys of registered have been copied
+	registeredCursor   int                   // Device whose key "copy key" copies
 }
 
 // NewDevicesModel creates a new devices screen model
-func NewDevicesModel(client *api.Client) DevicesModel {
+func NewDevicesModel(client api.APIClient) DevicesModel {
 	columns := []table.Column{
+		{Title: selectColumnTitle, Width: selectColumnWidth},
 		{Title: "ID", Width: 20},
 		{Title: "Name", Width: 24},
-		{Title: "Created", Width: 18},
-		{Title: "Last Packet", Width: 18},
+		{Title: "Created", Width: 22},
+		{Title: "Last Packet", Width: 22},
+		{Title: "Encryption", Width: encryptionWidth},
 	}
 
 	t := table.New(
 		table.WithColumns(columns),
 		table.WithFocused(true),
-		table.WithHeight(10),
+		table.WithHeight(common.DefaultTableHeight),
 	)
 
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(common.ColorBorder).
-		BorderBottom(true).
-		Bold(true).
-		Foreground(common.ColorSecondary)
-	s.Selected = s.Selected.
-		Foreground(common.ColorForeground).
-		Background(common.ColorPrimary).
-		Bold(true)
-	t.SetStyles(s)
+	t.SetStyles(common.TableStyles())
 
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
@@ -145,7 +228,7 @@ func NewDevicesModel(client *api.Client) DevicesModel {
 
 	// Initialize filter input
 	fi := textinput.New()
-	fi.Placeholder = "Filter by name or ID..."
+	fi.Placeholder = "Filter by name or ID (stale:>24h, active:<1h)..."
 	fi.CharLimit = 64
 	fi.Width = 40
 	fi.PromptStyle = lipgloss.NewStyle().Foreground(common.ColorSecondary)
@@ -159,18 +242,44 @@ func NewDevicesModel(client *api.Client) DevicesModel {
 	di.PromptStyle = lipgloss.NewStyle().Foreground(common.ColorSecondary)
 	di.TextStyle = lipgloss.NewStyle().Foreground(common.ColorForeground)
 
+	// Initialize rename input
+	ri := textinput.New()
+	ri.Placeholder = "Device name"
+	ri.CharLimit = 64
+	ri.Width = 40
+	ri.PromptStyle = lipgloss.NewStyle().Foreground(common.ColorSecondary)
+	ri.TextStyle = lipgloss.NewStyle().Foreground(common.ColorForeground)
+
+	// Initialize registration count input
+	ci := textinput.New()
+	ci.Placeholder = "1"
+	ci.CharLimit = 3
+	ci.Width = 5
+	ci.PromptStyle = lipgloss.NewStyle().Foreground(common.ColorSecondary)
+	ci.TextStyle = lipgloss.NewStyle().Foreground(common.ColorForeground)
+
 	return DevicesModel{
-		client:         client,
-		table:          t,
-		spinner:        sp,
-		help:           help.New(),
-		keys:           common.DefaultListKeyMap(),
-		state:          DevicesStateLoading,
-		filterInput:    fi,
-		deleteInput:    di,
-		sortColumn:     SortByLastPacket,
-		sortAsc:        false, // Default: most recent first
-		selectedColumn: SortByLastPacket,
+		client:        client,
+		table:         t,
+		spinner:       sp,
+		help:          help.New(),
+		keys:          common.DefaultListKeyMap(),
+		state:         DevicesStateLoading,
+		filterInput:   fi,
+		deleteInput:   di,
+		renameInput:   ri,
+		registerInput: ci,
+		loads:         &common.Canceller{},
+
+		// Default: most recent first
+		sort: common.ColumnSort{
+			Column:   int(SortByLastPacket),
+			Selected: int(SortByLastPacket),
+			Columns:  int(SortByLastPacket) + 1,
+		},
+
+		// Later registrations default to the last choice
+		registerEncryption: models.EncryptionAES256CTR,
 	}
 }
 
@@ -184,6 +293,13 @@ func (m DevicesModel) Init() tea.Cmd {
 
 // Update handles messages for the devices screen
 func (m DevicesModel) Update(msg tea.Msg) (DevicesModel, tea.Cmd) {
+	m, cmd := m.update(msg)
+	// Anything above or below the table may have changed height
+	m.fitTable()
+	return m, cmd
+}
+
+func (m DevicesModel) update(msg tea.Msg) (DevicesModel, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
@@ -191,12 +307,6 @@ func (m DevicesModel) Update(msg tea.Msg) (DevicesModel, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.help.Width = msg.Width
-		// Adjust table height (account for filter input when active)
-		tableHeight := m.height - 15
-		if m.filterActive {
-			tableHeight -= 2
-		}
-		m.table.SetHeight(tableHeight)
 		// Update column widths to fill screen
 		m.updateColumnHeaders()
 		return m, nil
@@ -231,6 +341,57 @@ func (m DevicesModel) Update(msg tea.Msg) (DevicesModel, tea.Cmd) {
 			}
 		}
 
+		// Handle bulk delete confirmation
+		if m.state == DevicesStateBulkDeleteConfirm {
+			switch msg.String() {
+			case "y", "Y":
+				m.state = DevicesStateDeleting
+				return m, tea.Batch(m.spinner.Tick, m.bulkDeleteCmd(m.selectedIDs()))
+			case "n", "N", "esc":
+				m.state = DevicesStateReady
+				m.table.Focus()
+			}
+			return m, nil
+		}
+
+		// Handle registration prompt and result
+		if m.state == DevicesStateRegisterInput {
+			return m.updateRegisterInput(msg)
+		}
+		if m.state == DevicesStateRegistered {
+			return m.updateRegistered(msg)
+		}
+
+		// Handle rename input mode
+		if m.state == DevicesStateRenameInput {
+			switch msg.String() {
+			case "esc":
+				m.cancelRename()
+				return m, nil
+			case "enter":
+				name := strings.TrimSpace(m.renameInput.Value())
+				if name == "" {
+					m.renameErr = "Name cannot be empty"
+					return m, nil
+				}
+				if name == m.renameDevice.Name {
+					m.cancelRename()
+					return m, nil
+				}
+				m.state = DevicesStateRenaming
+				m.renameInput.Blur()
+				deviceID := m.renameDevice.ID
+				m.renameDevice = nil
+				m.renameErr = ""
+				return m, tea.Batch(m.spinner.Tick, m.renameDeviceCmd(deviceID, name))
+			default:
+				var cmd tea.Cmd
+				m.renameInput, cmd = m.renameInput.Update(msg)
+				m.renameErr = ""
+				return m, cmd
+			}
+		}
+
 		// Handle filter input mode
 		if m.filterActive {
 			switch msg.String() {
@@ -257,6 +418,10 @@ func (m DevicesModel) Update(msg tea.Msg) (DevicesModel, tea.Cmd) {
 		}
 
 		switch {
+		case key.Matches(msg, m.keys.Back) && (m.state == DevicesStateLoading || m.loadingMore):
+			m.cancelLoad()
+			return m, nil
+
 		case key.Matches(msg, m.keys.Back):
 			// If filter has text, clear it first
 			if m.filterText != "" {
@@ -266,14 +431,15 @@ func (m DevicesModel) Update(msg tea.Msg) (DevicesModel, tea.Cmd) {
 				return m, nil
 			}
 			return m, func() tea.Msg {
-				return NavigateMsg{Screen: "home"}
+				return NavigateMsg{Screen: "back"}
 			}
 
 		case key.Matches(msg, m.keys.Quit):
-			return m, tea.Quit
+			return m, RequestQuit
 
 		case key.Matches(msg, m.keys.Refresh):
-			if m.state == DevicesStateReady || m.state == DevicesStateError {
+			// Wait for a page in flight so it isn't appended to the reloaded list
+			if (m.state == DevicesStateReady || m.state == DevicesStateError) && !m.loadingMore {
 				m.state = DevicesStateLoading
 				return m, tea.Batch(m.spinner.Tick, m.loadDevices())
 			}
@@ -293,7 +459,18 @@ func (m DevicesModel) Update(msg tea.Msg) (DevicesModel, tea.Cmd) {
 				if device != nil {
 					// Navigate to packets for this device
 					return m, func() tea.Msg {
-						return NavigateMsg{Screen: "packets", Data: device.ID}
+						return NavigateMsg{Screen: "packets", Data: PacketsNavData{DeviceID: device.ID}}
+					}
+				}
+			}
+
+		case msg.String() == "t":
+			// Packets for the selected device over the last day
+			if m.state == DevicesStateReady && !m.filterActive && len(m.filteredDevs) > 0 {
+				device := m.SelectedDevice()
+				if device != nil {
+					return m, func() tea.Msg {
+						return NavigateMsg{Screen: "packets", Data: PacketsNavData{DeviceID: device.ID, Days: 1}}
 					}
 				}
 			}
@@ -301,8 +478,60 @@ func (m DevicesModel) Update(msg tea.Msg) (DevicesModel, tea.Cmd) {
 		case msg.String() == "n":
 			// Register new device
 			if m.state == DevicesStateReady && !m.filterActive {
-				m.state = DevicesStateRegistering
-				return m, tea.Batch(m.spinner.Tick, m.registerDevice())
+				if m.atQuota() {
+					m.notice = fmt.Sprintf("Device quota reached (%s). Delete a device or raise the org quota to register more.", m.quotaUsage())
+					return m, nil
+				}
+				if _, ok := m.quotaRoom(); m.quota != nil && !ok {
+					m.notice = "Load every device with 'm' first, so registering can be checked against the quota"
+					return m, nil
+				}
+				m.notice = ""
+				m.state = DevicesStateRegisterInput
+				m.registerErr = ""
+				m.registerInput.SetValue("1")
+				m.registerInput.CursorEnd()
+				m.registerInput.Focus()
+				return m, textinput.Blink
+			}
+
+		case msg.String() == "z":
+			// Toggle UTC / local timestamps
+			if m.state == DevicesStateReady && !m.filterActive {
+				common.SetUTC(!common.UTC())
+				m.updateTableFromFiltered()
+				return m, nil
+			}
+
+		case msg.String() == "x":
+			// Export the devices as currently filtered and sorted
+			if m.state == DevicesStateReady && !m.filterActive && len(m.filteredDevs) > 0 {
+				return m, exportDevicesCSV(m.filteredDevs)
+			}
+
+		case msg.String() == "y":
+			// Copy the selected device's ID
+			if m.state == DevicesStateReady && !m.filterActive {
+				if device := m.SelectedDevice(); device != nil {
+					return m, common.CopyToClipboard("device ID", device.ID)
+				}
+			}
+
+		case msg.String() == " ":
+			// Toggle the selected device in or out of the bulk selection
+			if m.state == DevicesStateReady && !m.filterActive {
+				if device := m.SelectedDevice(); device != nil {
+					m.toggleSelected(device.ID)
+					m.updateTableFromFiltered()
+				}
+				return m, nil
+			}
+
+		case msg.String() == "d" && len(m.selected) > 0:
+			// Delete the multi-selected devices after one confirmation
+			if m.state == DevicesStateReady && !m.filterActive {
+				m.state = DevicesStateBulkDeleteConfirm
+				return m, nil
 			}
 
 		case msg.String() == "d":
@@ -319,53 +548,177 @@ func (m DevicesModel) Update(msg tea.Msg) (DevicesModel, tea.Cmd) {
 				}
 			}
 
+		case msg.String() == "e":
+			// Rename device - open the name input
+			if m.state == DevicesStateReady && !m.filterActive && len(m.filteredDevs) > 0 {
+				device := m.SelectedDevice()
+				if device != nil {
+					m.state = DevicesStateRenameInput
+					m.renameDevice = device
+					m.renameErr = ""
+					m.renameInput.SetValue(device.Name)
+					m.renameInput.CursorEnd()
+					m.renameInput.Focus()
+					return m, textinput.Blink
+				}
+			}
+
+		// Jump to the first or last device, or by a screenful
+		case m.state == DevicesStateReady && common.MoveTableCursor(&m.table, m.keys, msg):
+			return m, nil
+
 		// Select sort column with left/right arrows
 		case key.Matches(msg, m.keys.Left):
 			if m.state == DevicesStateReady {
-				if m.selectedColumn > 0 {
-					m.selectedColumn--
-				} else {
-					m.selectedColumn = SortByLastPacket // Wrap to last column
-				}
+				m.sort.SelectLeft()
 				m.updateColumnHeaders()
 				return m, nil
 			}
 		case key.Matches(msg, m.keys.Right):
 			if m.state == DevicesStateReady {
-				m.selectedColumn = (m.selectedColumn + 1) % 4
+				m.sort.SelectRight()
 				m.updateColumnHeaders()
 				return m, nil
 			}
 
+		case msg.String() == "m":
+			// Load more devices
+			if m.state == DevicesStateReady && !m.filterActive && m.hasMore && !m.loadingMore {
+				m.loadingMore = true
+				return m, m.loadMoreDevices()
+			}
+
 		// Sort by selected column with 's'
 		case msg.String() == "s":
 			if m.state == DevicesStateReady {
-				m.toggleSort(m.selectedColumn)
+				m.toggleSort()
 				return m, nil
 			}
 		}
 
 	case DevicesLoadedMsg:
+		m.loadingMore = false
+		m.continuationToken = msg.ContinuationToken
+		m.hasMore = msg.ContinuationToken != ""
+		if msg.Append {
+			m.devices = append(m.devices, msg.Devices...)
+			m.applyFilterAndSort()
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		m.changes = nil
+		if m.loaded {
+			if changes := diffDevices(m.devices, msg.Devices); len(changes) > 0 {
+				m.changes = changes
+				m.changesSeq++
+				seq := m.changesSeq
+				cmd = tea.Tick(deviceChangesDuration, func(time.Time) tea.Msg {
+					return devicesChangesExpiredMsg{seq: seq}
+				})
+			}
+		}
 		m.state = DevicesStateReady
+		m.loaded = true
 		m.devices = msg.Devices
+		m.quota = msg.Quota
+		m.notice = ""
+		m.pruneSelected()
 		m.applyFilterAndSort()
+		return m, cmd
+
+	case devicesChangesExpiredMsg:
+		if msg.seq == m.changesSeq && m.changes != nil {
+			m.changes = nil
+			m.updateTableFromFiltered()
+		}
+		return m, nil
+
+	case DevicesExportedMsg:
+		if msg.Err != nil {
+			m.notice = "Export failed: " + msg.Err.Error()
+			m.exported = ""
+		} else {
+			m.notice = ""
+			m.exported = fmt.Sprintf("Exported %d device(s) to %s", msg.Count, msg.Path)
+		}
 		return m, nil
 
 	case DevicesErrorMsg:
+		m.loadingMore = false
 		m.state = DevicesStateError
 		m.err = msg.Err
 		return m, nil
 
 	case DeviceRegisteredMsg:
+		// Keys are only returned once, so show them before reloading
+		m.state = DevicesStateRegistered
+		m.registered = msg.Devices
+		m.registeredCopied = false
+		m.registeredCursor = 0
+		m.notice = ""
+		return m, nil
+
+	case common.ClipboardCopiedMsg:
+		if m.state == DevicesStateRegistered {
+			if msg.Err != nil {
+				m.notice = "Copy failed: " + msg.Err.Error()
+			} else {
+				// One key is enough when only one device was created
+				if msg.Label == registeredKeysLabel || len(m.registered) == 1 {
+					m.registeredCopied = true
+				}
+				m.notice = "Copied " + msg.Label
+			}
+			return m, nil
+		}
+		if msg.Err != nil {
+			m.notice = "Copy failed: " + msg.Err.Error()
+			return m, nil
+		}
+		m.notice = ""
+		return m, m.copied.Set("Copied " + msg.Label)
+
+	case common.FlashExpiredMsg:
+		m.copied.Expire(msg)
+		return m, nil
+
+	case DeviceDeletedMsg:
 		m.state = DevicesStateLoading
 		return m, tea.Batch(m.spinner.Tick, m.loadDevices())
 
-	case DeviceDeletedMsg:
+	case DevicesBulkDeletedMsg:
+		// Drop the deleted rows rather than reloading, so the results stay
+		for _, id := range msg.Deleted {
+			m.removeDevice(id)
+		}
+		m.selected = nil
+		m.state = DevicesStateReady
+		m.notice = bulkDeleteNotice(msg)
+		m.applyFilterAndSort()
+		return m, nil
+
+	case DeviceGoneMsg:
+		// Drop the stale row rather than reloading, so the notice stays
+		m.removeDevice(msg.DeviceID)
+		m.state = DevicesStateReady
+		m.notice = fmt.Sprintf("Device %s was deleted elsewhere", msg.DeviceID)
+		m.applyFilterAndSort()
+		return m, nil
+
+	case DeviceRenamedMsg:
+		// Only the renamed device changed, so update it in place unless
+		// it has gone from the list
+		if msg.Device != nil && m.replaceDevice(*msg.Device) {
+			m.state = DevicesStateReady
+			m.applyFilterAndSort()
+			return m, nil
+		}
 		m.state = DevicesStateLoading
 		return m, tea.Batch(m.spinner.Tick, m.loadDevices())
 
 	case spinner.TickMsg:
-		if m.state == DevicesStateLoading || m.state == DevicesStateRegistering || m.state == DevicesStateDeleting {
+		if m.state == DevicesStateLoading || m.state == DevicesStateRegistering || m.state == DevicesStateDeleting || m.state == DevicesStateRenaming {
 			var cmd tea.Cmd
 			m.spinner, cmd = m.spinner.Update(msg)
 			return m, cmd
@@ -382,15 +735,12 @@ func (m DevicesModel) Update(msg tea.Msg) (DevicesModel, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
-// toggleSort sets sort column and toggles direction if same column
-func (m *DevicesModel) toggleSort(col SortColumn) {
-	if m.sortColumn == col {
-		m.sortAsc = !m.sortAsc
-	} else {
-		m.sortColumn = col
-		// Default to ascending for name/ID, descending for dates
-		m.sortAsc = col != SortByCreated && col != SortByLastPacket
-	}
+// toggleSort sorts by the selected column, toggling direction if it is
+// already sorted
+func (m *DevicesModel) toggleSort() {
+	// Default to ascending for name/ID, descending for dates
+	col := SortColumn(m.sort.Selected)
+	m.sort.SortSelected(col != SortByCreated && col != SortByLastPacket)
 	m.applyFilterAndSort()
 }
 
@@ -411,48 +761,56 @@ func (m *DevicesModel) applyFilterAndSort() {
 
 // updateColumnHeaders updates column titles to show sort indicator and selection brackets
 func (m *DevicesModel) updateColumnHeaders() {
-	sortIndicator := " ↓"
-	if m.sortAsc {
-		sortIndicator = " ↑"
-	}
-
-	titles := []string{"ID", "Name", "Created", "Last Packet"}
-
-	// Add sort indicator to sorted column
-	if m.sortColumn >= 0 && int(m.sortColumn) < len(titles) {
-		titles[m.sortColumn] += sortIndicator
-	}
-
-	// Add brackets around selected column
-	if m.selectedColumn >= 0 && int(m.selectedColumn) < len(titles) {
-		titles[m.selectedColumn] = "[" + titles[m.selectedColumn] + "]"
-	}
+	titles := m.sort.Titles([]string{"ID", "Name", "Created", "Last Packet"})
 
 	// Calculate dynamic column widths
 	idWidth, nameWidth, createdWidth, lastPacketWidth := m.calculateColumnWidths()
 
 	columns := []table.Column{
+		{Title: selectColumnTitle, Width: selectColumnWidth},
 		{Title: titles[0], Width: idWidth},
 		{Title: titles[1], Width: nameWidth},
 		{Title: titles[2], Width: createdWidth},
 		{Title: titles[3], Width: lastPacketWidth},
+		{Title: "Encryption", Width: encryptionWidth},
 	}
 	m.table.SetColumns(columns)
 }
 
+// The leading column marks devices selected for bulk delete. RenderTable
+// widens it to hold the cursor's common.SelectionMarker too.
+const (
+	selectColumnTitle = "✓"
+	selectedMarker    = "✓"
+	selectColumnWidth = 1 // The mark
+)
+
+// minDeviceNameWidth is the narrowest the Name column gets on small screens
+const minDeviceNameWidth = 12
+
+// minDeviceIDWidth is the narrowest the ID column gets on small screens,
+// enough to tell most devices apart by prefix
+const minDeviceIDWidth = 13
+
+// encryptionWidth is the width of the Encryption column, which is not
+// sortable and fits the longest encryption type
+const encryptionWidth = len(models.EncryptionAES256CTR)
+
 // calculateColumnWidths returns column widths based on screen width
 func (m *DevicesModel) calculateColumnWidths() (idWidth, nameWidth, createdWidth, lastPacketWidth int) {
 	// Fixed widths for date columns
-	createdWidth = 18
-	lastPacketWidth = 18
+	createdWidth = len(deviceTimeLayout) + common.TimeZoneWidth
+	lastPacketWidth = len(deviceTimeLayout) + common.TimeZoneWidth
 
-	// Available width for ID and Name (account for padding/borders)
-	availableWidth := m.width - createdWidth - lastPacketWidth - 10
+	// Available width for ID and Name (account for screen padding and the
+	// table header's cell padding)
+	availableWidth := m.width - 4 - common.TableFrameWidth(common.TableStyles(), 6) - common.SelectionMarkerWidth - selectColumnWidth - createdWidth - lastPacketWidth - encryptionWidth
 
 	if availableWidth < 60 {
-		// Minimum widths
-		idWidth = 36
-		nameWidth = 24
+		// Keep the full UUID if Name still gets its minimum, otherwise
+		// truncate the ID (SelectedDevice matches on the prefix)
+		nameWidth = max(availableWidth-36, minDeviceNameWidth)
+		idWidth = max(availableWidth-nameWidth, minDeviceIDWidth)
 	} else {
 		// Give 60% to ID (UUIDs are 36 chars), 40% to Name
 		idWidth = availableWidth * 60 / 100
@@ -476,23 +834,100 @@ func (m *DevicesModel) filterDevices() []models.Device {
 		return result
 	}
 
-	filter := strings.ToLower(m.filterText)
+	text, recency := parseDeviceFilter(m.filterText)
+	filter := strings.ToLower(text)
+	now := time.Now()
+
 	var result []models.Device
 	for _, d := range m.devices {
 		// Match against ID or Name
-		if strings.Contains(strings.ToLower(d.ID), filter) ||
-			strings.Contains(strings.ToLower(d.Name), filter) {
-			result = append(result, d)
+		if filter != "" &&
+			!strings.Contains(strings.ToLower(d.ID), filter) &&
+			!strings.Contains(strings.ToLower(d.Name), filter) {
+			continue
 		}
+		if !recency.matches(d, now) {
+			continue
+		}
+		result = append(result, d)
 	}
 	return result
 }
 
+// recencyFilter restricts devices by when they last sent a packet.
+// A zero duration means the bound is not set.
+type recencyFilter struct {
+	staleFor  time.Duration // Not seen for longer than this
+	activeFor time.Duration // Seen within this window
+}
+
+// matches reports whether the device satisfies the recency bounds at now
+func (f recencyFilter) matches(d models.Device, now time.Time) bool {
+	seen, ok := d.LastSeen()
+	if f.staleFor > 0 && ok && now.Sub(seen) <= f.staleFor {
+		return false
+	}
+	if f.activeFor > 0 && (!ok || now.Sub(seen) >= f.activeFor) {
+		return false
+	}
+	return true
+}
+
+// parseDeviceFilter splits filter text into free text and recency terms.
+// Recency terms are "stale:>DUR" (not seen for longer than DUR, including
+// devices that never sent a packet) and "active:<DUR" (seen within DUR).
+// DUR accepts Go durations plus a "d" suffix for days, e.g. 30m, 24h, 7d.
+// Terms that don't parse are treated as free text.
+func parseDeviceFilter(filter string) (string, recencyFilter) {
+	var f recencyFilter
+	var text []string
+
+	for _, term := range strings.Fields(filter) {
+		lower := strings.ToLower(term)
+		switch {
+		case strings.HasPrefix(lower, "stale:"):
+			if d, err := parseRecencyDuration(strings.TrimPrefix(lower[len("stale:"):], ">")); err == nil {
+				f.staleFor = d
+				continue
+			}
+		case strings.HasPrefix(lower, "active:"):
+			if d, err := parseRecencyDuration(strings.TrimPrefix(lower[len("active:"):], "<")); err == nil {
+				f.activeFor = d
+				continue
+			}
+		}
+		text = append(text, term)
+	}
+
+	return strings.Join(text, " "), f
+}
+
+// parseRecencyDuration parses a positive duration, allowing a "d" suffix for days
+func parseRecencyDuration(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive: %q", s)
+	}
+	return d, nil
+}
+
 // sortDevices sorts the filtered devices in place
 func (m *DevicesModel) sortDevices() {
 	sort.SliceStable(m.filteredDevs, func(i, j int) bool {
 		var less bool
-		switch m.sortColumn {
+		switch SortColumn(m.sort.Column) {
 		case SortByID:
 			less = m.filteredDevs[i].ID < m.filteredDevs[j].ID
 		case SortByName:
@@ -511,7 +946,7 @@ func (m *DevicesModel) sortDevices() {
 			}
 			less = iVal < jVal
 		}
-		if m.sortAsc {
+		if m.sort.Asc {
 			return less
 		}
 		return !less
@@ -522,21 +957,42 @@ func (m *DevicesModel) sortDevices() {
 func (m DevicesModel) View() string {
 	var content strings.Builder
 
-	// Header
-	content.WriteString(common.TitleStyle.Render("Devices"))
-	content.WriteString("\n")
-	content.WriteString(common.SubtitleStyle.Render("Manage your registered devices"))
-	content.WriteString("\n\n")
+	content.WriteString(m.renderHeader())
 
 	switch m.state {
 	case DevicesStateLoading:
 		content.WriteString(fmt.Sprintf("%s Loading devices...", m.spinner.View()))
 
 	case DevicesStateRegistering:
-		content.WriteString(fmt.Sprintf("%s Registering new device...", m.spinner.View()))
+		content.WriteString(fmt.Sprintf("%s Registering %d new device(s)...", m.spinner.View(), m.registerCount))
+
+	case DevicesStateRegisterInput:
+		content.WriteString(m.renderRegisterInput())
+
+	case DevicesStateRegistered:
+		content.WriteString(m.renderRegistered())
 
 	case DevicesStateDeleting:
-		content.WriteString(fmt.Sprintf("%s Deleting device...", m.spinner.View()))
+		if len(m.selected) > 0 {
+			content.WriteString(fmt.Sprintf("%s Deleting %d devices...", m.spinner.View(), len(m.selected)))
+		} else {
+			content.WriteString(fmt.Sprintf("%s Deleting device...", m.spinner.View()))
+		}
+
+	case DevicesStateBulkDeleteConfirm:
+		content.WriteString(m.renderBulkDeleteConfirm())
+
+	case DevicesStateRenaming:
+		content.WriteString(fmt.Sprintf("%s Renaming device...", m.spinner.View()))
+
+	case DevicesStateRenameInput:
+		content.WriteString(common.PrimaryTextStyle.Render("Rename Device"))
+		content.WriteString("\n\n")
+		content.WriteString(fmt.Sprintf("ID: %s\n\n", m.renameDevice.ID))
+		content.WriteString(fmt.Sprintf("  %s ", m.renameInput.View()))
+		if m.renameErr != "" {
+			content.WriteString(common.ErrorTextStyle.Render(" ✗ " + m.renameErr))
+		}
 
 	case DevicesStateDeleteConfirm:
 		// Show confirmation prompt
@@ -555,7 +1011,7 @@ func (m DevicesModel) View() string {
 		}
 
 	case DevicesStateError:
-		content.WriteString(common.ErrorTextStyle.Render("Error: " + m.err.Error()))
+		content.WriteString(common.ErrorTextStyle.Render("Error: " + errorText(m.err)))
 		content.WriteString("\n\n")
 		content.WriteString(common.MutedTextStyle.Render("Press 'r' to retry"))
 
@@ -565,143 +1021,641 @@ func (m DevicesModel) View() string {
 			content.WriteString("\n\n")
 			content.WriteString(common.MutedTextStyle.Render("Press 'n' to register a new device."))
 		} else {
-			// Filter input
-			if m.filterActive {
-				content.WriteString(common.PrimaryTextStyle.Render("Filter: "))
-				content.WriteString(m.filterInput.View())
-				content.WriteString("\n\n")
-			} else if m.filterText != "" {
-				content.WriteString(common.MutedTextStyle.Render(fmt.Sprintf("Filter: %q", m.filterText)))
-				content.WriteString("\n\n")
-			}
-
-			// Device count
-			countText := fmt.Sprintf("%d of %d device(s)", len(m.filteredDevs), len(m.devices))
-			content.WriteString(common.MutedTextStyle.Render(countText))
-			content.WriteString("\n\n")
+			content.WriteString(m.renderStatus())
 
 			// Table
-			content.WriteString(m.table.View())
+			content.WriteString(m.highlightMatches(common.RenderTable(m.table)))
+			content.WriteString(m.renderFooter())
 		}
 	}
 
 	// Help
 	content.WriteString("\n\n")
+	content.WriteString(m.renderHelp())
+
+	// Use full width with padding
+	style := lipgloss.NewStyle().
+		Width(m.width).
+		Padding(1, 2)
+
+	return style.Render(content.String())
+}
+
+// renderHeader renders the title and subtitle shown above the screen body
+func (m DevicesModel) renderHeader() string {
+	var content strings.Builder
+
+	content.WriteString(common.TitleStyle.Render("Devices"))
+	content.WriteString("\n")
+	content.WriteString(common.SubtitleStyle.Render("Manage your registered devices"))
+	content.WriteString("\n\n")
+
+	return content.String()
+}
+
+// renderStatus renders the filter, device count and notice shown above the
+// table
+func (m DevicesModel) renderStatus() string {
+	var content strings.Builder
+
+	// Filter input
+	if m.filterActive {
+		content.WriteString(common.PrimaryTextStyle.Render("Filter: "))
+		content.WriteString(m.filterInput.View())
+		content.WriteString("\n\n")
+	} else if m.filterText != "" {
+		content.WriteString(common.MutedTextStyle.Render(fmt.Sprintf("Filter: %q", m.filterText)))
+		content.WriteString("\n\n")
+	}
+
+	// Device count
+	countText := fmt.Sprintf("%d of %d device(s)", len(m.filteredDevs), len(m.devices))
+	if m.hasMore {
+		countText += " (more available)"
+	}
+	if m.loadingMore {
+		countText += " - loading more..."
+	}
+	content.WriteString(common.MutedTextStyle.Render(countText))
+	if usage := m.quotaUsage(); usage != "" {
+		content.WriteString(common.MutedTextStyle.Render("  •  "))
+		if m.nearQuota() {
+			content.WriteString(common.WarningTextStyle.Render(usage))
+		} else {
+			content.WriteString(common.MutedTextStyle.Render(usage))
+		}
+	}
+	if m.exported != "" {
+		content.WriteString("  ")
+		content.WriteString(common.SuccessTextStyle.Render(m.exported))
+	}
+	if copied := m.copied.Text(); copied != "" {
+		content.WriteString("  ")
+		content.WriteString(common.SuccessTextStyle.Render(copied))
+	}
+	if changes := m.changesSummary(); changes != "" {
+		content.WriteString("  ")
+		content.WriteString(common.SuccessTextStyle.Render(changes))
+	}
+	content.WriteString("\n\n")
+
+	if m.notice != "" {
+		content.WriteString(common.WarningTextStyle.Render("⚠ " + m.notice))
+		content.WriteString("\n\n")
+	}
+
+	return content.String()
+}
+
+// renderHelp renders the key help line
+func (m DevicesModel) renderHelp() string {
 	var helpText []string
 	if m.state == DevicesStateDeleteConfirm {
 		helpText = []string{
 			common.FormatHelp("enter", "confirm delete"),
 			common.FormatHelp("esc", "cancel"),
 		}
+	} else if m.state == DevicesStateBulkDeleteConfirm {
+		helpText = []string{
+			common.FormatHelp("y", "delete all"),
+			common.FormatHelp("n/esc", "cancel"),
+		}
+	} else if m.state == DevicesStateRegisterInput {
+		helpText = []string{
+			common.FormatHelp("enter", "register"),
+			common.FormatHelp("tab/←/→", "encryption"),
+			common.FormatHelp("esc", "cancel"),
+		}
+	} else if m.state == DevicesStateRegistered {
+		helpText = []string{
+			common.FormatHelp("c", "copy key"),
+			common.FormatHelp("y", "copy all IDs and keys"),
+			common.FormatHelp("enter/esc", "done"),
+		}
+		if len(m.registered) > 1 {
+			helpText = append([]string{common.FormatHelp("↑/↓", "select")}, helpText...)
+		}
+	} else if m.state == DevicesStateRenameInput {
+		helpText = []string{
+			common.FormatHelp("enter", "save name"),
+			common.FormatHelp("esc", "cancel"),
+		}
 	} else if m.filterActive {
 		helpText = []string{
 			common.FormatHelp("enter", "apply"),
 			common.FormatHelp("esc", "cancel"),
 		}
+	} else if m.state == DevicesStateLoading {
+		helpText = []string{common.FormatHelp("esc", "cancel")}
 	} else {
 		helpText = []string{
 			common.FormatHelp("↑/↓", "navigate"),
 			common.FormatHelp("←/→", "select column"),
 			common.FormatHelp("s", "sort"),
 			common.FormatHelp("enter", "view packets"),
+			common.FormatHelp("t", "last 24h"),
 			common.FormatHelp("/", "filter"),
 			common.FormatHelp("n", "new"),
-			common.FormatHelp("d", "delete"),
+			common.FormatHelp("e", "rename"),
+			common.FormatHelp("y", "copy ID"),
+			common.FormatHelp("space", "select"),
+			deleteHelp(len(m.selected)),
+			common.FormatHelp("x", "export CSV"),
+			timeZoneHelp(),
 			common.FormatHelp("r", "refresh"),
-			common.FormatHelp("esc", "back"),
+		}
+		if m.loadingMore {
+			helpText = append(helpText, common.FormatHelp("esc", "cancel loading"))
+		} else {
+			helpText = append(helpText, common.FormatHelp("esc", "back"))
+		}
+		if m.hasMore && !m.loadingMore {
+			helpText = append(helpText, common.FormatHelp("m", "load more"))
 		}
 	}
-	content.WriteString(strings.Join(helpText, "  "))
+	return strings.Join(helpText, "  ")
+}
 
-	// Use full width with padding
-	style := lipgloss.NewStyle().
-		Width(m.width).
-		Padding(1, 2)
+// deleteHelp returns the help entry for d, which deletes the multi-selected
+// devices if there are any
+func deleteHelp(selected int) string {
+	if selected > 0 {
+		return common.FormatHelp("d", fmt.Sprintf("delete %d selected", selected))
+	}
+	return common.FormatHelp("d", "delete")
+}
 
-	return style.Render(content.String())
+// fitTable sizes the table to the lines left over by the header, status
+// and help as currently rendered. The view is padded by one line top and
+// bottom and two columns left and right.
+func (m *DevicesModel) fitTable() {
+	if m.height == 0 {
+		return
+	}
+	above := m.renderHeader() + m.renderStatus()
+	below := m.renderFooter() + "\n\n" + m.renderHelp()
+	m.table.SetHeight(common.FitTableHeight(m.height-2, m.width-4, above, below))
 }
 
 func (m *DevicesModel) updateTable() {
 	m.applyFilterAndSort()
 }
 
+// deviceTimeLayout is the layout for the Created and Last Packet columns
+const deviceTimeLayout = "2006-01-02 15:04"
+
 func (m *DevicesModel) updateTableFromFiltered() {
 	idWidth, nameWidth, _, _ := m.calculateColumnWidths()
 
 	rows := make([]table.Row, len(m.filteredDevs))
 	for i, d := range m.filteredDevs {
-		name := d.Name
-		if name == "" {
-			name = "-"
-		}
 		// Convert unix timestamp (seconds) to formatted date
 		created := "-"
 		if d.CreatedTS > 0 {
-			created = time.Unix(d.CreatedTS, 0).Format("2006-01-02 15:04")
+			created = common.FormatTime(time.Unix(d.CreatedTS, 0), deviceTimeLayout)
 		}
 		lastPacket := "-"
 		if d.MostRecentPacket != nil && d.MostRecentPacket.Terrestrial != nil && d.MostRecentPacket.Terrestrial.Timestamp > 0 {
 			ts := int64(d.MostRecentPacket.Terrestrial.Timestamp)
-			lastPacket = time.Unix(ts, 0).Format("2006-01-02 15:04")
+			lastPacket = common.FormatTime(time.Unix(ts, 0), deviceTimeLayout)
+		}
+		encryption := string(d.Encryption)
+		if encryption == "" {
+			encryption = "-"
+		}
+		mark := ""
+		if m.selected[d.ID] {
+			mark = selectedMarker
 		}
 		rows[i] = table.Row{
+			mark,
 			truncate(d.ID, idWidth),
-			truncate(name, nameWidth),
+			truncate(m.nameMarker(d)+deviceName(d), nameWidth),
 			created,
 			lastPacket,
+			encryption,
 		}
 	}
 	m.table.SetRows(rows)
 }
 
+// deviceName returns the name shown for a device, or "-" if it has none
+func deviceName(d models.Device) string {
+	if d.Name == "" {
+		return "-"
+	}
+	return d.Name
+}
+
+// nameMarker returns the change marker drawn in front of a device's name
+func (m DevicesModel) nameMarker(d models.Device) string {
+	switch m.changes[d.ID] {
+	case deviceAdded:
+		return deviceAddedMarker
+	case deviceUpdated:
+		return deviceUpdatedMarker
+	}
+	return ""
+}
+
+// filterMatchSpan returns the byte range of the first case-insensitive
+// match of filter in text as shown by truncate(text, maxLen). A match that
+// runs into the "..." of a truncated value is cut short at the "...", and
+// one entirely hidden by truncation gives an empty range.
+func filterMatchSpan(text, filter string, maxLen int) (start, end int) {
+	if filter == "" {
+		return 0, 0
+	}
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Lowercasing changed byte offsets, so they can't be mapped back
+		return 0, 0
+	}
+	start = strings.Index(lower, strings.ToLower(filter))
+	if start < 0 {
+		return 0, 0
+	}
+	end = start + len(filter)
+
+	visible := len(text)
+	if visible > maxLen {
+		visible = maxLen
+		if maxLen > 3 {
+			visible = maxLen - 3
+		}
+	}
+	if start >= visible {
+		return 0, 0
+	}
+	return start, min(end, visible)
+}
+
+// highlightMatches highlights the part of each visible ID and name that
+// matched the free-text filter in the rendered table. Rows are identified
+// by their ID cell, as in SelectedDevice.
+func (m DevicesModel) highlightMatches(view string) string {
+	filter, _ := parseDeviceFilter(m.filterText)
+	if filter == "" {
+		return view
+	}
+
+	idWidth, nameWidth, _, _ := m.calculateColumnWidths()
+	styles := common.TableStyles()
+	cellFrame := common.TableFrameWidth(styles, 1)
+	idX := common.SelectionMarkerWidth + selectColumnWidth + cellFrame + styles.Cell.GetPaddingLeft()
+	nameX := idX + idWidth + cellFrame
+
+	lines := strings.Split(view, "\n")
+	for i, line := range lines {
+		d := m.deviceByDisplayedID(strings.TrimRight(common.LineText(line, idX, idX+idWidth), " "))
+		if d == nil {
+			continue
+		}
+
+		var spans []common.Span
+		if start, end := filterMatchSpan(d.ID, filter, idWidth); end > start {
+			spans = append(spans, common.Span{Start: idX + start, End: idX + end})
+		}
+		// Match the name alone, not the change marker in front of it
+		marker := m.nameMarker(*d)
+		cell := marker + deviceName(*d)
+		if start, end := filterMatchSpan(cell, filter, nameWidth); end > start && start >= len(marker) && d.Name != "" {
+			x := nameX + lipgloss.Width(cell[:start])
+			spans = append(spans, common.Span{Start: x, End: x + lipgloss.Width(cell[start:end])})
+		}
+		lines[i] = common.HighlightSpans(line, spans, common.TableMatchStyle)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exportDevicesCSV writes devices to a timestamped CSV file in the working
+// directory
+func exportDevicesCSV(devices []models.Device) tea.Cmd {
+	return func() tea.Msg {
+		path := fmt.Sprintf("devices-%s.csv", time.Now().Format("20060102-150405"))
+		f, err := os.Create(path)
+		if err != nil {
+			return DevicesExportedMsg{Err: err}
+		}
+		err = models.WriteDevicesCSV(f, devices)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return DevicesExportedMsg{Err: err}
+		}
+		return DevicesExportedMsg{Path: path, Count: len(devices)}
+	}
+}
+
 func (m DevicesModel) loadDevices() tea.Cmd {
+	ctx, cancel := m.loads.WithTimeout(common.RequestTimeout())
+	return func() tea.Msg {
+		defer cancel()
+		if m.client == nil {
+			return DevicesErrorMsg{Err: fmt.Errorf("no API client")}
+		}
+
+		// Only the first page is fetched; the rest load on demand with 'm'
+		devices, token, err := m.client.ListDevicesPage(ctx, "")
+		if common.Cancelled(ctx, err) {
+			return common.RequestCancelledMsg{}
+		}
+		if err != nil {
+			return DevicesErrorMsg{Err: err}
+		}
+
+		// Quota is informational; don't fail the load if the org can't be fetched
+		var quota *int
+		if org, err := m.client.GetOrganization(ctx); err == nil {
+			quota = org.DeviceQuota
+		}
+
+		return DevicesLoadedMsg{Devices: devices, Quota: quota, ContinuationToken: token}
+	}
+}
+
+// loadMoreDevices fetches the next page of devices after the current
+// continuation token
+func (m DevicesModel) loadMoreDevices() tea.Cmd {
+	token := m.continuationToken
+	ctx, cancel := m.loads.WithTimeout(common.RequestTimeout())
+	return func() tea.Msg {
+		defer cancel()
+		if m.client == nil {
+			return DevicesErrorMsg{Err: fmt.Errorf("no API client")}
+		}
+
+		devices, next, err := m.client.ListDevicesPage(ctx, token)
+		if common.Cancelled(ctx, err) {
+			return common.RequestCancelledMsg{}
+		}
+		if err != nil {
+			return DevicesErrorMsg{Err: err}
+		}
+
+		return DevicesLoadedMsg{Devices: devices, ContinuationToken: next, Append: true}
+	}
+}
+
+// Stop cancels the device load in flight, e.g. when the app quits
+func (m *DevicesModel) Stop() {
+	m.loads.Cancel()
+}
+
+// cancelLoad cancels the device load in flight. The devices already loaded
+// stay on screen; with none, the screen offers a retry.
+func (m *DevicesModel) cancelLoad() {
+	m.loads.Cancel()
+	m.loadingMore = false
+	if m.state != DevicesStateLoading {
+		m.notice = "Loading cancelled"
+		return
+	}
+	if !m.loaded {
+		m.state = DevicesStateError
+		m.err = common.ErrCancelled
+		return
+	}
+	m.state = DevicesStateReady
+	m.notice = "Loading cancelled"
+	m.applyFilterAndSort()
+}
+
+// replaceDevice swaps the loaded device with d's ID for d, reporting
+// whether it was found
+func (m *DevicesModel) replaceDevice(d models.Device) bool {
+	for i := range m.devices {
+		if m.devices[i].ID == d.ID {
+			m.devices[i] = d
+			return true
+		}
+	}
+	return false
+}
+
+// removeDevice drops the device with the given ID from the list
+func (m *DevicesModel) removeDevice(id string) {
+	m.devices = slices.DeleteFunc(m.devices, func(d models.Device) bool {
+		return d.ID == id
+	})
+}
+
+// cancelRename leaves the rename input without changing the device
+func (m *DevicesModel) cancelRename() {
+	m.state = DevicesStateReady
+	m.renameInput.Blur()
+	m.renameInput.SetValue("")
+	m.renameDevice = nil
+	m.renameErr = ""
+	m.table.Focus()
+}
+
+func (m DevicesModel) renameDeviceCmd(deviceID, name string) tea.Cmd {
 	return func() tea.Msg {
 		if m.client == nil {
 			return DevicesErrorMsg{Err: fmt.Errorf("no API client")}
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), common.RequestTimeout())
 		defer cancel()
 
-		devices, err := m.client.ListDevices(ctx)
+		device, err := m.client.SetDeviceName(ctx, deviceID, name)
+		if api.IsNotFound(err) {
+			return DeviceGoneMsg{DeviceID: deviceID}
+		}
 		if err != nil {
 			return DevicesErrorMsg{Err: err}
 		}
 
-		return DevicesLoadedMsg{Devices: devices}
+		// Fetch the whole device to refresh its row, falling back to the
+		// update response if that fails
+		if fresh, err := m.client.GetDevice(ctx, deviceID); err == nil {
+			device = fresh
+		}
+
+		return DeviceRenamedMsg{Device: device}
 	}
 }
 
-func (m DevicesModel) registerDevice() tea.Cmd {
+func (m DevicesModel) registerDevices(count int, encryption models.EncryptionType) tea.Cmd {
 	return func() tea.Msg {
 		if m.client == nil {
 			return DevicesErrorMsg{Err: fmt.Errorf("no API client")}
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), common.RequestTimeout())
 		defer cancel()
 
-		device, err := m.client.RegisterDevice(ctx, models.RegisterDeviceRequest{
-			Encryption: models.EncryptionAES256CTR,
+		devices, err := m.client.RegisterDevices(ctx, models.RegisterDeviceRequest{
+			NDevices:   count,
+			Encryption: encryption,
 		})
 		if err != nil {
 			return DevicesErrorMsg{Err: err}
 		}
 
-		return DeviceRegisteredMsg{Device: device}
+		return DeviceRegisteredMsg{Devices: devices}
 	}
 }
 
+// updateRegisterInput handles key presses in the registration prompt
+func (m DevicesModel) updateRegisterInput(msg tea.KeyMsg) (DevicesModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.state = DevicesStateReady
+		m.registerInput.Blur()
+		m.registerErr = ""
+		m.table.Focus()
+		return m, nil
+	case "tab", "left", "right":
+		m.registerEncryption = nextEncryption(m.registerEncryption)
+		return m, nil
+	case "enter":
+		count, err := strconv.Atoi(strings.TrimSpace(m.registerInput.Value()))
+		if err != nil || count < 1 || count > api.MaxRegisterDevices {
+			m.registerErr = fmt.Sprintf("Enter a number from 1 to %d", api.MaxRegisterDevices)
+			return m, nil
+		}
+		if room, ok := m.quotaRoom(); ok && count > room {
+			m.registerErr = fmt.Sprintf("Only %d more device(s) fit in the quota", room)
+			return m, nil
+		}
+		m.state = DevicesStateRegistering
+		m.registerInput.Blur()
+		m.registerErr = ""
+		m.registerCount = count
+		return m, tea.Batch(m.spinner.Tick, m.registerDevices(count, m.registerEncryption))
+	default:
+		var cmd tea.Cmd
+		m.registerInput, cmd = m.registerInput.Update(msg)
+		m.registerErr = ""
+		return m, cmd
+	}
+}
+
+// Clipboard labels for the keys of newly registered devices
+const (
+	registeredKeyLabel  = "device key"
+	registeredKeysLabel = "device IDs and keys"
+)
+
+// updateRegistered handles key presses while new device keys are shown
+func (m DevicesModel) updateRegistered(msg tea.KeyMsg) (DevicesModel, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if m.registeredCursor > 0 {
+			m.registeredCursor--
+		}
+	case "down", "j":
+		if m.registeredCursor < len(m.registered)-1 {
+			m.registeredCursor++
+		}
+	case "c":
+		return m, common.CopyToClipboard(registeredKeyLabel, m.registered[m.registeredCursor].Key)
+	case "y":
+		return m, common.CopyToClipboard(registeredKeysLabel, registeredDevicesText(m.registered))
+	case "enter", "esc":
+		m.registered = nil
+		m.notice = ""
+		m.state = DevicesStateLoading
+		m.table.Focus()
+		return m, tea.Batch(m.spinner.Tick, m.loadDevices())
+	}
+	return m, nil
+}
+
+// registeredDevicesText returns one "id,key" line per device, for copying
+func registeredDevicesText(devices []models.Device) string {
+	var b strings.Builder
+	for _, d := range devices {
+		fmt.Fprintf(&b, "%s,%s\n", d.ID, d.Key)
+	}
+	return b.String()
+}
+
+// renderRegisterInput renders the registration prompt
+func (m DevicesModel) renderRegisterInput() string {
+	var content strings.Builder
+	content.WriteString(common.PrimaryTextStyle.Render("Register Devices"))
+	content.WriteString("\n\n")
+	content.WriteString(fmt.Sprintf("Number of devices (1-%d): %s", api.MaxRegisterDevices, m.registerInput.View()))
+	if m.registerErr != "" {
+		content.WriteString(common.ErrorTextStyle.Render(" ✗ " + m.registerErr))
+	}
+	content.WriteString("\n\n")
+	content.WriteString("Encryption:")
+	for _, enc := range registerEncryptions {
+		if enc == m.registerEncryption {
+			content.WriteString("  " + common.PrimaryTextStyle.Render("(•) "+string(enc)))
+		} else {
+			content.WriteString("  " + common.MutedTextStyle.Render("( ) "+string(enc)))
+		}
+	}
+	content.WriteString("\n")
+	content.WriteString(common.MutedTextStyle.Render("AES-128-CTR suits constrained hardware; the choice is kept for the next registration."))
+	return content.String()
+}
+
+// registerEncryptions are the encryption types offered when registering
+var registerEncryptions = []models.EncryptionType{models.EncryptionAES256CTR, models.EncryptionAES128CTR}
+
+// nextEncryption returns the registration choice after enc
+func nextEncryption(enc models.EncryptionType) models.EncryptionType {
+	i := slices.Index(registerEncryptions, enc)
+	return registerEncryptions[(i+1)%len(registerEncryptions)]
+}
+
+// renderRegistered lists the devices just created with their keys, marking
+// the cursor row. The list scrolls to fit the screen height; copying all
+// includes every device.
+func (m DevicesModel) renderRegistered() string {
+	var content strings.Builder
+	content.WriteString(common.SuccessTextStyle.Render(fmt.Sprintf("Registered %d device(s)", len(m.registered))))
+	content.WriteString("\n")
+	content.WriteString(common.WarningTextStyle.Render("⚠ Keys won't be shown again. Copy them now to decrypt packets locally."))
+	content.WriteString("\n\n")
+
+	// Each device takes two lines; leave room for the header, the lines
+	// above and below, and the help
+	shown := len(m.registered)
+	if m.height > 0 {
+		shown = min(shown, max((m.height-16)/2, 1))
+	}
+	first := min(max(m.registeredCursor-shown+1, 0), len(m.registered)-shown)
+	for i, d := range m.registered[first : first+shown] {
+		marker := "  "
+		if first+i == m.registeredCursor {
+			marker = common.SelectionMarker
+		}
+		content.WriteString(fmt.Sprintf("%s%s  %s\n", marker, d.ID, d.Encryption))
+		content.WriteString(fmt.Sprintf("  Key: %s\n", common.PrimaryTextStyle.Render(d.Key)))
+	}
+	if hidden := len(m.registered) - shown; hidden > 0 {
+		content.WriteString(common.MutedTextStyle.Render(fmt.Sprintf("%d more not shown (press y to copy all)", hidden)))
+		content.WriteString("\n")
+	}
+
+	if m.notice != "" {
+		content.WriteString("\n")
+		content.WriteString(common.SuccessTextStyle.Render(m.notice))
+	}
+	return content.String()
+}
+
 func (m DevicesModel) deleteDeviceCmd(deviceID string) tea.Cmd {
 	return func() tea.Msg {
 		if m.client == nil {
 			return DevicesErrorMsg{Err: fmt.Errorf("no API client")}
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), common.RequestTimeout())
 		defer cancel()
 
 		err := m.client.DeleteDevice(ctx, deviceID)
+		if api.IsNotFound(err) {
+			return DeviceGoneMsg{DeviceID: deviceID}
+		}
 		if err != nil {
 			return DevicesErrorMsg{Err: err}
 		}
@@ -710,6 +1664,224 @@ func (m DevicesModel) deleteDeviceCmd(deviceID string) tea.Cmd {
 	}
 }
 
+// bulkDeleteCmd deletes the devices, a few at a time up to the client's
+// concurrency limit, carrying on past failures so every device gets a
+// result. Devices already deleted elsewhere count as deleted.
+func (m DevicesModel) bulkDeleteCmd(deviceIDs []string) tea.Cmd {
+	return func() tea.Msg {
+		if m.client == nil {
+			return DevicesErrorMsg{Err: fmt.Errorf("no API client")}
+		}
+
+		// Each request is bounded by the client's request timeout
+		errs := m.client.DeleteDevices(context.Background(), deviceIDs)
+
+		var msg DevicesBulkDeletedMsg
+		for i, id := range deviceIDs {
+			if err := errs[i]; err != nil && !api.IsNotFound(err) {
+				if msg.Failed == nil {
+					msg.Failed = make(map[string]error)
+				}
+				msg.Failed[id] = err
+				continue
+			}
+			msg.Deleted = append(msg.Deleted, id)
+		}
+		return msg
+	}
+}
+
+// bulkDeleteNotice summarizes a bulk delete, naming each device that
+// could not be deleted
+func bulkDeleteNotice(msg DevicesBulkDeletedMsg) string {
+	total := len(msg.Deleted) + len(msg.Failed)
+	notice := fmt.Sprintf("Deleted %d of %d device(s)", len(msg.Deleted), total)
+	if len(msg.Failed) == 0 {
+		return notice
+	}
+
+	ids := make([]string, 0, len(msg.Failed))
+	for id := range msg.Failed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	failures := make([]string, len(ids))
+	for i, id := range ids {
+		failures[i] = fmt.Sprintf("%s (%s)", id, errorText(msg.Failed[id]))
+	}
+	return notice + "; failed: " + strings.Join(failures, ", ")
+}
+
+// toggleSelected adds or removes a device from the bulk selection
+func (m *DevicesModel) toggleSelected(id string) {
+	if m.selected[id] {
+		delete(m.selected, id)
+		return
+	}
+	if m.selected == nil {
+		m.selected = make(map[string]bool)
+	}
+	m.selected[id] = true
+}
+
+// pruneSelected drops devices that are no longer loaded from the selection
+func (m *DevicesModel) pruneSelected() {
+	for id := range m.selected {
+		if !slices.ContainsFunc(m.devices, func(d models.Device) bool { return d.ID == id }) {
+			delete(m.selected, id)
+		}
+	}
+}
+
+// selectedIDs returns the IDs of the multi-selected devices in the order
+// they were loaded
+func (m DevicesModel) selectedIDs() []string {
+	ids := make([]string, 0, len(m.selected))
+	for _, d := range m.devices {
+		if m.selected[d.ID] {
+			ids = append(ids, d.ID)
+		}
+	}
+	return ids
+}
+
+// maxBulkDeleteListed is how many devices the bulk delete prompt lists
+const maxBulkDeleteListed = 10
+
+// renderBulkDeleteConfirm renders the prompt confirming a bulk delete
+func (m DevicesModel) renderBulkDeleteConfirm() string {
+	var content strings.Builder
+
+	ids := m.selectedIDs()
+	content.WriteString(common.ErrorTextStyle.Render(fmt.Sprintf("⚠ Delete %d Devices", len(ids))))
+	content.WriteString("\n\n")
+	for i, id := range ids {
+		if i == maxBulkDeleteListed {
+			content.WriteString(common.MutedTextStyle.Render(fmt.Sprintf("  and %d more", len(ids)-i)))
+			content.WriteString("\n")
+			break
+		}
+		content.WriteString("  " + id + "\n")
+	}
+	content.WriteString("\nThis cannot be undone. Delete all of them? (y/n)")
+	return content.String()
+}
+
+// quotaUsage returns "X of Y devices used", or "" if the quota is unknown.
+// Until every page is loaded X is only a lower bound, and says so.
+func (m DevicesModel) quotaUsage() string {
+	if m.quota == nil {
+		return ""
+	}
+	if m.hasMore {
+		return fmt.Sprintf("at least %d of %d devices used", len(m.devices), *m.quota)
+	}
+	return fmt.Sprintf("%d of %d devices used", len(m.devices), *m.quota)
+}
+
+// quotaRoom returns how many more devices fit in the quota. It is only
+// known once every page is loaded, since the org's device count is the
+// number of devices listed.
+func (m DevicesModel) quotaRoom() (int, bool) {
+	if m.quota == nil || m.hasMore {
+		return 0, false
+	}
+	return max(*m.quota-len(m.devices), 0), true
+}
+
+// fleetStats holds org-wide device counts for the devices screen footer
+type fleetStats struct {
+	total        int
+	seenRecently int // Sent a packet within recentlySeenWindow
+	tagged       int
+	byEncryption map[models.EncryptionType]int
+}
+
+// recentlySeenWindow is how recently a device must have sent a packet to
+// count as seen in the footer
+const recentlySeenWindow = 24 * time.Hour
+
+// computeFleetStats counts devices by freshness, encryption and tags
+func computeFleetStats(devices []models.Device, now time.Time) fleetStats {
+	stats := fleetStats{
+		total:        len(devices),
+		byEncryption: make(map[models.EncryptionType]int),
+	}
+	recent := recencyFilter{activeFor: recentlySeenWindow}
+	for _, d := range devices {
+		if recent.matches(d, now) {
+			stats.seenRecently++
+		}
+		if len(d.Tags) > 0 {
+			stats.tagged++
+		}
+		stats.byEncryption[d.Encryption]++
+	}
+	return stats
+}
+
+// String renders the stats as a single line
+func (s fleetStats) String() string {
+	parts := []string{
+		fmt.Sprintf("%d device(s)", s.total),
+		fmt.Sprintf("%d seen in 24h", s.seenRecently),
+	}
+
+	encryptions := make([]string, 0, len(s.byEncryption))
+	for enc := range s.byEncryption {
+		encryptions = append(encryptions, string(enc))
+	}
+	sort.Strings(encryptions)
+	for _, enc := range encryptions {
+		label := enc
+		if label == "" {
+			label = "unknown encryption"
+		}
+		parts = append(parts, fmt.Sprintf("%s: %d", label, s.byEncryption[models.EncryptionType(enc)]))
+	}
+
+	parts = append(parts, fmt.Sprintf("%d tagged, %d untagged", s.tagged, s.total-s.tagged))
+	return strings.Join(parts, "  •  ")
+}
+
+// renderFooter renders org-wide stats below the table. They are computed
+// from all devices, so they don't change while filtering.
+func (m DevicesModel) renderFooter() string {
+	if m.state != DevicesStateReady || len(m.devices) == 0 {
+		return ""
+	}
+	stats := computeFleetStats(m.devices, time.Now())
+	return "\n" + common.MutedTextStyle.Render("Fleet: "+stats.String())
+}
+
+// nearQuota reports whether at least 90% of the device quota is used. The
+// devices loaded so far are enough to tell once they reach it.
+func (m DevicesModel) nearQuota() bool {
+	return m.quota != nil && len(m.devices)*10 >= *m.quota*9
+}
+
+// atQuota reports whether no more devices can be registered, going by the
+// devices loaded so far
+func (m DevicesModel) atQuota() bool {
+	return m.quota != nil && len(m.devices) >= *m.quota
+}
+
+// Busy reports whether devices are being loaded, registered or deleted, or
+// the keys of newly registered devices have not been copied yet
+func (m DevicesModel) Busy() bool {
+	if m.loadingMore {
+		return true
+	}
+	switch m.state {
+	case DevicesStateLoading, DevicesStateRegistering, DevicesStateDeleting, DevicesStateRenaming:
+		return true
+	case DevicesStateRegistered:
+		// Leaving now would lose the new keys
+		return !m.registeredCopied
+	}
+	return false
+}
+
 // SelectedDevice returns the currently selected device, if any
 func (m DevicesModel) SelectedDevice() *models.Device {
 	if m.state != DevicesStateReady || len(m.devices) == 0 || len(m.filteredDevs) == 0 {
@@ -721,10 +1893,16 @@ func (m DevicesModel) SelectedDevice() *models.Device {
 		return nil
 	}
 
-	// Get the displayed ID, removing "..." suffix if truncated
-	displayedID := selectedRow[0]
-	if strings.HasSuffix(displayedID, "...") {
-		displayedID = displayedID[:len(displayedID)-3]
+	return m.deviceByDisplayedID(selectedRow[1]) // After the selection mark
+}
+
+// deviceByDisplayedID returns the filtered device whose ID is shown as
+// displayedID in the table, or nil if there is none
+func (m DevicesModel) deviceByDisplayedID(displayedID string) *models.Device {
+	// Remove the "..." suffix if truncated
+	displayedID = strings.TrimSuffix(displayedID, "...")
+	if displayedID == "" {
+		return nil
 	}
 
 	// Find device by ID prefix match in filtered list
@@ -737,6 +1915,77 @@ func (m DevicesModel) SelectedDevice() *models.Device {
 	return nil
 }
 
+// diffDevices compares two loads of the device list by ID and returns the
+// devices in current that are new or whose last packet time advanced.
+func diffDevices(previous, current []models.Device) map[string]deviceChange {
+	before := make(map[string]models.Device, len(previous))
+	for _, d := range previous {
+		before[d.ID] = d
+	}
+
+	changes := make(map[string]deviceChange)
+	for _, d := range current {
+		old, ok := before[d.ID]
+		if !ok {
+			changes[d.ID] = deviceAdded
+			continue
+		}
+		seen, ok := d.LastSeen()
+		if !ok {
+			continue
+		}
+		if oldSeen, ok := old.LastSeen(); !ok || seen.After(oldSeen) {
+			changes[d.ID] = deviceUpdated
+		}
+	}
+	return changes
+}
+
+// changesSummary describes the change markers shown after a refresh, or ""
+// if there are none
+func (m DevicesModel) changesSummary() string {
+	var added, updated int
+	for _, c := range m.changes {
+		switch c {
+		case deviceAdded:
+			added++
+		case deviceUpdated:
+			updated++
+		}
+	}
+
+	var parts []string
+	if added > 0 {
+		parts = append(parts, fmt.Sprintf("%s%d new", deviceAddedMarker, added))
+	}
+	if updated > 0 {
+		parts = append(parts, fmt.Sprintf("%s%d with new packets", deviceUpdatedMarker, updated))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// timeZoneHelp returns the help entry for toggling UTC / local timestamps
+func timeZoneHelp() string {
+	if common.UTC() {
+		return common.FormatHelp("z", "local time")
+	}
+	return common.FormatHelp("z", "UTC")
+}
+
+// errorText describes an error for the error views. Rate limiting is
+// reported with the wait the API asked for rather than the raw API error.
+func errorText(err error) string {
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+		if apiErr.RetryAfter > 0 {
+			secs := int(math.Ceil(apiErr.RetryAfter.Seconds()))
+			return fmt.Sprintf("rate limited, retry in %ds", secs)
+		}
+		return "rate limited, retry shortly"
+	}
+	return err.Error()
+}
+
 // truncate shortens a string to maxLen, adding "..." if needed
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
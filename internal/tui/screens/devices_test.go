@@ -1,12 +1,24 @@
 package screens
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hubblenetwork/hubcli/internal/api"
 	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/hubblenetwork/hubcli/internal/tui/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewDevicesModel(t *testing.T) {
@@ -64,6 +76,90 @@ func TestDevicesModel_DevicesErrorMsg(t *testing.T) {
 	assert.Error(t, m.err)
 }
 
+func TestDevicesModel_LoadDevices(t *testing.T) {
+	quota := 10
+	devices := []models.Device{{ID: "device-1"}, {ID: "device-2"}}
+	listed := func(ctx context.Context, token string) ([]models.Device, string, error) {
+		return devices, "page2", nil
+	}
+
+	tests := []struct {
+		name    string
+		client  api.APIClient
+		want    tea.Msg
+		wantErr string
+	}{
+		{
+			name: "loaded with quota",
+			client: &stubClient{
+				listDevicesPage: listed,
+				getOrganization: func(ctx context.Context) (*models.Organization, error) {
+					return &models.Organization{DeviceQuota: &quota}, nil
+				},
+			},
+			want: DevicesLoadedMsg{Devices: devices, Quota: &quota, ContinuationToken: "page2"},
+		},
+		{
+			name: "org unavailable",
+			client: &stubClient{
+				listDevicesPage: listed,
+				getOrganization: func(ctx context.Context) (*models.Organization, error) {
+					return nil, api.ErrForbidden
+				},
+			},
+			want: DevicesLoadedMsg{Devices: devices, ContinuationToken: "page2"},
+		},
+		{
+			name: "list fails",
+			client: &stubClient{
+				listDevicesPage: func(ctx context.Context, token string) ([]models.Device, string, error) {
+					return nil, "", api.ErrServerError
+				},
+			},
+			wantErr: api.ErrServerError.Error(),
+		},
+		{
+			name:    "no client",
+			wantErr: "no API client",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewDevicesModel(tt.client)
+			msg := m.loadDevices()()
+
+			if tt.wantErr == "" {
+				assert.Equal(t, tt.want, msg)
+				m, _ = m.Update(msg)
+				assert.Equal(t, DevicesStateReady, m.state)
+				return
+			}
+
+			errMsg, ok := msg.(DevicesErrorMsg)
+			require.True(t, ok, "got %T", msg)
+			assert.ErrorContains(t, errMsg.Err, tt.wantErr)
+			m, _ = m.Update(msg)
+			assert.Equal(t, DevicesStateError, m.state)
+		})
+	}
+}
+
+func TestDevicesModel_LoadMoreDevices(t *testing.T) {
+	var gotToken string
+	m := NewDevicesModel(&stubClient{
+		listDevicesPage: func(ctx context.Context, token string) ([]models.Device, string, error) {
+			gotToken = token
+			return []models.Device{{ID: "device-3"}}, "", nil
+		},
+	})
+	m.continuationToken = "page2"
+
+	msg := m.loadMoreDevices()()
+	assert.Equal(t, "page2", gotToken)
+	assert.Equal(t, DevicesLoadedMsg{Devices: []models.Device{{ID: "device-3"}}, Append: true}, msg)
+}
+
 func TestDevicesModel_BackNavigation(t *testing.T) {
 	m := NewDevicesModel(nil)
 	m.state = DevicesStateReady
@@ -75,7 +171,7 @@ func TestDevicesModel_BackNavigation(t *testing.T) {
 	msg := cmd()
 	navMsg, ok := msg.(NavigateMsg)
 	assert.True(t, ok)
-	assert.Equal(t, "home", navMsg.Screen)
+	assert.Equal(t, "back", navMsg.Screen)
 }
 
 func TestDevicesModel_QuitKey(t *testing.T) {
@@ -83,7 +179,7 @@ func TestDevicesModel_QuitKey(t *testing.T) {
 
 	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
 
-	// Should return tea.Quit
+	// Should request a quit
 	assert.NotNil(t, cmd)
 }
 
@@ -111,20 +207,207 @@ func TestDevicesModel_RegisterNewDevice(t *testing.T) {
 	m := NewDevicesModel(nil)
 	m.state = DevicesStateReady
 
-	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	assert.Equal(t, DevicesStateRegisterInput, m.state)
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	assert.Equal(t, DevicesStateRegistering, m.state)
+	assert.Equal(t, 1, m.registerCount)
+	assert.NotNil(t, cmd)
+}
+
+func TestDevicesModel_RegisterInput_Validation(t *testing.T) {
+	quota := 10
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "aaaa-1111"}}, Quota: &quota})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+
+	for _, value := range []string{"0", "101", "lots"} {
+		m.registerInput.SetValue(value)
+		var cmd tea.Cmd
+		m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Nil(t, cmd, value)
+		assert.Equal(t, DevicesStateRegisterInput, m.state, value)
+		assert.Contains(t, m.View(), "Enter a number from 1 to 100", value)
+	}
+
+	// The quota leaves room for 9 more
+	m.registerInput.SetValue("10")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, DevicesStateRegisterInput, m.state)
+	assert.Contains(t, m.View(), "Only 9 more device(s)")
+
+	// Tab switches the encryption type
+	assert.Equal(t, models.EncryptionAES256CTR, m.registerEncryption)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	assert.Equal(t, models.EncryptionAES128CTR, m.registerEncryption)
+	assert.Contains(t, m.View(), "AES-128-CTR")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Equal(t, DevicesStateReady, m.state)
+}
 
+func TestDevicesModel_RegisterEncryptionChoice(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m.width = 120
+	m.height = 40
+	m.state = DevicesStateReady
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	assert.Equal(t, models.EncryptionAES256CTR, m.registerEncryption)
+	assert.Contains(t, m.View(), "(•) AES-256-CTR")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	assert.Equal(t, models.EncryptionAES128CTR, m.registerEncryption)
+	assert.Contains(t, m.View(), "(•) AES-128-CTR")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	assert.Equal(t, models.EncryptionAES256CTR, m.registerEncryption)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	assert.Equal(t, DevicesStateRegistering, m.state)
 	assert.NotNil(t, cmd)
+
+	// The next registration starts from the last choice
+	m, _ = m.Update(DevicesErrorMsg{Err: errors.New("boom")})
+	m.state = DevicesStateReady
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	assert.Equal(t, models.EncryptionAES128CTR, m.registerEncryption)
 }
 
-func TestDevicesModel_DeviceRegisteredMsg(t *testing.T) {
+func TestDevicesModel_RegisteredKeyView(t *testing.T) {
 	m := NewDevicesModel(nil)
+	m.width = 100
+	m.height = 40
 	m.state = DevicesStateRegistering
 
-	device := &models.Device{ID: "new-device"}
-	m, cmd := m.Update(DeviceRegisteredMsg{Device: device})
+	m, _ = m.Update(DeviceRegisteredMsg{Devices: []models.Device{
+		{ID: "new-dev-1", Key: "a2V5MQ==", Encryption: models.EncryptionAES256CTR},
+		{ID: "new-dev-2", Key: "a2V5Mg==", Encryption: models.EncryptionAES256CTR},
+	}})
+	view := m.View()
+	assert.Contains(t, view, "won't be shown again")
+	assert.Contains(t, view, "Key: a2V5MQ==")
+	assert.Contains(t, view, "copy key")
+	assert.Contains(t, view, common.SelectionMarker+"new-dev-1")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	assert.Equal(t, 1, m.registeredCursor)
+	assert.Contains(t, m.View(), common.SelectionMarker+"new-dev-2")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	assert.Equal(t, 1, m.registeredCursor)
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	assert.NotNil(t, cmd)
+
+	// One key of several doesn't count as saving them all
+	m, _ = m.Update(common.ClipboardCopiedMsg{Label: registeredKeyLabel})
+	assert.Contains(t, m.View(), "Copied device key")
+	assert.True(t, m.Busy())
+	m, _ = m.Update(common.ClipboardCopiedMsg{Label: registeredKeysLabel})
+	assert.False(t, m.Busy())
+}
+
+func TestDevicesModel_RegisteredSingleKeyCopied(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(DeviceRegisteredMsg{Devices: []models.Device{{ID: "new-dev-1", Key: "a2V5MQ=="}}})
+	assert.True(t, m.Busy())
+	assert.NotContains(t, m.renderHelp(), "select")
+
+	m, _ = m.Update(common.ClipboardCopiedMsg{Label: registeredKeyLabel})
+	assert.False(t, m.Busy())
+
+	m, _ = m.Update(common.ClipboardCopiedMsg{Err: errors.New("no clipboard")})
+	assert.Contains(t, m.View(), "Copy failed")
+}
+
+func TestDevicesModel_CopyIDKey(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m.width = 160
+	m.height = 30
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "aaaa-1111"}}})
+	assert.Contains(t, m.View(), "copy ID")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	assert.NotNil(t, cmd)
+
+	m, cmd = m.Update(common.ClipboardCopiedMsg{Label: "device ID"})
+	assert.Contains(t, m.View(), "Copied device ID")
+	m, _ = m.Update(common.FlashExpiredMsg{ID: m.copied.ID()})
+	assert.NotContains(t, m.View(), "Copied device ID")
+
+	m, _ = m.Update(common.ClipboardCopiedMsg{Label: "device ID", Err: errors.New("clipboard not available")})
+	assert.Contains(t, m.View(), "Copy failed")
+}
+
+func TestDevicesModel_EncryptionColumn(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m.width = 160
+	m.height = 30
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{
+		{ID: "aaaa-1111", Encryption: models.EncryptionAES128CTR},
+		{ID: "bbbb-2222"},
+	}})
+
+	require.Len(t, m.table.Columns(), 6)
+	assert.Equal(t, "Encryption", m.table.Columns()[5].Title)
+
+	cells := map[string]string{}
+	for _, row := range m.table.Rows() {
+		require.Len(t, row, 6)
+		cells[row[1]] = row[5]
+	}
+	assert.Equal(t, "AES-128-CTR", cells["aaaa-1111"])
+	assert.Equal(t, "-", cells["bbbb-2222"])
+}
+
+func TestDevicesModel_RegisterDevices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.RegisterDeviceRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, 3, req.NDevices)
+		assert.Equal(t, models.EncryptionAES128CTR, req.Encryption)
+
+		devices := make([]models.Device, req.NDevices)
+		for i := range devices {
+			devices[i] = models.Device{ID: fmt.Sprintf("new-dev-%d", i+1), Key: fmt.Sprintf("key%d==", i+1)}
+		}
+		json.NewEncoder(w).Encode(devices)
+	}))
+	defer server.Close()
+
+	m := NewDevicesModel(api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)))
+	m.width = 100
+	m.height = 40
+	m.state = DevicesStateReady
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m.registerInput.SetValue("3")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	msg, ok := m.registerDevices(3, models.EncryptionAES128CTR)().(DeviceRegisteredMsg)
+	require.NotNil(t, cmd)
+	require.True(t, ok)
+	require.Len(t, msg.Devices, 3)
+
+	m, _ = m.Update(msg)
+	assert.Equal(t, DevicesStateRegistered, m.state)
+	view := m.View()
+	assert.Contains(t, view, "Registered 3 device(s)")
+	assert.Contains(t, view, "new-dev-3")
+	assert.Contains(t, view, "key3==")
+	assert.True(t, m.Busy(), "keys not copied yet")
 
+	assert.Equal(t, "new-dev-1,key1==\nnew-dev-2,key2==\nnew-dev-3,key3==\n", registeredDevicesText(m.registered))
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	assert.NotNil(t, cmd)
+	m, _ = m.Update(common.ClipboardCopiedMsg{Label: "device IDs and keys"})
+	assert.False(t, m.Busy())
+
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	assert.Equal(t, DevicesStateLoading, m.state)
+	assert.Nil(t, m.registered)
 	assert.NotNil(t, cmd) // Should reload devices
 }
 
@@ -144,6 +427,49 @@ func TestDevicesModel_SelectDevice(t *testing.T) {
 	navMsg, ok := msg.(NavigateMsg)
 	assert.True(t, ok)
 	assert.Equal(t, "packets", navMsg.Screen)
+	assert.Equal(t, PacketsNavData{DeviceID: "device-1"}, navMsg.Data)
+}
+
+func TestDevicesModel_ViewLastDay(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m.state = DevicesStateReady
+	m.devices = []models.Device{
+		{ID: "device-1", Name: "Test Device"},
+	}
+	m.updateTable()
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+
+	require.NotNil(t, cmd)
+	navMsg, ok := cmd().(NavigateMsg)
+	require.True(t, ok)
+	assert.Equal(t, "packets", navMsg.Screen)
+	assert.Equal(t, PacketsNavData{DeviceID: "device-1", Days: 1}, navMsg.Data)
+}
+
+func TestDevicesModel_JumpKeys(t *testing.T) {
+	m := NewDevicesModel(nil)
+	devices := make([]models.Device, 30)
+	for i := range devices {
+		devices[i] = models.Device{ID: fmt.Sprintf("device-%02d", i)}
+	}
+	m, _ = m.Update(DevicesLoadedMsg{Devices: devices})
+	page := m.table.Height()
+
+	tests := []struct {
+		key  tea.KeyMsg
+		want int
+	}{
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'G'}}, 29},
+		{tea.KeyMsg{Type: tea.KeyPgUp}, 29 - page},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}}, 0},
+		{tea.KeyMsg{Type: tea.KeyPgDown}, page},
+		{tea.KeyMsg{Type: tea.KeyPgDown}, 2 * page},
+	}
+	for _, tt := range tests {
+		m, _ = m.Update(tt.key)
+		assert.Equal(t, tt.want, m.table.Cursor(), "after %s", tt.key)
+	}
 }
 
 func TestDevicesModel_SelectedDevice(t *testing.T) {
@@ -225,6 +551,57 @@ func TestDevicesModel_ViewEmpty(t *testing.T) {
 	assert.Contains(t, view, "No devices found")
 }
 
+func TestFilterMatchSpan(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		filter     string
+		maxLen     int
+		start, end int
+	}{
+		{"no filter", "Pump North", "", 20, 0, 0},
+		{"no match", "Pump North", "south", 20, 0, 0},
+		{"match", "Pump North", "north", 20, 5, 10},
+		{"case insensitive", "Pump North", "PUMP", 20, 0, 4},
+		{"first match only", "pump pump", "pump", 20, 0, 4},
+		{"before truncation", "abcdef-123456", "cde", 10, 2, 5},
+		{"cut at the ellipsis", "abcdef-123456", "ef-12", 10, 4, 7},
+		{"hidden by truncation", "abcdef-123456", "345", 10, 0, 0},
+		{"starts at the ellipsis", "abcdef-123456", "123", 10, 0, 0},
+		{"short column has no ellipsis", "abcdef", "bc", 3, 1, 3},
+		{"non-ASCII name", "Pümpe", "mp", 20, 3, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := filterMatchSpan(tt.text, tt.filter, tt.maxLen)
+			assert.Equal(t, tt.start, start, "start")
+			assert.Equal(t, tt.end, end, "end")
+		})
+	}
+}
+
+func TestDevicesModel_HighlightMatchesFindsRows(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m.width = 160
+	m.height = 30
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{
+		{ID: "device-1", Name: "Pump North"},
+		{ID: "device-2", Name: "Valve"},
+	}})
+	m.filterText = "pump stale:>1h"
+	m.applyFilterAndSort()
+
+	// Without a color profile the highlight adds no escapes, so the view is
+	// unchanged and still lists the matching device
+	view := common.RenderTable(m.table)
+	assert.Equal(t, view, m.highlightMatches(view))
+	assert.Contains(t, view, "Pump North")
+	assert.NotContains(t, view, "Valve")
+	assert.Nil(t, m.deviceByDisplayedID(""))
+	assert.Equal(t, "device-1", m.deviceByDisplayedID("device-1").ID)
+}
+
 func TestTruncate(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -244,3 +621,613 @@ func TestTruncate(t *testing.T) {
 		assert.Equal(t, tt.expected, result, "truncate(%q, %d)", tt.input, tt.maxLen)
 	}
 }
+
+func TestErrorText(t *testing.T) {
+	assert.Equal(t, "boom", errorText(errors.New("boom")))
+	assert.Equal(t, "rate limited, retry in 3s",
+		errorText(&api.APIError{StatusCode: 429, RetryAfter: 2500 * time.Millisecond}))
+	assert.Equal(t, "rate limited, retry shortly", errorText(api.NewAPIError(429, "slow down")))
+	assert.Equal(t, "API error 500: oops", errorText(api.NewAPIError(500, "oops")))
+}
+
+func TestDevicesModel_ViewRateLimited(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(DevicesErrorMsg{Err: &api.APIError{StatusCode: 429, RetryAfter: 30 * time.Second}})
+
+	assert.Contains(t, m.View(), "rate limited, retry in 30s")
+}
+
+func TestDevicesModel_Busy(t *testing.T) {
+	tests := []struct {
+		state    DevicesState
+		expected bool
+	}{
+		{DevicesStateLoading, true},
+		{DevicesStateReady, false},
+		{DevicesStateError, false},
+		{DevicesStateRegistering, true},
+		{DevicesStateDeleteConfirm, false},
+		{DevicesStateDeleting, true},
+		{DevicesStateRenameInput, false},
+		{DevicesStateRenaming, true},
+		{DevicesStateRegisterInput, false},
+		{DevicesStateRegistered, true},
+	}
+
+	for _, tt := range tests {
+		m := NewDevicesModel(nil)
+		m.state = tt.state
+		assert.Equal(t, tt.expected, m.Busy(), "state %d", tt.state)
+	}
+}
+
+func TestParseDeviceFilter(t *testing.T) {
+	text, f := parseDeviceFilter("sensor stale:>24h")
+	assert.Equal(t, "sensor", text)
+	assert.Equal(t, 24*time.Hour, f.staleFor)
+	assert.Zero(t, f.activeFor)
+
+	text, f = parseDeviceFilter("active:<30m")
+	assert.Equal(t, "", text)
+	assert.Equal(t, 30*time.Minute, f.activeFor)
+
+	text, f = parseDeviceFilter("STALE:7d")
+	assert.Equal(t, "", text)
+	assert.Equal(t, 7*24*time.Hour, f.staleFor)
+
+	// Invalid durations fall back to free text
+	text, f = parseDeviceFilter("stale:>soon")
+	assert.Equal(t, "stale:>soon", text)
+	assert.Zero(t, f.staleFor)
+}
+
+func TestDevicesModel_FilterByRecency(t *testing.T) {
+	seen := func(ago time.Duration) *models.MostRecentPacketInfo {
+		return &models.MostRecentPacketInfo{
+			Terrestrial: &models.PacketTimestamp{Timestamp: float64(time.Now().Add(-ago).Unix())},
+		}
+	}
+
+	m := NewDevicesModel(nil)
+	m.devices = []models.Device{
+		{ID: "aaaa", Name: "fresh sensor", MostRecentPacket: seen(10 * time.Minute)},
+		{ID: "bbbb", Name: "old sensor", MostRecentPacket: seen(48 * time.Hour)},
+		{ID: "cccc", Name: "never seen"},
+	}
+
+	ids := func(devs []models.Device) []string {
+		var out []string
+		for _, d := range devs {
+			out = append(out, d.ID)
+		}
+		return out
+	}
+
+	m.filterText = "stale:>24h"
+	assert.Equal(t, []string{"bbbb", "cccc"}, ids(m.filterDevices()))
+
+	m.filterText = "active:<1h"
+	assert.Equal(t, []string{"aaaa"}, ids(m.filterDevices()))
+
+	m.filterText = "sensor stale:>24h"
+	assert.Equal(t, []string{"bbbb"}, ids(m.filterDevices()))
+}
+
+func TestDevicesModel_QuotaBlocksRegistration(t *testing.T) {
+	quota := 2
+	m := NewDevicesModel(nil)
+	m.width = 120
+	m.height = 30
+	m, _ = m.Update(DevicesLoadedMsg{
+		Devices: []models.Device{{ID: "aaaa-1111"}, {ID: "bbbb-2222"}},
+		Quota:   &quota,
+	})
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+
+	assert.Nil(t, cmd)
+	assert.Equal(t, DevicesStateReady, m.state)
+	assert.Contains(t, m.View(), "2 of 2 devices used")
+	assert.Contains(t, m.View(), "Device quota reached")
+}
+
+func TestDevicesModel_QuotaNeedsEveryPage(t *testing.T) {
+	quota := 3
+	m := NewDevicesModel(nil)
+	m.width = 120
+	m.height = 30
+	m, _ = m.Update(DevicesLoadedMsg{
+		Devices:           []models.Device{{ID: "aaaa-1111"}},
+		Quota:             &quota,
+		ContinuationToken: "page2",
+	})
+
+	// The first page can't tell how much room is left
+	assert.Contains(t, m.View(), "at least 1 of 3 devices used")
+	_, ok := m.quotaRoom()
+	assert.False(t, ok)
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	assert.Nil(t, cmd)
+	assert.Equal(t, DevicesStateReady, m.state)
+	assert.Contains(t, m.notice, "Load every device")
+
+	// Further pages can fill the quota, which blocks registering outright
+	m, _ = m.Update(DevicesLoadedMsg{
+		Devices:           []models.Device{{ID: "bbbb-2222"}, {ID: "cccc-3333"}},
+		ContinuationToken: "page3",
+		Append:            true,
+	})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	assert.Contains(t, m.notice, "Device quota reached (at least 3 of 3 devices used)")
+}
+
+func TestDevicesModel_QuotaUnknownAllowsRegistration(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "aaaa-1111"}}})
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	assert.NotNil(t, cmd)
+	assert.Equal(t, DevicesStateRegistering, m.state)
+	assert.Empty(t, m.quotaUsage())
+}
+
+func TestDevicesModel_NearQuota(t *testing.T) {
+	quota := 10
+	m := NewDevicesModel(nil)
+	m.quota = &quota
+
+	m.devices = make([]models.Device, 8)
+	assert.False(t, m.nearQuota())
+
+	m.devices = make([]models.Device, 9)
+	assert.True(t, m.nearQuota())
+	assert.False(t, m.atQuota())
+}
+
+func TestDevicesModel_TableFitsWindow(t *testing.T) {
+	devices := make([]models.Device, 100)
+	for i := range devices {
+		devices[i] = models.Device{ID: fmt.Sprintf("device-%d", i), CreatedAt: time.Now()}
+	}
+
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = m.Update(DevicesLoadedMsg{Devices: devices})
+	assert.Equal(t, 40, lipgloss.Height(m.View()))
+
+	// The filter input takes lines from the table
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	require.True(t, m.filterActive)
+	assert.Equal(t, 40, lipgloss.Height(m.View()))
+}
+
+func TestDevicesModel_ExportKey(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "device-1"}}})
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	assert.NotNil(t, cmd)
+
+	m, _ = m.Update(DevicesExportedMsg{Path: "devices.csv", Count: 1})
+	assert.Contains(t, m.View(), "Exported 1 device(s) to devices.csv")
+
+	m, _ = m.Update(DevicesExportedMsg{Err: errors.New("disk full")})
+	assert.Empty(t, m.exported)
+	assert.Contains(t, m.View(), "Export failed: disk full")
+}
+
+func TestDevicesModel_ExportKey_NoDevices(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(DevicesLoadedMsg{})
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	assert.Nil(t, cmd)
+}
+
+func TestComputeFleetStats(t *testing.T) {
+	now := time.Now()
+	seen := func(ago time.Duration) *models.MostRecentPacketInfo {
+		return &models.MostRecentPacketInfo{
+			Terrestrial: &models.PacketTimestamp{Timestamp: float64(now.Add(-ago).Unix())},
+		}
+	}
+	devices := []models.Device{
+		{ID: "a", Encryption: models.EncryptionAES256CTR, MostRecentPacket: seen(time.Hour), Tags: map[string]string{"site": "x"}},
+		{ID: "b", Encryption: models.EncryptionAES256CTR, MostRecentPacket: seen(48 * time.Hour)},
+		{ID: "c", Encryption: models.EncryptionAES128CTR},
+		{ID: "d"},
+	}
+
+	stats := computeFleetStats(devices, now)
+
+	assert.Equal(t, 4, stats.total)
+	assert.Equal(t, 1, stats.seenRecently)
+	assert.Equal(t, 1, stats.tagged)
+	assert.Equal(t, "4 device(s)  •  1 seen in 24h  •  unknown encryption: 1  •  AES-128-CTR: 1  •  AES-256-CTR: 2  •  1 tagged, 3 untagged", stats.String())
+}
+
+func TestDevicesModel_FooterIgnoresFilter(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "alpha"}, {ID: "beta"}}})
+
+	m.filterText = "alpha"
+	m.applyFilterAndSort()
+
+	view := m.View()
+	assert.Contains(t, view, "1 of 2 device(s)")
+	assert.Contains(t, view, "Fleet: 2 device(s)")
+}
+
+func TestDiffDevices(t *testing.T) {
+	seen := func(ts int64) *models.MostRecentPacketInfo {
+		return &models.MostRecentPacketInfo{Terrestrial: &models.PacketTimestamp{Timestamp: float64(ts)}}
+	}
+	previous := []models.Device{
+		{ID: "same", MostRecentPacket: seen(100)},
+		{ID: "advanced", MostRecentPacket: seen(100)},
+		{ID: "first-packet"},
+		{ID: "removed"},
+	}
+	current := []models.Device{
+		{ID: "same", MostRecentPacket: seen(100)},
+		{ID: "advanced", MostRecentPacket: seen(200)},
+		{ID: "first-packet", MostRecentPacket: seen(50)},
+		{ID: "added"},
+	}
+
+	changes := diffDevices(previous, current)
+
+	assert.Equal(t, map[string]deviceChange{
+		"advanced":     deviceUpdated,
+		"first-packet": deviceUpdated,
+		"added":        deviceAdded,
+	}, changes)
+}
+
+func TestDevicesModel_RefreshMarksChanges(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+
+	// The first load has nothing to compare against
+	m, cmd := m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "alpha", Name: "Alpha"}}})
+	assert.Nil(t, cmd)
+	assert.Empty(t, m.changes)
+
+	m, cmd = m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "alpha", Name: "Alpha"}, {ID: "beta", Name: "Beta"}}})
+	require.NotNil(t, cmd)
+	assert.Contains(t, m.View(), deviceAddedMarker+"Beta")
+	assert.Contains(t, m.View(), "+ 1 new")
+
+	// A stale expiry from an earlier refresh is ignored
+	m, _ = m.Update(devicesChangesExpiredMsg{seq: m.changesSeq - 1})
+	assert.NotEmpty(t, m.changes)
+
+	m, _ = m.Update(devicesChangesExpiredMsg{seq: m.changesSeq})
+	assert.Empty(t, m.changes)
+	assert.NotContains(t, m.View(), deviceAddedMarker+"Beta")
+}
+
+func TestDevicesModel_Rename(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m.width = 200
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "device-123", Name: "Old Name"}}})
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	assert.NotNil(t, cmd)
+	assert.Equal(t, DevicesStateRenameInput, m.state)
+	assert.Equal(t, "Old Name", m.renameInput.Value())
+	assert.Contains(t, m.View(), "Rename Device")
+
+	m.renameInput.SetValue("New Name")
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, DevicesStateRenaming, m.state)
+	assert.True(t, m.Busy())
+	assert.NotNil(t, cmd)
+	assert.Contains(t, m.View(), "Renaming device...")
+
+	// The renamed device is updated in place rather than reloading the list
+	m, cmd = m.Update(DeviceRenamedMsg{Device: &models.Device{ID: "device-123", Name: "New Name"}})
+	assert.Equal(t, DevicesStateReady, m.state)
+	assert.Nil(t, cmd)
+	assert.Equal(t, "New Name", m.devices[0].Name)
+	assert.Contains(t, m.View(), "New Name")
+
+	// A device that has gone from the list reloads it
+	m, cmd = m.Update(DeviceRenamedMsg{Device: &models.Device{ID: "device-456", Name: "Other"}})
+	assert.Equal(t, DevicesStateLoading, m.state)
+	assert.NotNil(t, cmd)
+}
+
+func TestDevicesModel_RenameRefetchesDevice(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		// The fetched device carries fields the update response lacks
+		device := models.Device{ID: "device-123", Name: "New Name"}
+		if r.Method == http.MethodGet {
+			device.Encryption = models.EncryptionAES128CTR
+		}
+		json.NewEncoder(w).Encode(device)
+	}))
+	defer server.Close()
+
+	m := NewDevicesModel(api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)))
+	msg := m.renameDeviceCmd("device-123", "New Name")()
+
+	renamed, ok := msg.(DeviceRenamedMsg)
+	require.True(t, ok)
+	assert.Equal(t, models.EncryptionAES128CTR, renamed.Device.Encryption)
+	assert.Equal(t, []string{"PATCH /org/test-org/devices/device-123", "GET /org/test-org/devices/device-123"}, requests)
+}
+
+func TestDevicesModel_DeviceDeletedElsewhere(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "device not found"}`))
+	}))
+	defer server.Close()
+
+	m := NewDevicesModel(api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)))
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{
+		{ID: "device-123", Name: "Gone"},
+		{ID: "device-456", Name: "Kept"},
+	}})
+
+	for _, cmd := range []tea.Cmd{
+		m.renameDeviceCmd("device-123", "New Name"),
+		m.deleteDeviceCmd("device-123"),
+	} {
+		msg := cmd()
+		assert.Equal(t, DeviceGoneMsg{DeviceID: "device-123"}, msg)
+	}
+
+	m, cmd := m.Update(DeviceGoneMsg{DeviceID: "device-123"})
+
+	assert.Nil(t, cmd)
+	assert.Equal(t, DevicesStateReady, m.state)
+	require.Len(t, m.devices, 1)
+	assert.Equal(t, "device-456", m.devices[0].ID)
+	assert.Contains(t, m.View(), "Device device-123 was deleted elsewhere")
+}
+
+func TestDevicesModel_ToggleSelection(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m.width = 160
+	m.height = 30
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{
+		{ID: "device-1", Name: "A"},
+		{ID: "device-2", Name: "B"},
+	}})
+	m.sort.Column = int(SortByID)
+	m.sort.Asc = true
+	m.applyFilterAndSort()
+
+	space := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{' '}}
+	m, _ = m.Update(space)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(space)
+	assert.Equal(t, []string{"device-1", "device-2"}, m.selectedIDs())
+	assert.Equal(t, selectedMarker, m.table.Rows()[0][0])
+	assert.Equal(t, selectedMarker, m.table.Rows()[1][0])
+	assert.Contains(t, m.View(), "delete 2 selected")
+
+	// Pressing space again deselects
+	m, _ = m.Update(space)
+	assert.Equal(t, []string{"device-1"}, m.selectedIDs())
+	assert.Equal(t, "", m.table.Rows()[1][0])
+
+	// Selections of devices that are no longer loaded are dropped
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "device-2"}}})
+	assert.Empty(t, m.selected)
+}
+
+func TestDevicesModel_BulkDelete(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/org/test-org/devices/")
+		mu.Lock()
+		deleted = append(deleted, r.Method+" "+id)
+		mu.Unlock()
+		switch id {
+		case "device-2":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "forbidden"}`))
+		case "device-3":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "device not found"}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	m := NewDevicesModel(api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)))
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{
+		{ID: "device-1"}, {ID: "device-2"}, {ID: "device-3"}, {ID: "device-4"},
+	}})
+	for _, id := range []string{"device-1", "device-2", "device-3"} {
+		m.toggleSelected(id)
+	}
+
+	// One confirmation covers the whole selection
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	assert.Nil(t, cmd)
+	assert.Equal(t, DevicesStateBulkDeleteConfirm, m.state)
+	assert.Contains(t, m.View(), "Delete 3 Devices")
+
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	require.NotNil(t, cmd)
+	assert.Equal(t, DevicesStateDeleting, m.state)
+
+	msg := m.bulkDeleteCmd(m.selectedIDs())()
+	assert.ElementsMatch(t, []string{"DELETE device-1", "DELETE device-2", "DELETE device-3"}, deleted)
+	result, ok := msg.(DevicesBulkDeletedMsg)
+	require.True(t, ok)
+	assert.Equal(t, []string{"device-1", "device-3"}, result.Deleted)
+	require.Contains(t, result.Failed, "device-2")
+	assert.ErrorIs(t, result.Failed["device-2"], api.ErrForbidden)
+
+	m, _ = m.Update(result)
+	assert.Equal(t, DevicesStateReady, m.state)
+	assert.Empty(t, m.selected)
+	require.Len(t, m.devices, 2)
+	assert.Equal(t, "device-2", m.devices[0].ID)
+	assert.Contains(t, m.notice, "Deleted 2 of 3 device(s); failed: device-2")
+}
+
+func TestDevicesModel_BulkDeleteCancel(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "device-1"}}})
+	m.toggleSelected("device-1")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Nil(t, cmd)
+	assert.Equal(t, DevicesStateReady, m.state)
+	assert.Equal(t, []string{"device-1"}, m.selectedIDs())
+}
+
+// newHangingServer returns a server that doesn't answer until the request
+// is cancelled
+func newHangingServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+}
+
+// awaitCancelled runs cmd and checks that it reports its request cancelled
+func awaitCancelled(t *testing.T, cmd tea.Cmd) {
+	t.Helper()
+	result := make(chan tea.Msg, 1)
+	go func() { result <- cmd() }()
+	select {
+	case msg := <-result:
+		assert.Equal(t, common.RequestCancelledMsg{}, msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not cancelled")
+	}
+}
+
+func TestDevicesModel_CancelLoad(t *testing.T) {
+	server := newHangingServer()
+	defer server.Close()
+
+	m := NewDevicesModel(api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)))
+	load := m.loadDevices()
+	assert.Contains(t, m.renderHelp(), "esc cancel")
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	assert.Nil(t, cmd)
+	assert.Equal(t, DevicesStateError, m.state)
+	assert.ErrorIs(t, m.err, common.ErrCancelled)
+	assert.False(t, m.Busy())
+	awaitCancelled(t, load)
+}
+
+func TestDevicesModel_CancelRefreshKeepsDevices(t *testing.T) {
+	server := newHangingServer()
+	defer server.Close()
+
+	m := NewDevicesModel(api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)))
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "device-123", Name: "Kept"}}})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	require.Equal(t, DevicesStateLoading, m.state)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	assert.Equal(t, DevicesStateReady, m.state)
+	assert.Len(t, m.devices, 1)
+	assert.Contains(t, m.View(), "Loading cancelled")
+}
+
+func TestDevicesModel_RenameEmptyName(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "device-123", Name: "Old Name"}}})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+
+	m.renameInput.SetValue("   ")
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	assert.Nil(t, cmd)
+	assert.Equal(t, DevicesStateRenameInput, m.state)
+	assert.Contains(t, m.View(), "Name cannot be empty")
+}
+
+func TestDevicesModel_RenameUnchangedOrCancelled(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "device-123", Name: "Old Name"}}})
+
+	// Saving the same name sends nothing
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Nil(t, cmd)
+	assert.Equal(t, DevicesStateReady, m.state)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	m.renameInput.SetValue("Other")
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Nil(t, cmd)
+	assert.Equal(t, DevicesStateReady, m.state)
+	assert.Nil(t, m.renameDevice)
+}
+
+func TestDevicesModel_RenameError(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "device-123", Name: "Old Name"}}})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	m.renameInput.SetValue("New Name")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+
+	// Without a client the request fails like any other API error
+	msg := m.renameDeviceCmd("device-123", "New Name")()
+	m, _ = m.Update(msg)
+	assert.Equal(t, DevicesStateError, m.state)
+	assert.Contains(t, m.View(), "no API client")
+}
+
+func TestDevicesModel_LoadMore(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(DevicesLoadedMsg{
+		Devices:           []models.Device{{ID: "device-1", Name: "One"}},
+		ContinuationToken: "token-123",
+	})
+	assert.True(t, m.hasMore)
+	assert.Contains(t, m.View(), "more available")
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+	assert.True(t, m.loadingMore)
+	assert.True(t, m.Busy())
+	assert.NotNil(t, cmd)
+
+	// Refreshing while a page is in flight is ignored
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	assert.Nil(t, cmd)
+
+	m, _ = m.Update(DevicesLoadedMsg{
+		Devices: []models.Device{{ID: "device-2", Name: "Two"}},
+		Append:  true,
+	})
+	assert.False(t, m.loadingMore)
+	assert.False(t, m.hasMore)
+	assert.Len(t, m.devices, 2)
+	assert.Len(t, m.filteredDevs, 2)
+	assert.Empty(t, m.changes, "appended pages are not marked as new")
+}
+
+func TestDevicesModel_LoadMore_NoMore(t *testing.T) {
+	m := NewDevicesModel(nil)
+	m, _ = m.Update(DevicesLoadedMsg{Devices: []models.Device{{ID: "device-1"}}})
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+
+	assert.False(t, m.loadingMore)
+	assert.Nil(t, cmd)
+	assert.NotContains(t, m.View(), "load more")
+}
@@ -0,0 +1,346 @@
+package screens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hubblenetwork/hubcli/internal/api"
+	"github.com/hubblenetwork/hubcli/internal/auth"
+	"github.com/hubblenetwork/hubcli/internal/ble"
+	"github.com/hubblenetwork/hubcli/internal/clipboard"
+	"github.com/hubblenetwork/hubcli/internal/tui/common"
+)
+
+// DiagnosticResultMsg is sent when a diagnostic check finishes
+type DiagnosticResultMsg struct {
+	Run    int // Run the check belongs to; results of earlier runs are dropped
+	Index  int
+	Detail string
+	Err    error
+}
+
+// diagnosticCheck is one check on the diagnostics screen. run returns a
+// short description of what it found, or why it failed.
+type diagnosticCheck struct {
+	name string
+	run  func(ctx context.Context) (string, error)
+}
+
+// diagnosticResult is the outcome of a finished check
+type diagnosticResult struct {
+	detail string
+	err    error
+}
+
+// DiagnosticsModel is the model for the diagnostics screen, which checks
+// what hubcli needs to work and shows what is wrong
+type DiagnosticsModel struct {
+	checks  []diagnosticCheck
+	results []*diagnosticResult // nil while a check is running
+	run     int
+	spinner spinner.Model
+	keys    common.ListKeyMap
+
+	width  int
+	height int
+
+	notice string // Copy failure, shown until the next copy
+	copied common.Flash
+}
+
+// NewDiagnosticsModel creates a new diagnostics screen model checking the
+// credentials, the API through client, Bluetooth and the clipboard
+func NewDiagnosticsModel(client api.APIClient) DiagnosticsModel {
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+	sp.Style = lipgloss.NewStyle().Foreground(common.ColorPrimary)
+
+	checks := diagnosticChecks(client)
+	return DiagnosticsModel{
+		checks:  checks,
+		results: make([]*diagnosticResult, len(checks)),
+		run:     1,
+		spinner: sp,
+		keys:    common.DefaultListKeyMap(),
+	}
+}
+
+// diagnosticChecks returns the checks run by the diagnostics screen
+func diagnosticChecks(client api.APIClient) []diagnosticCheck {
+	_, offline := client.(*api.FakeClient)
+
+	return []diagnosticCheck{
+		{"Credentials", func(ctx context.Context) (string, error) {
+			source, err := auth.GetCredentialSource()
+			switch {
+			case errors.Is(err, auth.ErrNoCredentials) && offline:
+				return "none needed in offline mode", nil
+			case err != nil:
+				return "", err
+			case source == auth.SourceEnv:
+				return fmt.Sprintf("from the environment (%s, %s)", auth.EnvOrgID, auth.EnvToken), nil
+			}
+			return "from the " + string(source), nil
+		}},
+		{"API", func(ctx context.Context) (string, error) {
+			if client == nil {
+				return "", fmt.Errorf("no API client; log in first")
+			}
+			if offline {
+				return "offline mode; using sample data instead of the Hubble API", nil
+			}
+			if err := client.CheckCredentials(ctx); err != nil {
+				return "", err
+			}
+			return "reachable, credentials accepted", nil
+		}},
+		{"Organization", func(ctx context.Context) (string, error) {
+			if client == nil {
+				return "", fmt.Errorf("no API client; log in first")
+			}
+			org, err := client.GetOrganization(ctx)
+			if err != nil {
+				return "", err
+			}
+			if org.Name == "" {
+				return org.ID, nil
+			}
+			return fmt.Sprintf("%s (%s)", org.Name, org.ID), nil
+		}},
+		{"Bluetooth", func(ctx context.Context) (string, error) {
+			if _, err := ble.NewScanner(); err != nil {
+				return "", err
+			}
+			return "adapter enabled", nil
+		}},
+		{"Clipboard", func(ctx context.Context) (string, error) {
+			if !clipboard.Available() {
+				return "", fmt.Errorf("%w; copied text is shown on screen instead", clipboard.ErrUnavailable)
+			}
+			return "available", nil
+		}},
+	}
+}
+
+// Init starts the checks
+func (m DiagnosticsModel) Init() tea.Cmd {
+	return m.checkCmds()
+}
+
+// rerun clears the results and starts every check again
+func (m *DiagnosticsModel) rerun() tea.Cmd {
+	m.run++
+	m.results = make([]*diagnosticResult, len(m.checks))
+	return m.checkCmds()
+}
+
+// checkCmds returns the commands running the checks of the current run
+func (m DiagnosticsModel) checkCmds() tea.Cmd {
+	cmds := []tea.Cmd{m.spinner.Tick}
+	for i, check := range m.checks {
+		cmds = append(cmds, runDiagnosticCheck(m.run, i, check))
+	}
+	return tea.Batch(cmds...)
+}
+
+// runDiagnosticCheck runs one check in the background, failing it if it
+// takes longer than the request timeout even if it ignores its context, so
+// an unreachable API or a stuck Bluetooth stack doesn't hang the screen
+func runDiagnosticCheck(run, index int, check diagnosticCheck) tea.Cmd {
+	return func() tea.Msg {
+		timeout := common.RequestTimeout()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		done := make(chan DiagnosticResultMsg, 1)
+		go func() {
+			detail, err := check.run(ctx)
+			done <- DiagnosticResultMsg{Run: run, Index: index, Detail: detail, Err: err}
+		}()
+
+		select {
+		case msg := <-done:
+			return msg
+		case <-ctx.Done():
+			return DiagnosticResultMsg{Run: run, Index: index, Err: fmt.Errorf("no answer after %s", timeout)}
+		}
+	}
+}
+
+// Update handles messages for the diagnostics screen
+func (m DiagnosticsModel) Update(msg tea.Msg) (DiagnosticsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.keys.Back):
+			return m, func() tea.Msg {
+				return NavigateMsg{Screen: "back"}
+			}
+
+		case key.Matches(msg, m.keys.Quit):
+			return m, RequestQuit
+
+		case key.Matches(msg, m.keys.Refresh):
+			if !m.Busy() {
+				return m, m.rerun()
+			}
+
+		case msg.String() == "y":
+			if !m.Busy() {
+				return m, common.CopyToClipboard("diagnostics report", m.report())
+			}
+		}
+
+	case DiagnosticResultMsg:
+		if msg.Run != m.run || msg.Index < 0 || msg.Index >= len(m.results) {
+			return m, nil
+		}
+		m.results[msg.Index] = &diagnosticResult{detail: msg.Detail, err: msg.Err}
+		return m, nil
+
+	case common.ClipboardCopiedMsg:
+		if msg.Err != nil {
+			m.notice = "Copy failed: " + msg.Err.Error()
+			return m, nil
+		}
+		m.notice = ""
+		return m, m.copied.Set("Copied " + msg.Label)
+
+	case common.FlashExpiredMsg:
+		m.copied.Expire(msg)
+		return m, nil
+
+	case spinner.TickMsg:
+		if m.Busy() {
+			var cmd tea.Cmd
+			m.spinner, cmd = m.spinner.Update(msg)
+			return m, cmd
+		}
+	}
+
+	return m, nil
+}
+
+// View renders the diagnostics screen
+func (m DiagnosticsModel) View() string {
+	var content strings.Builder
+
+	content.WriteString(common.TitleStyle.Render("Diagnostics"))
+	content.WriteString("\n")
+	content.WriteString(common.SubtitleStyle.Render("Check what hubcli needs to work"))
+	content.WriteString("\n\n")
+
+	boxWidth := m.width - 8
+	if boxWidth < 40 {
+		boxWidth = 40
+	}
+	content.WriteString(common.BoxStyle.Copy().Width(boxWidth).Render(m.renderChecks()))
+	content.WriteString("\n\n")
+	content.WriteString(m.renderSummary())
+
+	if m.notice != "" {
+		content.WriteString("\n\n")
+		content.WriteString(common.ErrorTextStyle.Render(m.notice))
+	} else if copied := m.copied.Text(); copied != "" {
+		content.WriteString("\n\n")
+		content.WriteString(common.SuccessTextStyle.Render(copied))
+	}
+
+	content.WriteString("\n\n")
+	var helpText []string
+	if !m.Busy() {
+		helpText = append(helpText,
+			common.FormatHelp("y", "copy report"),
+			common.FormatHelp("r", "run again"),
+		)
+	}
+	helpText = append(helpText, common.FormatHelp("esc", "back"))
+	content.WriteString(strings.Join(helpText, "  "))
+
+	style := lipgloss.NewStyle().
+		Width(m.width).
+		Padding(1, 2)
+
+	return style.Render(content.String())
+}
+
+// renderChecks renders a line per check with its status and detail
+func (m DiagnosticsModel) renderChecks() string {
+	labelStyle := lipgloss.NewStyle().Foreground(common.ColorMuted).Width(14)
+	valueStyle := lipgloss.NewStyle().Foreground(common.ColorForeground)
+
+	lines := make([]string, len(m.checks))
+	for i, check := range m.checks {
+		result := m.results[i]
+		switch {
+		case result == nil:
+			lines[i] = m.spinner.View() + " " + labelStyle.Render(check.name) + common.MutedTextStyle.Render("checking...")
+		case result.err != nil:
+			lines[i] = common.ErrorTextStyle.Render("✗") + " " + labelStyle.Render(check.name) + common.ErrorTextStyle.Render(errorText(result.err))
+		default:
+			lines[i] = common.SuccessTextStyle.Render("✓") + " " + labelStyle.Render(check.name) + valueStyle.Render(result.detail)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderSummary renders how many checks failed once they have all finished
+func (m DiagnosticsModel) renderSummary() string {
+	if m.Busy() {
+		return common.MutedTextStyle.Render("Running checks...")
+	}
+	if failed := m.failedCount(); failed > 0 {
+		return common.ErrorTextStyle.Render(fmt.Sprintf("%d of %d checks failed", failed, len(m.checks)))
+	}
+	return common.SuccessTextStyle.Render("All checks passed")
+}
+
+// failedCount returns how many finished checks failed
+func (m DiagnosticsModel) failedCount() int {
+	failed := 0
+	for _, result := range m.results {
+		if result != nil && result.err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// report returns the results as plain text, for pasting into a bug report
+func (m DiagnosticsModel) report() string {
+	var b strings.Builder
+	b.WriteString("hubcli diagnostics\n")
+	for i, check := range m.checks {
+		result := m.results[i]
+		switch {
+		case result == nil:
+			fmt.Fprintf(&b, "%s: not finished\n", check.name)
+		case result.err != nil:
+			fmt.Fprintf(&b, "%s: FAIL: %s\n", check.name, errorText(result.err))
+		default:
+			fmt.Fprintf(&b, "%s: ok: %s\n", check.name, result.detail)
+		}
+	}
+	return b.String()
+}
+
+// Busy reports whether any check is still running
+func (m DiagnosticsModel) Busy() bool {
+	for _, result := range m.results {
+		if result == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package screens
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hubblenetwork/hubcli/internal/api"
+	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/hubblenetwork/hubcli/internal/tui/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDiagnosticsModel returns a diagnostics model running the given
+// checks instead of the real ones
+func newTestDiagnosticsModel(checks ...diagnosticCheck) DiagnosticsModel {
+	m := NewDiagnosticsModel(nil)
+	m.checks = checks
+	m.results = make([]*diagnosticResult, len(checks))
+	m.width = 100
+	return m
+}
+
+// runDiagnostics runs every check of the current run and applies the results
+func runDiagnostics(m DiagnosticsModel) DiagnosticsModel {
+	for i, check := range m.checks {
+		m, _ = m.Update(runDiagnosticCheck(m.run, i, check)())
+	}
+	return m
+}
+
+func TestDiagnosticsModel_Checks(t *testing.T) {
+	m := newTestDiagnosticsModel(
+		diagnosticCheck{"Credentials", func(ctx context.Context) (string, error) {
+			return "from the keychain", nil
+		}},
+		diagnosticCheck{"API", func(ctx context.Context) (string, error) {
+			return "", api.ErrInvalidCredentials
+		}},
+	)
+	assert.True(t, m.Busy())
+	assert.Contains(t, m.View(), "checking...")
+	assert.NotContains(t, m.View(), "run again")
+
+	m = runDiagnostics(m)
+	assert.False(t, m.Busy())
+	view := m.View()
+	assert.Contains(t, view, "✓ Credentials")
+	assert.Contains(t, view, "from the keychain")
+	assert.Contains(t, view, "✗ API")
+	assert.Contains(t, view, "invalid credentials")
+	assert.Contains(t, view, "1 of 2 checks failed")
+	assert.Contains(t, view, "r run again")
+
+	assert.Equal(t, "hubcli diagnostics\nCredentials: ok: from the keychain\nAPI: FAIL: invalid credentials\n", m.report())
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	assert.NotNil(t, cmd)
+	m, _ = m.Update(common.ClipboardCopiedMsg{Label: "diagnostics report"})
+	assert.Contains(t, m.View(), "Copied diagnostics report")
+}
+
+func TestDiagnosticsModel_AllPassed(t *testing.T) {
+	m := newTestDiagnosticsModel(diagnosticCheck{"Clipboard", func(ctx context.Context) (string, error) {
+		return "available", nil
+	}})
+	m = runDiagnostics(m)
+	assert.Contains(t, m.View(), "All checks passed")
+}
+
+func TestDiagnosticsModel_Rerun(t *testing.T) {
+	runs := 0
+	m := newTestDiagnosticsModel(diagnosticCheck{"API", func(ctx context.Context) (string, error) {
+		runs++
+		return "", errors.New("unreachable")
+	}})
+
+	// Running checks can't be restarted
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	assert.Nil(t, cmd)
+
+	m = runDiagnostics(m)
+	stale := runDiagnosticCheck(m.run, 0, m.checks[0])()
+
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	assert.NotNil(t, cmd)
+	assert.True(t, m.Busy())
+
+	// A result from the previous run is dropped
+	m, _ = m.Update(stale)
+	assert.True(t, m.Busy())
+
+	m = runDiagnostics(m)
+	assert.False(t, m.Busy())
+	assert.Equal(t, 3, runs)
+}
+
+func TestDiagnosticsModel_BackNavigation(t *testing.T) {
+	m := newTestDiagnosticsModel()
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	require.NotNil(t, cmd)
+	assert.Equal(t, NavigateMsg{Screen: "back"}, cmd())
+}
+
+func TestDiagnosticChecks(t *testing.T) {
+	// check returns the result of the named check for client
+	check := func(client api.APIClient, name string) (string, error) {
+		for _, c := range diagnosticChecks(client) {
+			if c.name == name {
+				return c.run(context.Background())
+			}
+		}
+		t.Fatalf("no %s check", name)
+		return "", nil
+	}
+
+	_, err := check(nil, "API")
+	assert.ErrorContains(t, err, "log in first")
+
+	stub := &stubClient{
+		checkCredentials: func(ctx context.Context) error {
+			return api.ErrInvalidCredentials
+		},
+		getOrganization: func(ctx context.Context) (*models.Organization, error) {
+			return &models.Organization{ID: "test-org", Name: "Test Org"}, nil
+		},
+	}
+	_, err = check(stub, "API")
+	assert.ErrorIs(t, err, api.ErrInvalidCredentials)
+	detail, err := check(stub, "Organization")
+	require.NoError(t, err)
+	assert.Equal(t, "Test Org (test-org)", detail)
+
+	detail, err = check(api.NewFakeClient(time.Now()), "API")
+	require.NoError(t, err)
+	assert.Contains(t, detail, "offline mode")
+}
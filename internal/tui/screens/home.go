@@ -64,6 +64,12 @@ func NewHomeModel(orgName string) HomeModel {
 			Icon:        "⚙️",
 			Screen:      "settings",
 		},
+		{
+			Title:       "Diagnostics",
+			Description: "Check credentials, API access, Bluetooth and clipboard",
+			Icon:        "🩺",
+			Screen:      "diagnostics",
+		},
 	}
 
 	return HomeModel{
@@ -114,7 +120,7 @@ func (m HomeModel) Update(msg tea.Msg) (HomeModel, tea.Cmd) {
 			return m, nil
 
 		case key.Matches(msg, m.keys.Quit):
-			return m, tea.Quit
+			return m, RequestQuit
 		}
 	}
 
@@ -242,7 +248,28 @@ func (m HomeModel) navigateToSelected() tea.Cmd {
 // NavigateMsg is sent when navigating to a new screen
 type NavigateMsg struct {
 	Screen string
-	Data   interface{} // Optional data to pass to the target screen
+	Data   NavigationData // Optional data to pass to the target screen
+}
+
+// NavigationData is data passed to the screen a NavigateMsg opens. Each
+// screen that takes data has its own payload type, such as PacketsNavData.
+type NavigationData interface {
+	navigationData()
+}
+
+// QuitMsg is sent when the user asks to quit. App confirms before quitting
+// if the active screen is busy.
+type QuitMsg struct{}
+
+// BusyReporter is implemented by every screen model so App can tell whether
+// the active screen is in the middle of an operation
+type BusyReporter interface {
+	Busy() bool
+}
+
+// RequestQuit is a tea.Cmd that asks App to quit
+func RequestQuit() tea.Msg {
+	return QuitMsg{}
 }
 
 // SelectedItem returns the currently selected menu item
@@ -253,6 +280,11 @@ func (m HomeModel) SelectedItem() MenuItem {
 	return MenuItem{}
 }
 
+// Busy always returns false; the home menu has no background operations
+func (m HomeModel) Busy() bool {
+	return false
+}
+
 // SetOrgName sets the organization name
 func (m *HomeModel) SetOrgName(name string) {
 	m.orgName = name
@@ -12,7 +12,7 @@ func TestNewHomeModel(t *testing.T) {
 
 	assert.Equal(t, "Test Org", m.orgName)
 	assert.Equal(t, 0, m.cursor)
-	assert.Len(t, m.items, 5) // Devices, Packets, BLE Scan, Organization, Settings
+	assert.Len(t, m.items, 6) // Devices, Packets, BLE Scan, Organization, Settings, Diagnostics
 }
 
 func TestHomeModel_Init(t *testing.T) {
@@ -146,7 +146,7 @@ func TestHomeModel_QuitKey(t *testing.T) {
 
 	// Should return quit command
 	assert.NotNil(t, cmd)
-	// The cmd is tea.Quit which is a function
+	// The cmd requests a quit, which App confirms if needed
 }
 
 func TestHomeModel_SetOrgName(t *testing.T) {
@@ -177,3 +177,9 @@ func TestNavigateMsg(t *testing.T) {
 	msg := NavigateMsg{Screen: "devices"}
 	assert.Equal(t, "devices", msg.Screen)
 }
+
+func TestHomeModel_Busy(t *testing.T) {
+	m := NewHomeModel("")
+
+	assert.False(t, m.Busy())
+}
@@ -46,6 +46,15 @@ type (
 	}
 )
 
+// Focus positions on the login form
+const (
+	loginFocusOrgID = iota
+	loginFocusToken
+	loginFocusEnvironment
+	loginFocusSubmit
+	loginFocusCount
+)
+
 // LoginModel is the model for the login screen
 type LoginModel struct {
 	orgIDInput textinput.Model
@@ -53,8 +62,10 @@ type LoginModel struct {
 	spinner    spinner.Model
 	help       help.Model
 	keys       common.LoginKeyMap
+	store      auth.CredentialStore
 
 	focusIndex int
+	envIndex   int // Index into models.Environments
 	state      LoginState
 	err        error
 	orgName    string
@@ -63,8 +74,14 @@ type LoginModel struct {
 	height int
 }
 
-// NewLoginModel creates a new login screen model
+// NewLoginModel creates a new login screen model that saves to the keychain
 func NewLoginModel() LoginModel {
+	return NewLoginModelWithStore(auth.NewKeychainStore())
+}
+
+// NewLoginModelWithStore creates a login screen model that saves validated
+// credentials to store
+func NewLoginModelWithStore(store auth.CredentialStore) LoginModel {
 	// Organization ID input
 	orgID := textinput.New()
 	orgID.Placeholder = "your-organization-id"
@@ -91,6 +108,7 @@ func NewLoginModel() LoginModel {
 		spinner:    sp,
 		help:       help.New(),
 		keys:       common.DefaultLoginKeyMap(),
+		store:      store,
 		focusIndex: 0,
 		state:      LoginStateInput,
 	}
@@ -120,29 +138,37 @@ func (m LoginModel) Update(msg tea.Msg) (LoginModel, tea.Cmd) {
 
 		switch {
 		case key.Matches(msg, m.keys.Quit):
-			return m, tea.Quit
+			return m, RequestQuit
 
 		case key.Matches(msg, m.keys.Tab):
-			m.focusIndex = (m.focusIndex + 1) % 3 // 2 inputs + submit button
+			m.focusIndex = (m.focusIndex + 1) % loginFocusCount
 			m.updateFocus()
 			return m, nil
 
 		case key.Matches(msg, m.keys.ShiftTab):
 			m.focusIndex--
 			if m.focusIndex < 0 {
-				m.focusIndex = 2
+				m.focusIndex = loginFocusSubmit
 			}
 			m.updateFocus()
 			return m, nil
 
 		case key.Matches(msg, m.keys.Submit):
-			if m.focusIndex == 2 || m.canSubmit() {
+			if m.focusIndex == loginFocusSubmit || m.canSubmit() {
 				return m.submit()
 			}
 			// If on input field, move to next
-			m.focusIndex = (m.focusIndex + 1) % 3
+			m.focusIndex = (m.focusIndex + 1) % loginFocusCount
 			m.updateFocus()
 			return m, nil
+
+		case m.focusIndex == loginFocusEnvironment && (msg.String() == "right" || msg.String() == " "):
+			m.envIndex = (m.envIndex + 1) % len(models.Environments)
+			return m, nil
+
+		case m.focusIndex == loginFocusEnvironment && msg.String() == "left":
+			m.envIndex = (m.envIndex + len(models.Environments) - 1) % len(models.Environments)
+			return m, nil
 		}
 
 	case LoginSuccessMsg:
@@ -166,10 +192,10 @@ func (m LoginModel) Update(msg tea.Msg) (LoginModel, tea.Cmd) {
 	// Update focused input
 	if m.state == LoginStateInput {
 		var cmd tea.Cmd
-		if m.focusIndex == 0 {
+		if m.focusIndex == loginFocusOrgID {
 			m.orgIDInput, cmd = m.orgIDInput.Update(msg)
 			cmds = append(cmds, cmd)
-		} else if m.focusIndex == 1 {
+		} else if m.focusIndex == loginFocusToken {
 			m.tokenInput, cmd = m.tokenInput.Update(msg)
 			cmds = append(cmds, cmd)
 		}
@@ -222,7 +248,7 @@ func (m LoginModel) renderForm() string {
 
 	// Organization ID field
 	orgIDLabel := "Organization ID"
-	if m.focusIndex == 0 {
+	if m.focusIndex == loginFocusOrgID {
 		orgIDLabel = common.SelectedStyle.Render(orgIDLabel)
 	} else {
 		orgIDLabel = common.UnselectedStyle.Render(orgIDLabel)
@@ -231,7 +257,7 @@ func (m LoginModel) renderForm() string {
 	b.WriteString("\n")
 
 	inputStyle := common.InputStyle
-	if m.focusIndex == 0 {
+	if m.focusIndex == loginFocusOrgID {
 		inputStyle = common.FocusedInputStyle
 	}
 	b.WriteString(inputStyle.Render(m.orgIDInput.View()))
@@ -239,7 +265,7 @@ func (m LoginModel) renderForm() string {
 
 	// Token field
 	tokenLabel := "API Token"
-	if m.focusIndex == 1 {
+	if m.focusIndex == loginFocusToken {
 		tokenLabel = common.SelectedStyle.Render(tokenLabel)
 	} else {
 		tokenLabel = common.UnselectedStyle.Render(tokenLabel)
@@ -248,15 +274,30 @@ func (m LoginModel) renderForm() string {
 	b.WriteString("\n")
 
 	inputStyle = common.InputStyle
-	if m.focusIndex == 1 {
+	if m.focusIndex == loginFocusToken {
 		inputStyle = common.FocusedInputStyle
 	}
 	b.WriteString(inputStyle.Render(m.tokenInput.View()))
 	b.WriteString("\n\n")
 
+	// Environment picker
+	envLabel := "Environment"
+	env := string(m.environment())
+	if m.focusIndex == loginFocusEnvironment {
+		b.WriteString(common.SelectedStyle.Render(envLabel))
+		b.WriteString("\n")
+		b.WriteString(common.PrimaryTextStyle.Render("‹ " + env + " ›"))
+		b.WriteString(common.MutedTextStyle.Render("  ←/→ change"))
+	} else {
+		b.WriteString(common.UnselectedStyle.Render(envLabel))
+		b.WriteString("\n")
+		b.WriteString("  " + env)
+	}
+	b.WriteString("\n\n")
+
 	// Submit button
 	buttonText := "  Login  "
-	if m.focusIndex == 2 {
+	if m.focusIndex == loginFocusSubmit {
 		b.WriteString(common.ButtonStyle.Render(buttonText))
 	} else if m.canSubmit() {
 		b.WriteString(common.ButtonStyle.Copy().Background(common.ColorBorder).Render(buttonText))
@@ -265,7 +306,7 @@ func (m LoginModel) renderForm() string {
 	}
 
 	// Error message
-	if m.state == LoginStateError && m.err != nil {
+	if (m.state == LoginStateError || m.state == LoginStateInput) && m.err != nil {
 		b.WriteString("\n\n")
 		b.WriteString(common.ErrorTextStyle.Render("Error: " + m.err.Error()))
 	}
@@ -294,9 +335,9 @@ func (m *LoginModel) updateFocus() {
 	m.tokenInput.Blur()
 
 	switch m.focusIndex {
-	case 0:
+	case loginFocusOrgID:
 		m.orgIDInput.Focus()
-	case 1:
+	case loginFocusToken:
 		m.tokenInput.Focus()
 	}
 }
@@ -308,28 +349,29 @@ func (m LoginModel) canSubmit() bool {
 
 func (m LoginModel) submit() (LoginModel, tea.Cmd) {
 	if !m.canSubmit() {
+		// Explain what's missing rather than letting the API reject it
+		creds := m.GetCredentials()
+		m.err = api.ValidateCredentials(creds.OrgID, creds.Token)
 		return m, nil
 	}
 
 	m.state = LoginStateValidating
 	m.err = nil
 
-	creds := models.Credentials{
-		OrgID: strings.TrimSpace(m.orgIDInput.Value()),
-		Token: strings.TrimSpace(m.tokenInput.Value()),
-	}
+	creds := m.GetCredentials()
 
 	return m, tea.Batch(
 		m.spinner.Tick,
-		validateCredentials(creds),
+		validateCredentials(creds, m.store),
 	)
 }
 
 // validateCredentials returns a command that validates the credentials
-func validateCredentials(creds models.Credentials) tea.Cmd {
+func validateCredentials(creds models.Credentials, store auth.CredentialStore) tea.Cmd {
 	return func() tea.Msg {
-		client := api.NewClientFromCredentials(creds)
-		ctx := context.Background()
+		client := api.NewClientFromCredentials(creds, api.WithTimeout(common.RequestTimeout()))
+		ctx, cancel := context.WithTimeout(context.Background(), common.RequestTimeout())
+		defer cancel()
 
 		// Validate credentials by fetching the organization
 		// If this succeeds, the credentials are valid
@@ -344,7 +386,7 @@ func validateCredentials(creds models.Credentials) tea.Cmd {
 		}
 
 		// Save to keychain
-		if err := auth.SaveCredentials(&creds); err != nil {
+		if err := store.Save(&creds); err != nil {
 			return LoginErrorMsg{Err: fmt.Errorf("failed to save credentials: %w", err)}
 		}
 
@@ -355,19 +397,30 @@ func validateCredentials(creds models.Credentials) tea.Cmd {
 	}
 }
 
-// GetCredentials returns the entered credentials
+// GetCredentials returns the entered credentials and chosen environment
 func (m LoginModel) GetCredentials() models.Credentials {
 	return models.Credentials{
-		OrgID: strings.TrimSpace(m.orgIDInput.Value()),
-		Token: strings.TrimSpace(m.tokenInput.Value()),
+		OrgID:       strings.TrimSpace(m.orgIDInput.Value()),
+		Token:       strings.TrimSpace(m.tokenInput.Value()),
+		Environment: m.environment(),
 	}
 }
 
+// environment returns the environment selected in the picker
+func (m LoginModel) environment() models.Environment {
+	return models.Environments[m.envIndex]
+}
+
 // IsSuccess returns true if login was successful
 func (m LoginModel) IsSuccess() bool {
 	return m.state == LoginStateSuccess
 }
 
+// Busy reports whether credentials are being validated
+func (m LoginModel) Busy() bool {
+	return m.state == LoginStateValidating
+}
+
 // GetOrgName returns the organization name after successful login
 func (m LoginModel) GetOrgName() string {
 	return m.orgName
@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hubblenetwork/hubcli/internal/api"
+	"github.com/hubblenetwork/hubcli/internal/auth"
+	"github.com/hubblenetwork/hubcli/internal/models"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,6 +19,14 @@ func TestNewLoginModel(t *testing.T) {
 	assert.Empty(t, m.tokenInput.Value())
 }
 
+func TestNewLoginModelWithStore(t *testing.T) {
+	store := auth.NewMemoryStore(nil)
+	m := NewLoginModelWithStore(store)
+
+	assert.Equal(t, store, m.store)
+	assert.Equal(t, LoginStateInput, m.state)
+}
+
 func TestLoginModel_Init(t *testing.T) {
 	m := NewLoginModel()
 	cmd := m.Init()
@@ -34,10 +45,14 @@ func TestLoginModel_TabNavigation(t *testing.T) {
 	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
 	assert.Equal(t, 1, m.focusIndex)
 
-	// Tab to submit button
+	// Tab to environment picker
 	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
 	assert.Equal(t, 2, m.focusIndex)
 
+	// Tab to submit button
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	assert.Equal(t, 3, m.focusIndex)
+
 	// Tab wraps to org ID
 	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
 	assert.Equal(t, 0, m.focusIndex)
@@ -48,6 +63,10 @@ func TestLoginModel_ShiftTabNavigation(t *testing.T) {
 
 	// Shift+Tab from first field wraps to submit button
 	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	assert.Equal(t, 3, m.focusIndex)
+
+	// Shift+Tab to environment picker
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
 	assert.Equal(t, 2, m.focusIndex)
 
 	// Shift+Tab to token field
@@ -168,3 +187,60 @@ func TestLoginModel_NoKeysDuringValidation(t *testing.T) {
 	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
 	assert.Equal(t, originalFocus, m.focusIndex)
 }
+
+func TestLoginModel_Busy(t *testing.T) {
+	m := NewLoginModel()
+	assert.False(t, m.Busy())
+
+	m.state = LoginStateValidating
+	assert.True(t, m.Busy())
+
+	m.state = LoginStateError
+	assert.False(t, m.Busy())
+}
+
+func TestLoginModel_SubmitEmptyShowsError(t *testing.T) {
+	m := NewLoginModel()
+	m.tokenInput.SetValue("test-token")
+	m.focusIndex = loginFocusSubmit
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	assert.Nil(t, cmd)
+	assert.Equal(t, LoginStateInput, m.state)
+	assert.ErrorIs(t, m.err, api.ErrMissingCredentials)
+	assert.Contains(t, m.View(), "organization ID is empty")
+}
+
+func TestLoginModel_EnvironmentPicker(t *testing.T) {
+	m := NewLoginModel()
+	assert.Equal(t, models.EnvProduction, m.GetCredentials().Environment)
+
+	// Arrow keys only change the environment when the picker is focused
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	assert.Equal(t, models.EnvProduction, m.GetCredentials().Environment)
+
+	m.focusIndex = loginFocusEnvironment
+	m.updateFocus()
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	assert.Equal(t, models.EnvStaging, m.GetCredentials().Environment)
+	assert.Contains(t, m.View(), "staging")
+
+	// Left wraps around
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	assert.Equal(t, models.EnvDevelopment, m.GetCredentials().Environment)
+}
+
+func TestLoginModel_SubmitIncludesEnvironment(t *testing.T) {
+	m := NewLoginModelWithStore(auth.NewMemoryStore(nil))
+	m.orgIDInput.SetValue("test-org")
+	m.tokenInput.SetValue("test-token")
+	m.envIndex = 1
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	assert.NotNil(t, cmd)
+	assert.Equal(t, LoginStateValidating, m.state)
+	assert.Equal(t, models.Credentials{OrgID: "test-org", Token: "test-token", Environment: models.EnvStaging}, m.GetCredentials())
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -48,7 +47,7 @@ type (
 
 // OrgInfoModel is the model for the organization info screen
 type OrgInfoModel struct {
-	client      *api.Client
+	client      api.APIClient
 	org         *models.Organization
 	deviceCount int
 	credsValid  *bool
@@ -60,10 +59,15 @@ type OrgInfoModel struct {
 	err    error
 	width  int
 	height int
+
+	notice string // Copy failure, shown until the next copy
+	copied common.Flash
+
+	loads *common.Canceller // Cancels the org info load in flight
 }
 
 // NewOrgInfoModel creates a new org info screen model
-func NewOrgInfoModel(client *api.Client) OrgInfoModel {
+func NewOrgInfoModel(client api.APIClient) OrgInfoModel {
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
 	sp.Style = lipgloss.NewStyle().Foreground(common.ColorPrimary)
@@ -74,9 +78,15 @@ func NewOrgInfoModel(client *api.Client) OrgInfoModel {
 		help:    help.New(),
 		keys:    common.DefaultListKeyMap(),
 		state:   OrgInfoStateLoading,
+		loads:   &common.Canceller{},
 	}
 }
 
+// Stop cancels the org info load in flight, e.g. when the app quits
+func (m *OrgInfoModel) Stop() {
+	m.loads.Cancel()
+}
+
 // Init initializes the org info model
 func (m OrgInfoModel) Init() tea.Cmd {
 	return tea.Batch(
@@ -96,13 +106,20 @@ func (m OrgInfoModel) Update(msg tea.Msg) (OrgInfoModel, tea.Cmd) {
 
 	case tea.KeyMsg:
 		switch {
+		case key.Matches(msg, m.keys.Back) && m.state == OrgInfoStateLoading:
+			// Give up on the load rather than waiting for it to time out
+			m.loads.Cancel()
+			m.state = OrgInfoStateError
+			m.err = common.ErrCancelled
+			return m, nil
+
 		case key.Matches(msg, m.keys.Back):
 			return m, func() tea.Msg {
-				return NavigateMsg{Screen: "home"}
+				return NavigateMsg{Screen: "back"}
 			}
 
 		case key.Matches(msg, m.keys.Quit):
-			return m, tea.Quit
+			return m, RequestQuit
 
 		case key.Matches(msg, m.keys.Refresh):
 			if m.state == OrgInfoStateReady || m.state == OrgInfoStateError {
@@ -110,7 +127,24 @@ func (m OrgInfoModel) Update(msg tea.Msg) (OrgInfoModel, tea.Cmd) {
 				m.credsValid = nil
 				return m, tea.Batch(m.spinner.Tick, m.loadOrgInfo())
 			}
+
+		case msg.String() == "y":
+			if orgID := m.orgID(); orgID != "" {
+				return m, common.CopyToClipboard("org ID", orgID)
+			}
+		}
+
+	case common.ClipboardCopiedMsg:
+		if msg.Err != nil {
+			m.notice = "Copy failed: " + msg.Err.Error()
+			return m, nil
 		}
+		m.notice = ""
+		return m, m.copied.Set("Copied " + msg.Label)
+
+	case common.FlashExpiredMsg:
+		m.copied.Expire(msg)
+		return m, nil
 
 	case OrgInfoLoadedMsg:
 		m.state = OrgInfoStateReady
@@ -168,7 +202,7 @@ func (m OrgInfoModel) View() string {
 		content.WriteString(fmt.Sprintf("%s Validating credentials...", m.spinner.View()))
 
 	case OrgInfoStateError:
-		content.WriteString(common.ErrorTextStyle.Render("Error: " + m.err.Error()))
+		content.WriteString(common.ErrorTextStyle.Render("Error: " + errorText(m.err)))
 		content.WriteString("\n\n")
 		content.WriteString(common.MutedTextStyle.Render("Press 'r' to retry"))
 
@@ -176,11 +210,27 @@ func (m OrgInfoModel) View() string {
 		content.WriteString(m.renderInfo())
 	}
 
+	if m.notice != "" {
+		content.WriteString("\n\n")
+		content.WriteString(common.ErrorTextStyle.Render(m.notice))
+	} else if copied := m.copied.Text(); copied != "" {
+		content.WriteString("\n\n")
+		content.WriteString(common.SuccessTextStyle.Render(copied))
+	}
+
 	// Help
 	content.WriteString("\n\n")
-	helpText := []string{
-		common.FormatHelp("r", "refresh"),
-		common.FormatHelp("esc", "back"),
+	var helpText []string
+	if m.orgID() != "" {
+		helpText = append(helpText, common.FormatHelp("y", "copy org ID"))
+	}
+	if m.state == OrgInfoStateLoading {
+		helpText = append(helpText, common.FormatHelp("esc", "cancel"))
+	} else {
+		helpText = append(helpText,
+			common.FormatHelp("r", "refresh"),
+			common.FormatHelp("esc", "back"),
+		)
 	}
 	content.WriteString(strings.Join(helpText, "  "))
 
@@ -225,10 +275,8 @@ func (m OrgInfoModel) renderOrgDetails() string {
 
 	// Org ID
 	b.WriteString(labelStyle.Render("Org ID:"))
-	if m.org != nil {
-		b.WriteString(valueStyle.Render(m.org.ID))
-	} else if m.client != nil {
-		b.WriteString(valueStyle.Render(m.client.OrgID()))
+	if orgID := m.orgID(); orgID != "" {
+		b.WriteString(valueStyle.Render(orgID))
 	} else {
 		b.WriteString(common.MutedTextStyle.Render("Unknown"))
 	}
@@ -243,13 +291,44 @@ func (m OrgInfoModel) renderOrgDetails() string {
 	}
 	b.WriteString("\n")
 
-	// Device Count
+	// Device Count (with quota if the org has one)
 	b.WriteString(labelStyle.Render("Devices:"))
-	b.WriteString(valueStyle.Render(fmt.Sprintf("%d", m.deviceCount)))
+	if m.org != nil && m.org.DeviceQuota != nil {
+		b.WriteString(valueStyle.Render(fmt.Sprintf("%d / %d", m.deviceCount, *m.org.DeviceQuota)))
+	} else {
+		b.WriteString(valueStyle.Render(fmt.Sprintf("%d", m.deviceCount)))
+	}
+
+	// Org settings, only shown when the API returns them
+	if m.org != nil && m.org.RetentionDays != nil {
+		b.WriteString("\n")
+		b.WriteString(labelStyle.Render("Retention:"))
+		b.WriteString(valueStyle.Render(fmt.Sprintf("%d days", *m.org.RetentionDays)))
+	}
+	if m.org != nil && len(m.org.AllowedEncryptionTypes) > 0 {
+		types := make([]string, len(m.org.AllowedEncryptionTypes))
+		for i, t := range m.org.AllowedEncryptionTypes {
+			types[i] = string(t)
+		}
+		b.WriteString("\n")
+		b.WriteString(labelStyle.Render("Encryption:"))
+		b.WriteString(valueStyle.Render(strings.Join(types, ", ")))
+	}
 
 	return b.String()
 }
 
+// orgID returns the ID of the loaded org, falling back to the client's
+func (m OrgInfoModel) orgID() string {
+	if m.org != nil {
+		return m.org.ID
+	}
+	if m.client != nil {
+		return m.client.OrgID()
+	}
+	return ""
+}
+
 func (m OrgInfoModel) renderCredStatus() string {
 	var b strings.Builder
 
@@ -272,22 +351,27 @@ func (m OrgInfoModel) renderCredStatus() string {
 }
 
 func (m OrgInfoModel) loadOrgInfo() tea.Cmd {
+	ctx, cancel := m.loads.WithTimeout(common.RequestTimeout())
 	return func() tea.Msg {
+		defer cancel()
 		if m.client == nil {
 			return OrgInfoErrorMsg{Err: fmt.Errorf("no API client")}
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-
 		// Get org info
 		org, err := m.client.GetOrganization(ctx)
+		if common.Cancelled(ctx, err) {
+			return common.RequestCancelledMsg{}
+		}
 		if err != nil {
 			return OrgInfoErrorMsg{Err: err}
 		}
 
 		// Get device count
 		devices, err := m.client.ListDevices(ctx)
+		if common.Cancelled(ctx, err) {
+			return common.RequestCancelledMsg{}
+		}
 		deviceCount := 0
 		if err == nil {
 			deviceCount = len(devices)
@@ -306,7 +390,7 @@ func (m OrgInfoModel) validateCredentials() tea.Cmd {
 			return CredsValidMsg{Valid: false, Err: fmt.Errorf("no API client")}
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), common.RequestTimeout())
 		defer cancel()
 
 		err := m.client.CheckCredentials(ctx)
@@ -317,3 +401,8 @@ func (m OrgInfoModel) validateCredentials() tea.Cmd {
 		return CredsValidMsg{Valid: true}
 	}
 }
+
+// Busy reports whether org info is loading or credentials are being checked
+func (m OrgInfoModel) Busy() bool {
+	return m.state == OrgInfoStateLoading || m.state == OrgInfoStateCheckingCreds
+}
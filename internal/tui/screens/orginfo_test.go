@@ -1,11 +1,18 @@
 package screens
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hubblenetwork/hubcli/internal/api"
 	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/hubblenetwork/hubcli/internal/tui/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewOrgInfoModel(t *testing.T) {
@@ -68,6 +75,110 @@ func TestOrgInfoModel_OrgInfoErrorMsg(t *testing.T) {
 	assert.False(t, *m.credsValid)
 }
 
+func TestOrgInfoModel_LoadOrgInfo(t *testing.T) {
+	org := &models.Organization{ID: "test-org", Name: "Test Org"}
+	gotOrg := func(ctx context.Context) (*models.Organization, error) {
+		return org, nil
+	}
+
+	tests := []struct {
+		name    string
+		client  api.APIClient
+		want    tea.Msg
+		wantErr string
+	}{
+		{
+			name: "loaded",
+			client: &stubClient{
+				getOrganization: gotOrg,
+				listDevices: func(ctx context.Context) ([]models.Device, error) {
+					return []models.Device{{ID: "device-1"}, {ID: "device-2"}}, nil
+				},
+			},
+			want: OrgInfoLoadedMsg{Org: org, DeviceCount: 2},
+		},
+		{
+			name: "devices unavailable",
+			client: &stubClient{
+				getOrganization: gotOrg,
+				listDevices: func(ctx context.Context) ([]models.Device, error) {
+					return nil, api.ErrServerError
+				},
+			},
+			want: OrgInfoLoadedMsg{Org: org},
+		},
+		{
+			name: "org fails",
+			client: &stubClient{
+				getOrganization: func(ctx context.Context) (*models.Organization, error) {
+					return nil, api.ErrInvalidCredentials
+				},
+			},
+			wantErr: api.ErrInvalidCredentials.Error(),
+		},
+		{
+			name:    "no client",
+			wantErr: "no API client",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewOrgInfoModel(tt.client)
+			msg := m.loadOrgInfo()()
+
+			if tt.wantErr == "" {
+				assert.Equal(t, tt.want, msg)
+				return
+			}
+			errMsg, ok := msg.(OrgInfoErrorMsg)
+			require.True(t, ok, "got %T", msg)
+			assert.ErrorContains(t, errMsg.Err, tt.wantErr)
+		})
+	}
+}
+
+func TestOrgInfoModel_ValidateCredentials(t *testing.T) {
+	tests := []struct {
+		name      string
+		client    api.APIClient
+		wantValid bool
+		wantErr   string
+	}{
+		{
+			name: "valid",
+			client: &stubClient{checkCredentials: func(ctx context.Context) error {
+				return nil
+			}},
+			wantValid: true,
+		},
+		{
+			name: "invalid",
+			client: &stubClient{checkCredentials: func(ctx context.Context) error {
+				return api.ErrInvalidCredentials
+			}},
+			wantErr: api.ErrInvalidCredentials.Error(),
+		},
+		{
+			name:    "no client",
+			wantErr: "no API client",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, ok := NewOrgInfoModel(tt.client).validateCredentials()().(CredsValidMsg)
+			require.True(t, ok)
+			assert.Equal(t, tt.wantValid, msg.Valid)
+			if tt.wantErr == "" {
+				assert.NoError(t, msg.Err)
+			} else {
+				assert.ErrorContains(t, msg.Err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestOrgInfoModel_CredsValidMsg_Valid(t *testing.T) {
 	m := NewOrgInfoModel(nil)
 	m.state = OrgInfoStateCheckingCreds
@@ -112,7 +223,25 @@ func TestOrgInfoModel_BackNavigation(t *testing.T) {
 	msg := cmd()
 	navMsg, ok := msg.(NavigateMsg)
 	assert.True(t, ok)
-	assert.Equal(t, "home", navMsg.Screen)
+	assert.Equal(t, "back", navMsg.Screen)
+}
+
+func TestOrgInfoModel_CancelLoad(t *testing.T) {
+	server := newHangingServer()
+	defer server.Close()
+
+	m := NewOrgInfoModel(api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)))
+	load := m.loadOrgInfo()
+	assert.Contains(t, m.View(), "esc cancel")
+
+	// esc cancels the load instead of leaving the screen
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	assert.Nil(t, cmd)
+	assert.Equal(t, OrgInfoStateError, m.state)
+	assert.ErrorIs(t, m.err, common.ErrCancelled)
+	assert.Contains(t, m.View(), "Press 'r' to retry")
+	awaitCancelled(t, load)
 }
 
 func TestOrgInfoModel_QuitKey(t *testing.T) {
@@ -120,7 +249,7 @@ func TestOrgInfoModel_QuitKey(t *testing.T) {
 
 	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
 
-	// Should return tea.Quit
+	// Should request a quit
 	assert.NotNil(t, cmd)
 }
 
@@ -175,6 +304,43 @@ func TestOrgInfoModel_View(t *testing.T) {
 	assert.Contains(t, view, "refresh")
 }
 
+func TestOrgInfoModel_ViewOrgSettings(t *testing.T) {
+	retention := 30
+	quota := 500
+
+	m := NewOrgInfoModel(nil)
+	m.width = 100
+	m.height = 30
+	m.state = OrgInfoStateReady
+	m.deviceCount = 12
+	m.org = &models.Organization{
+		ID:                     "org-123",
+		Name:                   "Test Organization",
+		RetentionDays:          &retention,
+		DeviceQuota:            &quota,
+		AllowedEncryptionTypes: []models.EncryptionType{models.EncryptionAES256CTR, models.EncryptionAES128CTR},
+	}
+
+	view := m.View()
+
+	assert.Contains(t, view, "12 / 500")
+	assert.Contains(t, view, "30 days")
+	assert.Contains(t, view, "AES-256-CTR, AES-128-CTR")
+}
+
+func TestOrgInfoModel_ViewOmitsMissingSettings(t *testing.T) {
+	m := NewOrgInfoModel(nil)
+	m.width = 100
+	m.height = 30
+	m.state = OrgInfoStateReady
+	m.org = &models.Organization{ID: "org-123"}
+
+	view := m.View()
+
+	assert.NotContains(t, view, "Retention")
+	assert.NotContains(t, view, "Encryption")
+}
+
 func TestOrgInfoModel_ViewLoading(t *testing.T) {
 	m := NewOrgInfoModel(nil)
 	m.width = 80
@@ -210,6 +376,18 @@ func TestOrgInfoModel_ViewError(t *testing.T) {
 	assert.Contains(t, view, "retry")
 }
 
+func TestOrgInfoModel_ViewRateLimited(t *testing.T) {
+	m := NewOrgInfoModel(nil)
+	m.width = 80
+	m.height = 24
+	m.state = OrgInfoStateError
+	m.err = fmt.Errorf("failed to fetch organization: %w", &api.APIError{StatusCode: 429, RetryAfter: 5 * time.Second})
+
+	view := m.View()
+
+	assert.Contains(t, view, "rate limited, retry in 5s")
+}
+
 func TestOrgInfoModel_ViewCredsValid(t *testing.T) {
 	m := NewOrgInfoModel(nil)
 	m.width = 80
@@ -249,3 +427,40 @@ func TestOrgInfoModel_ViewNoOrgName(t *testing.T) {
 
 	assert.Contains(t, view, "Not set")
 }
+
+func TestOrgInfoModel_Busy(t *testing.T) {
+	m := NewOrgInfoModel(nil)
+	assert.True(t, m.Busy()) // Starts loading
+
+	m.state = OrgInfoStateCheckingCreds
+	assert.True(t, m.Busy())
+
+	m.state = OrgInfoStateReady
+	assert.False(t, m.Busy())
+}
+
+func TestOrgInfoModel_CopyOrgID(t *testing.T) {
+	m := NewOrgInfoModel(api.NewClient("test-org", "test-token"))
+	m.width = 100
+	m, _ = m.Update(OrgInfoLoadedMsg{Org: &models.Organization{ID: "org-123"}})
+	assert.Contains(t, m.View(), "copy org ID")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	assert.NotNil(t, cmd)
+
+	m, cmd = m.Update(common.ClipboardCopiedMsg{Label: "org ID"})
+	assert.Contains(t, m.View(), "Copied org ID")
+	m, _ = m.Update(common.FlashExpiredMsg{ID: m.copied.ID()})
+	assert.NotContains(t, m.View(), "Copied org ID")
+
+	m, _ = m.Update(common.ClipboardCopiedMsg{Label: "org ID", Err: errors.New("clipboard not available")})
+	assert.Contains(t, m.View(), "Copy failed")
+}
+
+func TestOrgInfoModel_CopyOrgID_Unknown(t *testing.T) {
+	m := NewOrgInfoModel(nil)
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	assert.Nil(t, cmd)
+	assert.NotContains(t, m.View(), "copy org ID")
+}
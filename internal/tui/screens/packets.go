@@ -2,7 +2,11 @@ package screens
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -10,9 +14,13 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/hubblenetwork/hubcli/internal/analysis"
 	"github.com/hubblenetwork/hubcli/internal/api"
+	"github.com/hubblenetwork/hubcli/internal/crypto"
+	"github.com/hubblenetwork/hubcli/internal/export"
 	"github.com/hubblenetwork/hubcli/internal/models"
 	"github.com/hubblenetwork/hubcli/internal/tui/common"
 )
@@ -26,24 +34,119 @@ const (
 	PacketsStateError
 )
 
+// PacketSortColumn represents which packets column to sort by
+type PacketSortColumn int
+
+const (
+	PacketSortByDeviceID PacketSortColumn = iota
+	PacketSortByTimestamp
+	PacketSortByLocation
+	PacketSortByPayload
+)
+
+// PayloadView selects how the Payload column shows payloads
+type PayloadView int
+
+const (
+	PayloadViewBase64 PayloadView = iota
+	PayloadViewHex
+	PayloadViewDecrypted // Decrypted with the device key, hex where that fails
+)
+
 // Packets screen messages
 type (
-	// PacketsLoadedMsg is sent when packets are fetched
+	// PacketsStreamStartedMsg is sent when retrieval has started. Its pages
+	// arrive as PacketsPageMsg, followed by PacketsStreamDoneMsg.
+	PacketsStreamStartedMsg struct {
+		Pages  <-chan api.PacketsPage
+		Errs   <-chan error
+		Cancel context.CancelFunc
+		Append bool // If true, the pages are appended to existing packets
+		Load   int  // Load the stream was started for; stale streams are cancelled
+	}
+
+	// PacketsPageMsg is sent for each page of packets as it arrives
+	PacketsPageMsg struct {
+		Pages <-chan api.PacketsPage // Stream the page came from
+		Page  api.PacketsPage
+	}
+
+	// PacketsStreamDoneMsg is sent when a stream has no more pages, with Err
+	// set if a page failed
+	PacketsStreamDoneMsg struct {
+		Pages <-chan api.PacketsPage
+		Err   error
+	}
+
+	// PacketsLoadedMsg is sent when packets are fetched, or when a stream
+	// of pages ends
 	PacketsLoadedMsg struct {
 		Packets           []models.RetrievedPacket
 		ContinuationToken string
-		Append            bool // If true, append to existing packets
+		Append            bool  // If true, append to existing packets
+		Err               error // Set if retrieval stopped early; Packets holds what was fetched
 	}
 
 	// PacketsErrorMsg is sent when fetching fails
 	PacketsErrorMsg struct {
 		Err error
 	}
+
+	// PacketsPageSizeSetMsg is sent when the user picks a page size, so it
+	// can be saved to the config
+	PacketsPageSizeSetMsg struct {
+		Size int
+	}
+
+	// PacketsKeysLoadedMsg is sent when the device keys for decrypting
+	// payloads have been fetched
+	PacketsKeysLoadedMsg struct {
+		Devices []models.Device
+		Err     error
+	}
+
+	// PacketsDecryptedMsg carries decrypted payloads keyed by packet, nil
+	// where decryption failed
+	PacketsDecryptedMsg struct {
+		Payloads map[packetKey][]byte
+	}
+
+	// PacketsExportedMsg is sent when the loaded packets have been written
+	// to CSV
+	PacketsExportedMsg struct {
+		Path  string
+		Count int
+		Err   error
+	}
+
+	// PacketsRefreshedMsg is sent when an auto-refresh has fetched the
+	// most recent packets
+	PacketsRefreshedMsg struct {
+		Packets []models.RetrievedPacket
+		Err     error
+		Load    int // Load the refresh was made for; packets are dropped if reloaded since
+		Seq     int // Auto-refresh the result belongs to; stale results are dropped
+	}
+
+	// packetsRefreshTickMsg updates the time since the last auto-refresh
+	// and starts the next one when it is due
+	packetsRefreshTickMsg struct {
+		seq int
+		at  time.Time
+	}
+)
+
+const (
+	// packetsRefreshInterval is how often auto-refresh fetches new packets
+	packetsRefreshInterval = 30 * time.Second
+	// packetsRefreshWindow is how far back each auto-refresh looks, so
+	// packets the API receives late are still picked up
+	packetsRefreshWindow = 10 * time.Minute
 )
 
 // PacketsModel is the model for the packets screen
 type PacketsModel struct {
-	client   *api.Client
+	client   api.APIClient
 	packets  []models.RetrievedPacket
 	table    table.Model
 	spinner  spinner.Model
@@ -52,20 +155,83 @@ type PacketsModel struct {
 
 	state             PacketsState
 	err               error
-	deviceID          string // Optional filter by device ID
-	days              int    // Number of days to query
+	deviceID          string     // Optional filter by device ID
+	days              int        // Number of days to query
+	pageSize          int        // Packets per load
+	rangeStart        *time.Time // Start of a custom time range, used instead of days
+	rangeEnd          *time.Time // Exclusive end of the custom time range, if any
 	width             int
 	height            int
 	continuationToken string // Token for loading more packets
 	hasMore           bool   // Whether more packets are available
 	loadingMore       bool   // Whether currently loading more packets
+	showHistogram     bool   // Show packets by hour of day instead of the table
+	showGaps          bool   // Show sequence number gaps instead of the table
+	notice            string // Status message, e.g. after exporting
+	copied            common.Flash
+	partialErr        error             // Why the last retrieval stopped early, if it did
+	jumpToLatest      bool              // Select the newest packet once all pages are loaded
+	sort              common.ColumnSort // Sorted and selected columns are PacketSortColumn values
+	exportPrompt      bool              // Waiting for the export format key
+
+	// Sequence gaps in the loaded packets, kept up to date when filtered to
+	// a device
+	gaps []analysis.SequenceGap
+
+	// Filtering
+	filterInput     textinput.Model
+	filterActive    bool
+	filterText      string
+	filteredPackets []models.RetrievedPacket
+
+	// Packet shown in the detail view, if any
+	detail *models.RetrievedPacket
+
+	// Payload display
+	payloadView PayloadView
+	keyDevices  map[string]models.Device // Devices with a usable key by ID, nil until loaded
+	keysLoading bool
+	keysErr     error
+	decrypted   map[packetKey][]byte // Plaintext by packet; nil while decrypting or if it failed
+
+	// Retrieval in progress
+	stream       <-chan api.PacketsPage
+	streamErrs   <-chan error
+	cancelStream context.CancelFunc
+	streamAppend bool // Pages are appended rather than replacing packets
+	streamPages  int  // Pages received from the retrieval in progress
+	streamCount  int  // Packets received from the retrieval in progress
+	load         int  // Incremented for each retrieval so stale ones can be told apart
+
+	// Auto-refresh
+	autoRefresh  bool
+	refreshSeq   int               // Incremented when auto-refresh is toggled so stale ticks stop
+	refreshing   bool              // A refresh is in flight
+	lastRefresh  time.Time         // When the last refresh was started
+	refreshAdded int               // New packets found by the last refresh
+	refreshErr   error             // Why the last refresh failed, if it did
+	refreshes    *common.Canceller // Cancels the refresh in flight
+
+	// Custom time range input
+	rangeInput   textinput.Model
+	editingRange bool
+	rangeErr     string // Validation message shown under the input
 }
 
+// PacketsNavData is NavigateMsg data that opens the packets screen filtered
+// to a device over a given number of days
+type PacketsNavData struct {
+	DeviceID string
+	Days     int // Days of packets to show; 0 for the default
+}
+
+func (PacketsNavData) navigationData() {}
+
 // NewPacketsModel creates a new packets screen model
-func NewPacketsModel(client *api.Client, deviceID string) PacketsModel {
+func NewPacketsModel(client api.APIClient, deviceID string) PacketsModel {
 	columns := []table.Column{
 		{Title: "Device ID", Width: 18},
-		{Title: "Timestamp", Width: 20},
+		{Title: "Timestamp ↓", Width: 25}, // Newest first
 		{Title: "Location", Width: 25},
 		{Title: "Payload", Width: 30},
 	}
@@ -73,35 +239,50 @@ func NewPacketsModel(client *api.Client, deviceID string) PacketsModel {
 	t := table.New(
 		table.WithColumns(columns),
 		table.WithFocused(true),
-		table.WithHeight(10),
+		table.WithHeight(common.DefaultTableHeight),
 	)
 
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(common.ColorBorder).
-		BorderBottom(true).
-		Bold(true).
-		Foreground(common.ColorSecondary)
-	s.Selected = s.Selected.
-		Foreground(common.ColorForeground).
-		Background(common.ColorPrimary).
-		Bold(true)
-	t.SetStyles(s)
+	t.SetStyles(common.TableStyles())
 
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
 	sp.Style = lipgloss.NewStyle().Foreground(common.ColorPrimary)
 
+	// Initialize filter input
+	fi := textinput.New()
+	fi.Placeholder = "Filter by device ID, location or payload..."
+	fi.CharLimit = 64
+	fi.Width = 40
+	fi.PromptStyle = lipgloss.NewStyle().Foreground(common.ColorSecondary)
+	fi.TextStyle = lipgloss.NewStyle().Foreground(common.ColorForeground)
+
+	// Initialize custom time range input
+	ri := textinput.New()
+	ri.Placeholder = "YYYY-MM-DD [YYYY-MM-DD]"
+	ri.CharLimit = 32
+	ri.Width = 30
+	ri.PromptStyle = lipgloss.NewStyle().Foreground(common.ColorSecondary)
+	ri.TextStyle = lipgloss.NewStyle().Foreground(common.ColorForeground)
+
 	return PacketsModel{
-		client:   client,
-		table:    t,
-		spinner:  sp,
-		help:     help.New(),
-		keys:     common.DefaultListKeyMap(),
-		state:    PacketsStateLoading,
-		deviceID: deviceID,
-		days:     7, // Default to 7 days
+		client:      client,
+		table:       t,
+		spinner:     sp,
+		help:        help.New(),
+		keys:        common.DefaultListKeyMap(),
+		state:       PacketsStateLoading,
+		deviceID:    deviceID,
+		days:        7, // Default to 7 days
+		pageSize:    defaultPacketPageSize,
+		filterInput: fi,
+		rangeInput:  ri,
+		refreshes:   &common.Canceller{},
+		// Default: newest first
+		sort: common.ColumnSort{
+			Column:   int(PacketSortByTimestamp),
+			Selected: int(PacketSortByTimestamp),
+			Columns:  int(PacketSortByPayload) + 1,
+		},
 	}
 }
 
@@ -115,6 +296,18 @@ func (m PacketsModel) Init() tea.Cmd {
 
 // Update handles messages for the packets screen
 func (m PacketsModel) Update(msg tea.Msg) (PacketsModel, tea.Cmd) {
+	m, cmd := m.update(msg)
+	// Anything above or below the table may have changed height, except on
+	// ticks, which only redraw the spinner or the time since the last refresh
+	switch msg.(type) {
+	case spinner.TickMsg, packetsRefreshTickMsg:
+	default:
+		m.fitTable()
+	}
+	return m, cmd
+}
+
+func (m PacketsModel) update(msg tea.Msg) (PacketsModel, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
@@ -122,61 +315,327 @@ func (m PacketsModel) Update(msg tea.Msg) (PacketsModel, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.help.Width = msg.Width
-		m.table.SetHeight(m.height - 15)
 		m.updateColumnWidths()
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.detail != nil {
+			return m.updateDetail(msg)
+		}
+
+		// Any key other than a format cancels the export
+		if m.exportPrompt {
+			m.exportPrompt = false
+			if f, ok := exportFormatForKey(msg.String(), packetExportFormats); ok {
+				return m, exportPackets(m.filteredPackets, f)
+			}
+			return m, nil
+		}
+
+		if m.editingRange {
+			return m.updateRangeInput(msg)
+		}
+
+		// Handle filter input mode
+		if m.filterActive {
+			switch msg.String() {
+			case "esc":
+				m.filterActive = false
+				m.filterInput.Blur()
+				m.table.Focus()
+				return m, nil
+			case "enter":
+				m.filterActive = false
+				m.filterInput.Blur()
+				m.table.Focus()
+				m.filterText = m.filterInput.Value()
+				m.updateTable()
+				return m, nil
+			default:
+				var cmd tea.Cmd
+				m.filterInput, cmd = m.filterInput.Update(msg)
+				// Apply filter as user types
+				m.filterText = m.filterInput.Value()
+				m.updateTable()
+				return m, cmd
+			}
+		}
+
 		switch {
+		case key.Matches(msg, m.keys.Back) && (m.state == PacketsStateLoading || m.loadingMore):
+			m.cancelLoad()
+			return m, nil
+
 		case key.Matches(msg, m.keys.Back):
+			// If filter has text, clear it first
+			if m.filterText != "" {
+				m.filterText = ""
+				m.filterInput.SetValue("")
+				m.updateTable()
+				return m, nil
+			}
 			return m, func() tea.Msg {
 				return NavigateMsg{Screen: "back"}
 			}
 
 		case key.Matches(msg, m.keys.Quit):
-			return m, tea.Quit
+			return m, RequestQuit
 
 		case key.Matches(msg, m.keys.Refresh):
 			if m.state == PacketsStateReady || m.state == PacketsStateError {
-				m.state = PacketsStateLoading
-				m.continuationToken = ""
-				return m, tea.Batch(m.spinner.Tick, m.loadPackets(false))
+				return m, m.reload()
+			}
+
+		case key.Matches(msg, m.keys.Search):
+			// Toggle filter input
+			if m.state == PacketsStateReady && len(m.packets) > 0 {
+				m.filterActive = true
+				m.filterInput.Focus()
+				return m, textinput.Blink
 			}
 
 		case msg.String() == "1":
-			m.days = 1
-			m.state = PacketsStateLoading
-			m.continuationToken = ""
-			return m, tea.Batch(m.spinner.Tick, m.loadPackets(false))
+			return m, m.setDays(1)
 
 		case msg.String() == "7":
-			m.days = 7
-			m.state = PacketsStateLoading
-			m.continuationToken = ""
-			return m, tea.Batch(m.spinner.Tick, m.loadPackets(false))
+			return m, m.setDays(7)
+
+		case msg.String() == "3":
+			return m, m.setDays(30)
+
+		case msg.String() == "9":
+			return m, m.setDays(90)
 
-		case msg.String() == "3" && msg.Alt:
-			m.days = 30
-			m.state = PacketsStateLoading
-			m.continuationToken = ""
-			return m, tea.Batch(m.spinner.Tick, m.loadPackets(false))
+		case msg.String() == "D":
+			// Enter a custom date range
+			if m.state != PacketsStateLoading && !m.loadingMore {
+				m.editingRange = true
+				m.rangeErr = ""
+				m.rangeInput.SetValue(m.customRangeText())
+				m.rangeInput.CursorEnd()
+				m.rangeInput.Focus()
+				m.table.Blur()
+				return m, textinput.Blink
+			}
 
 		case msg.String() == "c":
 			// Clear device filter
 			if m.deviceID != "" {
 				m.deviceID = ""
-				m.continuationToken = ""
-				m.state = PacketsStateLoading
-				return m, tea.Batch(m.spinner.Tick, m.loadPackets(false))
+				return m, m.reload()
+			}
+
+		case msg.String() == "z":
+			// Toggle UTC / local timestamps
+			if m.state == PacketsStateReady && len(m.packets) > 0 {
+				common.SetUTC(!common.UTC())
+				m.updateTable()
+				return m, nil
+			}
+
+		case msg.String() == "o":
+			// Toggle sort direction
+			if m.state == PacketsStateReady && len(m.packets) > 0 {
+				m.SetSortAscending(!m.sort.Asc)
+				return m, nil
+			}
+
+		case msg.String() == "t":
+			// Toggle hour-of-day histogram
+			if m.state == PacketsStateReady && len(m.packets) > 0 {
+				m.showHistogram = !m.showHistogram
+				m.showGaps = false
+				return m, nil
+			}
+
+		// Select sort column with left/right arrows
+		case key.Matches(msg, m.keys.Left):
+			if m.state == PacketsStateReady {
+				m.sort.SelectLeft()
+				m.updateColumnWidths()
+				return m, nil
+			}
+		case key.Matches(msg, m.keys.Right):
+			if m.state == PacketsStateReady {
+				m.sort.SelectRight()
+				m.updateColumnWidths()
+				return m, nil
+			}
+
+		// Sort by selected column with 's'
+		case msg.String() == "s":
+			if m.state == PacketsStateReady {
+				m.toggleSort()
+				return m, nil
+			}
+
+		case msg.String() == "S":
+			// Toggle sequence gap list (only meaningful for a single device)
+			if m.state == PacketsStateReady && m.deviceID != "" && len(m.packets) > 0 {
+				m.showGaps = !m.showGaps
+				m.showHistogram = false
+				return m, nil
+			}
+
+		case msg.String() == "x":
+			// Export the packets as currently filtered, in display order,
+			// once a format is picked
+			if m.state == PacketsStateReady && len(m.filteredPackets) > 0 {
+				m.exportPrompt = true
+				m.notice = ""
+				return m, nil
+			}
+
+		case msg.String() == "u":
+			// Copy the packets API URL for the current filter and time range
+			if m.client != nil {
+				return m, common.CopyToClipboard("packets API URL (token not included)", m.client.PacketsURL(m.retrieveOptions(false)))
+			}
+
+		// Jump to the first or last packet, or by a screenful
+		case m.state == PacketsStateReady && !m.showHistogram && !m.showGaps && common.MoveTableCursor(&m.table, m.keys, msg):
+			return m, nil
+
+		case msg.String() == "p":
+			// Copy a map link for the selected packet's location
+			if m.state == PacketsStateReady && !m.showHistogram && !m.showGaps {
+				if i := m.table.Cursor(); i >= 0 && i < len(m.filteredPackets) {
+					if url := m.filteredPackets[i].Location.MapURL(); url != "" {
+						return m, common.CopyToClipboard("map link", url)
+					}
+					m.notice = "Selected packet has no location"
+					return m, nil
+				}
+			}
+
+		case msg.String() == "y":
+			// Copy the selected packet's payload as hex
+			if m.state == PacketsStateReady && !m.showHistogram && !m.showGaps {
+				if i := m.table.Cursor(); i >= 0 && i < len(m.filteredPackets) {
+					payload, err := m.filteredPackets[i].PayloadBytes()
+					if err != nil || len(payload) == 0 {
+						m.notice = "Selected packet has no payload"
+						return m, nil
+					}
+					return m, common.CopyToClipboard("payload hex", hex.EncodeToString(payload))
+				}
+			}
+
+		case key.Matches(msg, m.keys.Select):
+			// Show every field of the selected packet
+			if m.state == PacketsStateReady && !m.showHistogram && !m.showGaps {
+				if i := m.table.Cursor(); i >= 0 && i < len(m.filteredPackets) {
+					p := m.filteredPackets[i]
+					m.detail = &p
+					m.notice = ""
+					return m, nil
+				}
+			}
+
+		case msg.String() == "v":
+			// Cycle the Payload column between base64, hex and decrypted
+			if m.state == PacketsStateReady && len(m.packets) > 0 {
+				m.payloadView = (m.payloadView + 1) % 3
+				m.keysErr = nil
+				m.updateColumnWidths()
+				m.updateTable()
+				return m, m.decryptPayloads()
+			}
+
+		case msg.String() == "n":
+			// Jump to the device's most recent packet, loading remaining pages first
+			if m.state == PacketsStateReady && m.deviceID != "" && len(m.packets) > 0 && !m.loadingMore {
+				if m.hasMore {
+					m.jumpToLatest = true
+					m.loadingMore = true
+					return m, tea.Batch(m.spinner.Tick, m.startLoad(true))
+				}
+				m.selectLatest()
+				return m, nil
+			}
+
+		case msg.String() == "L":
+			// Cycle the page size used by the next load
+			m.pageSize = nextPacketPageSize(m.pageSize)
+			m.notice = fmt.Sprintf("Loading %d packets at a time", m.pageSize)
+			size := m.pageSize
+			return m, func() tea.Msg {
+				return PacketsPageSizeSetMsg{Size: size}
 			}
 
 		case msg.String() == "m":
 			// Load more packets
 			if m.state == PacketsStateReady && m.hasMore && !m.loadingMore {
 				m.loadingMore = true
-				return m, m.loadPackets(true)
+				return m, tea.Batch(m.spinner.Tick, m.startLoad(true))
 			}
+
+		case msg.String() == "a":
+			// Toggle polling for new packets
+			if m.autoRefresh {
+				m.stopAutoRefresh()
+				m.notice = "Auto-refresh off"
+				return m, nil
+			}
+			if m.client != nil && m.state == PacketsStateReady {
+				if m.rangeEnd != nil {
+					m.notice = "Auto-refresh needs a range up to now"
+					return m, nil
+				}
+				m.notice = ""
+				return m, m.startAutoRefresh(time.Now())
+			}
+		}
+
+	case PacketsStreamStartedMsg:
+		if msg.Load != m.load {
+			// Another retrieval was started since
+			msg.Cancel()
+			return m, nil
+		}
+		m.stream = msg.Pages
+		m.streamErrs = msg.Errs
+		m.cancelStream = msg.Cancel
+		m.streamAppend = msg.Append
+		m.streamPages = 0
+		m.streamCount = 0
+		return m, waitForPacketsPage(msg.Pages, msg.Errs)
+
+	case PacketsPageMsg:
+		if msg.Pages == nil || msg.Pages != m.stream {
+			return m, nil
+		}
+		// Show each page as it arrives; the first replaces any packets
+		// from a previous load
+		m.streamPages++
+		m.streamCount += len(msg.Page.Packets)
+		if m.streamAppend {
+			m.packets = append(m.packets, msg.Page.Packets...)
+		} else {
+			m.packets = msg.Page.Packets
+			m.streamAppend = true
+		}
+		m.state = PacketsStateReady
+		m.loadingMore = true
+		m.continuationToken = msg.Page.ContinuationToken
+		m.packetsChanged()
+		return m, tea.Batch(waitForPacketsPage(m.stream, m.streamErrs), m.decryptPayloads())
+
+	case PacketsStreamDoneMsg:
+		if msg.Pages == nil || msg.Pages != m.stream {
+			return m, nil
 		}
+		appended := m.streamAppend
+		m.stopStream()
+		if msg.Err != nil && !appended {
+			return m.update(PacketsErrorMsg{Err: msg.Err})
+		}
+		// Pages already arrived, so this only settles the final state
+		return m.update(PacketsLoadedMsg{
+			ContinuationToken: m.continuationToken,
+			Append:            appended,
+			Err:               msg.Err,
+		})
 
 	case PacketsLoadedMsg:
 		m.state = PacketsStateReady
@@ -188,16 +647,90 @@ func (m PacketsModel) Update(msg tea.Msg) (PacketsModel, tea.Cmd) {
 		}
 		m.continuationToken = msg.ContinuationToken
 		m.hasMore = msg.ContinuationToken != ""
+		m.partialErr = msg.Err
+		m.packetsChanged()
+		if m.jumpToLatest {
+			// Keep paging until the newest packet is loaded or a page fails
+			if m.hasMore && m.partialErr == nil {
+				m.loadingMore = true
+				return m, tea.Batch(m.spinner.Tick, m.startLoad(true))
+			}
+			m.jumpToLatest = false
+			m.selectLatest()
+		}
+		return m, nil
+
+	case common.ClipboardCopiedMsg:
+		if msg.Err != nil {
+			m.notice = "Copy failed: " + msg.Err.Error()
+			return m, nil
+		}
+		m.notice = ""
+		return m, m.copied.Set("Copied " + msg.Label)
+
+	case common.FlashExpiredMsg:
+		m.copied.Expire(msg)
+		return m, nil
+
+	case PacketsKeysLoadedMsg:
+		m.keysLoading = false
+		if msg.Err != nil {
+			m.keysErr = msg.Err
+			return m, nil
+		}
+		m.keyDevices = keyDevices(msg.Devices)
+		return m, m.decryptPayloads()
+
+	case PacketsDecryptedMsg:
+		for key, plain := range msg.Payloads {
+			m.decrypted[key] = plain
+		}
 		m.updateTable()
 		return m, nil
 
+	case PacketsExportedMsg:
+		if msg.Err != nil {
+			m.notice = "Export failed: " + msg.Err.Error()
+		} else {
+			m.notice = fmt.Sprintf("Exported %d packet(s) to %s", msg.Count, msg.Path)
+		}
+		return m, nil
+
+	case packetsRefreshTickMsg:
+		if msg.seq != m.refreshSeq || !m.autoRefresh {
+			return m, nil
+		}
+		// Tick every second so the time since the last refresh stays current
+		tick := m.refreshTick()
+		if !m.refreshing && m.state == PacketsStateReady && !m.loadingMore &&
+			msg.at.Sub(m.lastRefresh) >= packetsRefreshInterval {
+			return m, tea.Batch(tick, m.refresh(msg.at))
+		}
+		return m, tick
+
+	case PacketsRefreshedMsg:
+		if msg.Seq != m.refreshSeq {
+			return m, nil
+		}
+		m.refreshing = false
+		m.refreshErr = msg.Err
+		if msg.Err != nil || msg.Load != m.load {
+			return m, nil
+		}
+		m.refreshAdded = m.mergePackets(msg.Packets)
+		if m.refreshAdded == 0 {
+			return m, nil
+		}
+		return m, m.decryptPayloads()
+
 	case PacketsErrorMsg:
+		m.jumpToLatest = false
 		m.state = PacketsStateError
 		m.err = msg.Err
 		return m, nil
 
 	case spinner.TickMsg:
-		if m.state == PacketsStateLoading {
+		if m.state == PacketsStateLoading || m.loadingMore {
 			var cmd tea.Cmd
 			m.spinner, cmd = m.spinner.Update(msg)
 			return m, cmd
@@ -218,28 +751,14 @@ func (m PacketsModel) Update(msg tea.Msg) (PacketsModel, tea.Cmd) {
 func (m PacketsModel) View() string {
 	var content strings.Builder
 
-	// Header
-	content.WriteString(common.TitleStyle.Render("Packets"))
-	content.WriteString("\n")
-
-	subtitle := "View packet history"
-	if m.deviceID != "" {
-		subtitle = fmt.Sprintf("Packets for device: %s", truncate(m.deviceID, 20))
-	}
-	content.WriteString(common.SubtitleStyle.Render(subtitle))
-	content.WriteString("\n\n")
-
-	// Time range indicator
-	timeRange := fmt.Sprintf("Showing last %d day(s)", m.days)
-	content.WriteString(common.MutedTextStyle.Render(timeRange))
-	content.WriteString("\n\n")
+	content.WriteString(m.renderHeader())
 
 	switch m.state {
 	case PacketsStateLoading:
 		content.WriteString(fmt.Sprintf("%s Loading packets...", m.spinner.View()))
 
 	case PacketsStateError:
-		content.WriteString(common.ErrorTextStyle.Render("Error: " + m.err.Error()))
+		content.WriteString(common.ErrorTextStyle.Render("Error: " + errorText(m.err)))
 		content.WriteString("\n\n")
 		content.WriteString(common.MutedTextStyle.Render("Press 'r' to retry"))
 
@@ -247,37 +766,24 @@ func (m PacketsModel) View() string {
 		if len(m.packets) == 0 {
 			content.WriteString(common.MutedTextStyle.Render("No packets found in the selected time range."))
 		} else {
-			// Packet count
-			countText := fmt.Sprintf("%d packet(s)", len(m.packets))
-			if m.hasMore {
-				countText += " (more available)"
-			}
-			if m.loadingMore {
-				countText += " - loading more..."
-			}
-			content.WriteString(common.MutedTextStyle.Render(countText))
-			content.WriteString("\n\n")
+			content.WriteString(m.renderStatus())
 
-			// Table
-			content.WriteString(m.table.View())
+			if m.detail != nil {
+				content.WriteString(m.renderDetail())
+			} else if m.showHistogram {
+				content.WriteString(m.renderHistogram())
+			} else if m.showGaps {
+				content.WriteString(m.renderGaps())
+			} else {
+				// Table
+				content.WriteString(common.RenderTable(m.table))
+			}
 		}
 	}
 
 	// Help
 	content.WriteString("\n\n")
-	helpText := []string{
-		common.FormatHelp("↑/↓", "navigate"),
-		common.FormatHelp("1/7", "1/7 days"),
-		common.FormatHelp("r", "refresh"),
-	}
-	if m.hasMore && !m.loadingMore {
-		helpText = append(helpText, common.FormatHelp("m", "load more"))
-	}
-	if m.deviceID != "" {
-		helpText = append(helpText, common.FormatHelp("c", "clear filter"))
-	}
-	helpText = append(helpText, common.FormatHelp("esc", "back"))
-	content.WriteString(strings.Join(helpText, "  "))
+	content.WriteString(m.renderHelp())
 
 	// Use full width with padding
 	style := lipgloss.NewStyle().
@@ -287,103 +793,1071 @@ func (m PacketsModel) View() string {
 	return style.Render(content.String())
 }
 
-func (m *PacketsModel) updateTable() {
-	deviceWidth, _, locationWidth, payloadWidth := m.calculateColumnWidths()
+// renderHeader renders the title, subtitle and time range shown above the
+// screen body
+func (m PacketsModel) renderHeader() string {
+	var content strings.Builder
 
-	rows := make([]table.Row, len(m.packets))
-	for i, p := range m.packets {
-		location := formatRetrievedLocation(p.Location)
-		rows[i] = table.Row{
-			truncate(p.DeviceID(), deviceWidth),
-			p.Timestamp().Format("2006-01-02 15:04:05"),
-			truncate(location, locationWidth),
-			truncate(p.Payload(), payloadWidth),
+	content.WriteString(common.TitleStyle.Render("Packets"))
+	content.WriteString("\n")
+
+	subtitle := "View packet history"
+	if m.deviceID != "" {
+		subtitle = fmt.Sprintf("Packets for device: %s", truncate(m.deviceID, 20))
+	}
+	content.WriteString(common.SubtitleStyle.Render(subtitle))
+	content.WriteString("\n\n")
+
+	// Time range indicator
+	content.WriteString(common.MutedTextStyle.Render(m.timeRangeText()))
+	if m.autoRefresh {
+		content.WriteString("  ")
+		if m.refreshErr != nil {
+			content.WriteString(common.ErrorTextStyle.Render("Auto-refresh failed: " + errorText(m.refreshErr)))
+		} else {
+			content.WriteString(common.PrimaryTextStyle.Render(m.refreshText(time.Now())))
 		}
 	}
-	m.table.SetRows(rows)
+	if m.notice != "" {
+		content.WriteString("  ")
+		content.WriteString(common.SuccessTextStyle.Render(m.notice))
+	}
+	if copied := m.copied.Text(); copied != "" {
+		content.WriteString("  ")
+		content.WriteString(common.SuccessTextStyle.Render(copied))
+	}
+	content.WriteString("\n\n")
+
+	if m.editingRange {
+		content.WriteString(common.PrimaryTextStyle.Render("Date range: "))
+		content.WriteString(m.rangeInput.View())
+		content.WriteString("\n")
+		if m.rangeErr != "" {
+			content.WriteString(common.ErrorTextStyle.Render(" ✗ " + m.rangeErr))
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+	}
+
+	return content.String()
 }
 
-// updateColumnWidths updates table column widths based on screen width
-func (m *PacketsModel) updateColumnWidths() {
-	deviceWidth, timestampWidth, locationWidth, payloadWidth := m.calculateColumnWidths()
+// timeRangeText describes the time range being shown
+func (m PacketsModel) timeRangeText() string {
+	if m.rangeStart == nil {
+		return fmt.Sprintf("Showing last %d day(s)", m.days)
+	}
+	start := m.rangeStart.In(common.DisplayLocation()).Format(packetDateLayout)
+	if m.rangeEnd == nil {
+		return "Showing since " + start
+	}
+	end := m.rangeEnd.AddDate(0, 0, -1).In(common.DisplayLocation()).Format(packetDateLayout)
+	return fmt.Sprintf("Showing %s to %s", start, end)
+}
 
-	columns := []table.Column{
-		{Title: "Device ID", Width: deviceWidth},
-		{Title: "Timestamp", Width: timestampWidth},
-		{Title: "Location", Width: locationWidth},
-		{Title: "Payload", Width: payloadWidth},
+// refreshText describes the auto-refresh state at now
+func (m PacketsModel) refreshText(now time.Time) string {
+	if m.refreshing {
+		return "● Auto-refresh on, checking..."
 	}
-	m.table.SetColumns(columns)
+	text := fmt.Sprintf("● Auto-refresh on, updated %s ago", now.Sub(m.lastRefresh).Truncate(time.Second))
+	if m.refreshAdded > 0 {
+		text += fmt.Sprintf(" (%d new)", m.refreshAdded)
+	}
+	return text
 }
 
-// calculateColumnWidths returns column widths based on screen width
-func (m *PacketsModel) calculateColumnWidths() (deviceWidth, timestampWidth, locationWidth, payloadWidth int) {
-	// Fixed width for timestamp
-	timestampWidth = 20
+// renderStatus renders the filter, packet count and warnings shown above
+// the table
+func (m PacketsModel) renderStatus() string {
+	var content strings.Builder
 
-	// Available width for other columns (account for padding/borders)
-	availableWidth := m.width - timestampWidth - 10
+	// Filter input
+	if m.filterActive {
+		content.WriteString(common.PrimaryTextStyle.Render("Filter: "))
+		content.WriteString(m.filterInput.View())
+		content.WriteString("\n\n")
+	} else if m.filterText != "" {
+		content.WriteString(common.MutedTextStyle.Render(fmt.Sprintf("Filter: %q", m.filterText)))
+		content.WriteString("\n\n")
+	}
 
-	if availableWidth < 80 {
-		// Minimum widths
-		deviceWidth = 36
-		locationWidth = 20
-		payloadWidth = 24
-	} else {
-		// Device ID needs 36 chars for full UUID
-		deviceWidth = 36
-		remaining := availableWidth - deviceWidth
-		// Split remaining between location and payload
-		locationWidth = remaining * 35 / 100
-		payloadWidth = remaining - locationWidth
+	countText := fmt.Sprintf("%d of %d packet(s)", len(m.filteredPackets), len(m.packets))
+	if m.hasMore {
+		countText += " (more available)"
+	}
+	content.WriteString(common.MutedTextStyle.Render(countText))
+	if m.loadingMore {
+		content.WriteString(" " + m.spinner.View() + " ")
+		content.WriteString(common.MutedTextStyle.Render(m.progressText()))
+	}
+	content.WriteString("\n")
+	if m.payloadView == PayloadViewDecrypted {
+		content.WriteString(m.renderDecryptStatus())
+		content.WriteString("\n")
+	}
+	if m.deviceID != "" && len(m.packets) > 1 {
+		content.WriteString(m.renderGapSummary())
+		content.WriteString("\n")
+	}
+	if m.partialErr != nil {
+		content.WriteString(common.WarningTextStyle.Render("Retrieval stopped early: " + m.partialErr.Error()))
+		if m.hasMore {
+			content.WriteString(common.MutedTextStyle.Render(" - press 'm' to resume"))
+		}
+		content.WriteString("\n")
 	}
+	content.WriteString("\n")
 
-	return
+	return content.String()
 }
 
-func (m PacketsModel) loadPackets(append bool) tea.Cmd {
-	return func() tea.Msg {
-		if m.client == nil {
-			return PacketsErrorMsg{Err: fmt.Errorf("no API client")}
-		}
+// renderDecryptStatus describes how many payloads the decrypted view could
+// decrypt
+func (m PacketsModel) renderDecryptStatus() string {
+	switch {
+	case m.keysLoading:
+		return common.MutedTextStyle.Render("Loading device keys...")
+	case m.keysErr != nil:
+		return common.WarningTextStyle.Render("Couldn't load device keys: " + errorText(m.keysErr) + "; payloads are shown as hex")
+	}
+	return common.MutedTextStyle.Render(fmt.Sprintf("Decrypted %d of %d packet(s); the rest are shown as hex", m.decryptedCount(), len(m.packets)))
+}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-		defer cancel()
+// progressText describes the retrieval in progress once packets are shown
+func (m PacketsModel) progressText() string {
+	text := "loading more..."
+	if m.jumpToLatest {
+		text = "loading to newest packet..."
+	}
+	if m.streamPages > 0 {
+		text += fmt.Sprintf(" fetched %d packet(s) so far (page %d)", m.streamCount, m.streamPages)
+	}
+	return text
+}
 
-		opts := api.RetrievePacketsOptions{
-			Days: m.days,
+// renderHelp renders the key help line
+func (m PacketsModel) renderHelp() string {
+	if m.exportPrompt {
+		return "Export as:  " + strings.Join(exportFormatHelp(packetExportFormats), "  ")
+	}
+	if m.detail != nil {
+		return strings.Join([]string{
+			common.FormatHelp("y", "copy payload hex"),
+			common.FormatHelp("esc", "close"),
+		}, "  ")
+	}
+	if m.editingRange {
+		return strings.Join([]string{
+			common.FormatHelp("enter", "apply"),
+			common.FormatHelp("esc", "cancel"),
+		}, "  ")
+	}
+	helpText := []string{
+		common.FormatHelp("↑/↓", "navigate"),
+		common.FormatHelp("1/7/3/9", "1/7/30/90 days"),
+		common.FormatHelp("D", "date range"),
+		common.FormatHelp("r", "refresh"),
+	}
+	if m.state == PacketsStateReady && len(m.packets) > 0 {
+		helpText = append(helpText, common.FormatHelp("←/→", "select column"))
+		helpText = append(helpText, common.FormatHelp("s", "sort"))
+		switch {
+		case PacketSortColumn(m.sort.Column) != PacketSortByTimestamp:
+			helpText = append(helpText, common.FormatHelp("o", "reverse"))
+		case m.sort.Asc:
+			helpText = append(helpText, common.FormatHelp("o", "newest first"))
+		default:
+			helpText = append(helpText, common.FormatHelp("o", "oldest first"))
 		}
-		if m.deviceID != "" {
-			opts.DeviceID = &m.deviceID
+		helpText = append(helpText, timeZoneHelp())
+		helpText = append(helpText, common.FormatHelp("/", "filter"))
+		if !m.showHistogram && !m.showGaps {
+			helpText = append(helpText, common.FormatHelp("y", "copy payload"))
+			helpText = append(helpText, common.FormatHelp("p", "copy map link"))
+		}
+		if m.showHistogram {
+			helpText = append(helpText, common.FormatHelp("t", "table"))
 		} else {
-			// When no device filter, limit to 100 packets per request
-			opts.Limit = 100
+			helpText = append(helpText, common.FormatHelp("t", "by hour"))
 		}
-
-		// If appending, use the continuation token
-		if append && m.continuationToken != "" {
-			opts.ContinuationToken = m.continuationToken
+		helpText = append(helpText, common.FormatHelp("enter", "details"))
+		helpText = append(helpText, common.FormatHelp("v", m.nextPayloadViewName()))
+		helpText = append(helpText, common.FormatHelp("x", "export"))
+	}
+	if m.autoRefresh {
+		helpText = append(helpText, common.FormatHelp("a", "stop auto-refresh"))
+	} else if m.state == PacketsStateReady && m.rangeEnd == nil {
+		helpText = append(helpText, common.FormatHelp("a", "auto-refresh"))
+	}
+	if m.hasMore && !m.loadingMore {
+		if m.partialErr != nil {
+			helpText = append(helpText, common.FormatHelp("m", "resume"))
+		} else {
+			helpText = append(helpText, common.FormatHelp("m", "load more"))
 		}
-
-		result, err := m.client.RetrievePacketsWithPagination(ctx, opts)
-		if err != nil {
-			return PacketsErrorMsg{Err: err}
+	}
+	if m.deviceID != "" {
+		if m.state == PacketsStateReady && len(m.packets) > 0 {
+			helpText = append(helpText, common.FormatHelp("n", "newest"))
+			if m.showGaps {
+				helpText = append(helpText, common.FormatHelp("S", "table"))
+			} else {
+				helpText = append(helpText, common.FormatHelp("S", "seq gaps"))
+			}
 		}
+		helpText = append(helpText, common.FormatHelp("c", "clear filter"))
+	}
+	helpText = append(helpText, common.FormatHelp("L", fmt.Sprintf("page size (%d)", m.pageSize)))
+	if m.client != nil {
+		helpText = append(helpText, common.FormatHelp("u", "copy API URL"))
+	}
+	if m.state == PacketsStateLoading || m.loadingMore {
+		helpText = append(helpText, common.FormatHelp("esc", "cancel loading"))
+	} else {
+		helpText = append(helpText, common.FormatHelp("esc", "back"))
+	}
+	return strings.Join(helpText, "  ")
+}
 
-		return PacketsLoadedMsg{
-			Packets:           result.Packets,
-			ContinuationToken: result.ContinuationToken,
-			Append:            append,
-		}
+// fitTable sizes the table to the lines left over by the header, status
+// and help as currently rendered. The view is padded by one line top and
+// bottom and two columns left and right.
+func (m *PacketsModel) fitTable() {
+	if m.height == 0 {
+		return
 	}
+	above := m.renderHeader() + m.renderStatus()
+	below := "\n\n" + m.renderHelp()
+	m.table.SetHeight(common.FitTableHeight(m.height-2, m.width-4, above, below))
 }
 
-// SetDeviceFilter sets the device ID filter
-func (m *PacketsModel) SetDeviceFilter(deviceID string) {
-	m.deviceID = deviceID
+// packetsChanged sorts newly loaded packets into the table and updates the
+// sequence gaps, which are kept rather than worked out on every redraw
+func (m *PacketsModel) packetsChanged() {
+	m.sortPackets()
+	m.gaps = nil
+	if m.deviceID != "" {
+		m.gaps = sequenceGaps(m.packets)
+	}
+	m.updateTable()
 }
 
-// formatLocation formats a location for display
+// sequenceGaps returns the sequence number gaps in packets, taken in time
+// order
+func sequenceGaps(packets []models.RetrievedPacket) []analysis.SequenceGap {
+	ordered := make([]models.RetrievedPacket, len(packets))
+	copy(ordered, packets)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Device.Timestamp < ordered[j].Device.Timestamp
+	})
+	return analysis.SequenceGaps(ordered)
+}
+
+// renderGapSummary renders a one-line summary of missing sequence numbers,
+// allowing for packets that arrived out of order
+func (m PacketsModel) renderGapSummary() string {
+	ranges := make([]analysis.SequenceRange, len(m.gaps))
+	for i, g := range m.gaps {
+		ranges[i] = g.Missing
+	}
+	summary := analysis.FormatMissing(ranges, 3)
+	if summary == "" {
+		return common.MutedTextStyle.Render("Sequence: no gaps")
+	}
+	return common.WarningTextStyle.Render(summary)
+}
+
+// renderGaps lists each sequence gap with the packets on either side
+func (m PacketsModel) renderGaps() string {
+	gaps := m.gaps
+	if len(gaps) == 0 {
+		return common.MutedTextStyle.Render("No sequence number gaps in the loaded packets.")
+	}
+
+	var b strings.Builder
+	for i, g := range gaps {
+		before := common.FormatTime(g.Before.Timestamp(), packetTimeLayout)
+		after := common.FormatTime(g.After.Timestamp(), packetTimeLayout)
+		b.WriteString(fmt.Sprintf("%s → %s  seq %d → %d  ", before, after, g.Before.Device.SequenceNumber, g.After.Device.SequenceNumber))
+		b.WriteString(common.WarningTextStyle.Render(fmt.Sprintf("%d missing", g.Missing.Len())))
+		if i < len(gaps)-1 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// renderHistogram renders a bar chart of loaded packets by hour of day in
+// the display time zone
+func (m PacketsModel) renderHistogram() string {
+	buckets := models.PacketsByHour(m.packets, common.DisplayLocation())
+
+	maxCount := 0
+	for _, c := range buckets {
+		if c > maxCount {
+			maxCount = c
+		}
+	}
+
+	// Leave room for the hour label, count and padding
+	barWidth := m.width - 20
+	if barWidth > 60 {
+		barWidth = 60
+	}
+	if barWidth < 10 {
+		barWidth = 10
+	}
+
+	barStyle := lipgloss.NewStyle().Foreground(common.ColorPrimary)
+
+	var b strings.Builder
+	zone := "local time"
+	if common.UTC() {
+		zone = "UTC"
+	}
+	b.WriteString(common.MutedTextStyle.Render("Packets by hour of day (" + zone + ")"))
+	b.WriteString("\n\n")
+	for hour, count := range buckets {
+		b.WriteString(fmt.Sprintf("%02d │ ", hour))
+		b.WriteString(barStyle.Render(common.RenderBar(count, maxCount, barWidth)))
+		if count > 0 {
+			b.WriteString(" " + common.MutedTextStyle.Render(fmt.Sprintf("%d", count)))
+		}
+		if hour < len(buckets)-1 {
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
+
+// packetTimeLayout is the layout for the Timestamp column
+const packetTimeLayout = "2006-01-02 15:04:05"
+
+// updateTable filters the loaded packets and refreshes the table rows
+func (m *PacketsModel) updateTable() {
+	deviceWidth, _, locationWidth, payloadWidth := m.calculateColumnWidths()
+
+	m.filteredPackets = filterPackets(m.packets, m.filterText)
+	rows := make([]table.Row, len(m.filteredPackets))
+	for i, p := range m.filteredPackets {
+		// Show altitude and accuracy when the column is wide enough
+		location := formatRetrievedLocationExpanded(p.Location)
+		if len(location) > locationWidth {
+			location = formatRetrievedLocation(p.Location)
+		}
+		rows[i] = table.Row{
+			truncate(p.DeviceID(), deviceWidth),
+			common.FormatTime(p.Timestamp(), packetTimeLayout),
+			truncate(location, locationWidth),
+			truncate(m.payloadText(p), payloadWidth),
+		}
+	}
+	m.table.SetRows(rows)
+	if m.table.Cursor() >= len(rows) {
+		m.table.SetCursor(max(len(rows)-1, 0))
+	}
+}
+
+// filterPackets returns the packets matching the filter text
+func filterPackets(packets []models.RetrievedPacket, filter string) []models.RetrievedPacket {
+	if filter == "" {
+		return packets
+	}
+
+	var filtered []models.RetrievedPacket
+	for _, p := range packets {
+		if packetMatchesFilter(p, filter) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// packetMatchesFilter reports whether a packet's device ID, formatted
+// location or payload contains the filter text, ignoring case
+func packetMatchesFilter(p models.RetrievedPacket, filter string) bool {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	if filter == "" {
+		return true
+	}
+	for _, field := range []string{p.DeviceID(), formatRetrievedLocationExpanded(p.Location), p.Payload()} {
+		if strings.Contains(strings.ToLower(field), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateColumnWidths updates table column widths based on screen width
+func (m *PacketsModel) updateColumnWidths() {
+	deviceWidth, timestampWidth, locationWidth, payloadWidth := m.calculateColumnWidths()
+
+	titles := m.sort.Titles([]string{"Device ID", "Timestamp", "Location", m.payloadTitle()})
+	columns := []table.Column{
+		{Title: titles[0], Width: deviceWidth},
+		{Title: titles[1], Width: timestampWidth},
+		{Title: titles[2], Width: locationWidth},
+		{Title: titles[3], Width: payloadWidth},
+	}
+	m.table.SetColumns(columns)
+}
+
+// SetSortAscending sets whether the sorted column is in ascending order,
+// i.e. oldest first when sorted by timestamp, re-sorting any packets that
+// are already loaded
+func (m *PacketsModel) SetSortAscending(asc bool) {
+	m.sort.Asc = asc
+	m.applySort()
+}
+
+// toggleSort sorts by the selected column, toggling direction if it is
+// already sorted
+func (m *PacketsModel) toggleSort() {
+	// Default to newest first for timestamps, ascending otherwise
+	m.sort.SortSelected(PacketSortColumn(m.sort.Selected) != PacketSortByTimestamp)
+	m.applySort()
+}
+
+// applySort re-sorts the loaded packets and updates the column headers
+// and table
+func (m *PacketsModel) applySort() {
+	m.updateColumnWidths()
+	if len(m.packets) > 0 {
+		m.sortPackets()
+		m.updateTable()
+	}
+}
+
+// sortPackets orders the loaded packets by the sorted column in the
+// current direction
+func (m *PacketsModel) sortPackets() {
+	col := PacketSortColumn(m.sort.Column)
+	sort.SliceStable(m.packets, func(i, j int) bool {
+		if m.sort.Asc {
+			return packetLess(col, m.packets[i], m.packets[j])
+		}
+		return packetLess(col, m.packets[j], m.packets[i])
+	})
+}
+
+// packetLess reports whether a sorts before b in ascending order of col
+func packetLess(col PacketSortColumn, a, b models.RetrievedPacket) bool {
+	switch col {
+	case PacketSortByDeviceID:
+		return a.DeviceID() < b.DeviceID()
+	case PacketSortByLocation:
+		if a.Location.Latitude != b.Location.Latitude {
+			return a.Location.Latitude < b.Location.Latitude
+		}
+		return a.Location.Longitude < b.Location.Longitude
+	case PacketSortByPayload:
+		return a.Payload() < b.Payload()
+	default:
+		return packetSortTimestamp(a) < packetSortTimestamp(b)
+	}
+}
+
+// packetSortTimestamp returns the time a packet is sorted by: when the
+// device sent it, or when it was located if the device time is missing
+func packetSortTimestamp(p models.RetrievedPacket) float64 {
+	if p.Device.Timestamp != 0 {
+		return p.Device.Timestamp
+	}
+	return p.Location.Timestamp
+}
+
+// calculateColumnWidths returns column widths based on screen width
+func (m *PacketsModel) calculateColumnWidths() (deviceWidth, timestampWidth, locationWidth, payloadWidth int) {
+	// Fixed width for timestamp
+	timestampWidth = len(packetTimeLayout) + common.TimeZoneWidth
+
+	// Available width for other columns (account for screen padding and the
+	// table header's cell padding)
+	availableWidth := m.width - 4 - common.TableFrameWidth(common.TableStyles(), 4) - common.SelectionMarkerWidth - timestampWidth
+
+	if availableWidth < 80 {
+		// Minimum widths
+		deviceWidth = 36
+		locationWidth = 20
+		payloadWidth = 24
+	} else {
+		// Device ID needs 36 chars for full UUID
+		deviceWidth = 36
+		remaining := availableWidth - deviceWidth
+		// Split remaining between location and payload
+		locationWidth = remaining * 35 / 100
+		payloadWidth = remaining - locationWidth
+	}
+
+	return
+}
+
+// loadPackets starts retrieving packets, as the next pages if append is
+// set. Pages are sent as they arrive once PacketsStreamStartedMsg is handled.
+func (m PacketsModel) loadPackets(append bool) tea.Cmd {
+	client := m.client
+	opts := m.retrieveOptions(append)
+	load := m.load
+	return func() tea.Msg {
+		if client == nil {
+			return PacketsErrorMsg{Err: fmt.Errorf("no API client")}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		pages, errs := client.RetrievePacketsPages(ctx, opts)
+		return PacketsStreamStartedMsg{
+			Pages:  pages,
+			Errs:   errs,
+			Cancel: cancel,
+			Append: append,
+			Load:   load,
+		}
+	}
+}
+
+// startLoad stops any retrieval in progress and starts a new one
+func (m *PacketsModel) startLoad(append bool) tea.Cmd {
+	m.stopStream()
+	m.load++
+	return m.loadPackets(append)
+}
+
+// waitForPacketsPage waits for the next page from a stream, or for the
+// stream to end
+func waitForPacketsPage(pages <-chan api.PacketsPage, errs <-chan error) tea.Cmd {
+	return func() tea.Msg {
+		page, ok := <-pages
+		if !ok {
+			return PacketsStreamDoneMsg{Pages: pages, Err: <-errs}
+		}
+		return PacketsPageMsg{Pages: pages, Page: page}
+	}
+}
+
+// stopStream cancels the retrieval in progress, if any, and forgets it
+func (m *PacketsModel) stopStream() {
+	if m.cancelStream != nil {
+		m.cancelStream()
+	}
+	m.stream = nil
+	m.streamErrs = nil
+	m.cancelStream = nil
+}
+
+// cancelLoad stops the retrieval in flight at the user's request. Packets
+// already shown stay, and the rest can be resumed with 'm'.
+func (m *PacketsModel) cancelLoad() {
+	m.stopStream()
+	m.load++ // A retrieval still starting is cancelled when it arrives
+	m.jumpToLatest = false
+	if m.state == PacketsStateLoading {
+		m.state = PacketsStateError
+		m.err = common.ErrCancelled
+		return
+	}
+	m.loadingMore = false
+	m.hasMore = m.continuationToken != ""
+	m.partialErr = common.ErrCancelled
+}
+
+// Stop cancels any retrieval in progress, e.g. when leaving the screen, so
+// it doesn't wait for its pages to be read. The screen still reports
+// itself busy, since the packets are incomplete. Auto-refresh is turned
+// off, since its ticks aren't delivered while the screen is hidden.
+func (m *PacketsModel) Stop() {
+	m.stopStream()
+	m.stopAutoRefresh()
+}
+
+// startAutoRefresh turns on auto-refresh, fetching new packets right away
+// and then every packetsRefreshInterval
+func (m *PacketsModel) startAutoRefresh(now time.Time) tea.Cmd {
+	m.autoRefresh = true
+	m.refreshSeq++
+	m.refreshAdded = 0
+	m.refreshErr = nil
+	return tea.Batch(m.refreshTick(), m.refresh(now))
+}
+
+// stopAutoRefresh turns off auto-refresh, cancelling a refresh in flight;
+// a tick or refresh result still to arrive is dropped
+func (m *PacketsModel) stopAutoRefresh() {
+	m.refreshes.Cancel()
+	m.autoRefresh = false
+	m.refreshSeq++
+	m.refreshing = false
+}
+
+// refreshTick returns a command sending the next auto-refresh tick
+func (m PacketsModel) refreshTick() tea.Cmd {
+	seq := m.refreshSeq
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return packetsRefreshTickMsg{seq: seq, at: t}
+	})
+}
+
+// refresh fetches the packets received in the last packetsRefreshWindow
+// for the current device filter, in one request without a page limit
+func (m *PacketsModel) refresh(now time.Time) tea.Cmd {
+	m.refreshing = true
+	m.lastRefresh = now
+
+	client := m.client
+	opts := m.refreshOptions(now)
+	load, seq := m.load, m.refreshSeq
+	ctx, cancel := m.refreshes.WithTimeout(common.RequestTimeout())
+	return func() tea.Msg {
+		defer cancel()
+
+		result, err := client.RetrievePacketsWithPagination(ctx, opts)
+		if err != nil {
+			return PacketsRefreshedMsg{Err: err, Load: load, Seq: seq}
+		}
+		return PacketsRefreshedMsg{Packets: result.Packets, Load: load, Seq: seq}
+	}
+}
+
+// refreshOptions returns the retrieval options for an auto-refresh at now
+func (m PacketsModel) refreshOptions(now time.Time) api.RetrievePacketsOptions {
+	start := now.Add(-packetsRefreshWindow)
+	if m.rangeStart != nil && m.rangeStart.After(start) {
+		start = *m.rangeStart
+	}
+	opts := api.RetrievePacketsOptions{Start: &start}
+	if m.deviceID != "" {
+		deviceID := m.deviceID
+		opts.DeviceID = &deviceID
+	}
+	return opts
+}
+
+// mergePackets adds the fetched packets that aren't loaded yet, keeping
+// the selected packet selected, and returns how many were added
+func (m *PacketsModel) mergePackets(fetched []models.RetrievedPacket) int {
+	added := newPackets(m.packets, fetched)
+	if len(added) == 0 {
+		return 0
+	}
+
+	var selected *packetKey
+	if i := m.table.Cursor(); i >= 0 && i < len(m.filteredPackets) {
+		k := packetKeyOf(m.filteredPackets[i])
+		selected = &k
+	}
+
+	m.packets = append(m.packets, added...)
+	m.packetsChanged()
+
+	if selected != nil {
+		for i, p := range m.filteredPackets {
+			if packetKeyOf(p) == *selected {
+				m.table.SetCursor(i)
+				break
+			}
+		}
+	}
+	return len(added)
+}
+
+// packetKey identifies a packet, for telling refreshed packets apart from
+// ones already loaded
+type packetKey struct {
+	deviceID  string
+	timestamp float64
+	sequence  int
+}
+
+func packetKeyOf(p models.RetrievedPacket) packetKey {
+	return packetKey{p.Device.ID, p.Device.Timestamp, p.Device.SequenceNumber}
+}
+
+// newPackets returns the packets in fetched that aren't in loaded, matched
+// by device ID, timestamp and sequence number, each only once
+func newPackets(loaded, fetched []models.RetrievedPacket) []models.RetrievedPacket {
+	seen := make(map[packetKey]bool, len(loaded))
+	for _, p := range loaded {
+		seen[packetKeyOf(p)] = true
+	}
+
+	var added []models.RetrievedPacket
+	for _, p := range fetched {
+		k := packetKeyOf(p)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		added = append(added, p)
+	}
+	return added
+}
+
+// updateDetail handles key presses while a packet's details are shown
+func (m PacketsModel) updateDetail(msg tea.KeyMsg) (PacketsModel, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Back), key.Matches(msg, m.keys.Select):
+		m.detail = nil
+		m.notice = ""
+	case msg.String() == "y":
+		payload, err := m.detail.PayloadBytes()
+		if err != nil || len(payload) == 0 {
+			m.notice = "Packet has no payload"
+			return m, nil
+		}
+		return m, common.CopyToClipboard("payload hex", hex.EncodeToString(payload))
+	case key.Matches(msg, m.keys.Quit):
+		return m, RequestQuit
+	}
+	return m, nil
+}
+
+// renderDetail renders every field of the packet in the detail view. Copy
+// results show in the header as usual.
+func (m PacketsModel) renderDetail() string {
+	p := *m.detail
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(common.ColorSecondary)
+	labelStyle := lipgloss.NewStyle().Foreground(common.ColorMuted).Width(20)
+	valueStyle := lipgloss.NewStyle().Foreground(common.ColorForeground)
+
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("Packet"))
+	b.WriteString("\n\n")
+
+	line := func(label, value string) {
+		b.WriteString(labelStyle.Render(label))
+		b.WriteString(valueStyle.Render(value))
+		b.WriteString("\n")
+	}
+
+	line("Device ID:", orDash(p.DeviceID()))
+	line("Device Name:", orDash(p.Device.Name))
+	if len(p.Device.Tags) > 0 {
+		tags := make([]string, 0, len(p.Device.Tags))
+		for k, v := range p.Device.Tags {
+			tags = append(tags, k+"="+v)
+		}
+		sort.Strings(tags)
+		line("Tags:", strings.Join(tags, ", "))
+	}
+	line("Timestamp:", common.FormatTime(p.Timestamp(), "2006-01-02 15:04:05.000"))
+	line("Network Type:", orDash(p.NetworkType))
+	line("RSSI:", fmt.Sprintf("%d dBm", p.Device.RSSI))
+	line("Sequence Number:", fmt.Sprintf("%d", p.Device.SequenceNumber))
+	line("Counter:", fmt.Sprintf("%d", p.Device.Counter))
+
+	loc := p.Location
+	if loc.Latitude == 0 && loc.Longitude == 0 {
+		line("Location:", "Unknown")
+	} else {
+		line("Location:", fmt.Sprintf("%.6f, %.6f", loc.Latitude, loc.Longitude))
+		line("Altitude:", fmt.Sprintf("%.1f m", loc.Altitude))
+		line("Horizontal Accuracy:", fmt.Sprintf("%.1f m", loc.HorizontalAccuracy))
+		line("Vertical Accuracy:", fmt.Sprintf("%.1f m", loc.VerticalAccuracy))
+		if loc.Timestamp != 0 {
+			line("Location Time:", common.FormatTime(p.GetLocation().Timestamp, packetTimeLayout))
+		}
+	}
+
+	payload, err := p.PayloadBytes()
+	if err != nil {
+		line("Payload:", orDash(p.Payload()))
+	} else {
+		line("Payload:", orDash(hex.EncodeToString(payload)))
+		line("Payload Length:", fmt.Sprintf("%d bytes", len(payload)))
+		line("Payload Kind:", formatPayloadKind(payload))
+		for _, field := range payloadBreakdown(payload) {
+			line("  "+field[0], field[1])
+		}
+	}
+	if plain := m.decrypted[packetKeyOf(p)]; plain != nil {
+		line("Decrypted:", orDash(hex.EncodeToString(plain)))
+	}
+
+	return common.BoxStyle.Render(b.String())
+}
+
+// exportFormatKey is a key offered when asking for an export format
+type exportFormatKey struct {
+	key    string
+	format export.Format
+	label  string
+}
+
+// packetExportFormats are the formats offered for retrieved packets
+var packetExportFormats = []exportFormatKey{
+	{"c", export.FormatCSV, "CSV"},
+	{"j", export.FormatJSON, "JSON"},
+	{"n", export.FormatNDJSON, "NDJSON"},
+}
+
+// exportFormatForKey returns the format picked by a key press, if any
+func exportFormatForKey(k string, formats []exportFormatKey) (export.Format, bool) {
+	for _, f := range formats {
+		if f.key == k {
+			return f.format, true
+		}
+	}
+	return "", false
+}
+
+// exportFormatHelp returns the help entries shown while asking for an
+// export format
+func exportFormatHelp(formats []exportFormatKey) []string {
+	helpText := make([]string, 0, len(formats)+1)
+	for _, f := range formats {
+		helpText = append(helpText, common.FormatHelp(f.key, f.label))
+	}
+	return append(helpText, common.FormatHelp("esc", "cancel export"))
+}
+
+// writeExportFile writes a timestamped file named after prefix and the
+// format's extension in the working directory, returning its path
+func writeExportFile(prefix string, format export.Format, write func(io.Writer) error) (string, error) {
+	path := fmt.Sprintf("%s-%s.%s", prefix, time.Now().Format("20060102-150405"), format)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// exportPackets writes packets to a timestamped file in the given format
+func exportPackets(packets []models.RetrievedPacket, format export.Format) tea.Cmd {
+	return func() tea.Msg {
+		path, err := writeExportFile("packets", format, func(w io.Writer) error {
+			return export.WritePackets(w, format, packets)
+		})
+		if err != nil {
+			return PacketsExportedMsg{Err: err}
+		}
+		return PacketsExportedMsg{Path: path, Count: len(packets)}
+	}
+}
+
+// selectLatest moves the table cursor to the newest loaded packet
+func (m *PacketsModel) selectLatest() {
+	if i := latestPacketIndex(m.filteredPackets); i >= 0 {
+		m.table.SetCursor(i)
+	}
+}
+
+// latestPacketIndex returns the index of the packet with the newest
+// timestamp, or -1 if there are none
+func latestPacketIndex(packets []models.RetrievedPacket) int {
+	latest := -1
+	for i, p := range packets {
+		if latest < 0 || p.Device.Timestamp > packets[latest].Device.Timestamp {
+			latest = i
+		}
+	}
+	return latest
+}
+
+// retrieveOptions returns the retrieval options for the current device filter
+// and time range
+func (m PacketsModel) retrieveOptions(append bool) api.RetrievePacketsOptions {
+	// Load a page at a time, so a long history doesn't hold up the table
+	opts := api.RetrievePacketsOptions{
+		Days:         m.days,
+		Start:        m.rangeStart,
+		End:          m.rangeEnd,
+		Limit:        m.pageSize,
+		AllowPartial: true,
+	}
+	if m.deviceID != "" {
+		deviceID := m.deviceID
+		opts.DeviceID = &deviceID
+	}
+
+	// If appending, use the continuation token
+	if append && m.continuationToken != "" {
+		opts.ContinuationToken = m.continuationToken
+	}
+
+	return opts
+}
+
+// Busy reports whether packets are being loaded
+func (m PacketsModel) Busy() bool {
+	return m.state == PacketsStateLoading || m.loadingMore
+}
+
+// DeviceFilter returns the device ID the packets are filtered by, if any
+func (m PacketsModel) DeviceFilter() string {
+	return m.deviceID
+}
+
+// Days returns the number of days of packets being shown
+func (m PacketsModel) Days() int {
+	return m.days
+}
+
+// SetDays sets the number of days of packets to load. Call it before Init;
+// non-positive values are ignored.
+func (m *PacketsModel) SetDays(days int) {
+	if days > 0 {
+		m.days = days
+	}
+}
+
+// defaultPacketPageSize is how many packets are loaded at a time, unless
+// configured otherwise
+const defaultPacketPageSize = 100
+
+// packetPageSizes are the page sizes the packets screen cycles through
+var packetPageSizes = []int{25, 50, 100, 250, 500}
+
+// nextPacketPageSize returns the page size after size in packetPageSizes,
+// wrapping to the smallest. A size not in the list moves to the next
+// larger one.
+func nextPacketPageSize(size int) int {
+	for _, s := range packetPageSizes {
+		if s > size {
+			return s
+		}
+	}
+	return packetPageSizes[0]
+}
+
+// SetPageSize sets how many packets are loaded at a time. Non-positive sizes
+// are ignored.
+func (m *PacketsModel) SetPageSize(size int) {
+	if size > 0 {
+		m.pageSize = size
+	}
+}
+
+// PageSize returns how many packets are loaded at a time
+func (m PacketsModel) PageSize() int {
+	return m.pageSize
+}
+
+// setDays shows the last days days of packets, replacing any custom range,
+// and reloads them
+func (m *PacketsModel) setDays(days int) tea.Cmd {
+	m.days = days
+	m.rangeStart = nil
+	m.rangeEnd = nil
+	return m.reload()
+}
+
+// reload fetches the packets for the current time range from scratch.
+// Auto-refresh only polls up to now, so a range with an end turns it off.
+func (m *PacketsModel) reload() tea.Cmd {
+	if m.autoRefresh && m.rangeEnd != nil {
+		m.stopAutoRefresh()
+		m.notice = "Auto-refresh off: it needs a range up to now"
+	}
+	m.state = PacketsStateLoading
+	m.loadingMore = false
+	m.jumpToLatest = false
+	m.continuationToken = ""
+	return tea.Batch(m.spinner.Tick, m.startLoad(false))
+}
+
+// updateRangeInput handles keys while the custom date range is being
+// entered
+func (m PacketsModel) updateRangeInput(msg tea.KeyMsg) (PacketsModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.editingRange = false
+		m.rangeErr = ""
+		m.rangeInput.Blur()
+		m.table.Focus()
+		return m, nil
+	case "enter":
+		start, end, err := parseDateRange(m.rangeInput.Value(), common.DisplayLocation(), time.Now())
+		if err != nil {
+			m.rangeErr = err.Error()
+			return m, nil
+		}
+		m.editingRange = false
+		m.rangeErr = ""
+		m.rangeInput.Blur()
+		m.table.Focus()
+		m.rangeStart = &start
+		m.rangeEnd = end
+		return m, m.reload()
+	default:
+		var cmd tea.Cmd
+		m.rangeInput, cmd = m.rangeInput.Update(msg)
+		return m, cmd
+	}
+}
+
+// customRangeText formats the custom time range as parseDateRange accepts
+// it, or returns "" if there is none
+func (m PacketsModel) customRangeText() string {
+	if m.rangeStart == nil {
+		return ""
+	}
+	text := m.rangeStart.In(common.DisplayLocation()).Format(packetDateLayout)
+	if m.rangeEnd != nil {
+		text += " " + m.rangeEnd.AddDate(0, 0, -1).In(common.DisplayLocation()).Format(packetDateLayout)
+	}
+	return text
+}
+
+// packetDateLayout is the layout of dates in a custom time range
+const packetDateLayout = "2006-01-02"
+
+// parseDateRange parses a custom time range typed as "start [end]", e.g.
+// "2024-01-01 2024-01-31", with an optional "to" between the dates. Dates
+// are whole days in loc and the end day is included, so the returned end
+// is midnight after it. end is nil when only a start is given.
+func parseDateRange(s string, loc *time.Location, now time.Time) (start time.Time, end *time.Time, err error) {
+	fields := strings.Fields(s)
+	if len(fields) == 3 && strings.EqualFold(fields[1], "to") {
+		fields = []string{fields[0], fields[2]}
+	}
+	if len(fields) == 0 || len(fields) > 2 {
+		return time.Time{}, nil, fmt.Errorf("enter a start date and optional end date as YYYY-MM-DD")
+	}
+
+	start, err = time.ParseInLocation(packetDateLayout, fields[0], loc)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("start date %q is not YYYY-MM-DD", fields[0])
+	}
+	if start.After(now) {
+		return time.Time{}, nil, fmt.Errorf("start date is in the future")
+	}
+	if len(fields) == 1 {
+		return start, nil, nil
+	}
+
+	last, err := time.ParseInLocation(packetDateLayout, fields[1], loc)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("end date %q is not YYYY-MM-DD", fields[1])
+	}
+	if last.Before(start) {
+		return time.Time{}, nil, fmt.Errorf("end date is before start date")
+	}
+	endOfRange := last.AddDate(0, 0, 1)
+	return start, &endOfRange, nil
+}
+
+// SetDeviceFilter sets the device ID filter
+func (m *PacketsModel) SetDeviceFilter(deviceID string) {
+	m.deviceID = deviceID
+}
+
+// formatLocation formats a location for display
 func formatLocation(loc models.Location) string {
 	if loc.Fake {
 		return "Local scan"
@@ -394,6 +1868,187 @@ func formatLocation(loc models.Location) string {
 	return fmt.Sprintf("%.4f, %.4f", loc.Latitude, loc.Longitude)
 }
 
+// formatPayloadKind returns a short badge guessing whether a payload is
+// still encrypted or looks like plaintext, with the heuristics behind it
+func formatPayloadKind(data []byte) string {
+	a := models.AnalyzePayload(data)
+	if a.Kind == models.PayloadKindEmpty {
+		return "[" + a.Kind.Badge() + "]"
+	}
+	return fmt.Sprintf("[%s] entropy %.2f bits/byte, %.0f%% printable", a.Kind.Badge(), a.Entropy, a.PrintableRatio*100)
+}
+
+// formatPayloadHex returns a base64 payload as hex, or unchanged if it
+// isn't valid base64
+func formatPayloadHex(payload string) string {
+	data, err := models.DecodePayload(payload)
+	if err != nil {
+		return payload
+	}
+	return hex.EncodeToString(data)
+}
+
+// payloadText returns the Payload column value for p in the current view.
+// Decrypted payloads are badged "dec"; packets that can't be decrypted
+// fall back to hex.
+func (m PacketsModel) payloadText(p models.RetrievedPacket) string {
+	switch m.payloadView {
+	case PayloadViewHex:
+		return formatPayloadHex(p.Payload())
+	case PayloadViewDecrypted:
+		if plain := m.decrypted[packetKeyOf(p)]; plain != nil {
+			if len(plain) == 0 {
+				return "[dec] (empty)"
+			}
+			return "[dec] " + hex.EncodeToString(plain)
+		}
+		return formatPayloadHex(p.Payload())
+	}
+	return p.Payload()
+}
+
+// payloadTitle returns the Payload column title for the current view
+func (m PacketsModel) payloadTitle() string {
+	switch m.payloadView {
+	case PayloadViewHex:
+		return "Payload (hex)"
+	case PayloadViewDecrypted:
+		return "Payload (decrypted)"
+	}
+	return "Payload"
+}
+
+// nextPayloadViewName names the view the 'v' key switches to
+func (m PacketsModel) nextPayloadViewName() string {
+	switch m.payloadView {
+	case PayloadViewBase64:
+		return "hex"
+	case PayloadViewHex:
+		return "decrypt"
+	}
+	return "base64"
+}
+
+// decryptPayloads decrypts the loaded packets not tried yet while the
+// decrypted view is shown, fetching the device keys first if needed
+func (m *PacketsModel) decryptPayloads() tea.Cmd {
+	if m.payloadView != PayloadViewDecrypted {
+		return nil
+	}
+	if m.keyDevices == nil {
+		if m.keysLoading || m.keysErr != nil || m.client == nil {
+			return nil
+		}
+		m.keysLoading = true
+		return m.loadDeviceKeys()
+	}
+
+	if m.decrypted == nil {
+		m.decrypted = make(map[packetKey][]byte)
+	}
+	var pending []models.RetrievedPacket
+	for _, p := range m.packets {
+		key := packetKeyOf(p)
+		if _, tried := m.decrypted[key]; tried {
+			continue
+		}
+		m.decrypted[key] = nil
+		if _, ok := m.keyDevices[p.DeviceID()]; ok {
+			pending = append(pending, p)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	devices := m.keyDevices
+	return func() tea.Msg {
+		return PacketsDecryptedMsg{Payloads: decryptPackets(pending, devices)}
+	}
+}
+
+// loadDeviceKeys fetches the org's devices for their keys
+func (m PacketsModel) loadDeviceKeys() tea.Cmd {
+	client := m.client
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), common.RequestTimeout())
+		defer cancel()
+
+		devices, err := client.ListDevices(ctx)
+		return PacketsKeysLoadedMsg{Devices: devices, Err: err}
+	}
+}
+
+// keyDevices returns the devices with a usable key by device ID
+func keyDevices(devices []models.Device) map[string]models.Device {
+	usable := make(map[string]models.Device, len(devices))
+	for _, d := range devices {
+		if _, err := crypto.DeviceKey(d); err == nil {
+			usable[d.ID] = d
+		}
+	}
+	return usable
+}
+
+// decryptPackets decrypts the packets a device at a time with its key,
+// returning the plaintext by packet, nil where decryption failed
+func decryptPackets(packets []models.RetrievedPacket, devices map[string]models.Device) map[packetKey][]byte {
+	plaintexts := make(map[packetKey][]byte, len(packets))
+
+	// Packets whose payload can't be decoded fail without being tried
+	byDevice := make(map[string][]models.RetrievedPacket)
+	var order []string
+	for _, p := range packets {
+		plaintexts[packetKeyOf(p)] = nil
+		if _, err := p.PayloadBytes(); err != nil {
+			continue
+		}
+		if _, ok := byDevice[p.DeviceID()]; !ok {
+			order = append(order, p.DeviceID())
+		}
+		byDevice[p.DeviceID()] = append(byDevice[p.DeviceID()], p)
+	}
+
+	for _, id := range order {
+		group := byDevice[id]
+		encrypted := make([]models.EncryptedPacket, len(group))
+		for i, p := range group {
+			encrypted[i], _ = p.ToEncryptedPacket()
+		}
+
+		decrypted, failed, err := crypto.DecryptPacketsForDevice(devices[id], encrypted)
+		if err != nil {
+			continue
+		}
+		skip := make(map[int]bool, len(failed))
+		for _, f := range failed {
+			skip[f.Index] = true
+		}
+		// decrypted holds the packets that didn't fail, in order
+		next := 0
+		for i, p := range group {
+			if skip[i] {
+				continue
+			}
+			// Keep empty plaintext distinct from a failure
+			plaintexts[packetKeyOf(p)] = append([]byte{}, decrypted[next].Payload...)
+			next++
+		}
+	}
+	return plaintexts
+}
+
+// decryptedCount returns how many loaded packets were decrypted
+func (m PacketsModel) decryptedCount() int {
+	count := 0
+	for _, p := range m.packets {
+		if m.decrypted[packetKeyOf(p)] != nil {
+			count++
+		}
+	}
+	return count
+}
+
 // formatRetrievedLocation formats a retrieved packet location for display
 func formatRetrievedLocation(loc models.RetrievedLocation) string {
 	if loc.Latitude == 0 && loc.Longitude == 0 {
@@ -401,3 +2056,25 @@ func formatRetrievedLocation(loc models.RetrievedLocation) string {
 	}
 	return fmt.Sprintf("%.4f, %.4f", loc.Latitude, loc.Longitude)
 }
+
+// formatRetrievedLocationExpanded formats a retrieved packet location with
+// altitude and accuracy, e.g. "37.7749, -122.4194 alt 12m ±10m (v ±5m)".
+// Zero values are omitted since the API reports them for unknown fields.
+func formatRetrievedLocationExpanded(loc models.RetrievedLocation) string {
+	if loc.Latitude == 0 && loc.Longitude == 0 {
+		return "Unknown"
+	}
+
+	parts := []string{fmt.Sprintf("%.4f, %.4f", loc.Latitude, loc.Longitude)}
+	if loc.Altitude != 0 {
+		parts = append(parts, fmt.Sprintf("alt %.0fm", loc.Altitude))
+	}
+	if loc.HorizontalAccuracy != 0 {
+		parts = append(parts, fmt.Sprintf("±%.0fm", loc.HorizontalAccuracy))
+	}
+	if loc.VerticalAccuracy != 0 {
+		parts = append(parts, fmt.Sprintf("(v ±%.0fm)", loc.VerticalAccuracy))
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -1,12 +1,26 @@
 package screens
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hubblenetwork/hubcli/internal/api"
+	"github.com/hubblenetwork/hubcli/internal/clipboard"
+	"github.com/hubblenetwork/hubcli/internal/export"
 	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/hubblenetwork/hubcli/internal/tui/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewPacketsModel(t *testing.T) {
@@ -69,6 +83,32 @@ func TestPacketsModel_PacketsLoadedMsg(t *testing.T) {
 	assert.Equal(t, "device-1", m.packets[0].DeviceID())
 }
 
+func TestPacketsModel_JumpKeys(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	packets := make([]models.RetrievedPacket, 30)
+	for i := range packets {
+		packets[i] = models.RetrievedPacket{Device: models.RetrievedDevice{ID: "device-1", Timestamp: float64(1000 + i)}}
+	}
+	m, _ = m.Update(PacketsLoadedMsg{Packets: packets})
+	page := m.table.Height()
+
+	tests := []struct {
+		key  tea.KeyMsg
+		want int
+	}{
+		{tea.KeyMsg{Type: tea.KeyEnd}, 29},
+		{tea.KeyMsg{Type: tea.KeyPgUp}, 29 - page},
+		{tea.KeyMsg{Type: tea.KeyHome}, 0},
+		{tea.KeyMsg{Type: tea.KeyPgDown}, page},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'G'}}, 29},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}}, 0},
+	}
+	for _, tt := range tests {
+		m, _ = m.Update(tt.key)
+		assert.Equal(t, tt.want, m.table.Cursor(), "after %s", tt.key)
+	}
+}
+
 func TestPacketsModel_PacketsErrorMsg(t *testing.T) {
 	m := NewPacketsModel(nil, "")
 	m.state = PacketsStateLoading
@@ -98,7 +138,7 @@ func TestPacketsModel_QuitKey(t *testing.T) {
 
 	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
 
-	// Should return tea.Quit
+	// Should request a quit
 	assert.NotNil(t, cmd)
 }
 
@@ -145,6 +185,105 @@ func TestPacketsModel_ChangeDays7(t *testing.T) {
 	assert.NotNil(t, cmd)
 }
 
+func TestPacketsModel_ChangeDays30And90(t *testing.T) {
+	for key, days := range map[rune]int{'3': 30, '9': 90} {
+		m := NewPacketsModel(nil, "")
+		m.width = 100
+		m.state = PacketsStateReady
+
+		m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{key}})
+
+		assert.Equal(t, days, m.days)
+		assert.Equal(t, PacketsStateLoading, m.state)
+		assert.NotNil(t, cmd)
+		assert.Equal(t, days, m.retrieveOptions(false).Days)
+		assert.Contains(t, m.View(), fmt.Sprintf("Showing last %d day(s)", days))
+	}
+}
+
+func TestPacketsModel_CustomDateRange(t *testing.T) {
+	common.SetUTC(true)
+	t.Cleanup(func() { common.SetUTC(false) })
+
+	m := NewPacketsModel(nil, "")
+	m.width = 100
+	m.state = PacketsStateReady
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'D'}})
+	assert.NotNil(t, cmd)
+	require.True(t, m.editingRange)
+	assert.Contains(t, m.View(), "Date range:")
+
+	// Invalid input keeps the prompt open
+	m.rangeInput.SetValue("yesterday")
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Nil(t, cmd)
+	assert.True(t, m.editingRange)
+	assert.Contains(t, m.View(), "is not YYYY-MM-DD")
+
+	m.rangeInput.SetValue("2024-01-01 2024-01-31")
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.NotNil(t, cmd)
+	assert.False(t, m.editingRange)
+	assert.Equal(t, PacketsStateLoading, m.state)
+
+	// Both ends of the range go to the API, the end date included
+	opts := m.retrieveOptions(false)
+	require.NotNil(t, opts.Start)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), *opts.Start)
+	require.NotNil(t, opts.End)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), *opts.End)
+	m, _ = m.Update(PacketsLoadedMsg{})
+	assert.Contains(t, m.View(), "Showing 2024-01-01 to 2024-01-31")
+
+	// Picking a number of days drops the custom range
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'7'}})
+	assert.Nil(t, m.retrieveOptions(false).Start)
+	assert.Nil(t, m.rangeEnd)
+}
+
+func TestPacketsModel_CustomDateRange_Cancel(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m.state = PacketsStateReady
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'D'}})
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	// Esc closes the prompt rather than leaving the screen
+	assert.Nil(t, cmd)
+	assert.False(t, m.editingRange)
+	assert.Nil(t, m.rangeStart)
+}
+
+func TestParseDateRange(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	start, end, err := parseDateRange("2024-01-01", time.UTC, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Nil(t, end)
+
+	// The end day is included
+	for _, input := range []string{"2024-01-01 2024-01-31", "2024-01-01 to 2024-01-31"} {
+		start, end, err = parseDateRange(input, time.UTC, now)
+		require.NoError(t, err, input)
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), start, input)
+		require.NotNil(t, end, input)
+		assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), *end, input)
+	}
+
+	// Dates are in the given zone
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	start, _, err = parseDateRange("2024-01-01", loc, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 12, 31, 22, 0, 0, 0, time.UTC), start.UTC())
+
+	for _, input := range []string{"", "01/01/2024", "2024-01-01 soon", "2024-01-31 2024-01-01", "2024-07-01", "2024-01-01 2024-01-02 2024-01-03"} {
+		_, _, err := parseDateRange(input, time.UTC, now)
+		assert.Error(t, err, input)
+	}
+}
+
 func TestPacketsModel_ClearDeviceFilter(t *testing.T) {
 	m := NewPacketsModel(nil, "device-123")
 	m.state = PacketsStateReady
@@ -190,7 +329,7 @@ func TestPacketsModel_View(t *testing.T) {
 
 func TestPacketsModel_ViewWithDeviceFilter(t *testing.T) {
 	m := NewPacketsModel(nil, "device-123")
-	m.width = 80
+	m.width = 100
 	m.height = 24
 	m.state = PacketsStateReady
 
@@ -224,6 +363,19 @@ func TestPacketsModel_ViewError(t *testing.T) {
 	assert.Contains(t, view, "retry")
 }
 
+func TestPacketsModel_ViewRateLimited(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m.width = 80
+	m.height = 24
+	m.state = PacketsStateError
+	m.err = &api.APIError{StatusCode: 429, Message: "rate limit exceeded", RetryAfter: 12 * time.Second}
+
+	view := m.View()
+
+	assert.Contains(t, view, "rate limited, retry in 12s")
+	assert.NotContains(t, view, "API error 429")
+}
+
 func TestPacketsModel_ViewEmpty(t *testing.T) {
 	m := NewPacketsModel(nil, "")
 	m.width = 80
@@ -308,7 +460,7 @@ func TestPacketsModel_PacketsLoadedMsg_NoMorePackets(t *testing.T) {
 
 func TestPacketsModel_ViewWithLoadMore(t *testing.T) {
 	m := NewPacketsModel(nil, "")
-	m.width = 80
+	m.width = 120 // Wide enough that the help line doesn't wrap
 	m.height = 24
 	m.state = PacketsStateReady
 	m.hasMore = true
@@ -352,3 +504,1373 @@ func TestFormatLocation(t *testing.T) {
 		assert.Equal(t, tt.expected, result)
 	}
 }
+
+func TestPacketsModel_Busy(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	assert.True(t, m.Busy()) // Starts loading
+
+	m.state = PacketsStateReady
+	assert.False(t, m.Busy())
+
+	m.loadingMore = true
+	assert.True(t, m.Busy())
+}
+
+func TestFormatPayloadKind(t *testing.T) {
+	assert.Equal(t, "[-]", formatPayloadKind(nil))
+	assert.Equal(t, "[txt] entropy 2.85 bits/byte, 100% printable", formatPayloadKind([]byte("hello world")))
+}
+
+func TestPacketsModel_HistogramToggle(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m.width = 100
+	m.height = 50
+
+	ts := time.Date(2024, 3, 1, 14, 30, 0, 0, time.Local)
+	m, _ = m.Update(PacketsLoadedMsg{Packets: []models.RetrievedPacket{
+		{Device: models.RetrievedDevice{ID: "device-1", Timestamp: float64(ts.Unix())}},
+	}})
+
+	assert.Contains(t, m.View(), "by hour")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	assert.True(t, m.showHistogram)
+
+	view := m.View()
+	assert.Contains(t, view, "Packets by hour of day")
+	assert.Contains(t, view, "14 │ ")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	assert.False(t, m.showHistogram)
+}
+
+func TestPacketsModel_HistogramToggle_NoPackets(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m, _ = m.Update(PacketsLoadedMsg{})
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	assert.False(t, m.showHistogram)
+}
+
+func TestPacketsModel_RetrieveOptions(t *testing.T) {
+	m := NewPacketsModel(nil, "device-1")
+	m.days = 1
+	m.continuationToken = "next"
+
+	opts := m.retrieveOptions(false)
+	require.NotNil(t, opts.DeviceID)
+	assert.Equal(t, "device-1", *opts.DeviceID)
+	assert.Equal(t, 1, opts.Days)
+	assert.Empty(t, opts.ContinuationToken)
+
+	opts = m.retrieveOptions(true)
+	assert.Equal(t, "next", opts.ContinuationToken)
+}
+
+func TestPacketsModel_RetrieveOptions_PageSize(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	assert.Equal(t, 100, m.retrieveOptions(false).Limit)
+
+	// The configured size is used for the first page and for loading more
+	m.SetPageSize(500)
+	m.continuationToken = "next"
+	assert.Equal(t, 500, m.retrieveOptions(false).Limit)
+	opts := m.retrieveOptions(true)
+	assert.Equal(t, 500, opts.Limit)
+	assert.Equal(t, "next", opts.ContinuationToken)
+
+	// Unset sizes keep the current one
+	m.SetPageSize(0)
+	assert.Equal(t, 500, m.PageSize())
+
+	// A device filter loads a page at a time too
+	m.SetDeviceFilter("device-1")
+	opts = m.retrieveOptions(false)
+	assert.Equal(t, 500, opts.Limit)
+	require.NotNil(t, opts.DeviceID)
+	assert.Equal(t, "device-1", *opts.DeviceID)
+}
+
+func TestPacketsModel_PageSizeKey(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m.width = 160
+	m.state = PacketsStateReady
+	assert.Contains(t, m.View(), "page size (100)")
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'L'}})
+	require.NotNil(t, cmd)
+	assert.Equal(t, PacketsPageSizeSetMsg{Size: 250}, cmd())
+	assert.Equal(t, 250, m.retrieveOptions(true).Limit)
+	assert.Contains(t, m.View(), "Loading 250 packets at a time")
+
+	// Wraps from the largest to the smallest
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'L'}})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'L'}})
+	assert.Equal(t, 25, m.PageSize())
+}
+
+func TestNextPacketPageSize(t *testing.T) {
+	assert.Equal(t, 50, nextPacketPageSize(25))
+	assert.Equal(t, 25, nextPacketPageSize(500))
+	assert.Equal(t, 250, nextPacketPageSize(120))
+	assert.Equal(t, 25, nextPacketPageSize(1000))
+}
+
+func TestPacketsModel_CopyURLKey(t *testing.T) {
+	m := NewPacketsModel(api.NewClient("test-org", "test-token"), "device-1")
+	m.width = 100
+
+	assert.Contains(t, m.View(), "copy API URL")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}})
+	assert.NotNil(t, cmd)
+
+	m, _ = m.Update(common.ClipboardCopiedMsg{Label: "packets API URL"})
+	assert.Contains(t, m.View(), "Copied packets API URL")
+}
+
+func TestPacketsModel_CopyMapLinkKey(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m.width = 120
+	m.height = 40
+
+	packets := []models.RetrievedPacket{
+		{
+			Device:   models.RetrievedDevice{ID: "device-1", Timestamp: float64(time.Now().Unix())},
+			Location: models.RetrievedLocation{Latitude: 37.7749, Longitude: -122.4194},
+		},
+	}
+	m, _ = m.Update(PacketsLoadedMsg{Packets: packets})
+	assert.Contains(t, m.View(), "copy map link")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	assert.NotNil(t, cmd)
+
+	m, _ = m.Update(common.ClipboardCopiedMsg{Label: "map link"})
+	assert.Contains(t, m.View(), "Copied map link")
+}
+
+func TestPacketsModel_CopyMapLinkKey_NoLocation(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m.width = 120
+	m.height = 40
+
+	packets := []models.RetrievedPacket{
+		{Device: models.RetrievedDevice{ID: "device-1", Timestamp: float64(time.Now().Unix())}},
+	}
+	m, _ = m.Update(PacketsLoadedMsg{Packets: packets})
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	assert.Nil(t, cmd)
+	assert.Equal(t, "Selected packet has no location", m.notice)
+}
+
+func TestPacketsModel_PacketsLoadedMsg_Partial(t *testing.T) {
+	m := NewPacketsModel(nil, "device-1")
+	m.width = 100
+	m.height = 40
+
+	packets := []models.RetrievedPacket{
+		{Device: models.RetrievedDevice{ID: "device-1", Timestamp: float64(time.Now().Unix())}},
+	}
+	m, _ = m.Update(PacketsLoadedMsg{Packets: packets, ContinuationToken: "page3", Err: assert.AnError})
+
+	assert.Equal(t, PacketsStateReady, m.state)
+	assert.Len(t, m.packets, 1)
+	assert.True(t, m.hasMore)
+	assert.Equal(t, "page3", m.continuationToken)
+
+	view := m.View()
+	assert.Contains(t, view, "Retrieval stopped early")
+	assert.Contains(t, view, "resume")
+
+	// A successful resume clears the warning
+	m, _ = m.Update(PacketsLoadedMsg{Packets: packets, Append: true})
+	assert.Nil(t, m.partialErr)
+	assert.Len(t, m.packets, 2)
+}
+
+func TestLatestPacketIndex(t *testing.T) {
+	packets := []models.RetrievedPacket{
+		{Device: models.RetrievedDevice{Timestamp: 100}},
+		{Device: models.RetrievedDevice{Timestamp: 300}},
+		{Device: models.RetrievedDevice{Timestamp: 200}},
+	}
+
+	assert.Equal(t, 1, latestPacketIndex(packets))
+	assert.Equal(t, -1, latestPacketIndex(nil))
+}
+
+func TestPacketsModel_JumpToLatest(t *testing.T) {
+	m := NewPacketsModel(nil, "device-1")
+	m.height = 40
+	m.table.SetHeight(20)
+	m.SetSortAscending(true) // Newest packet last, away from the cursor
+
+	m, _ = m.Update(PacketsLoadedMsg{Packets: []models.RetrievedPacket{
+		{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 100}},
+		{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 200}},
+		{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 300}},
+	}})
+	require.Equal(t, 0, m.table.Cursor())
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	assert.Nil(t, cmd)
+	assert.Equal(t, 2, m.table.Cursor())
+}
+
+func TestPacketsModel_JumpToLatest_LoadsRemainingPages(t *testing.T) {
+	m := NewPacketsModel(nil, "device-1")
+	m.height = 40
+	m.table.SetHeight(20)
+	m.SetSortAscending(true) // Newest packet last, away from the cursor
+
+	m, _ = m.Update(PacketsLoadedMsg{
+		Packets:           []models.RetrievedPacket{{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 100}}},
+		ContinuationToken: "page2",
+	})
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	require.NotNil(t, cmd)
+	assert.True(t, m.jumpToLatest)
+	assert.True(t, m.loadingMore)
+
+	// Another page is still pending, so loading continues
+	m, cmd = m.Update(PacketsLoadedMsg{
+		Packets:           []models.RetrievedPacket{{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 300}}},
+		ContinuationToken: "page3",
+		Append:            true,
+	})
+	assert.NotNil(t, cmd)
+	assert.True(t, m.jumpToLatest)
+
+	m, _ = m.Update(PacketsLoadedMsg{
+		Packets: []models.RetrievedPacket{{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 200}}},
+		Append:  true,
+	})
+	assert.False(t, m.jumpToLatest)
+	assert.Equal(t, 2, m.table.Cursor()) // Pages are merged in timestamp order
+}
+
+func TestPacketsModel_JumpToLatest_RequiresDeviceFilter(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m, _ = m.Update(PacketsLoadedMsg{Packets: []models.RetrievedPacket{{}}})
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	assert.Nil(t, cmd)
+}
+
+func TestFormatRetrievedLocationExpanded(t *testing.T) {
+	tests := []struct {
+		name     string
+		loc      models.RetrievedLocation
+		expected string
+	}{
+		{"unknown", models.RetrievedLocation{}, "Unknown"},
+		{"lat/lon only", models.RetrievedLocation{Latitude: 37.7749, Longitude: -122.4194}, "37.7749, -122.4194"},
+		{
+			"full",
+			models.RetrievedLocation{Latitude: 37.7749, Longitude: -122.4194, Altitude: 12.4, HorizontalAccuracy: 10, VerticalAccuracy: 5},
+			"37.7749, -122.4194 alt 12m ±10m (v ±5m)",
+		},
+		{"accuracy only", models.RetrievedLocation{Latitude: 1, Longitude: 2, HorizontalAccuracy: 25}, "1.0000, 2.0000 ±25m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatRetrievedLocationExpanded(tt.loc))
+		})
+	}
+}
+
+func TestPacketsModel_LocationColumnWidth(t *testing.T) {
+	packets := []models.RetrievedPacket{{
+		Location: models.RetrievedLocation{Latitude: 37.7749, Longitude: -122.4194, Altitude: 12, HorizontalAccuracy: 10},
+		Device:   models.RetrievedDevice{ID: "device-1"},
+	}}
+
+	wide := NewPacketsModel(nil, "")
+	wide.width = 200
+	wide, _ = wide.Update(PacketsLoadedMsg{Packets: packets})
+	assert.Equal(t, "37.7749, -122.4194 alt 12m ±10m", wide.table.Rows()[0][2])
+
+	narrow := NewPacketsModel(nil, "")
+	narrow.width = 60
+	narrow, _ = narrow.Update(PacketsLoadedMsg{Packets: packets})
+	assert.Equal(t, "37.7749, -122.4194", narrow.table.Rows()[0][2])
+}
+
+func TestPacketsModel_SequenceGaps(t *testing.T) {
+	m := NewPacketsModel(nil, "device-1")
+	m.width = 120
+	m.height = 40
+
+	// Loaded out of time order; sorted by time the sequence is 1022, 1023, 2, 3
+	m, _ = m.Update(PacketsLoadedMsg{Packets: []models.RetrievedPacket{
+		{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 300, SequenceNumber: 2}},
+		{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 100, SequenceNumber: 1022}},
+		{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 400, SequenceNumber: 3}},
+		{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 200, SequenceNumber: 1023}},
+	}})
+
+	gaps := m.gaps
+	require.Len(t, gaps, 1)
+	assert.Equal(t, 2, gaps[0].Missing.Len())
+
+	assert.Contains(t, m.View(), "2 packets missing: seq 0–1")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'S'}})
+	assert.True(t, m.showGaps)
+	assert.Contains(t, m.View(), "seq 1023 → 2")
+}
+
+func TestPacketsModel_SequenceGaps_LateArrival(t *testing.T) {
+	m := NewPacketsModel(nil, "device-1")
+	m.width = 120
+	m.height = 40
+
+	// Sorted by time the sequence is 5, 7, 6, 8: the late packet fills the gap
+	m, _ = m.Update(PacketsLoadedMsg{Packets: []models.RetrievedPacket{
+		{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 100, SequenceNumber: 5}},
+		{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 200, SequenceNumber: 7}},
+		{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 300, SequenceNumber: 6}},
+		{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 400, SequenceNumber: 8}},
+	}})
+	assert.Contains(t, m.View(), "Sequence: no gaps")
+
+	// The gap list agrees with the summary
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'S'}})
+	assert.Contains(t, m.View(), "No sequence number gaps")
+}
+
+func TestPacketsModel_SequenceGaps_RequiresDeviceFilter(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m, _ = m.Update(PacketsLoadedMsg{Packets: []models.RetrievedPacket{{}, {}}})
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'S'}})
+	assert.False(t, m.showGaps)
+	assert.NotContains(t, m.View(), "Sequence:")
+}
+
+func TestPacketsModel_TableFitsWindow(t *testing.T) {
+	now := time.Now()
+	packets := make([]models.RetrievedPacket, 100)
+	for i := range packets {
+		packets[i] = models.RetrievedPacket{
+			Device: models.RetrievedDevice{
+				ID:             "device-1",
+				Timestamp:      float64(now.Add(time.Duration(i) * time.Minute).Unix()),
+				SequenceNumber: i,
+			},
+		}
+	}
+
+	for _, deviceID := range []string{"", "device-1"} {
+		m := NewPacketsModel(nil, deviceID)
+		m, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+		m, _ = m.Update(PacketsLoadedMsg{Packets: packets, ContinuationToken: "more"})
+		assert.Equal(t, 40, lipgloss.Height(m.View()), "device filter %q", deviceID)
+
+		// A warning line above the table shrinks it rather than clipping
+		m, _ = m.Update(PacketsLoadedMsg{Packets: packets, Err: errors.New("timeout")})
+		assert.Equal(t, 40, lipgloss.Height(m.View()), "device filter %q", deviceID)
+	}
+}
+
+func TestPacketsModel_SortDirection(t *testing.T) {
+	packets := []models.RetrievedPacket{
+		{Device: models.RetrievedDevice{ID: "b", Timestamp: 200}},
+		{Device: models.RetrievedDevice{ID: "a", Timestamp: 100}},
+		{Device: models.RetrievedDevice{ID: "c", Timestamp: 300}},
+	}
+	ids := func(m PacketsModel) []string {
+		var out []string
+		for _, p := range m.packets {
+			out = append(out, p.Device.ID)
+		}
+		return out
+	}
+
+	// Newest first by default
+	m := NewPacketsModel(nil, "")
+	m, _ = m.Update(PacketsLoadedMsg{Packets: append([]models.RetrievedPacket(nil), packets...)})
+	assert.Equal(t, []string{"c", "b", "a"}, ids(m))
+
+	// Configured direction applies to the initial load
+	m = NewPacketsModel(nil, "")
+	m.SetSortAscending(true)
+	m, _ = m.Update(PacketsLoadedMsg{Packets: append([]models.RetrievedPacket(nil), packets...)})
+	assert.Equal(t, []string{"a", "b", "c"}, ids(m))
+	assert.Equal(t, "[Timestamp ↑]", m.table.Columns()[1].Title)
+
+	// 'o' flips it
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	assert.Equal(t, []string{"c", "b", "a"}, ids(m))
+	assert.Equal(t, "[Timestamp ↓]", m.table.Columns()[1].Title)
+}
+
+func TestPacketsModel_ColumnSort(t *testing.T) {
+	packets := []models.RetrievedPacket{
+		{
+			Device:   models.RetrievedDevice{ID: "b", Payload: "CCC", Timestamp: 200},
+			Location: models.RetrievedLocation{Latitude: 10, Longitude: 5},
+		},
+		{
+			Device:   models.RetrievedDevice{ID: "a", Payload: "AAA", Timestamp: 100},
+			Location: models.RetrievedLocation{Latitude: 30, Longitude: 1},
+		},
+		{
+			Device:   models.RetrievedDevice{ID: "c", Payload: "BBB", Timestamp: 300},
+			Location: models.RetrievedLocation{Latitude: 10, Longitude: 2},
+		},
+	}
+	ids := func(m PacketsModel) []string {
+		var out []string
+		for _, p := range m.filteredPackets {
+			out = append(out, p.Device.ID)
+		}
+		return out
+	}
+	press := func(m PacketsModel, k tea.KeyMsg) PacketsModel {
+		m, _ = m.Update(k)
+		return m
+	}
+	left := tea.KeyMsg{Type: tea.KeyLeft}
+	right := tea.KeyMsg{Type: tea.KeyRight}
+	sortKey := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}}
+
+	m := NewPacketsModel(nil, "")
+	m.width = 160
+	m.height = 40
+	m, _ = m.Update(PacketsLoadedMsg{Packets: packets})
+	assert.Contains(t, m.View(), "select column")
+
+	// Device ID sorts ascending first, and again flips it
+	m = press(m, left)
+	assert.Equal(t, "[Device ID]", m.table.Columns()[0].Title)
+	m = press(m, sortKey)
+	assert.Equal(t, []string{"a", "b", "c"}, ids(m))
+	assert.Equal(t, "[Device ID ↑]", m.table.Columns()[0].Title)
+	assert.Equal(t, "Timestamp", m.table.Columns()[1].Title)
+	m = press(m, sortKey)
+	assert.Equal(t, []string{"c", "b", "a"}, ids(m))
+
+	// Location sorts by latitude, then longitude
+	m = press(press(m, right), right)
+	m = press(m, sortKey)
+	assert.Equal(t, []string{"c", "b", "a"}, ids(m))
+	assert.Equal(t, "[Location ↑]", m.table.Columns()[2].Title)
+
+	// Payload
+	m = press(press(m, right), sortKey)
+	assert.Equal(t, []string{"a", "c", "b"}, ids(m))
+
+	// Timestamp starts newest first; selection wraps around
+	m = press(press(press(m, right), right), sortKey)
+	assert.Equal(t, []string{"c", "b", "a"}, ids(m))
+	assert.Equal(t, "[Timestamp ↓]", m.table.Columns()[1].Title)
+}
+
+func TestPacketSortTimestamp(t *testing.T) {
+	// Device time wins, location time fills in when it is missing
+	assert.Equal(t, 200.0, packetSortTimestamp(models.RetrievedPacket{
+		Device:   models.RetrievedDevice{Timestamp: 200},
+		Location: models.RetrievedLocation{Timestamp: 100},
+	}))
+	assert.Equal(t, 100.0, packetSortTimestamp(models.RetrievedPacket{
+		Location: models.RetrievedLocation{Timestamp: 100},
+	}))
+}
+
+func TestPacketsModel_ExportKey(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m.width = 120
+	m.height = 40
+	m, _ = m.Update(PacketsLoadedMsg{Packets: []models.RetrievedPacket{{Device: models.RetrievedDevice{ID: "device-1"}}}})
+
+	// x asks for a format, which then starts the export
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	assert.Nil(t, cmd)
+	assert.Contains(t, m.View(), "NDJSON")
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	assert.NotNil(t, cmd)
+	assert.False(t, m.exportPrompt)
+
+	m, _ = m.Update(PacketsExportedMsg{Path: "packets.csv", Count: 1})
+	assert.Contains(t, m.View(), "Exported 1 packet(s) to packets.csv")
+
+	m, _ = m.Update(PacketsExportedMsg{Err: errors.New("disk full")})
+	assert.Contains(t, m.View(), "Export failed: disk full")
+}
+
+func TestPacketsModel_ExportKey_NoPackets(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m, _ = m.Update(PacketsLoadedMsg{})
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	assert.Nil(t, cmd)
+}
+
+func TestPacketsModel_ExportCancel(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m, _ = m.Update(PacketsLoadedMsg{Packets: []models.RetrievedPacket{{Device: models.RetrievedDevice{ID: "device-1"}}}})
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	require.True(t, m.exportPrompt)
+
+	// Esc cancels the export rather than leaving the screen
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Nil(t, cmd)
+	assert.False(t, m.exportPrompt)
+}
+
+func TestExportPackets_WritesFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	packets := []models.RetrievedPacket{{Device: models.RetrievedDevice{ID: "device-1", Payload: "AQID"}}}
+
+	msg := exportPackets(packets, export.FormatNDJSON)()
+
+	exported, ok := msg.(PacketsExportedMsg)
+	require.True(t, ok)
+	require.NoError(t, exported.Err)
+	assert.Equal(t, 1, exported.Count)
+	assert.True(t, strings.HasSuffix(exported.Path, ".ndjson"))
+
+	data, err := os.ReadFile(exported.Path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"device_id":"device-1"`)
+}
+
+func TestPacketsModel_CopyPayloadKey(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m.width = 120
+	m.height = 40
+
+	packets := []models.RetrievedPacket{
+		{Device: models.RetrievedDevice{ID: "device-1", Payload: "AQID", Timestamp: float64(time.Now().Unix())}},
+	}
+	m, _ = m.Update(PacketsLoadedMsg{Packets: packets})
+	assert.Contains(t, m.View(), "copy payload")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	assert.NotNil(t, cmd)
+
+	// The confirmation is flashed, then clears itself
+	m, cmd = m.Update(common.ClipboardCopiedMsg{Label: "payload hex"})
+	assert.NotNil(t, cmd)
+	assert.Contains(t, m.View(), "Copied payload hex")
+	m, _ = m.Update(common.FlashExpiredMsg{ID: m.copied.ID()})
+	assert.NotContains(t, m.View(), "Copied payload hex")
+}
+
+func TestPacketsModel_CopyPayloadKey_Headless(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m.width = 120
+	m.height = 40
+
+	m, _ = m.Update(common.ClipboardCopiedMsg{Label: "payload hex", Err: clipboard.ErrUnavailable})
+	assert.Contains(t, m.View(), "Copy failed: clipboard not available")
+}
+
+func TestPacketMatchesFilter(t *testing.T) {
+	p := models.RetrievedPacket{
+		Device:   models.RetrievedDevice{ID: "Device-ABC", Payload: "AQID"},
+		Location: models.RetrievedLocation{Latitude: 37.7749, Longitude: -122.4194},
+	}
+
+	// Empty filter matches everything
+	assert.True(t, packetMatchesFilter(p, ""))
+	assert.True(t, packetMatchesFilter(p, "  "))
+
+	// Device ID, location and payload all match, ignoring case
+	assert.True(t, packetMatchesFilter(p, "device-abc"))
+	assert.True(t, packetMatchesFilter(p, "DEVICE"))
+	assert.True(t, packetMatchesFilter(p, "37.7749"))
+	assert.True(t, packetMatchesFilter(p, "-122.41"))
+	assert.True(t, packetMatchesFilter(p, "aqid"))
+
+	assert.False(t, packetMatchesFilter(p, "device-xyz"))
+	assert.False(t, packetMatchesFilter(p, "unknown"))
+}
+
+func TestPacketsModel_Filter(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m.width = 120
+	m.height = 40
+	packets := []models.RetrievedPacket{
+		{Device: models.RetrievedDevice{ID: "alpha", Payload: "AQID", Timestamp: 300}},
+		{Device: models.RetrievedDevice{ID: "beta", Payload: "BAUG", Timestamp: 200}},
+		{Device: models.RetrievedDevice{ID: "alphabet", Payload: "BwgJ", Timestamp: 100}},
+	}
+	m, _ = m.Update(PacketsLoadedMsg{Packets: packets})
+	assert.Contains(t, m.View(), "3 of 3 packet(s)")
+
+	// '/' opens the filter, which applies as the user types
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	assert.NotNil(t, cmd)
+	require.True(t, m.filterActive)
+	for _, r := range "ALPHA" {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	assert.Len(t, m.filteredPackets, 2)
+	assert.Len(t, m.table.Rows(), 2)
+	assert.Contains(t, m.View(), "2 of 3 packet(s)")
+
+	// Enter keeps the filter, and row keys act on the filtered packets
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, m.filterActive)
+	assert.Equal(t, "ALPHA", m.filterText)
+	m.table.SetCursor(1)
+	assert.Equal(t, "alphabet", m.filteredPackets[m.table.Cursor()].DeviceID())
+
+	// Esc clears the filter before leaving the screen
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Nil(t, cmd)
+	assert.Empty(t, m.filterText)
+	assert.Len(t, m.filteredPackets, 3)
+	assert.Contains(t, m.View(), "3 of 3 packet(s)")
+}
+
+func TestPacketsModel_Filter_NewPagesAreFiltered(t *testing.T) {
+	m := NewPacketsModel(nil, "")
+	m, _ = m.Update(PacketsLoadedMsg{Packets: []models.RetrievedPacket{{Device: models.RetrievedDevice{ID: "alpha"}}}})
+	m.filterText = "beta"
+	m, _ = m.Update(PacketsLoadedMsg{Packets: []models.RetrievedPacket{{Device: models.RetrievedDevice{ID: "beta"}}}, Append: true})
+
+	assert.Len(t, m.packets, 2)
+	require.Len(t, m.filteredPackets, 1)
+	assert.Equal(t, "beta", m.filteredPackets[0].DeviceID())
+}
+
+// newPacketsPagesServer serves one page per entry of pages, each holding a
+// packet per device ID, linked by continuation tokens
+func newPacketsPagesServer(pages ...[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := 0
+		fmt.Sscanf(r.Header.Get("Continuation-Token"), "page%d", &n)
+		if n >= len(pages) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if n+1 < len(pages) {
+			w.Header().Set("Continuation-Token", fmt.Sprintf("page%d", n+1))
+		}
+		var b strings.Builder
+		b.WriteString(`{"packets": [`)
+		for i, id := range pages[n] {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, `{"device": {"id": %q, "timestamp": %d}}`, id, 1000*(n+1)+i)
+		}
+		b.WriteString("]}")
+		w.Write([]byte(b.String()))
+	}))
+}
+
+func TestPacketsModel_LoadPackets(t *testing.T) {
+	packet := func(id string) models.RetrievedPacket {
+		return models.RetrievedPacket{Device: models.RetrievedDevice{ID: id, Timestamp: 1000}}
+	}
+
+	tests := []struct {
+		name        string
+		pages       []api.PacketsPage
+		err         error
+		wantState   PacketsState
+		wantPackets int
+		wantErr     error // Error shown instead of the packets
+		wantPartial error // Error shown with the packets that arrived
+		wantHasMore bool
+	}{
+		{
+			name: "all pages",
+			pages: []api.PacketsPage{
+				{Packets: []models.RetrievedPacket{packet("dev-1"), packet("dev-2")}, ContinuationToken: "page1"},
+				{Packets: []models.RetrievedPacket{packet("dev-3")}},
+			},
+			wantState:   PacketsStateReady,
+			wantPackets: 3,
+		},
+		{
+			name:      "no packets",
+			pages:     []api.PacketsPage{{}},
+			wantState: PacketsStateReady,
+		},
+		{
+			name:      "first page fails",
+			err:       api.ErrServerError,
+			wantState: PacketsStateError,
+			wantErr:   api.ErrServerError,
+		},
+		{
+			name: "later page fails",
+			pages: []api.PacketsPage{
+				{Packets: []models.RetrievedPacket{packet("dev-1")}, ContinuationToken: "page1"},
+			},
+			err:         api.ErrRateLimited,
+			wantState:   PacketsStateReady,
+			wantPackets: 1,
+			wantPartial: api.ErrRateLimited,
+			wantHasMore: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotOpts api.RetrievePacketsOptions
+			m := NewPacketsModel(&stubClient{
+				retrievePacketsPages: func(ctx context.Context, opts api.RetrievePacketsOptions) (<-chan api.PacketsPage, <-chan error) {
+					gotOpts = opts
+					return stubPages(tt.pages, tt.err)
+				},
+			}, "device-1")
+
+			started, ok := m.loadPackets(false)().(PacketsStreamStartedMsg)
+			require.True(t, ok)
+			require.NotNil(t, gotOpts.DeviceID)
+			assert.Equal(t, "device-1", *gotOpts.DeviceID)
+
+			// Read the stream until it ends
+			m, _ = m.Update(started)
+			for m.stream != nil {
+				m, _ = m.Update(waitForPacketsPage(m.stream, m.streamErrs)())
+			}
+
+			assert.Equal(t, tt.wantState, m.state)
+			assert.Len(t, m.packets, tt.wantPackets)
+			assert.Equal(t, tt.wantHasMore, m.hasMore)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, m.err, tt.wantErr)
+			}
+			if tt.wantPartial != nil {
+				assert.ErrorIs(t, m.partialErr, tt.wantPartial)
+			} else {
+				assert.NoError(t, m.partialErr)
+			}
+		})
+	}
+}
+
+func TestPacketsModel_LoadMoreWithDeviceFilter(t *testing.T) {
+	packet := func(ts float64) models.RetrievedPacket {
+		return models.RetrievedPacket{Device: models.RetrievedDevice{ID: "device-1", Timestamp: ts}}
+	}
+	// Pages by the continuation token that requests them
+	pages := map[string]api.PacketsPage{
+		"":      {Packets: []models.RetrievedPacket{packet(3000), packet(2000)}, ContinuationToken: "page1"},
+		"page1": {Packets: []models.RetrievedPacket{packet(1000)}},
+	}
+
+	var requests []api.RetrievePacketsOptions
+	m := NewPacketsModel(&stubClient{
+		retrievePacketsPages: func(ctx context.Context, opts api.RetrievePacketsOptions) (<-chan api.PacketsPage, <-chan error) {
+			requests = append(requests, opts)
+			return stubPages([]api.PacketsPage{pages[opts.ContinuationToken]}, nil)
+		},
+	}, "device-1")
+	m.SetPageSize(25)
+	m.width = 120
+	m.height = 40
+
+	// readStream applies a started load and every page it sends
+	readStream := func(m PacketsModel, msg tea.Msg) PacketsModel {
+		m, _ = m.Update(msg)
+		for m.stream != nil {
+			m, _ = m.Update(waitForPacketsPage(m.stream, m.streamErrs)())
+		}
+		return m
+	}
+
+	m = readStream(m, m.startLoad(false)())
+	assert.Len(t, m.packets, 2)
+	assert.True(t, m.hasMore)
+	assert.Contains(t, m.View(), "(more available)")
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+	require.NotNil(t, cmd)
+	m = readStream(m, m.loadPackets(true)())
+	assert.Len(t, m.packets, 3)
+	assert.False(t, m.hasMore)
+
+	// Both pages are requested for the device, a page at a time
+	require.Len(t, requests, 2)
+	for _, opts := range requests {
+		require.NotNil(t, opts.DeviceID)
+		assert.Equal(t, "device-1", *opts.DeviceID)
+		assert.Equal(t, 25, opts.Limit)
+	}
+	assert.Empty(t, requests[0].ContinuationToken)
+	assert.Equal(t, "page1", requests[1].ContinuationToken)
+}
+
+func TestPacketsModel_LoadMoreKeepsEveryPacket(t *testing.T) {
+	// loadAll loads the device's packets with the 'm' key until none are left
+	loadAll := func(t *testing.T, client api.APIClient, deviceID string) PacketsModel {
+		m := NewPacketsModel(client, deviceID)
+		m.SetPageSize(25)
+		m.SetDays(3)
+		msg := m.startLoad(false)()
+		for {
+			m, _ = m.Update(msg)
+			for m.stream != nil {
+				m, _ = m.Update(waitForPacketsPage(m.stream, m.streamErrs)())
+			}
+			require.Equal(t, PacketsStateReady, m.state)
+			require.NoError(t, m.partialErr)
+			if !m.hasMore {
+				return m
+			}
+			m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+			msg = m.loadPackets(true)()
+		}
+	}
+
+	// assertAll checks want distinct packets were loaded, none of them twice
+	assertAll := func(t *testing.T, m PacketsModel, want int) {
+		seen := make(map[float64]int)
+		for _, p := range m.packets {
+			seen[p.Device.Timestamp]++
+		}
+		assert.Len(t, m.packets, want)
+		assert.Len(t, seen, want)
+	}
+
+	t.Run("server ignoring the limit", func(t *testing.T) {
+		// Server pages of 40 don't line up with the 25 asked for
+		ids := make([]string, 40)
+		for i := range ids {
+			ids[i] = "device-1"
+		}
+		server := newPacketsPagesServer(ids, ids, ids)
+		defer server.Close()
+
+		m := loadAll(t, api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)), "device-1")
+		assertAll(t, m, 120)
+	})
+
+	t.Run("server ending pages at the limit", func(t *testing.T) {
+		client := api.NewFakeClient(time.Now())
+		devices, err := client.ListDevices(context.Background())
+		require.NoError(t, err)
+		deviceID := devices[0].ID
+		all, err := client.RetrievePacketsWithPagination(context.Background(), api.RetrievePacketsOptions{DeviceID: &deviceID, Days: 3})
+		require.NoError(t, err)
+		require.Greater(t, len(all.Packets), 100) // More than one server page
+
+		assertAll(t, loadAll(t, client, deviceID), len(all.Packets))
+	})
+}
+
+func TestPacketsModel_LoadPacketsNoClient(t *testing.T) {
+	msg, ok := NewPacketsModel(nil, "").loadPackets(false)().(PacketsErrorMsg)
+	require.True(t, ok)
+	assert.ErrorContains(t, msg.Err, "no API client")
+}
+
+func TestPacketsModel_StreamsPages(t *testing.T) {
+	server := newPacketsPagesServer([]string{"dev-1", "dev-2"}, []string{"dev-3"})
+	defer server.Close()
+
+	m := NewPacketsModel(api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)), "device-1")
+	m.width = 120
+	m.height = 40
+
+	m, cmd := m.Update(m.loadPackets(false)())
+	require.NotNil(t, m.stream)
+
+	// The first page is shown while the second is still loading
+	m, cmd = m.Update(cmd())
+	assert.Equal(t, PacketsStateReady, m.state)
+	assert.Len(t, m.table.Rows(), 2)
+	assert.True(t, m.Busy())
+	assert.Contains(t, m.View(), "loading more... fetched 2 packet(s) so far (page 1)")
+
+	m, cmd = m.Update(cmd())
+	assert.Len(t, m.table.Rows(), 3)
+	assert.Contains(t, m.View(), "fetched 3 packet(s) so far (page 2)")
+
+	// The end of the stream settles the state
+	done := cmd()
+	assert.Equal(t, PacketsStreamDoneMsg{Pages: m.stream}, done)
+	m, _ = m.Update(done)
+	assert.False(t, m.Busy())
+	assert.False(t, m.hasMore)
+	assert.Nil(t, m.stream)
+	assert.Len(t, m.packets, 3)
+}
+
+func TestPacketsModel_StreamFailsAfterPages(t *testing.T) {
+	// The second page fails
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Continuation-Token") == "" {
+			w.Header().Set("Continuation-Token", "page1")
+			w.Write([]byte(`{"packets": [{"device": {"id": "dev-1"}}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	m := NewPacketsModel(api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)), "device-1")
+	m, cmd := m.Update(m.loadPackets(false)())
+	m, cmd = m.Update(cmd())
+	m, _ = m.Update(cmd())
+
+	// The packets that arrived are kept and can be resumed from
+	assert.Equal(t, PacketsStateReady, m.state)
+	assert.Len(t, m.packets, 1)
+	assert.ErrorIs(t, m.partialErr, api.ErrBadRequest)
+	assert.True(t, m.hasMore)
+	assert.Equal(t, "page1", m.continuationToken)
+}
+
+func TestPacketsModel_CancelStream(t *testing.T) {
+	// The second page never arrives
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Continuation-Token") == "" {
+			w.Header().Set("Continuation-Token", "page1")
+			w.Write([]byte(`{"packets": [{"device": {"id": "dev-1"}}]}`))
+			return
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	m := NewPacketsModel(api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)), "device-1")
+	m, cmd := m.Update(m.loadPackets(false)())
+	m, cmd = m.Update(cmd())
+	require.True(t, m.loadingMore)
+	assert.Contains(t, m.renderHelp(), "esc cancel loading")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	// The packets that arrived are kept and can be resumed from
+	assert.Equal(t, PacketsStateReady, m.state)
+	assert.False(t, m.Busy())
+	assert.Nil(t, m.stream)
+	assert.Len(t, m.packets, 1)
+	assert.True(t, m.hasMore)
+	assert.ErrorIs(t, m.partialErr, common.ErrCancelled)
+
+	// The stream ends without an error once cancelled, and is ignored
+	m, _ = m.Update(cmd())
+	assert.Len(t, m.packets, 1)
+	assert.ErrorIs(t, m.partialErr, common.ErrCancelled)
+}
+
+func TestPacketsModel_CancelFirstLoad(t *testing.T) {
+	server := newHangingServer()
+	defer server.Close()
+
+	m := NewPacketsModel(api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)), "")
+	started := m.loadPackets(false)()
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Nil(t, cmd)
+	assert.Equal(t, PacketsStateError, m.state)
+	assert.ErrorIs(t, m.err, common.ErrCancelled)
+
+	// A retrieval that starts after the cancel is stopped straight away
+	m, cmd = m.Update(started)
+	assert.Nil(t, cmd)
+	assert.Nil(t, m.stream)
+	assert.Equal(t, PacketsStateError, m.state)
+}
+
+func TestPacketsModel_StaleStreamIgnored(t *testing.T) {
+	server := newPacketsPagesServer([]string{"dev-1"})
+	defer server.Close()
+
+	m := NewPacketsModel(api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)), "")
+	stale := m.loadPackets(false)().(PacketsStreamStartedMsg)
+
+	// A refresh before the first retrieval started supersedes it
+	m.state = PacketsStateReady
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m, cmd := m.Update(stale)
+	assert.Nil(t, cmd)
+	assert.Nil(t, m.stream)
+
+	// Pages from a stream that was replaced are dropped
+	m, _ = m.Update(PacketsPageMsg{Pages: stale.Pages, Page: api.PacketsPage{Packets: []models.RetrievedPacket{{}}}})
+	assert.Empty(t, m.packets)
+	assert.Equal(t, PacketsStateLoading, m.state)
+}
+
+func TestPacketsModel_PayloadViews(t *testing.T) {
+	key := make([]byte, 16)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	encrypted := encryptedScanPacket(t, key, 20000, []byte{0xca, 0xfe})
+	packet := func(deviceID string, payload []byte) models.RetrievedPacket {
+		return models.RetrievedPacket{Device: models.RetrievedDevice{
+			ID:        deviceID,
+			Payload:   base64.StdEncoding.EncodeToString(payload),
+			Timestamp: float64(encrypted.Timestamp.Unix()),
+		}}
+	}
+
+	m := NewPacketsModel(api.NewClient("test-org", "test-token"), "")
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	m, _ = m.Update(PacketsLoadedMsg{Packets: []models.RetrievedPacket{
+		packet("dev-1", encrypted.Payload),
+		packet("dev-2", []byte{0x01, 0x02}),
+	}})
+	payloadCell := func(deviceID string) string {
+		for _, row := range m.table.Rows() {
+			if row[0] == deviceID {
+				return row[3]
+			}
+		}
+		return ""
+	}
+	assert.Contains(t, m.renderHelp(), "v hex")
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	assert.Nil(t, cmd)
+	assert.Equal(t, "0102", payloadCell("dev-2"))
+	assert.Contains(t, m.table.Columns()[3].Title, "Payload (hex)")
+
+	// The decrypted view fetches device keys first
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	require.NotNil(t, cmd)
+	assert.Contains(t, m.View(), "Loading device keys...")
+
+	m, cmd = m.Update(PacketsKeysLoadedMsg{Devices: []models.Device{{
+		ID:         "dev-1",
+		Key:        base64.StdEncoding.EncodeToString(key),
+		Encryption: models.EncryptionAES128CTR,
+	}}})
+	require.NotNil(t, cmd)
+	m, _ = m.Update(cmd())
+
+	// Packets without a key fall back to hex
+	assert.Equal(t, "[dec] cafe", payloadCell("dev-1"))
+	assert.Equal(t, "0102", payloadCell("dev-2"))
+	assert.Contains(t, m.View(), "Decrypted 1 of 2 packet(s)")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	assert.Equal(t, "AQI=", payloadCell("dev-2"))
+}
+
+func TestDecryptPackets_KeyedByPacket(t *testing.T) {
+	key := make([]byte, 16)
+	other := make([]byte, 16)
+	for i := range key {
+		key[i] = byte(i)
+		other[i] = byte(0xff - i)
+	}
+	device := func(id string, key []byte) models.Device {
+		return models.Device{ID: id, Key: base64.StdEncoding.EncodeToString(key), Encryption: models.EncryptionAES128CTR}
+	}
+	devices := keyDevices([]models.Device{device("dev-1", key), device("dev-2", other), {ID: "dev-3", Key: "not a key"}})
+	require.Len(t, devices, 2)
+
+	// The same payload reported for two devices only decrypts with the
+	// key of the device that sent it
+	encrypted := encryptedScanPacket(t, key, 20000, []byte{0xca, 0xfe})
+	packet := func(deviceID string, seq int) models.RetrievedPacket {
+		return models.RetrievedPacket{Device: models.RetrievedDevice{
+			ID:             deviceID,
+			Payload:        base64.StdEncoding.EncodeToString(encrypted.Payload),
+			Timestamp:      float64(encrypted.Timestamp.Unix()),
+			SequenceNumber: seq,
+		}}
+	}
+	first, second, wrongKey := packet("dev-1", 1), packet("dev-1", 2), packet("dev-2", 1)
+	undecodable := models.RetrievedPacket{Device: models.RetrievedDevice{ID: "dev-1", Payload: "!!", SequenceNumber: 3}}
+
+	plain := decryptPackets([]models.RetrievedPacket{first, wrongKey, undecodable, second}, devices)
+	assert.Len(t, plain, 4)
+	assert.Equal(t, []byte{0xca, 0xfe}, plain[packetKeyOf(first)])
+	assert.Equal(t, []byte{0xca, 0xfe}, plain[packetKeyOf(second)])
+	assert.Nil(t, plain[packetKeyOf(wrongKey)])
+	assert.Nil(t, plain[packetKeyOf(undecodable)])
+}
+
+func TestPacketsModel_PayloadKeysFailed(t *testing.T) {
+	m := NewPacketsModel(api.NewClient("test-org", "test-token"), "")
+	m, _ = m.Update(PacketsLoadedMsg{Packets: []models.RetrievedPacket{
+		{Device: models.RetrievedDevice{ID: "dev-1", Payload: "AQI="}},
+	}})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+
+	m, cmd := m.Update(PacketsKeysLoadedMsg{Err: errors.New("boom")})
+
+	assert.Nil(t, cmd)
+	assert.Contains(t, m.View(), "Couldn't load device keys: boom")
+	assert.Equal(t, "0102", m.table.Rows()[0][3])
+}
+
+func TestPacketsModel_Detail(t *testing.T) {
+	payload := []byte{0x00, 0x05, 0x11, 0x22, 0x33, 0x44, 0xaa, 0xbb, 0xcc, 0xdd, 0x01, 0x02, 0x03}
+	m := NewPacketsModel(nil, "")
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 60})
+	m, _ = m.Update(PacketsLoadedMsg{Packets: []models.RetrievedPacket{{
+		Device: models.RetrievedDevice{
+			ID:             "c0ffee00-1234-5678-9abc-def012345678",
+			Name:           "Sensor",
+			Payload:        base64.StdEncoding.EncodeToString(payload),
+			Timestamp:      1700000000.25,
+			RSSI:           -71,
+			SequenceNumber: 5,
+			Counter:        19675,
+		},
+		Location: models.RetrievedLocation{
+			Latitude:           37.774929,
+			Longitude:          -122.419416,
+			Altitude:           12.5,
+			HorizontalAccuracy: 4,
+		},
+		NetworkType: "terrestrial",
+	}}})
+	assert.Contains(t, m.renderHelp(), "enter details")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, m.detail)
+
+	view := m.View()
+	for _, want := range []string{
+		"c0ffee00-1234-5678-9abc-def012345678",
+		"Sensor",
+		common.FormatTime(time.Unix(1700000000, 250e6), "2006-01-02 15:04:05.000"),
+		"terrestrial",
+		"-71 dBm",
+		"19675",
+		"37.774929, -122.419416",
+		"12.5 m",
+		"000511223344aabbccdd010203",
+		"13 bytes",
+		"Payload Kind:",
+		formatPayloadKind(payload),
+		"Device ID [2-5]:",
+		"11223344",
+		"Auth Tag [6-9]:",
+		"aabbccdd",
+		"Encrypted [10-]:",
+		"010203",
+	} {
+		assert.Contains(t, view, want)
+	}
+	assert.Contains(t, m.renderHelp(), "copy payload hex")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	assert.NotNil(t, cmd)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Nil(t, m.detail)
+	assert.NotContains(t, m.View(), "Auth Tag")
+}
+
+func TestNewPackets(t *testing.T) {
+	packet := func(id string, ts float64, seq int) models.RetrievedPacket {
+		return models.RetrievedPacket{Device: models.RetrievedDevice{ID: id, Timestamp: ts, SequenceNumber: seq}}
+	}
+	loaded := []models.RetrievedPacket{packet("device-1", 100, 1), packet("device-2", 100, 1)}
+	fetched := []models.RetrievedPacket{
+		packet("device-1", 100, 1), // Already loaded
+		packet("device-1", 100, 2), // Same time, different sequence number
+		packet("device-3", 100, 1),
+		packet("device-3", 100, 1), // Fetched twice
+		packet("device-2", 200, 1),
+	}
+
+	assert.Equal(t, []models.RetrievedPacket{
+		packet("device-1", 100, 2),
+		packet("device-3", 100, 1),
+		packet("device-2", 200, 1),
+	}, newPackets(loaded, fetched))
+	assert.Empty(t, newPackets(loaded, loaded))
+}
+
+func TestPacketsModel_AutoRefresh(t *testing.T) {
+	packet := func(ts float64) models.RetrievedPacket {
+		return models.RetrievedPacket{Device: models.RetrievedDevice{ID: "device-1", Timestamp: ts, SequenceNumber: int(ts)}}
+	}
+	var requests []api.RetrievePacketsOptions
+	m := NewPacketsModel(&stubClient{
+		retrievePacketsWithPagination: func(ctx context.Context, opts api.RetrievePacketsOptions) (*api.RetrievePacketsResult, error) {
+			requests = append(requests, opts)
+			// The recent window overlaps the packets already loaded
+			return &api.RetrievePacketsResult{Packets: []models.RetrievedPacket{packet(400), packet(300)}}, nil
+		},
+	}, "device-1")
+	m.width = 120
+	m.height = 40
+	m.table.SetHeight(20)
+
+	m, _ = m.Update(PacketsLoadedMsg{Packets: []models.RetrievedPacket{packet(300), packet(200), packet(100)}})
+	m.table.SetCursor(1) // The packet at 200, newest first
+	assert.Contains(t, m.View(), "a auto-refresh")
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	require.NotNil(t, cmd)
+	assert.True(t, m.autoRefresh)
+	assert.True(t, m.refreshing)
+	assert.Contains(t, m.View(), "Auto-refresh on, checking...")
+
+	now := time.Now()
+	m, _ = m.Update(m.refresh(now)())
+	assert.False(t, m.refreshing)
+
+	// Only the new packet is added, and the same packet stays selected
+	require.Len(t, m.packets, 4)
+	assert.Equal(t, 2, m.table.Cursor())
+	assert.Equal(t, float64(200), m.filteredPackets[m.table.Cursor()].Device.Timestamp)
+	assert.Equal(t, 1, m.refreshAdded)
+	assert.Contains(t, m.View(), "(1 new)")
+	assert.Contains(t, m.View(), "a stop auto-refresh")
+
+	// The recent window is requested for the device, without a page limit
+	require.Len(t, requests, 1)
+	require.NotNil(t, requests[0].DeviceID)
+	assert.Equal(t, "device-1", *requests[0].DeviceID)
+	require.NotNil(t, requests[0].Start)
+	assert.Equal(t, now.Add(-packetsRefreshWindow), *requests[0].Start)
+	assert.Zero(t, requests[0].Limit)
+
+	// Refreshing again finds nothing new
+	m, _ = m.Update(m.refresh(now)())
+	assert.Len(t, m.packets, 4)
+	assert.Equal(t, 0, m.refreshAdded)
+}
+
+func TestPacketsModel_AutoRefreshTicks(t *testing.T) {
+	m := NewPacketsModel(&stubClient{}, "")
+	m, _ = m.Update(PacketsLoadedMsg{})
+	now := time.Now()
+	m.startAutoRefresh(now)
+	m.refreshing = false
+
+	// Ticks keep coming, but a refresh only starts once one is due
+	m, cmd := m.Update(packetsRefreshTickMsg{seq: m.refreshSeq, at: now.Add(time.Second)})
+	assert.NotNil(t, cmd)
+	assert.False(t, m.refreshing)
+	assert.Contains(t, m.View(), "updated 0s ago")
+	m, cmd = m.Update(packetsRefreshTickMsg{seq: m.refreshSeq, at: now.Add(packetsRefreshInterval)})
+	assert.NotNil(t, cmd)
+	assert.True(t, m.refreshing)
+
+	// Turning it off drops ticks and results already in flight
+	seq := m.refreshSeq
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	assert.Nil(t, cmd)
+	assert.False(t, m.autoRefresh)
+	assert.Contains(t, m.View(), "Auto-refresh off")
+	_, cmd = m.Update(packetsRefreshTickMsg{seq: seq, at: now.Add(2 * packetsRefreshInterval)})
+	assert.Nil(t, cmd)
+	m, _ = m.Update(PacketsRefreshedMsg{
+		Packets: []models.RetrievedPacket{{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 100}}},
+		Seq:     seq,
+		Load:    m.load,
+	})
+	assert.Empty(t, m.packets)
+}
+
+func TestPacketsModel_AutoRefreshAfterReload(t *testing.T) {
+	m := NewPacketsModel(&stubClient{}, "")
+	m, _ = m.Update(PacketsLoadedMsg{})
+	m.startAutoRefresh(time.Now())
+	m.load++ // Reloaded while the refresh was in flight
+
+	m, _ = m.Update(PacketsRefreshedMsg{
+		Packets: []models.RetrievedPacket{{Device: models.RetrievedDevice{ID: "device-1", Timestamp: 100}}},
+		Seq:     m.refreshSeq,
+		Load:    m.load - 1,
+	})
+	assert.Empty(t, m.packets)
+	assert.False(t, m.refreshing)
+	assert.True(t, m.autoRefresh)
+}
+
+func TestPacketsModel_AutoRefreshFailed(t *testing.T) {
+	m := NewPacketsModel(&stubClient{}, "")
+	m.width = 120
+	m, _ = m.Update(PacketsLoadedMsg{})
+	m.startAutoRefresh(time.Now())
+
+	m, _ = m.Update(PacketsRefreshedMsg{Err: errors.New("connection reset"), Seq: m.refreshSeq, Load: m.load})
+	assert.True(t, m.autoRefresh)
+	assert.Contains(t, m.View(), "Auto-refresh failed: connection reset")
+}
+
+func TestPacketsModel_AutoRefreshNeedsOpenRange(t *testing.T) {
+	m := NewPacketsModel(&stubClient{}, "")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	m.rangeStart, m.rangeEnd = &start, &end
+	m, _ = m.Update(PacketsLoadedMsg{})
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	assert.Nil(t, cmd)
+	assert.False(t, m.autoRefresh)
+	assert.Equal(t, "Auto-refresh needs a range up to now", m.notice)
+}
+
+func TestPacketsModel_BoundedRangeEndsAutoRefresh(t *testing.T) {
+	m := NewPacketsModel(&stubClient{}, "")
+	m.width = 120
+	m, _ = m.Update(PacketsLoadedMsg{})
+	m.startAutoRefresh(time.Now())
+	seq := m.refreshSeq
+
+	// A range with only a start runs up to now, so polling carries on
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'D'}})
+	m.rangeInput.SetValue("2024-01-01")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.True(t, m.autoRefresh)
+	m, _ = m.Update(PacketsLoadedMsg{})
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'D'}})
+	m.rangeInput.SetValue("2024-01-01 2024-01-31")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, m.autoRefresh)
+	assert.Equal(t, "Auto-refresh off: it needs a range up to now", m.notice)
+
+	// A refresh of the last few minutes still in flight is dropped
+	m, _ = m.Update(PacketsLoadedMsg{})
+	m, cmd := m.Update(PacketsRefreshedMsg{
+		Packets: []models.RetrievedPacket{{Device: models.RetrievedDevice{ID: "device-1", Timestamp: float64(time.Now().Unix())}}},
+		Seq:     seq,
+		Load:    m.load,
+	})
+	assert.Nil(t, cmd)
+	assert.Empty(t, m.packets)
+}
+
+func TestPacketsModel_StopEndsAutoRefresh(t *testing.T) {
+	m := NewPacketsModel(&stubClient{}, "")
+	m, _ = m.Update(PacketsLoadedMsg{})
+	m.startAutoRefresh(time.Now())
+
+	m.Stop()
+	assert.False(t, m.autoRefresh)
+	assert.False(t, m.refreshing)
+	assert.False(t, m.Busy())
+}
+
+func TestPacketsModel_StopCancelsRefresh(t *testing.T) {
+	server := newHangingServer()
+	defer server.Close()
+
+	m := NewPacketsModel(api.NewClient("test-org", "test-token", api.WithBaseURL(server.URL)), "")
+	m, _ = m.Update(PacketsLoadedMsg{})
+	m.autoRefresh = true
+	refresh := m.refresh(time.Now())
+
+	result := make(chan tea.Msg, 1)
+	go func() { result <- refresh() }()
+	m.Stop()
+
+	select {
+	case msg := <-result:
+		refreshed, ok := msg.(PacketsRefreshedMsg)
+		require.True(t, ok)
+		assert.ErrorIs(t, refreshed.Err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("refresh was not cancelled")
+	}
+}
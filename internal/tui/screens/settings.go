@@ -3,12 +3,15 @@ package screens
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/hubblenetwork/hubcli/internal/auth"
+	"github.com/hubblenetwork/hubcli/internal/location"
 	"github.com/hubblenetwork/hubcli/internal/tui/common"
 )
 
@@ -29,13 +32,19 @@ type (
 	CredentialsClearedMsg struct {
 		Error error
 	}
+
+	// RequestTimeoutSetMsg is sent when the user picks a request timeout,
+	// so it can be applied to the API client and saved to the config
+	RequestTimeoutSetMsg struct {
+		Timeout time.Duration
+	}
 )
 
 // SettingsModel is the model for the settings screen
 type SettingsModel struct {
 	help   help.Model
 	keys   settingsKeyMap
-	store  *auth.KeychainStore
+	store  auth.CredentialStore
 
 	state          SettingsState
 	err            error
@@ -43,13 +52,28 @@ type SettingsModel struct {
 	hasEnvVars     bool
 	keychainOrgID  string
 	envOrgID       string
+	keychainToken  string // Masked; the real token is never kept
+	envToken       string // Masked; the real token is never kept
+	notice         string
 	width          int
 	height         int
+
+	// Location attached to packets from local BLE scans
+	scanLocation    *location.Manual
+	editingLocation bool
+	locationInput   textinput.Model
+	locationErr     string
+
+	// Timeout for API requests
+	requestTimeout time.Duration
 }
 
 // settingsKeyMap defines key bindings for the settings screen
 type settingsKeyMap struct {
 	Clear   key.Binding
+	Export  key.Binding
+	Location key.Binding
+	Timeout key.Binding
 	Confirm key.Binding
 	Cancel  key.Binding
 	Back    key.Binding
@@ -62,6 +86,18 @@ func defaultSettingsKeyMap() settingsKeyMap {
 			key.WithKeys("c"),
 			key.WithHelp("c", "clear keychain"),
 		),
+		Export: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "copy env exports"),
+		),
+		Location: key.NewBinding(
+			key.WithKeys("l"),
+			key.WithHelp("l", "set scan location"),
+		),
+		Timeout: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "request timeout"),
+		),
 		Confirm: key.NewBinding(
 			key.WithKeys("y"),
 			key.WithHelp("y", "confirm"),
@@ -81,13 +117,29 @@ func defaultSettingsKeyMap() settingsKeyMap {
 	}
 }
 
-// NewSettingsModel creates a new settings screen model
+// NewSettingsModel creates a new settings screen model backed by the keychain
 func NewSettingsModel() SettingsModel {
+	return NewSettingsModelWithStore(auth.NewKeychainStore())
+}
+
+// NewSettingsModelWithStore creates a settings screen model that reads and
+// clears credentials through store
+func NewSettingsModelWithStore(store auth.CredentialStore) SettingsModel {
+	li := textinput.New()
+	li.Placeholder = "37.7749, -122.4194"
+	li.CharLimit = 48
+	li.Width = 30
+	li.PromptStyle = lipgloss.NewStyle().Foreground(common.ColorSecondary)
+	li.TextStyle = lipgloss.NewStyle().Foreground(common.ColorForeground)
+
 	m := SettingsModel{
-		help:  help.New(),
-		keys:  defaultSettingsKeyMap(),
-		store: auth.NewKeychainStore(),
-		state: SettingsStateReady,
+		help:          help.New(),
+		keys:          defaultSettingsKeyMap(),
+		store:         store,
+		state:         SettingsStateReady,
+		locationInput: li,
+
+		requestTimeout: common.RequestTimeout(),
 	}
 
 	// Check credential sources
@@ -111,6 +163,10 @@ func (m SettingsModel) Update(msg tea.Msg) (SettingsModel, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.editingLocation {
+			return m.updateLocationInput(msg)
+		}
+
 		switch m.state {
 		case SettingsStateConfirmClear:
 			switch {
@@ -132,13 +188,39 @@ func (m SettingsModel) Update(msg tea.Msg) (SettingsModel, tea.Cmd) {
 			switch {
 			case key.Matches(msg, m.keys.Back):
 				return m, func() tea.Msg {
-					return NavigateMsg{Screen: "home"}
+					return NavigateMsg{Screen: "back"}
 				}
 
 			case key.Matches(msg, m.keys.Quit):
-				return m, tea.Quit
+				return m, RequestQuit
+
+			case key.Matches(msg, m.keys.Export):
+				if orgID := m.activeOrgID(); orgID != "" {
+					return m, common.CopyToClipboard("env exports", envExportSnippet(orgID))
+				}
+
+			case key.Matches(msg, m.keys.Location):
+				m.notice = ""
+				m.editingLocation = true
+				m.locationErr = ""
+				m.locationInput.SetValue("")
+				if m.scanLocation != nil {
+					m.locationInput.SetValue(m.scanLocation.String())
+				}
+				m.locationInput.CursorEnd()
+				m.locationInput.Focus()
+				return m, textinput.Blink
+
+			case key.Matches(msg, m.keys.Timeout):
+				m.requestTimeout = nextRequestTimeout(m.requestTimeout)
+				m.notice = "Requests time out after " + m.requestTimeout.String()
+				timeout := m.requestTimeout
+				return m, func() tea.Msg {
+					return RequestTimeoutSetMsg{Timeout: timeout}
+				}
 
 			case key.Matches(msg, m.keys.Clear):
+				m.notice = ""
 				if m.hasKeychain {
 					m.state = SettingsStateConfirmClear
 					return m, nil
@@ -146,6 +228,14 @@ func (m SettingsModel) Update(msg tea.Msg) (SettingsModel, tea.Cmd) {
 			}
 		}
 
+	case common.ClipboardCopiedMsg:
+		if msg.Err != nil {
+			m.notice = "Copy failed: " + msg.Err.Error()
+		} else {
+			m.notice = "Copied " + msg.Label + " - fill in the API token before use"
+		}
+		return m, nil
+
 	case CredentialsClearedMsg:
 		if msg.Error != nil {
 			m.state = SettingsStateError
@@ -180,6 +270,14 @@ func (m SettingsModel) View() string {
 	content.WriteString(boxStyle.Render(m.renderEnvVarInfo()))
 	content.WriteString("\n\n")
 
+	// Scan location section
+	content.WriteString(boxStyle.Render(m.renderScanLocation()))
+	content.WriteString("\n\n")
+
+	// API requests section
+	content.WriteString(boxStyle.Render(m.renderRequests()))
+	content.WriteString("\n\n")
+
 	// State-specific content
 	switch m.state {
 	case SettingsStateConfirmClear:
@@ -210,6 +308,10 @@ func (m SettingsModel) View() string {
 		content.WriteString(common.MutedTextStyle.Render("Press any key to continue."))
 
 	default:
+		if m.notice != "" {
+			content.WriteString(common.SuccessTextStyle.Render(m.notice))
+			content.WriteString("\n\n")
+		}
 		// Help
 		content.WriteString(m.renderHelp())
 	}
@@ -240,6 +342,9 @@ func (m SettingsModel) renderCredentialStatus() string {
 		b.WriteString("\n")
 		b.WriteString(labelStyle.Render("  Org ID:"))
 		b.WriteString(valueStyle.Render(maskString(m.keychainOrgID)))
+		b.WriteString("\n")
+		b.WriteString(labelStyle.Render("  Token:"))
+		b.WriteString(valueStyle.Render(m.keychainToken))
 	} else {
 		b.WriteString(common.MutedTextStyle.Render("Not stored"))
 	}
@@ -252,6 +357,9 @@ func (m SettingsModel) renderCredentialStatus() string {
 		b.WriteString("\n")
 		b.WriteString(labelStyle.Render("  Org ID:"))
 		b.WriteString(valueStyle.Render(maskString(m.envOrgID)))
+		b.WriteString("\n")
+		b.WriteString(labelStyle.Render("  Token:"))
+		b.WriteString(valueStyle.Render(m.envToken))
 	} else {
 		b.WriteString(common.MutedTextStyle.Render("Not set"))
 	}
@@ -292,12 +400,71 @@ func (m SettingsModel) renderEnvVarInfo() string {
 	return b.String()
 }
 
+func (m SettingsModel) renderScanLocation() string {
+	var b strings.Builder
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(common.ColorSecondary)
+	labelStyle := lipgloss.NewStyle().Foreground(common.ColorMuted).Width(20)
+
+	b.WriteString(headerStyle.Render("BLE Scan Location"))
+	b.WriteString("\n\n")
+
+	if m.editingLocation {
+		b.WriteString(common.MutedTextStyle.Render("Enter latitude, longitude (empty clears):"))
+		b.WriteString("\n\n")
+		b.WriteString(m.locationInput.View())
+		if m.locationErr != "" {
+			b.WriteString("\n")
+			b.WriteString(common.ErrorTextStyle.Render("✗ " + m.locationErr))
+		}
+		return b.String()
+	}
+
+	b.WriteString(labelStyle.Render("Location:"))
+	if m.scanLocation != nil {
+		b.WriteString(common.PrimaryTextStyle.Render(m.scanLocation.String()))
+	} else {
+		b.WriteString(common.MutedTextStyle.Render("Not set (placeholder used)"))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(common.MutedTextStyle.Render("Attached to packets captured by BLE scans."))
+
+	return b.String()
+}
+
+func (m SettingsModel) renderRequests() string {
+	var b strings.Builder
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(common.ColorSecondary)
+	labelStyle := lipgloss.NewStyle().Foreground(common.ColorMuted).Width(20)
+
+	b.WriteString(headerStyle.Render("API Requests"))
+	b.WriteString("\n\n")
+	b.WriteString(labelStyle.Render("Timeout:"))
+	b.WriteString(common.PrimaryTextStyle.Render(m.requestTimeout.String()))
+	b.WriteString("\n\n")
+	b.WriteString(common.MutedTextStyle.Render("Raise it if requests time out on a slow network."))
+
+	return b.String()
+}
+
 func (m SettingsModel) renderHelp() string {
 	var helpText []string
 
+	if m.editingLocation {
+		helpText = append(helpText, common.FormatHelp("enter", "save"))
+		helpText = append(helpText, common.FormatHelp("esc", "cancel"))
+		return strings.Join(helpText, "  ")
+	}
+
+	if m.activeOrgID() != "" {
+		helpText = append(helpText, common.FormatHelp("e", "copy env exports"))
+	}
 	if m.hasKeychain {
 		helpText = append(helpText, common.FormatHelp("c", "clear keychain"))
 	}
+	helpText = append(helpText, common.FormatHelp("l", "scan location"))
+	helpText = append(helpText, common.FormatHelp("t", "request timeout"))
 	helpText = append(helpText, common.FormatHelp("esc", "back"))
 
 	return strings.Join(helpText, "  ")
@@ -305,14 +472,15 @@ func (m SettingsModel) renderHelp() string {
 
 func (m *SettingsModel) checkCredentials() {
 	// Check keychain
+	m.hasKeychain = false
+	m.keychainOrgID = ""
+	m.keychainToken = ""
 	if m.store != nil && m.store.Exists() {
 		creds, err := m.store.Get()
 		if err == nil && creds != nil {
 			m.hasKeychain = true
 			m.keychainOrgID = creds.OrgID
-		} else {
-			m.hasKeychain = false
-			m.keychainOrgID = ""
+			m.keychainToken = maskToken(creds.Token)
 		}
 	}
 
@@ -321,10 +489,91 @@ func (m *SettingsModel) checkCredentials() {
 	if envCreds != nil && envCreds.IsValid() {
 		m.hasEnvVars = true
 		m.envOrgID = envCreds.OrgID
+		m.envToken = maskToken(envCreds.Token)
 	} else {
 		m.hasEnvVars = false
 		m.envOrgID = ""
+		m.envToken = ""
+	}
+}
+
+// activeOrgID returns the org ID of the active credential source, if any
+func (m SettingsModel) activeOrgID() string {
+	if m.hasEnvVars {
+		return m.envOrgID
+	}
+	if m.hasKeychain {
+		return m.keychainOrgID
+	}
+	return ""
+}
+
+// envExportSnippet returns shell exports for env-based credentials. The
+// token is always a placeholder; the real value is never copied.
+func envExportSnippet(orgID string) string {
+	return fmt.Sprintf("export %s=%q\nexport %s=\"your-api-token\"\n", auth.EnvOrgID, orgID, auth.EnvToken)
+}
+
+// SetScanLocation sets the scan location shown and edited on the screen;
+// nil means none is set
+func (m *SettingsModel) SetScanLocation(loc *location.Manual) {
+	m.scanLocation = loc
+}
+
+// requestTimeouts are the request timeouts the settings screen cycles through
+var requestTimeouts = []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 5 * time.Minute}
+
+// nextRequestTimeout returns the timeout after d in requestTimeouts, or the
+// first one if d is the last or not in the list
+func nextRequestTimeout(d time.Duration) time.Duration {
+	for _, t := range requestTimeouts {
+		if t > d {
+			return t
+		}
 	}
+	return requestTimeouts[0]
+}
+
+// updateLocationInput handles key presses while the scan location is being
+// entered. An empty value clears it.
+func (m SettingsModel) updateLocationInput(msg tea.KeyMsg) (SettingsModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.editingLocation = false
+		m.locationInput.Blur()
+		return m, nil
+	case "enter":
+		var loc *location.Manual
+		if value := strings.TrimSpace(m.locationInput.Value()); value != "" {
+			manual, err := location.ParseManual(value)
+			if err != nil {
+				m.locationErr = err.Error()
+				return m, nil
+			}
+			loc = &manual
+		}
+		m.editingLocation = false
+		m.locationInput.Blur()
+		m.scanLocation = loc
+		if loc == nil {
+			m.notice = "Scan location cleared"
+		} else {
+			m.notice = "Scan location set to " + loc.String()
+		}
+		return m, func() tea.Msg {
+			return ScanLocationSetMsg{Location: loc}
+		}
+	default:
+		var cmd tea.Cmd
+		m.locationInput, cmd = m.locationInput.Update(msg)
+		m.locationErr = ""
+		return m, cmd
+	}
+}
+
+// Busy reports whether stored credentials are being cleared
+func (m SettingsModel) Busy() bool {
+	return m.state == SettingsStateClearing
 }
 
 func (m SettingsModel) clearCredentials() tea.Cmd {
@@ -345,3 +594,12 @@ func maskString(s string) string {
 	}
 	return s[:2] + strings.Repeat("*", len(s)-4) + s[len(s)-2:]
 }
+
+// maskToken masks an API token, showing at most its last 4 characters so
+// tokens can be told apart. Short tokens are hidden entirely.
+func maskToken(token string) string {
+	if len(token) < 16 {
+		return "********"
+	}
+	return "********" + token[len(token)-4:]
+}
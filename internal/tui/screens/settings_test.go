@@ -1,21 +1,33 @@
 package screens
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hubblenetwork/hubcli/internal/auth"
+	"github.com/hubblenetwork/hubcli/internal/models"
+	"github.com/hubblenetwork/hubcli/internal/tui/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// newTestSettingsModel returns a settings model backed by an in-memory store
+// so tests never touch the system keychain
+func newTestSettingsModel(creds *models.Credentials) SettingsModel {
+	return NewSettingsModelWithStore(auth.NewMemoryStore(creds))
+}
+
 func TestNewSettingsModel(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 
 	assert.Equal(t, SettingsStateReady, m.state)
 	assert.NotNil(t, m.store)
 }
 
 func TestSettingsModel_Init(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	cmd := m.Init()
 
 	// Init should return nil for settings screen
@@ -23,7 +35,7 @@ func TestSettingsModel_Init(t *testing.T) {
 }
 
 func TestSettingsModel_WindowSizeMsg(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 
 	m, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 50})
 
@@ -32,7 +44,7 @@ func TestSettingsModel_WindowSizeMsg(t *testing.T) {
 }
 
 func TestSettingsModel_BackNavigation(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.state = SettingsStateReady
 
 	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
@@ -41,11 +53,11 @@ func TestSettingsModel_BackNavigation(t *testing.T) {
 	msg := cmd()
 	navMsg, ok := msg.(NavigateMsg)
 	assert.True(t, ok)
-	assert.Equal(t, "home", navMsg.Screen)
+	assert.Equal(t, "back", navMsg.Screen)
 }
 
 func TestSettingsModel_QuitKey(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 
 	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
 
@@ -53,7 +65,7 @@ func TestSettingsModel_QuitKey(t *testing.T) {
 }
 
 func TestSettingsModel_ClearKey_NoKeychain(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.state = SettingsStateReady
 	m.hasKeychain = false
 
@@ -65,7 +77,7 @@ func TestSettingsModel_ClearKey_NoKeychain(t *testing.T) {
 }
 
 func TestSettingsModel_ClearKey_WithKeychain(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.state = SettingsStateReady
 	m.hasKeychain = true
 
@@ -75,7 +87,7 @@ func TestSettingsModel_ClearKey_WithKeychain(t *testing.T) {
 }
 
 func TestSettingsModel_ConfirmClear_Confirm(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.state = SettingsStateConfirmClear
 
 	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
@@ -85,7 +97,7 @@ func TestSettingsModel_ConfirmClear_Confirm(t *testing.T) {
 }
 
 func TestSettingsModel_ConfirmClear_Cancel(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.state = SettingsStateConfirmClear
 
 	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
@@ -94,7 +106,7 @@ func TestSettingsModel_ConfirmClear_Cancel(t *testing.T) {
 }
 
 func TestSettingsModel_CredentialsClearedMsg_Success(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.state = SettingsStateClearing
 
 	m, _ = m.Update(CredentialsClearedMsg{Error: nil})
@@ -103,7 +115,7 @@ func TestSettingsModel_CredentialsClearedMsg_Success(t *testing.T) {
 }
 
 func TestSettingsModel_CredentialsClearedMsg_Error(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.state = SettingsStateClearing
 
 	m, _ = m.Update(CredentialsClearedMsg{Error: assert.AnError})
@@ -113,7 +125,7 @@ func TestSettingsModel_CredentialsClearedMsg_Error(t *testing.T) {
 }
 
 func TestSettingsModel_AnyKeyFromSuccess(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.state = SettingsStateSuccess
 
 	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
@@ -122,7 +134,7 @@ func TestSettingsModel_AnyKeyFromSuccess(t *testing.T) {
 }
 
 func TestSettingsModel_AnyKeyFromError(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.state = SettingsStateError
 
 	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
@@ -131,7 +143,7 @@ func TestSettingsModel_AnyKeyFromError(t *testing.T) {
 }
 
 func TestSettingsModel_View(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.width = 80
 	m.height = 24
 	m.state = SettingsStateReady
@@ -146,7 +158,7 @@ func TestSettingsModel_View(t *testing.T) {
 }
 
 func TestSettingsModel_ViewConfirmClear(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.width = 80
 	m.height = 24
 	m.state = SettingsStateConfirmClear
@@ -159,7 +171,7 @@ func TestSettingsModel_ViewConfirmClear(t *testing.T) {
 }
 
 func TestSettingsModel_ViewClearing(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.width = 80
 	m.height = 24
 	m.state = SettingsStateClearing
@@ -170,7 +182,7 @@ func TestSettingsModel_ViewClearing(t *testing.T) {
 }
 
 func TestSettingsModel_ViewSuccess(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.width = 80
 	m.height = 24
 	m.state = SettingsStateSuccess
@@ -181,7 +193,7 @@ func TestSettingsModel_ViewSuccess(t *testing.T) {
 }
 
 func TestSettingsModel_ViewError(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.width = 80
 	m.height = 24
 	m.state = SettingsStateError
@@ -193,7 +205,7 @@ func TestSettingsModel_ViewError(t *testing.T) {
 }
 
 func TestSettingsModel_ViewWithKeychain(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.width = 80
 	m.height = 24
 	m.state = SettingsStateReady
@@ -207,7 +219,7 @@ func TestSettingsModel_ViewWithKeychain(t *testing.T) {
 }
 
 func TestSettingsModel_ViewWithEnvVars(t *testing.T) {
-	m := NewSettingsModel()
+	m := newTestSettingsModel(nil)
 	m.width = 80
 	m.height = 24
 	m.state = SettingsStateReady
@@ -235,3 +247,140 @@ func TestMaskString(t *testing.T) {
 		assert.Equal(t, tt.expected, result, "maskString(%q)", tt.input)
 	}
 }
+
+func TestSettingsModel_Busy(t *testing.T) {
+	m := newTestSettingsModel(nil)
+	assert.False(t, m.Busy())
+
+	m.state = SettingsStateClearing
+	assert.True(t, m.Busy())
+
+	m.state = SettingsStateConfirmClear
+	assert.False(t, m.Busy())
+}
+
+func TestSettingsModel_StoredCredentials(t *testing.T) {
+	m := newTestSettingsModel(&models.Credentials{OrgID: "test-org-123", Token: "test-token"})
+
+	assert.True(t, m.hasKeychain)
+	assert.Equal(t, "test-org-123", m.keychainOrgID)
+}
+
+func TestSettingsModel_TokenMasked(t *testing.T) {
+	t.Setenv(auth.EnvOrgID, "env-org-456")
+	t.Setenv(auth.EnvToken, "env-s3cr3t-t0ken-9876")
+	m := newTestSettingsModel(&models.Credentials{OrgID: "test-org-123", Token: "keychain-s3cr3t-t0ken-1234"})
+	m.width = 100
+	m.height = 60
+
+	view := m.View()
+	assert.NotContains(t, view, "s3cr3t")
+	assert.Contains(t, view, "********1234")
+	assert.Contains(t, view, "********9876")
+	assert.NotContains(t, fmt.Sprintf("%+v", m), "s3cr3t")
+}
+
+func TestMaskToken(t *testing.T) {
+	assert.Equal(t, "********", maskToken("short-token"))
+	assert.Equal(t, "********cdef", maskToken("0123456789abcdef"))
+}
+
+func TestSettingsModel_ClearFlow(t *testing.T) {
+	store := auth.NewMemoryStore(&models.Credentials{OrgID: "test-org-123", Token: "test-token"})
+	m := NewSettingsModelWithStore(store)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	require.Equal(t, SettingsStateConfirmClear, m.state)
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	require.NotNil(t, cmd)
+
+	m, _ = m.Update(cmd())
+
+	assert.Equal(t, SettingsStateSuccess, m.state)
+	assert.False(t, store.Exists())
+	assert.False(t, m.hasKeychain)
+	assert.Empty(t, m.keychainOrgID)
+}
+
+func TestEnvExportSnippet(t *testing.T) {
+	snippet := envExportSnippet("test-org-123")
+
+	assert.Contains(t, snippet, `export HUBBLE_ORG_ID="test-org-123"`)
+	assert.Contains(t, snippet, `export HUBBLE_API_TOKEN="your-api-token"`)
+}
+
+func TestSettingsModel_ExportKey(t *testing.T) {
+	m := newTestSettingsModel(&models.Credentials{OrgID: "test-org-123", Token: "secret-token"})
+	m.width = 100
+	m.height = 40
+
+	assert.Contains(t, m.View(), "copy env exports")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	assert.NotNil(t, cmd)
+
+	m, _ = m.Update(common.ClipboardCopiedMsg{Label: "env exports"})
+	assert.Contains(t, m.View(), "fill in the API token")
+}
+
+func TestSettingsModel_ExportKey_NoCredentials(t *testing.T) {
+	m := newTestSettingsModel(nil)
+	m.hasEnvVars = false
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	assert.Nil(t, cmd)
+}
+
+func TestSettingsModel_ScanLocation(t *testing.T) {
+	m := newTestSettingsModel(nil)
+	m.width = 100
+	m.height = 60
+	assert.Contains(t, m.View(), "Not set")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'l'}})
+	require.True(t, m.editingLocation)
+
+	// Invalid input keeps the prompt open
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("91, 0")})
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Nil(t, cmd)
+	assert.True(t, m.editingLocation)
+	assert.Contains(t, m.View(), "latitude 91")
+
+	m.locationInput.SetValue("37.7749, -122.4194")
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	assert.False(t, m.editingLocation)
+	msg, ok := cmd().(ScanLocationSetMsg)
+	require.True(t, ok)
+	require.NotNil(t, msg.Location)
+	assert.Equal(t, 37.7749, msg.Location.Latitude)
+	assert.Contains(t, m.View(), "37.7749, -122.4194")
+
+	// An empty value clears the location
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'l'}})
+	m.locationInput.SetValue("")
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	assert.Nil(t, cmd().(ScanLocationSetMsg).Location)
+	assert.Nil(t, m.scanLocation)
+}
+
+func TestSettingsModel_RequestTimeout(t *testing.T) {
+	m := newTestSettingsModel(nil)
+	m.width = 100
+	m.height = 60
+	assert.Contains(t, m.View(), "30s")
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	require.NotNil(t, cmd)
+	assert.Equal(t, RequestTimeoutSetMsg{Timeout: time.Minute}, cmd())
+	assert.Contains(t, m.View(), "Requests time out after 1m0s")
+
+	// The last timeout wraps around to the first
+	m.requestTimeout = 5 * time.Minute
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	require.NotNil(t, cmd)
+	assert.Equal(t, RequestTimeoutSetMsg{Timeout: 30 * time.Second}, cmd())
+}